@@ -0,0 +1,115 @@
+package backoff
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Adaptive is a backoff for pollers whose interval is driven by the caller
+// rather than by the number of calls to Next: Slower doubles it, up to max,
+// while nothing changes, and Faster halves it, down to base, when there is
+// activity. Next always returns the current interval and never signals to
+// stop. repeat.DoAdaptive drives one from the result of each poll.
+//
+// It is safe for concurrent use. The zero Adaptive has no interval, and Next
+// always signals to stop; use NewAdaptive.
+type Adaptive struct {
+	mu        sync.Mutex
+	base, max time.Duration
+	interval  time.Duration
+}
+
+// NewAdaptive creates an Adaptive backoff that starts at base and stays
+// between base and max.
+//
+// It returns an error if base is not greater than zero or max is less than
+// base.
+func NewAdaptive(base, max time.Duration) (*Adaptive, error) {
+	if base <= 0 {
+		return nil, fmt.Errorf("base must be greater than 0")
+	}
+	if max < base {
+		return nil, fmt.Errorf("max must not be less than base")
+	}
+
+	return &Adaptive{
+		base:     base,
+		max:      max,
+		interval: base,
+	}, nil
+}
+
+// Next implements Backoff. It returns the current interval.
+func (a *Adaptive) Next() (time.Duration, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.interval <= 0 {
+		return 0, true
+	}
+	return a.interval, false
+}
+
+// Reset implements Backoff. It sets the interval back to base.
+func (a *Adaptive) Reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.interval = a.base
+}
+
+// String describes a, such as "adaptive(1s, 1m0s)".
+func (a *Adaptive) String() string {
+	return fmt.Sprintf("adaptive(%v, %v)", a.base, a.max)
+}
+
+// Snapshot implements Snapshotter. It saves the current interval.
+func (a *Adaptive) Snapshot() ([]byte, error) {
+	return encodeInts(int64(a.Interval())), nil
+}
+
+// Restore implements Snapshotter.
+func (a *Adaptive) Restore(state []byte) error {
+	d, err := decodeDuration(state, a.base, a.max)
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.interval = d
+	return nil
+}
+
+// Slower doubles the interval, up to max.
+func (a *Adaptive) Slower() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.interval > a.max/2 {
+		a.interval = a.max
+		return
+	}
+	a.interval *= 2
+}
+
+// Faster halves the interval, down to base.
+func (a *Adaptive) Faster() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.interval /= 2
+	if a.interval < a.base {
+		a.interval = a.base
+	}
+}
+
+// Interval returns the current interval.
+func (a *Adaptive) Interval() time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.interval
+}
@@ -0,0 +1,111 @@
+package backoff
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewAdaptive_badValues(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name      string
+		base, max time.Duration
+	}{
+		{name: "zero_base", base: 0, max: time.Second},
+		{name: "negative_base", base: -1, max: time.Second},
+		{name: "max_below_base", base: time.Second, max: time.Millisecond},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			a, err := NewAdaptive(tc.base, tc.max)
+			if err == nil || a != nil {
+				t.Errorf("expected an error and no backoff, got %v and %v", err, a)
+			}
+		})
+	}
+}
+
+func TestAdaptive(t *testing.T) {
+	t.Parallel()
+
+	a, err := NewAdaptive(1*time.Second, 10*time.Second)
+	if err != nil {
+		t.Fatalf("failed to create adaptive backoff: %v", err)
+	}
+
+	// Each step is true for activity, false for none, and the interval after.
+	steps := []struct {
+		activity bool
+		val      time.Duration
+	}{
+		{activity: false, val: 2 * time.Second},
+		{activity: false, val: 4 * time.Second},
+		{activity: true, val: 2 * time.Second},
+		{activity: false, val: 4 * time.Second},
+		{activity: false, val: 8 * time.Second},
+		{activity: false, val: 10 * time.Second}, // capped at max
+		{activity: false, val: 10 * time.Second},
+		{activity: true, val: 5 * time.Second},
+		{activity: true, val: 2500 * time.Millisecond},
+		{activity: true, val: 1250 * time.Millisecond},
+		{activity: true, val: 1 * time.Second}, // floored at base
+		{activity: true, val: 1 * time.Second},
+	}
+
+	if val, stop := a.Next(); stop || val != time.Second {
+		t.Fatalf("expected (%v, %t) to be (%v, false)", val, stop, time.Second)
+	}
+	for i, s := range steps {
+		if s.activity {
+			a.Faster()
+		} else {
+			a.Slower()
+		}
+		val, stop := a.Next()
+		if stop || val != s.val {
+			t.Fatalf("step %d: expected (%v, %t) to be (%v, false)", i, val, stop, s.val)
+		}
+	}
+
+	a.Slower()
+	a.Reset()
+	if got := a.Interval(); got != time.Second {
+		t.Errorf("expected %v to be %v", got, time.Second)
+	}
+}
+
+func TestAdaptive_concurrent(t *testing.T) {
+	t.Parallel()
+
+	a, err := NewAdaptive(1*time.Second, 1*time.Minute)
+	if err != nil {
+		t.Fatalf("failed to create adaptive backoff: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			for j := 0; j < 100; j++ {
+				if (i+j)%3 == 0 {
+					a.Faster()
+				} else {
+					a.Slower()
+				}
+				if val, _ := a.Next(); val < time.Second || val > time.Minute {
+					t.Errorf("expected %v to be within [%v, %v]", val, time.Second, time.Minute)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -3,25 +3,35 @@ package backoff
 import (
 	"context"
 	"fmt"
+	"math"
+	"math/rand"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/swayne275/go-retry/internal/random"
+	"github.com/swayne275/go-retry/internal/clock"
+	"github.com/swayne275/go-retry/internal/label"
 )
 
+// Clock tells the time for WithMaxDurationClock. It is the same type as
+// retry.Clock, so a fake from the retrytest package works here too.
+type Clock = clock.Clock
+
 // Backoff is an interface that backs off.
 type Backoff interface {
 	// Next returns the time duration to wait and whether to stop.
 	Next() (next time.Duration, stop bool)
-	// Reset sets the undecorated backoff back to its initial parameters
+	// Reset sets the undecorated backoff back to its initial parameters. A
+	// decorator resets its own state and calls Reset on the backoff it wraps
+	// exactly once, so a reset passes through a stack of decorators once.
 	Reset()
 }
 
 var (
 	// ErrInvalidJitter is returned when the jitter is invalid.
-	ErrInvalidJitter = fmt.Errorf("invalid jitter: must be a positive value")
+	ErrInvalidJitter = label.New("invalid jitter: must be a positive value", "invalid_jitter")
 	// ErrInvalidJitterPercent is returned when the jitter percent is invalid.
-	ErrInvalidJitterPercent = fmt.Errorf("invalid jitter percent: must be > 0 and <= 100")
+	ErrInvalidJitterPercent = label.New("invalid jitter percent: must be > 0", "invalid_jitter_percent")
 )
 
 var _ Backoff = (BackoffFunc)(nil)
@@ -36,41 +46,354 @@ func (b BackoffFunc) Next() (time.Duration, bool) {
 
 func (b BackoffFunc) Reset() {}
 
+// ResettableBackoff is a Backoff that can be returned to its initial state.
+// It is returned by the constructors and decorators of this package.
+//
+// The zero ResettableBackoff wraps no backoff: Next always signals to stop,
+// Reset does nothing, and Validate rejects it.
+//
+// Next and Reset may be called concurrently, such as when a health checker
+// resets the backoff of a loop running on another goroutine. The embedded
+// Backoff must not be assigned directly once b is in use.
 type ResettableBackoff struct {
 	Backoff
+	// mu guards Backoff, which Reset replaces.
+	mu sync.RWMutex
 	// reset returns the backoff to its initial state.
 	reset func()
+	// next is the backoff a decorator wraps, if decorated is set, and name
+	// describes the decorator.
+	next      Backoff
+	decorated bool
+	name      string
+	// rnd is the source a decorator draws random numbers from, if any.
+	rnd *source
+	// exhausted, if set, reports whether a retry limit caused the last stop.
+	exhausted func() bool
+	// remaining, if set, reports how many values a retry limit has left.
+	remaining func() uint64
+	// remainingTime, if set, reports how long a duration limit has left.
+	remainingTime func() time.Duration
+	// success, if set, is told that an attempt succeeded.
+	success func()
+	// started and finished, if set, are told when an attempt starts and
+	// finishes.
+	started, finished func()
+	// save and load, if set, snapshot and restore a decorator's state.
+	save func() ([]byte, error)
+	load func(state []byte) error
 }
 
+// Next implements Backoff.
 func (b *ResettableBackoff) Next() (time.Duration, bool) {
-	return b.Backoff.Next()
+	current := b.current()
+	if current == nil {
+		return 0, true
+	}
+	return current.Next()
+}
+
+// current returns the backoff b currently delegates to.
+func (b *ResettableBackoff) current() Backoff {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.Backoff
 }
 
+// Reset implements Backoff.
 func (b *ResettableBackoff) Reset() {
+	if b.reset == nil {
+		return
+	}
 	b.reset()
 }
 
+// Unwrap returns the backoff that b decorates.
+func (b *ResettableBackoff) Unwrap() Backoff {
+	if b.decorated {
+		return b.next
+	}
+	return b.current()
+}
+
+// String describes b as the backoff it wraps followed by its decorators,
+// innermost first and separated by "|", such as
+// "exponential(1s)|cap(30s)|max_retries(5)". A ResettableBackoff that is not a
+// decorator is described as the backoff it currently delegates to.
+func (b *ResettableBackoff) String() string {
+	if b.decorated {
+		return describe(b.next) + "|" + b.name
+	}
+	return describe(b.current())
+}
+
+// describe returns the String of b if it has one, and its type otherwise.
+func describe(b Backoff) string {
+	if s, ok := b.(fmt.Stringer); ok {
+		return s.String()
+	}
+	if b == nil {
+		return "nil"
+	}
+	return fmt.Sprintf("%T", b)
+}
+
+// RetriesExhausted reports whether the last time b signaled to stop, it was
+// because b is a WithMaxRetries decorator whose limit ran out, rather than
+// because the backoff it wraps stopped. See Exhausted.
+func (b *ResettableBackoff) RetriesExhausted() bool {
+	return b.exhausted != nil && b.exhausted()
+}
+
+// Budgeted is implemented by backoffs that can tell how much of their limits
+// is left, such as those returned by WithMaxRetries and WithMaxDuration.
+type Budgeted interface {
+	// Remaining returns how many more values the backoff allows, that is,
+	// how many retries are left, and whether it has a retry limit at all.
+	Remaining() (attempts uint64, ok bool)
+	// RemainingTime returns how much longer the backoff allows retrying, and
+	// whether it has a duration limit at all.
+	RemainingTime() (time.Duration, bool)
+}
+
+// Remaining returns how many more values b allows before a retry limit set
+// with WithMaxRetries or WithMaxAttempts runs out, which is how many retries
+// are left. It walks the chain of decorators b wraps, so it also works when
+// the limit is wrapped in other decorators; when several are stacked, the
+// smallest is returned. ok is false if there is no retry limit.
+func (b *ResettableBackoff) Remaining() (attempts uint64, ok bool) {
+	walkBudgets(b, func(b *ResettableBackoff) {
+		if b.remaining != nil {
+			if n := b.remaining(); !ok || n < attempts {
+				attempts, ok = n, true
+			}
+		}
+	}, func(b Budgeted) {
+		if n, found := b.Remaining(); found && (!ok || n < attempts) {
+			attempts, ok = n, true
+		}
+	})
+	return attempts, ok
+}
+
+// RemainingTime returns how much longer b allows retrying before a limit set
+// with WithMaxDuration, or the deadline of WithDeadline, runs out. The
+// WithMaxDuration limit only starts counting at the first call to Next, so
+// until then it is the whole limit. Like Remaining, it walks
+// the chain of decorators and returns the smallest limit. ok is false if there
+// is no duration limit.
+func (b *ResettableBackoff) RemainingTime() (d time.Duration, ok bool) {
+	walkBudgets(b, func(b *ResettableBackoff) {
+		if b.remainingTime != nil {
+			if left := b.remainingTime(); !ok || left < d {
+				d, ok = left, true
+			}
+		}
+	}, func(b Budgeted) {
+		if left, found := b.RemainingTime(); found && (!ok || left < d) {
+			d, ok = left, true
+		}
+	})
+	return d, ok
+}
+
+// walkBudgets calls own for every ResettableBackoff in the chain starting at
+// b, and other for the first Budgeted backoff of another type, which is left
+// to walk what it wraps itself.
+func walkBudgets(b Backoff, own func(b *ResettableBackoff), other func(b Budgeted)) {
+	for b != nil {
+		if rb, ok := b.(*ResettableBackoff); ok {
+			own(rb)
+		} else if bb, ok := b.(Budgeted); ok {
+			other(bb)
+			return
+		}
+		u, ok := b.(Unwrapper)
+		if !ok {
+			return
+		}
+		b = u.Unwrap()
+	}
+}
+
+// Exhausted reports whether b, or a backoff it wraps, last signaled to stop
+// because a retry limit set with WithMaxRetries ran out. It walks the chain of
+// decorators as Validate does, asking each backoff that has a
+// RetriesExhausted() bool method. Call it right after Next signals to stop;
+// the answer is undefined otherwise.
+func Exhausted(b Backoff) bool {
+	for b != nil {
+		if e, ok := b.(interface{ RetriesExhausted() bool }); ok && e.RetriesExhausted() {
+			return true
+		}
+		u, ok := b.(Unwrapper)
+		if !ok {
+			return false
+		}
+		b = u.Unwrap()
+	}
+	return false
+}
+
+// RecordSuccess tells b that an attempt succeeded. It has an effect only if b
+// is a decorator that tracks successes, such as WithCircuitBreaker. See
+// RecordSuccess the function to tell every decorator in a chain.
+func (b *ResettableBackoff) RecordSuccess() {
+	if b.success != nil {
+		b.success()
+	}
+}
+
+// RecordSuccess tells b, and every backoff it wraps, that an attempt
+// succeeded. It walks the chain of decorators as Exhausted does, calling each
+// RecordSuccess() method it finds.
+func RecordSuccess(b Backoff) {
+	for b != nil {
+		if s, ok := b.(interface{ RecordSuccess() }); ok {
+			s.RecordSuccess()
+		}
+		u, ok := b.(Unwrapper)
+		if !ok {
+			return
+		}
+		b = u.Unwrap()
+	}
+}
+
+// AttemptStarted tells b that an attempt is starting. It has an effect only if
+// b is a decorator that tracks attempts, such as WithMaxSleepWindow. See
+// AttemptStarted the function to tell every decorator in a chain.
+func (b *ResettableBackoff) AttemptStarted() {
+	if b.started != nil {
+		b.started()
+	}
+}
+
+// AttemptFinished tells b that the attempt reported by AttemptStarted has
+// finished. It has an effect only if b is a decorator that tracks attempts,
+// such as WithMaxSleepWindow.
+func (b *ResettableBackoff) AttemptFinished() {
+	if b.finished != nil {
+		b.finished()
+	}
+}
+
+// AttemptObserver is implemented by backoffs that are told when each attempt
+// of a retry loop starts and finishes, such as WithMaxSleepWindow.
+type AttemptObserver interface {
+	AttemptStarted()
+	AttemptFinished()
+}
+
+// AttemptStarted tells b, and every backoff it wraps, that an attempt is
+// starting. It walks the chain of decorators as RecordSuccess does, calling
+// each AttemptObserver it finds. The loops of the retry package call it before
+// every attempt, and AttemptFinished after it.
+func AttemptStarted(b Backoff) {
+	observeAttempts(b, AttemptObserver.AttemptStarted)
+}
+
+// AttemptFinished tells b, and every backoff it wraps, that the attempt
+// reported by AttemptStarted has finished.
+func AttemptFinished(b Backoff) {
+	observeAttempts(b, AttemptObserver.AttemptFinished)
+}
+
+func observeAttempts(b Backoff, call func(AttemptObserver)) {
+	for b != nil {
+		if o, ok := b.(AttemptObserver); ok {
+			call(o)
+		}
+		u, ok := b.(Unwrapper)
+		if !ok {
+			return
+		}
+		b = u.Unwrap()
+	}
+}
+
+// Randomized reports whether b itself draws random numbers, as the jitter
+// decorators do.
+func (b *ResettableBackoff) Randomized() bool {
+	return b.rnd != nil
+}
+
+// SetRandomSource makes b draw random numbers from src. It has no effect if b
+// doesn't draw random numbers.
+func (b *ResettableBackoff) SetRandomSource(src rand.Source64) {
+	if b.rnd != nil {
+		b.rnd.set(src)
+	}
+}
+
+// decorate builds the ResettableBackoff returned by a decorator. name
+// describes the decorator in String, current is the decorator's own Backoff,
+// next is the backoff it wraps, and r is its random source, if it has one.
+// reset must call next.Reset exactly once.
+func decorate(name string, reset func() Backoff, next, current Backoff, r *source) *ResettableBackoff {
+	b := WithReset(reset, current)
+	b.name = name
+	b.next = next
+	b.decorated = true
+	b.rnd = r
+	return b
+}
+
+// WithReset wraps next so that Reset replaces it with the backoff reset
+// returns, which should be next as it was first built, decorators included. A
+// nil reset resets next in place instead, which keeps every decorator; see
+// Resettable. If reset returns nil, the backoff signals to stop from then on,
+// like the zero ResettableBackoff, and Validate rejects it.
 func WithReset(reset func() Backoff, next Backoff) *ResettableBackoff {
+	if reset == nil {
+		reset = func() Backoff {
+			if next != nil {
+				next.Reset()
+			}
+			return next
+		}
+	}
+
 	resettableBackoff := &ResettableBackoff{
 		Backoff: next,
 	}
 	resettableBackoff.reset = func() {
-		resettableBackoff.Backoff = reset()
+		next := reset()
+
+		resettableBackoff.mu.Lock()
+		defer resettableBackoff.mu.Unlock()
+
+		resettableBackoff.Backoff = next
 	}
 
 	return resettableBackoff
 }
 
+// Resettable wraps next in a ResettableBackoff whose Reset calls next.Reset.
+// It is the same as WithReset(nil, next).
+func Resettable(next Backoff) *ResettableBackoff {
+	return WithReset(nil, next)
+}
+
 // WithJitter wraps a backoff function and adds the specified jitter. j can be
 // interpreted as "+/- j". For example, if j were 5 seconds and the backoff
 // returned 20s, the value could be between 15 and 25 seconds. The value must
 // be greater than 0.
 func WithJitter(j time.Duration, next Backoff) (*ResettableBackoff, error) {
+	return WithJitterSource(j, nil, next)
+}
+
+// WithJitterSource is like WithJitter, but draws the jitter from src instead of
+// a source seeded from the current time, so a fixed seed gives a reproducible
+// sequence. src may be shared with other backoffs; draws from it are
+// serialized. A nil src selects a self-seeded source.
+func WithJitterSource(j time.Duration, src rand.Source64, next Backoff) (*ResettableBackoff, error) {
 	if j <= 0 {
 		return nil, ErrInvalidJitter
 	}
 
-	r := random.NewLockedRandom(time.Now().UnixNano())
+	r := newSource(src)
 
 	nextWithJitter := BackoffFunc(func() (time.Duration, bool) {
 		val, stop := next.Next()
@@ -91,19 +414,74 @@ func WithJitter(j time.Duration, next Backoff) (*ResettableBackoff, error) {
 		return nextWithJitter
 	}
 
-	return WithReset(reset, nextWithJitter), nil
+	return decorate(fmt.Sprintf("jitter(%v)", j), reset, next, nextWithJitter, r).stateless(), nil
+}
+
+// WithPositiveJitter is like WithJitter, but only ever adds to the backoff's
+// values: it adds a random duration in [0, j), so a value is never shorter than
+// the backoff asked for, such as a minimum poll interval. j must be greater
+// than 0. Values are capped at the maximum time.Duration.
+func WithPositiveJitter(j time.Duration, next Backoff) (*ResettableBackoff, error) {
+	return WithPositiveJitterSource(j, nil, next)
+}
+
+// WithPositiveJitterSource is like WithPositiveJitter, but draws the jitter
+// from src, as WithJitterSource does. A nil src selects a self-seeded source.
+func WithPositiveJitterSource(j time.Duration, src rand.Source64, next Backoff) (*ResettableBackoff, error) {
+	if j <= 0 {
+		return nil, ErrInvalidJitter
+	}
+
+	r := newSource(src)
+
+	nextWithPositiveJitter := BackoffFunc(func() (time.Duration, bool) {
+		val, stop := next.Next()
+		if stop {
+			return 0, true
+		}
+		if val < 0 {
+			val = 0
+		}
+
+		diff := time.Duration(r.Int63n(int64(j)))
+		if val > math.MaxInt64-diff {
+			return math.MaxInt64, false
+		}
+		return val + diff, false
+	})
+
+	reset := func() Backoff {
+		next.Reset()
+		return nextWithPositiveJitter
+	}
+
+	return decorate(fmt.Sprintf("positive_jitter(%v)", j), reset, next, nextWithPositiveJitter, r).stateless(), nil
 }
 
 // WithJitterPercent wraps a backoff function and adds the specified jitter
 // percentage. j can be interpreted as "+/- j%". For example, if j were 5 and
-// the backoff returned 20s, the value could be between 19 and 21 seconds. The
-// value can never be less than 1 or greater than 100.
+// the backoff returned 20s, the value could be between 19 and 21 seconds. j
+// must be at least 1, and can be more than 100 to spread callers further:
+// with 150 the value is between 0 and 2.5 times the backoff's, since values
+// below zero are clamped to zero. Values are capped at the maximum
+// time.Duration.
 func WithJitterPercent(j uint64, next Backoff) (*ResettableBackoff, error) {
-	if j <= 0 || j > 100 {
+	return WithJitterPercentSource(j, nil, next)
+}
+
+// WithJitterPercentSource is like WithJitterPercent, but draws the jitter from
+// src, as WithJitterSource does. A nil src selects a self-seeded source.
+func WithJitterPercentSource(j uint64, src rand.Source64, next Backoff) (*ResettableBackoff, error) {
+	if j == 0 {
 		return nil, ErrInvalidJitterPercent
 	}
+	// Beyond this, 2*j overflows the range drawn from; no backoff value
+	// survives such a jitter unclamped anyway.
+	if j > math.MaxInt64/2 {
+		j = math.MaxInt64 / 2
+	}
 
-	r := random.NewLockedRandom(time.Now().UnixNano())
+	r := newSource(src)
 
 	nextWithJitterPercent := BackoffFunc(func() (time.Duration, bool) {
 		val, stop := next.Next()
@@ -115,9 +493,14 @@ func WithJitterPercent(j uint64, next Backoff) (*ResettableBackoff, error) {
 		top := r.Int63n(int64(j)*2) - int64(j)
 		pct := 1 - float64(top)/100.0
 
-		val = time.Duration(float64(val) * pct)
-		if val < 0 {
+		scaled := float64(val) * pct
+		switch {
+		case scaled < 0:
 			val = 0
+		case scaled >= math.MaxInt64:
+			val = math.MaxInt64
+		default:
+			val = time.Duration(scaled)
 		}
 		return val, false
 	})
@@ -127,19 +510,150 @@ func WithJitterPercent(j uint64, next Backoff) (*ResettableBackoff, error) {
 		return nextWithJitterPercent
 	}
 
-	return WithReset(reset, nextWithJitterPercent), nil
+	return decorate(fmt.Sprintf("jitter_percent(%d)", j), reset, next, nextWithJitterPercent, r).stateless(), nil
+}
+
+// WithFullJitter wraps a backoff function and replaces each value v it returns
+// with a random value between 0 and v, inclusive. This "full jitter" spreads
+// callers out more than WithJitter, at the cost of sometimes retrying almost
+// immediately.
+func WithFullJitter(next Backoff) *ResettableBackoff {
+	r := newSource(nil)
+
+	nextWithFullJitter := BackoffFunc(func() (time.Duration, bool) {
+		val, stop := next.Next()
+		if stop {
+			return 0, true
+		}
+		if val <= 0 {
+			return 0, false
+		}
+
+		n := int64(val)
+		if n < math.MaxInt64 {
+			n++
+		}
+		return time.Duration(r.Int63n(n)), false
+	})
+
+	reset := func() Backoff {
+		next.Reset()
+		return nextWithFullJitter
+	}
+
+	return decorate("full_jitter", reset, next, nextWithFullJitter, r).stateless()
 }
 
-// WithMaxRetries executes the backoff function up until the maximum attempts.
+// WithMonotonicJitter is like WithJitter, but never returns a value smaller
+// than the one it returned before, so wrapping a non-decreasing backoff gives a
+// non-decreasing sequence with random growth between steps. Reset clears the
+// memory of the previous value.
+func WithMonotonicJitter(j time.Duration, next Backoff) (*ResettableBackoff, error) {
+	jittered, err := WithJitter(j, next)
+	if err != nil {
+		return nil, err
+	}
+	return withMonotonic(jittered), nil
+}
+
+// WithMonotonicJitterPercent is like WithJitterPercent, but never returns a
+// value smaller than the one it returned before, as WithMonotonicJitter.
+func WithMonotonicJitterPercent(j uint64, next Backoff) (*ResettableBackoff, error) {
+	jittered, err := WithJitterPercent(j, next)
+	if err != nil {
+		return nil, err
+	}
+	return withMonotonic(jittered), nil
+}
+
+// withMonotonic clamps each value returned by next to at least the previous
+// one.
+func withMonotonic(next Backoff) *ResettableBackoff {
+	var l sync.Mutex
+	var last time.Duration
+
+	nextMonotonic := BackoffFunc(func() (time.Duration, bool) {
+		l.Lock()
+		defer l.Unlock()
+
+		val, stop := next.Next()
+		if stop {
+			return 0, true
+		}
+
+		if val < last {
+			val = last
+		}
+		last = val
+		return val, false
+	})
+
+	reset := func() Backoff {
+		l.Lock()
+		defer l.Unlock()
+		last = 0
+
+		next.Reset()
+		return nextMonotonic
+	}
+
+	b := decorate("monotonic", reset, next, nextMonotonic, nil)
+	b.save = func() ([]byte, error) {
+		l.Lock()
+		defer l.Unlock()
+
+		return encodeInts(int64(last)), nil
+	}
+	b.load = func(state []byte) error {
+		d, err := decodeDuration(state, 0, math.MaxInt64)
+		if err != nil {
+			return err
+		}
+
+		l.Lock()
+		defer l.Unlock()
+		last = d
+		return nil
+	}
+	return b
+}
+
+// WithMaxRetries stops after max values from next, which allows max retries:
+// retry.Do with it calls f up to max+1 times, the first attempt plus max
+// retries. Use WithMaxAttempts to count the first attempt too. Its
+// RetriesExhausted method tells whether it stopped because the limit ran out
+// or because next stopped.
 func WithMaxRetries(max uint64, next Backoff) *ResettableBackoff {
+	return withRetryLimit(max, next)
+}
+
+// WithMaxAttempts is like WithMaxRetries, but n counts every call of the
+// function, the first attempt included: retry.Do with it calls f at most n
+// times, so WithMaxAttempts(1, b) allows no retries. It is the same as
+// WithMaxRetries(n-1, next). An n of 0 is treated as 1, since the first
+// attempt is always made.
+func WithMaxAttempts(n uint64, next Backoff) *ResettableBackoff {
+	if n == 0 {
+		n = 1
+	}
+	b := withRetryLimit(n-1, next)
+	b.name = fmt.Sprintf("max_attempts(%d)", n)
+	return b
+}
+
+// withRetryLimit implements WithMaxRetries and WithMaxAttempts: it stops after
+// max values from next.
+func withRetryLimit(max uint64, next Backoff) *ResettableBackoff {
 	var l sync.Mutex
 	var attempt uint64
+	var exhausted bool
 
 	nextWithMaxRetries := BackoffFunc(func() (time.Duration, bool) {
 		l.Lock()
 		defer l.Unlock()
 
-		if attempt >= max {
+		exhausted = attempt >= max
+		if exhausted {
 			return 0, true
 		}
 		attempt++
@@ -156,18 +670,51 @@ func WithMaxRetries(max uint64, next Backoff) *ResettableBackoff {
 		l.Lock()
 		defer l.Unlock()
 		attempt = 0
+		exhausted = false
 
 		next.Reset()
 		return nextWithMaxRetries
 	}
 
-	return WithReset(reset, nextWithMaxRetries)
+	b := decorate(fmt.Sprintf("max_retries(%d)", max), reset, next, nextWithMaxRetries, nil)
+	b.exhausted = func() bool {
+		l.Lock()
+		defer l.Unlock()
+
+		return exhausted
+	}
+	b.remaining = func() uint64 {
+		l.Lock()
+		defer l.Unlock()
+
+		return max - attempt
+	}
+	b.save = func() ([]byte, error) {
+		l.Lock()
+		defer l.Unlock()
+
+		return encodeInts(int64(attempt)), nil
+	}
+	b.load = func(state []byte) error {
+		n, err := decodeCount(state, max)
+		if err != nil {
+			return err
+		}
+
+		l.Lock()
+		defer l.Unlock()
+		attempt = n
+		exhausted = false
+		return nil
+	}
+	return b
 }
 
 // WithCappedDuration sets a maximum on the duration returned from the next
 // backoff. This is NOT a total backoff time, but rather a cap on the maximum
-// value a backoff can return. Without another middleware, the backoff will
-// continue infinitely.
+// value a backoff can return. A zero from the next backoff, which asks for an
+// immediate retry, is kept, and negative values become zero. Without another
+// middleware, the backoff will continue infinitely.
 func WithCappedDuration(cap time.Duration, next Backoff) *ResettableBackoff {
 	nextWithCappedDuration := BackoffFunc(func() (time.Duration, bool) {
 		val, stop := next.Next()
@@ -175,7 +722,10 @@ func WithCappedDuration(cap time.Duration, next Backoff) *ResettableBackoff {
 			return 0, true
 		}
 
-		if val <= 0 || val > cap {
+		switch {
+		case val < 0:
+			val = 0
+		case val > cap:
 			val = cap
 		}
 		return val, false
@@ -186,21 +736,88 @@ func WithCappedDuration(cap time.Duration, next Backoff) *ResettableBackoff {
 		return nextWithCappedDuration
 	}
 
-	return WithReset(reset, nextWithCappedDuration)
+	return decorate(fmt.Sprintf("cap(%v)", cap), reset, next, nextWithCappedDuration, nil).stateless()
+}
+
+// WithImmediateFirst wraps a backoff so that its first value is 0, for an
+// immediate retry, and the values after that come from next, starting with its
+// first. Reset makes the next value immediate again.
+//
+// The immediate value doesn't consult next, so limits on next don't count it.
+// To count it, put the limit outside: WithMaxRetries(3, WithImmediateFirst(b))
+// makes the immediate retry the first of the three.
+func WithImmediateFirst(next Backoff) *ResettableBackoff {
+	var used atomic.Bool
+
+	nextWithImmediateFirst := BackoffFunc(func() (time.Duration, bool) {
+		if used.CompareAndSwap(false, true) {
+			return 0, false
+		}
+
+		val, stop := next.Next()
+		if stop {
+			return 0, true
+		}
+		return val, false
+	})
+
+	reset := func() Backoff {
+		used.Store(false)
+
+		next.Reset()
+		return nextWithImmediateFirst
+	}
+
+	b := decorate("immediate_first", reset, next, nextWithImmediateFirst, nil)
+	b.save = func() ([]byte, error) {
+		var n int64
+		if used.Load() {
+			n = 1
+		}
+		return encodeInts(n), nil
+	}
+	b.load = func(state []byte) error {
+		n, err := decodeCount(state, 1)
+		if err != nil {
+			return err
+		}
+		used.Store(n == 1)
+		return nil
+	}
+	return b
 }
 
 // WithMaxDuration sets a maximum on the total amount of time a backoff should
-// execute. It's best-effort, and should not be used to guarantee an exact
-// amount of time.
+// execute. The time is measured from the first call to Next, not from when the
+// backoff is created, so a backoff built ahead of time keeps its full budget.
+// It's best-effort, and should not be used to guarantee an exact amount of
+// time.
+//
+// Time spent in the retried function counts against the budget as much as
+// time spent sleeping, so slow attempts leave fewer retries: with a 30s budget,
+// a 25s second attempt leaves at most 5s. Use WithMaxSleepWindow to count only
+// the time between attempts.
 func WithMaxDuration(timeout time.Duration, next Backoff) *ResettableBackoff {
-	var l sync.RWMutex
-	start := time.Now()
+	return WithMaxDurationClock(timeout, nil, next)
+}
+
+// WithMaxDurationClock is like WithMaxDuration, but measures the elapsed time
+// with c instead of the real clock. A nil c selects the real clock.
+func WithMaxDurationClock(timeout time.Duration, c Clock, next Backoff) *ResettableBackoff {
+	c = clock.Or(c)
+	var l sync.Mutex
+	var start time.Time
 
 	nextWithMaxDuration := BackoffFunc(func() (time.Duration, bool) {
-		l.RLock()
-		defer l.RUnlock()
+		l.Lock()
+		defer l.Unlock()
+
+		now := c.Now()
+		if start.IsZero() {
+			start = now
+		}
 
-		diff := timeout - time.Since(start)
+		diff := timeout - now.Sub(start)
 		if diff <= 0 {
 			return 0, true
 		}
@@ -219,18 +836,212 @@ func WithMaxDuration(timeout time.Duration, next Backoff) *ResettableBackoff {
 	reset := func() Backoff {
 		l.Lock()
 		defer l.Unlock()
-		start = time.Now()
+		start = time.Time{}
 
 		next.Reset()
 		return nextWithMaxDuration
 	}
 
-	return WithReset(reset, nextWithMaxDuration)
+	b := decorate(fmt.Sprintf("max_duration(%v)", timeout), reset, next, nextWithMaxDuration, nil)
+	b.remainingTime = func() time.Duration {
+		l.Lock()
+		defer l.Unlock()
+
+		left := timeout
+		if !start.IsZero() {
+			left -= c.Now().Sub(start)
+		}
+		if left < 0 {
+			return 0
+		}
+		return left
+	}
+	return b
+}
+
+// WithMaxSleepWindow is like WithMaxDuration, but excludes the time spent in
+// attempts from the budget, so only the time between attempts counts, however
+// slow the retried function is. It learns when attempts start and finish
+// through the AttemptObserver interface, as the loops of the retry package
+// report them; in a loop that doesn't report attempts it behaves exactly like
+// WithMaxDuration.
+//
+// Unlike WithMaxCumulativeSleep, which adds up the values returned, it
+// measures the time that actually passes, including whatever the loop does
+// between attempts besides sleeping.
+func WithMaxSleepWindow(timeout time.Duration, next Backoff) *ResettableBackoff {
+	return WithMaxSleepWindowClock(timeout, nil, next)
+}
+
+// WithMaxSleepWindowClock is like WithMaxSleepWindow, but measures the elapsed
+// time with c instead of the real clock. A nil c selects the real clock.
+func WithMaxSleepWindowClock(timeout time.Duration, c Clock, next Backoff) *ResettableBackoff {
+	c = clock.Or(c)
+	var l sync.Mutex
+	// start is when the window opened, and excluded the time spent in the
+	// attempts that have finished since. attemptStart is when the current
+	// attempt started, or zero between attempts.
+	var start, attemptStart time.Time
+	var excluded time.Duration
+
+	// elapsed returns how much of the window has been used. The caller must
+	// hold l.
+	elapsed := func(now time.Time) time.Duration {
+		if start.IsZero() {
+			return 0
+		}
+		d := now.Sub(start) - excluded
+		if !attemptStart.IsZero() {
+			d -= now.Sub(attemptStart)
+		}
+		return d
+	}
+
+	nextWithSleepWindow := BackoffFunc(func() (time.Duration, bool) {
+		l.Lock()
+		defer l.Unlock()
+
+		now := c.Now()
+		if start.IsZero() {
+			start = now
+		}
+
+		diff := timeout - elapsed(now)
+		if diff <= 0 {
+			return 0, true
+		}
+
+		val, stop := next.Next()
+		if stop {
+			return 0, true
+		}
+
+		if val <= 0 || val > diff {
+			val = diff
+		}
+		return val, false
+	})
+
+	reset := func() Backoff {
+		l.Lock()
+		defer l.Unlock()
+		start, attemptStart = time.Time{}, time.Time{}
+		excluded = 0
+
+		next.Reset()
+		return nextWithSleepWindow
+	}
+
+	b := decorate(fmt.Sprintf("max_sleep_window(%v)", timeout), reset, next, nextWithSleepWindow, nil)
+	b.started = func() {
+		l.Lock()
+		defer l.Unlock()
+
+		now := c.Now()
+		if start.IsZero() {
+			start = now
+		}
+		attemptStart = now
+	}
+	b.finished = func() {
+		l.Lock()
+		defer l.Unlock()
+
+		if !attemptStart.IsZero() {
+			excluded += c.Now().Sub(attemptStart)
+			attemptStart = time.Time{}
+		}
+	}
+	b.remainingTime = func() time.Duration {
+		l.Lock()
+		defer l.Unlock()
+
+		if left := timeout - elapsed(c.Now()); left > 0 {
+			return left
+		}
+		return 0
+	}
+	return b
+}
+
+// WithMaxCumulativeSleep sets a maximum on the total of the durations the
+// backoff returns, which is the time spent sleeping between retries no matter
+// how long each retry takes. It signals to stop once returning the next value
+// would exceed budget. Negative values from next count as zero.
+func WithMaxCumulativeSleep(budget time.Duration, next Backoff) *ResettableBackoff {
+	return withCumulativeSleep(budget, false, next)
+}
+
+// WithTruncatedCumulativeSleep is like WithMaxCumulativeSleep, but instead of
+// stopping when the next value would exceed budget, it returns what is left of
+// budget, and stops after that.
+func WithTruncatedCumulativeSleep(budget time.Duration, next Backoff) *ResettableBackoff {
+	return withCumulativeSleep(budget, true, next)
+}
+
+func withCumulativeSleep(budget time.Duration, truncate bool, next Backoff) *ResettableBackoff {
+	var l sync.Mutex
+	var slept time.Duration
+
+	nextWithCumulativeSleep := BackoffFunc(func() (time.Duration, bool) {
+		l.Lock()
+		defer l.Unlock()
+
+		val, stop := next.Next()
+		if stop {
+			return 0, true
+		}
+
+		if val < 0 {
+			val = 0
+		}
+		if left := budget - slept; val > left {
+			if !truncate || left <= 0 {
+				return 0, true
+			}
+			val = left
+		}
+		slept += val
+		return val, false
+	})
+
+	reset := func() Backoff {
+		l.Lock()
+		defer l.Unlock()
+		slept = 0
+
+		next.Reset()
+		return nextWithCumulativeSleep
+	}
+
+	name := fmt.Sprintf("max_cumulative_sleep(%v)", budget)
+	if truncate {
+		name = fmt.Sprintf("truncated_cumulative_sleep(%v)", budget)
+	}
+	b := decorate(name, reset, next, nextWithCumulativeSleep, nil)
+	b.save = func() ([]byte, error) {
+		l.Lock()
+		defer l.Unlock()
+
+		return encodeInts(int64(slept)), nil
+	}
+	b.load = func(state []byte) error {
+		d, err := decodeDuration(state, 0, max(budget, 0))
+		if err != nil {
+			return err
+		}
+
+		l.Lock()
+		defer l.Unlock()
+		slept = d
+		return nil
+	}
+	return b
 }
 
 // WithContext creates a Backoff that stops if the context is done.
-func WithContext(ctx context.Context, next Backoff) Backoff {
-	return BackoffFunc(func() (time.Duration, bool) {
+func WithContext(ctx context.Context, next Backoff) *ResettableBackoff {
+	nextWithContext := BackoffFunc(func() (time.Duration, bool) {
 		select {
 		case <-ctx.Done():
 			return 0, true
@@ -238,4 +1049,61 @@ func WithContext(ctx context.Context, next Backoff) Backoff {
 			return next.Next()
 		}
 	})
+
+	reset := func() Backoff {
+		next.Reset()
+		return nextWithContext
+	}
+
+	return decorate("context", reset, next, nextWithContext, nil).stateless()
+}
+
+// WithDeadline is like WithContext, but also knows about ctx's deadline, if it
+// has one: it caps each value at the time left before the deadline, and
+// signals to stop once none is left, so a loop never plans a sleep that ctx
+// would cut short. Its RemainingTime reports the time left before the
+// deadline. Without a deadline it behaves exactly like WithContext.
+func WithDeadline(ctx context.Context, next Backoff) *ResettableBackoff {
+	deadline, hasDeadline := ctx.Deadline()
+
+	nextWithDeadline := BackoffFunc(func() (time.Duration, bool) {
+		select {
+		case <-ctx.Done():
+			return 0, true
+		default:
+		}
+		if !hasDeadline {
+			return next.Next()
+		}
+
+		left := time.Until(deadline)
+		if left <= 0 {
+			return 0, true
+		}
+
+		val, stop := next.Next()
+		if stop {
+			return 0, true
+		}
+		if val > left {
+			val = left
+		}
+		return val, false
+	})
+
+	reset := func() Backoff {
+		next.Reset()
+		return nextWithDeadline
+	}
+
+	b := decorate("deadline", reset, next, nextWithDeadline, nil).stateless()
+	if hasDeadline {
+		b.remainingTime = func() time.Duration {
+			if left := time.Until(deadline); left > 0 {
+				return left
+			}
+			return 0
+		}
+	}
+	return b
 }
@@ -12,7 +12,32 @@ func NewConstant(t time.Duration) (Backoff, error) {
 		return nil, fmt.Errorf("constant backoff must be greater than zero")
 	}
 
-	return BackoffFunc(func() (time.Duration, bool) {
-		return t, false
-	}), nil
+	return constantBackoff{t: t}, nil
+}
+
+type constantBackoff struct {
+	t time.Duration
+}
+
+// Next implements Backoff.
+func (b constantBackoff) Next() (time.Duration, bool) {
+	return b.t, false
+}
+
+// Reset implements Backoff.
+func (b constantBackoff) Reset() {}
+
+// String describes the backoff, such as "constant(1s)".
+func (b constantBackoff) String() string {
+	return fmt.Sprintf("constant(%v)", b.t)
+}
+
+// Snapshot implements Snapshotter. A constant backoff has no state.
+func (b constantBackoff) Snapshot() ([]byte, error) {
+	return nil, nil
+}
+
+// Restore implements Snapshotter.
+func (b constantBackoff) Restore(state []byte) error {
+	return noState(state)
 }
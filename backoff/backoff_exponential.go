@@ -10,6 +10,8 @@ import (
 type exponentialBackoff struct {
 	base    time.Duration
 	attempt uint64
+	// maxShifts is the number of doublings after which the value holds.
+	maxShifts uint64
 }
 
 // NewExponential creates a new exponential backoff using the starting value of
@@ -25,21 +27,83 @@ func NewExponential(base time.Duration) (Backoff, error) {
 	}
 
 	return &exponentialBackoff{
-		base: base,
+		base:      base,
+		maxShifts: math.MaxUint64,
+	}, nil
+}
+
+// NewExponentialLimited is like NewExponential, but stops doubling after
+// maxShifts doublings and holds that value, base << maxShifts, from then on.
+// For example, a base of 1s and maxShifts of 3 gives 1s, 2s, 4s, 8s, 8s...
+//
+// It returns an error if base is not greater than zero, if maxShifts is not
+// less than 63, or if the value held would overflow.
+func NewExponentialLimited(base time.Duration, maxShifts uint64) (Backoff, error) {
+	if base <= 0 {
+		return nil, fmt.Errorf("base must be greater than 0")
+	}
+	if maxShifts >= 63 {
+		return nil, fmt.Errorf("maxShifts must be less than 63")
+	}
+	if base > math.MaxInt64>>maxShifts {
+		return nil, fmt.Errorf("base %v doubled %d times overflows", base, maxShifts)
+	}
+
+	return &exponentialBackoff{
+		base:      base,
+		maxShifts: maxShifts,
 	}, nil
 }
 
 // Next implements Backoff. It is safe for concurrent use.
 func (b *exponentialBackoff) Next() (time.Duration, bool) {
-	next := b.base << (atomic.AddUint64(&b.attempt, 1) - 1)
-	if next <= 0 {
-		atomic.AddUint64(&b.attempt, ^uint64(0))
-		next = math.MaxInt64
-	}
+	for {
+		attempt := atomic.LoadUint64(&b.attempt)
+		if attempt >= b.maxShifts {
+			return b.base << b.maxShifts, false
+		}
+
+		next := b.base << attempt
+		if next <= 0 {
+			// Stay pegged without moving attempt, so a concurrent Reset is
+			// never undone.
+			return math.MaxInt64, false
+		}
 
-	return next, false
+		if atomic.CompareAndSwapUint64(&b.attempt, attempt, attempt+1) {
+			return next, false
+		}
+	}
 }
 
+// Reset implements Backoff. It is safe to call concurrently with Next, and
+// takes effect atomically: each call of Next returns a value from either
+// before or after the reset, and the first to start after Reset returns gets
+// base.
 func (b *exponentialBackoff) Reset() {
 	atomic.StoreUint64(&b.attempt, 0)
 }
+
+// String describes the backoff, such as "exponential(1s)", or
+// "exponential_limited(1s, 3)" for one from NewExponentialLimited.
+func (b *exponentialBackoff) String() string {
+	if b.maxShifts == math.MaxUint64 {
+		return fmt.Sprintf("exponential(%v)", b.base)
+	}
+	return fmt.Sprintf("exponential_limited(%v, %d)", b.base, b.maxShifts)
+}
+
+// Snapshot implements Snapshotter. It saves the number of doublings so far.
+func (b *exponentialBackoff) Snapshot() ([]byte, error) {
+	return encodeInts(int64(atomic.LoadUint64(&b.attempt))), nil
+}
+
+// Restore implements Snapshotter.
+func (b *exponentialBackoff) Restore(state []byte) error {
+	n, err := decodeCount(state, min(b.maxShifts, 64))
+	if err != nil {
+		return err
+	}
+	atomic.StoreUint64(&b.attempt, n)
+	return nil
+}
@@ -115,6 +115,132 @@ func TestExponentialBackoff(t *testing.T) {
 	}
 }
 
+func TestExponentialLimited(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name      string
+		base      time.Duration
+		maxShifts uint64
+		exp       []time.Duration
+		expectErr bool
+	}{
+		{
+			name:      "plateau",
+			base:      1 * time.Second,
+			maxShifts: 3,
+			exp: []time.Duration{
+				1 * time.Second,
+				2 * time.Second,
+				4 * time.Second,
+				8 * time.Second,
+				8 * time.Second,
+				8 * time.Second,
+			},
+		},
+		{
+			name:      "no_shifts",
+			base:      1 * time.Second,
+			maxShifts: 0,
+			exp:       []time.Duration{1 * time.Second, 1 * time.Second, 1 * time.Second},
+		},
+		{
+			name:      "largest_shift",
+			base:      1 * time.Nanosecond,
+			maxShifts: 62,
+			exp:       []time.Duration{1 << 0, 1 << 1, 1 << 2},
+		},
+		{
+			name:      "bad_base",
+			base:      0,
+			maxShifts: 3,
+			expectErr: true,
+		},
+		{
+			name:      "too_many_shifts",
+			base:      1 * time.Nanosecond,
+			maxShifts: 63,
+			expectErr: true,
+		},
+		{
+			name:      "overflowing_plateau",
+			base:      1 * time.Hour,
+			maxShifts: 30,
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			b, err := NewExponentialLimited(tc.base, tc.maxShifts)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var results []time.Duration
+			for range tc.exp {
+				val, stop := b.Next()
+				if stop {
+					t.Fatal("should not stop")
+				}
+				results = append(results, val)
+			}
+			if !reflect.DeepEqual(results, tc.exp) {
+				t.Errorf("expected %v to be %v", results, tc.exp)
+			}
+		})
+	}
+
+	t.Run("reset", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := NewExponentialLimited(1*time.Second, 2)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		exp := []time.Duration{1 * time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second}
+		for round := 0; round < 2; round++ {
+			var results []time.Duration
+			for range exp {
+				val, _ := b.Next()
+				results = append(results, val)
+			}
+			if !reflect.DeepEqual(results, exp) {
+				t.Errorf("round %d: expected %v to be %v", round, results, exp)
+			}
+			b.Reset()
+		}
+	})
+}
+
+func TestExponentialBackoff_concurrentReset(t *testing.T) {
+	t.Parallel()
+
+	// Large enough to overflow within a round.
+	base := time.Duration(1 << 40)
+	b, err := NewExponential(base)
+	if err != nil {
+		t.Fatalf("failed to create exponential backoff: %v", err)
+	}
+
+	checkConcurrentReset(t, b, base, func(val time.Duration) bool {
+		if val == math.MaxInt64 {
+			return true
+		}
+		return val >= base && val%base == 0 && (val/base)&(val/base-1) == 0
+	})
+}
+
 func TestExponentialBackoff_WithReset(t *testing.T) {
 	base := 2 * time.Second
 	numRounds := 3
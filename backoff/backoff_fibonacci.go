@@ -51,6 +51,34 @@ func (b *fibonacciBackoff) Next() (time.Duration, bool) {
 	}
 }
 
+// Reset implements Backoff. Like that of NewExponential, it is safe to call
+// concurrently with Next and takes effect atomically: it installs a fresh
+// state, so a Next that read the old one fails its swap and starts over from
+// the reset state.
 func (b *fibonacciBackoff) Reset() {
 	atomic.StorePointer(&b.state, unsafe.Pointer(&state{0, b.base}))
 }
+
+// String describes the backoff, such as "fibonacci(1s)".
+func (b *fibonacciBackoff) String() string {
+	return fmt.Sprintf("fibonacci(%v)", b.base)
+}
+
+// Snapshot implements Snapshotter. It saves the last two values.
+func (b *fibonacciBackoff) Snapshot() ([]byte, error) {
+	s := (*state)(atomic.LoadPointer(&b.state))
+	return encodeInts(int64(s[0]), int64(s[1])), nil
+}
+
+// Restore implements Snapshotter.
+func (b *fibonacciBackoff) Restore(st []byte) error {
+	var prev, curr int64
+	if err := decodeInts(st, &prev, &curr); err != nil {
+		return err
+	}
+	if prev < 0 || curr < int64(b.base) || prev > curr {
+		return fmt.Errorf("%w: values %v and %v out of sequence", ErrInvalidSnapshot, time.Duration(prev), time.Duration(curr))
+	}
+	atomic.StorePointer(&b.state, unsafe.Pointer(&state{time.Duration(prev), time.Duration(curr)}))
+	return nil
+}
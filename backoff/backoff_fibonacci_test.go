@@ -127,6 +127,25 @@ func TestFibonacciBackoff(t *testing.T) {
 	}
 }
 
+func TestFibonacciBackoff_concurrentReset(t *testing.T) {
+	t.Parallel()
+
+	// Large enough to overflow within a round.
+	base := time.Duration(1 << 30)
+	b, err := NewFibonacci(base)
+	if err != nil {
+		t.Fatalf("failed to create fibonacci backoff: %v", err)
+	}
+
+	fibs := map[time.Duration]bool{math.MaxInt64: true}
+	for prev, curr := time.Duration(0), base; curr > 0; prev, curr = curr, prev+curr {
+		fibs[curr] = true
+	}
+	checkConcurrentReset(t, b, base, func(val time.Duration) bool {
+		return fibs[val]
+	})
+}
+
 func TestFibonacciBackoff_WithReset(t *testing.T) {
 	base := 1 * time.Second
 	numRounds := 5
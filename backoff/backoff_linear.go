@@ -0,0 +1,78 @@
+package backoff
+
+import (
+	"fmt"
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+type linearBackoff struct {
+	base      time.Duration
+	increment time.Duration
+	attempt   uint64
+}
+
+// NewLinear creates a new linear backoff using the starting value of base and
+// adding increment on each failure (base, base+increment, base+2*increment...).
+//
+// Once it overflows, the function constantly returns the maximum time.Duration
+// for a 64-bit integer.
+//
+// It returns an error if base is not greater than zero or increment is
+// negative.
+func NewLinear(base, increment time.Duration) (Backoff, error) {
+	if base <= 0 {
+		return nil, fmt.Errorf("base must be greater than 0")
+	}
+	if increment < 0 {
+		return nil, fmt.Errorf("increment must not be negative")
+	}
+
+	return &linearBackoff{
+		base:      base,
+		increment: increment,
+	}, nil
+}
+
+// Next implements Backoff. It is safe for concurrent use.
+func (b *linearBackoff) Next() (time.Duration, bool) {
+	for {
+		n := atomic.LoadUint64(&b.attempt)
+		if b.increment > 0 && n > uint64((math.MaxInt64-b.base)/b.increment) {
+			// Stay pegged without moving attempt, so a concurrent Reset is
+			// never undone.
+			return math.MaxInt64, false
+		}
+
+		if atomic.CompareAndSwapUint64(&b.attempt, n, n+1) {
+			return b.base + time.Duration(n)*b.increment, false
+		}
+	}
+}
+
+// Reset implements Backoff. Like that of NewExponential, it is safe to call
+// concurrently with Next and takes effect atomically.
+func (b *linearBackoff) Reset() {
+	atomic.StoreUint64(&b.attempt, 0)
+}
+
+// String describes the backoff, such as "linear(1s, 500ms)".
+func (b *linearBackoff) String() string {
+	return fmt.Sprintf("linear(%v, %v)", b.base, b.increment)
+}
+
+// Snapshot implements Snapshotter. It saves the number of increments so far.
+func (b *linearBackoff) Snapshot() ([]byte, error) {
+	return encodeInts(int64(atomic.LoadUint64(&b.attempt))), nil
+}
+
+// Restore implements Snapshotter.
+func (b *linearBackoff) Restore(state []byte) error {
+	n, err := decodeCount(state, math.MaxInt64)
+	if err != nil {
+		return err
+	}
+	atomic.StoreUint64(&b.attempt, n)
+	return nil
+}
@@ -0,0 +1,178 @@
+package backoff
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestLinearBackoff(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name      string
+		base      time.Duration
+		increment time.Duration
+		tries     int
+		exp       []time.Duration
+		expectErr bool
+	}{
+		{
+			name:      "single",
+			base:      1 * time.Nanosecond,
+			increment: 1 * time.Nanosecond,
+			tries:     1,
+			exp: []time.Duration{
+				1 * time.Nanosecond,
+			},
+		},
+		{
+			name:      "many",
+			base:      2 * time.Nanosecond,
+			increment: 3 * time.Nanosecond,
+			tries:     8,
+			exp: []time.Duration{
+				2 * time.Nanosecond,
+				5 * time.Nanosecond,
+				8 * time.Nanosecond,
+				11 * time.Nanosecond,
+				14 * time.Nanosecond,
+				17 * time.Nanosecond,
+				20 * time.Nanosecond,
+				23 * time.Nanosecond,
+			},
+		},
+		{
+			name:      "zero_increment",
+			base:      5 * time.Nanosecond,
+			increment: 0,
+			tries:     3,
+			exp: []time.Duration{
+				5 * time.Nanosecond,
+				5 * time.Nanosecond,
+				5 * time.Nanosecond,
+			},
+		},
+		{
+			name:      "overflow",
+			base:      math.MaxInt64 - 10,
+			increment: 4,
+			tries:     6,
+			exp: []time.Duration{
+				math.MaxInt64 - 10,
+				math.MaxInt64 - 6,
+				math.MaxInt64 - 2,
+				math.MaxInt64,
+				math.MaxInt64,
+				math.MaxInt64,
+			},
+		},
+		{
+			name:      "bad input duration",
+			base:      0 * time.Nanosecond,
+			increment: 1 * time.Nanosecond,
+			tries:     0,
+			exp:       []time.Duration{},
+			expectErr: true,
+		},
+		{
+			name:      "bad input increment",
+			base:      1 * time.Nanosecond,
+			increment: -1 * time.Nanosecond,
+			tries:     0,
+			exp:       []time.Duration{},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			b, err := NewLinear(tc.base, tc.increment)
+			if tc.expectErr && err == nil {
+				t.Fatal("expected an error")
+			}
+			if !tc.expectErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			resultsCh := make(chan time.Duration, tc.tries)
+			for i := 0; i < tc.tries; i++ {
+				go func() {
+					r, _ := b.Next()
+					resultsCh <- r
+				}()
+			}
+
+			results := make([]time.Duration, tc.tries)
+			for i := 0; i < tc.tries; i++ {
+				select {
+				case val := <-resultsCh:
+					results[i] = val
+				case <-time.After(5 * time.Second):
+					t.Fatal("timeout")
+				}
+			}
+			sort.Slice(results, func(i, j int) bool {
+				return results[i] < results[j]
+			})
+
+			if !reflect.DeepEqual(results, tc.exp) {
+				t.Errorf("expected \n\n%v\n\n to be \n\n%v\n\n", results, tc.exp)
+			}
+		})
+	}
+}
+
+func TestLinearBackoff_concurrentReset(t *testing.T) {
+	t.Parallel()
+
+	// Large enough to overflow within a round.
+	base, increment := time.Duration(1<<60), time.Duration(1<<56)
+	b, err := NewLinear(base, increment)
+	if err != nil {
+		t.Fatalf("failed to create linear backoff: %v", err)
+	}
+
+	checkConcurrentReset(t, b, base, func(val time.Duration) bool {
+		return val == math.MaxInt64 || (val >= base && (val-base)%increment == 0)
+	})
+}
+
+func TestLinearBackoff_Reset(t *testing.T) {
+	base := 2 * time.Second
+	increment := 1 * time.Second
+	numRounds := 3
+	expected := []time.Duration{
+		2 * time.Second,
+		3 * time.Second,
+		4 * time.Second,
+	}
+
+	b, err := NewLinear(base, increment)
+	if err != nil {
+		t.Fatalf("failed to create linear backoff: %v", err)
+	}
+
+	// test pre reset
+	for i := 0; i < numRounds; i++ {
+		val, _ := b.Next()
+		if val != expected[i] {
+			t.Errorf("pre reset: expected %v to be %v", val, expected[i])
+		}
+	}
+
+	// test post reset. since we reset we expect the same sequence of values as before
+	b.Reset()
+	for i := 0; i < numRounds; i++ {
+		val, _ := b.Next()
+		if val != expected[i] {
+			t.Errorf("post reset: expected %v to be %v", val, expected[i])
+		}
+	}
+}
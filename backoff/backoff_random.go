@@ -0,0 +1,75 @@
+package backoff
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+type randomBetweenBackoff struct {
+	min time.Duration
+	// span is the number of values to draw from, max-min+1.
+	span int64
+	r    *source
+}
+
+// NewRandomBetween creates a backoff whose every value is an independent,
+// uniformly distributed draw between min and max, inclusive. It never signals
+// to stop, and Reset has no effect.
+//
+// It returns an error if min is not greater than zero or max is less than min.
+func NewRandomBetween(min, max time.Duration) (Backoff, error) {
+	return NewRandomBetweenSource(min, max, nil)
+}
+
+// NewRandomBetweenSource is like NewRandomBetween, but draws the values from
+// src, as WithJitterSource does. A nil src selects a self-seeded source.
+func NewRandomBetweenSource(min, max time.Duration, src rand.Source64) (Backoff, error) {
+	if min <= 0 {
+		return nil, fmt.Errorf("min must be greater than 0")
+	}
+	if max < min {
+		return nil, fmt.Errorf("max must not be less than min")
+	}
+
+	return &randomBetweenBackoff{
+		min:  min,
+		span: int64(max-min) + 1,
+		r:    newSource(src),
+	}, nil
+}
+
+// Next implements Backoff. It is safe for concurrent use.
+func (b *randomBetweenBackoff) Next() (time.Duration, bool) {
+	return b.min + time.Duration(b.r.Int63n(b.span)), false
+}
+
+// Reset implements Backoff. Every value is drawn independently, so there is
+// nothing to reset.
+func (b *randomBetweenBackoff) Reset() {}
+
+// Randomized implements Randomized.
+func (b *randomBetweenBackoff) Randomized() bool {
+	return true
+}
+
+// SetRandomSource implements RandomSourceSetter.
+func (b *randomBetweenBackoff) SetRandomSource(src rand.Source64) {
+	b.r.set(src)
+}
+
+// String describes the backoff, such as "random_between(1s, 2s)".
+func (b *randomBetweenBackoff) String() string {
+	return fmt.Sprintf("random_between(%v, %v)", b.min, b.min+time.Duration(b.span-1))
+}
+
+// Snapshot implements Snapshotter. Every value is drawn independently, so
+// there is no state to save.
+func (b *randomBetweenBackoff) Snapshot() ([]byte, error) {
+	return nil, nil
+}
+
+// Restore implements Snapshotter.
+func (b *randomBetweenBackoff) Restore(state []byte) error {
+	return noState(state)
+}
@@ -0,0 +1,167 @@
+package backoff
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRandomBetweenBackoff(t *testing.T) {
+	t.Parallel()
+
+	t.Run("bounds_and_spread", func(t *testing.T) {
+		t.Parallel()
+
+		low, high := 10*time.Millisecond, 20*time.Millisecond
+		b, err := NewRandomBetween(low, high)
+		if err != nil {
+			t.Fatalf("failed to create random backoff: %v", err)
+		}
+
+		// Split the range into ten buckets; uniform draws fill each of them.
+		const draws = 10000
+		var buckets [10]int
+		lo, hi := high, low
+		for i := 0; i < draws; i++ {
+			val, stop := b.Next()
+			if stop {
+				t.Fatalf("stopped after %d draws", i)
+			}
+			if val < low || val > high {
+				t.Fatalf("expected %v to be between %v and %v", val, low, high)
+			}
+			lo, hi = min(lo, val), max(hi, val)
+			buckets[int64(val-low)*int64(len(buckets))/int64(high-low+1)]++
+		}
+		if hi-lo < (high-low)*9/10 {
+			t.Errorf("expected draws to spread over the range, got %v to %v", lo, hi)
+		}
+		for i, n := range buckets {
+			if n < draws/len(buckets)/2 {
+				t.Errorf("bucket %d: expected about %d draws, got %d", i, draws/len(buckets), n)
+			}
+		}
+	})
+
+	t.Run("min_equals_max", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := NewRandomBetween(time.Second, time.Second)
+		if err != nil {
+			t.Fatalf("failed to create random backoff: %v", err)
+		}
+		for i := 0; i < 100; i++ {
+			if val, stop := b.Next(); stop || val != time.Second {
+				t.Fatalf("expected (%v, %t) to be (%v, false)", val, stop, time.Second)
+			}
+		}
+	})
+
+	t.Run("full_range", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := NewRandomBetween(1, math.MaxInt64)
+		if err != nil {
+			t.Fatalf("failed to create random backoff: %v", err)
+		}
+		for i := 0; i < 100; i++ {
+			if val, stop := b.Next(); stop || val < 1 {
+				t.Fatalf("expected (%v, %t) to be positive and not stop", val, stop)
+			}
+		}
+	})
+
+	t.Run("reset_is_noop", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := NewRandomBetweenSource(time.Second, 2*time.Second, rand.NewSource(1).(rand.Source64))
+		if err != nil {
+			t.Fatalf("failed to create random backoff: %v", err)
+		}
+		first, _ := b.Next()
+		b.Reset()
+
+		same, err := NewRandomBetweenSource(time.Second, 2*time.Second, rand.NewSource(1).(rand.Source64))
+		if err != nil {
+			t.Fatalf("failed to create random backoff: %v", err)
+		}
+		same.Next()
+
+		// Reset doesn't rewind the draws.
+		got, _ := b.Next()
+		exp, _ := same.Next()
+		if got != exp {
+			t.Errorf("expected %v to be %v (first draw was %v)", got, exp, first)
+		}
+	})
+
+	t.Run("seeded", func(t *testing.T) {
+		t.Parallel()
+
+		draw := func() []time.Duration {
+			b, err := NewRandomBetween(time.Second, time.Minute)
+			if err != nil {
+				t.Fatalf("failed to create random backoff: %v", err)
+			}
+			if err := UseRandomSource(b, rand.NewSource(42).(rand.Source64)); err != nil {
+				t.Fatalf("failed to set random source: %v", err)
+			}
+			vals := make([]time.Duration, 5)
+			for i := range vals {
+				vals[i], _ = b.Next()
+			}
+			return vals
+		}
+
+		a, b := draw(), draw()
+		for i := range a {
+			if a[i] != b[i] {
+				t.Errorf("draw %d: expected %v to be %v", i, a[i], b[i])
+			}
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := NewRandomBetween(time.Second, 2*time.Second)
+		if err != nil {
+			t.Fatalf("failed to create random backoff: %v", err)
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 1000; j++ {
+					if val, _ := b.Next(); val < time.Second || val > 2*time.Second {
+						t.Errorf("expected %v to be between %v and %v", val, time.Second, 2*time.Second)
+						return
+					}
+				}
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+
+		cases := []struct {
+			name     string
+			min, max time.Duration
+		}{
+			{name: "zero_min", min: 0, max: time.Second},
+			{name: "negative_min", min: -time.Second, max: time.Second},
+			{name: "max_below_min", min: 2 * time.Second, max: time.Second},
+		}
+		for _, tc := range cases {
+			if b, err := NewRandomBetween(tc.min, tc.max); err == nil || b != nil {
+				t.Errorf("%s: expected an error and no backoff, got %v and %v", tc.name, err, b)
+			}
+		}
+	})
+}
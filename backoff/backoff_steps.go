@@ -0,0 +1,90 @@
+package backoff
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+type stepsBackoff struct {
+	steps    []time.Duration
+	holdLast bool
+	attempt  uint64
+}
+
+// NewSteps creates a backoff that returns the given durations in order, for a
+// hand-tuned schedule such as 100ms, 500ms, 2s, 10s, 30s. After the last
+// duration it signals to stop or, if holdLast is set, keeps returning the last
+// duration. durations is copied, so the caller may reuse it.
+//
+// It returns an error if durations is empty or any of them is not greater
+// than zero.
+func NewSteps(durations []time.Duration, holdLast bool) (Backoff, error) {
+	if len(durations) == 0 {
+		return nil, fmt.Errorf("durations must not be empty")
+	}
+	for i, d := range durations {
+		if d <= 0 {
+			return nil, fmt.Errorf("durations must be greater than 0, but duration %d is %v", i, d)
+		}
+	}
+
+	return &stepsBackoff{
+		steps:    append([]time.Duration(nil), durations...),
+		holdLast: holdLast,
+	}, nil
+}
+
+// Next implements Backoff. It is safe for concurrent use.
+func (b *stepsBackoff) Next() (time.Duration, bool) {
+	for {
+		n := atomic.LoadUint64(&b.attempt)
+		if n >= uint64(len(b.steps)) {
+			// Stay on the end without moving attempt, so a concurrent Reset
+			// is never undone.
+			if !b.holdLast {
+				return 0, true
+			}
+			return b.steps[len(b.steps)-1], false
+		}
+
+		if atomic.CompareAndSwapUint64(&b.attempt, n, n+1) {
+			return b.steps[n], false
+		}
+	}
+}
+
+// Reset implements Backoff. It is safe to call concurrently with Next, and
+// the first call of Next to start after Reset returns gets the first step.
+func (b *stepsBackoff) Reset() {
+	atomic.StoreUint64(&b.attempt, 0)
+}
+
+// String describes the backoff, such as "steps(1s, 5s, 30s)", with ", hold"
+// added if it holds the last step.
+func (b *stepsBackoff) String() string {
+	steps := make([]string, len(b.steps))
+	for i, d := range b.steps {
+		steps[i] = d.String()
+	}
+	if b.holdLast {
+		steps = append(steps, "hold")
+	}
+	return "steps(" + strings.Join(steps, ", ") + ")"
+}
+
+// Snapshot implements Snapshotter. It saves the number of steps taken.
+func (b *stepsBackoff) Snapshot() ([]byte, error) {
+	return encodeInts(int64(atomic.LoadUint64(&b.attempt))), nil
+}
+
+// Restore implements Snapshotter.
+func (b *stepsBackoff) Restore(state []byte) error {
+	n, err := decodeCount(state, uint64(len(b.steps)))
+	if err != nil {
+		return err
+	}
+	atomic.StoreUint64(&b.attempt, n)
+	return nil
+}
@@ -0,0 +1,157 @@
+package backoff
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStepsBackoff(t *testing.T) {
+	t.Parallel()
+
+	steps := []time.Duration{100 * time.Millisecond, 500 * time.Millisecond, 2 * time.Second}
+
+	cases := []struct {
+		name      string
+		durations []time.Duration
+		holdLast  bool
+		tries     int
+		exp       []time.Duration
+		expStops  int
+		expectErr bool
+	}{
+		{
+			name:      "stop",
+			durations: steps,
+			tries:     5,
+			exp:       steps,
+			expStops:  2,
+		},
+		{
+			name:      "hold_last",
+			durations: steps,
+			holdLast:  true,
+			tries:     5,
+			exp: []time.Duration{
+				100 * time.Millisecond,
+				500 * time.Millisecond,
+				2 * time.Second,
+				2 * time.Second,
+				2 * time.Second,
+			},
+		},
+		{
+			name:      "single",
+			durations: []time.Duration{1 * time.Second},
+			tries:     2,
+			exp:       []time.Duration{1 * time.Second},
+			expStops:  1,
+		},
+		{
+			name:      "bad input empty",
+			expectErr: true,
+		},
+		{
+			name:      "bad input zero",
+			durations: []time.Duration{1 * time.Second, 0},
+			expectErr: true,
+		},
+		{
+			name:      "bad input negative",
+			durations: []time.Duration{-1 * time.Second},
+			holdLast:  true,
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			b, err := NewSteps(tc.durations, tc.holdLast)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var mu sync.Mutex
+			var results []time.Duration
+			var stops int
+			var wg sync.WaitGroup
+			for i := 0; i < tc.tries; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+
+					val, stop := b.Next()
+					mu.Lock()
+					defer mu.Unlock()
+					if stop {
+						stops++
+						return
+					}
+					results = append(results, val)
+				}()
+			}
+			wg.Wait()
+			sort.Slice(results, func(i, j int) bool {
+				return results[i] < results[j]
+			})
+
+			if !reflect.DeepEqual(results, tc.exp) {
+				t.Errorf("expected \n\n%v\n\n to be \n\n%v\n\n", results, tc.exp)
+			}
+			if stops != tc.expStops {
+				t.Errorf("expected %d to be %d", stops, tc.expStops)
+			}
+		})
+	}
+}
+
+func TestStepsBackoff_Reset(t *testing.T) {
+	for _, holdLast := range []bool{false, true} {
+		durations := []time.Duration{1 * time.Second, 2 * time.Second}
+		b, err := NewSteps(durations, holdLast)
+		if err != nil {
+			t.Fatalf("failed to create steps backoff: %v", err)
+		}
+
+		// The caller's slice is copied.
+		durations[0] = time.Hour
+
+		// Run past the end, then start over.
+		for i := 0; i < 4; i++ {
+			b.Next()
+		}
+		b.Reset()
+		for i, want := range []time.Duration{1 * time.Second, 2 * time.Second} {
+			val, stop := b.Next()
+			if stop || val != want {
+				t.Errorf("holdLast %t: expected value %d %v, %t to be %v, false", holdLast, i, val, stop, want)
+			}
+		}
+	}
+}
+
+func TestStepsBackoff_concurrentReset(t *testing.T) {
+	t.Parallel()
+
+	base := 1 * time.Second
+	b, err := NewSteps([]time.Duration{base, 2 * time.Second}, true)
+	if err != nil {
+		t.Fatalf("failed to create steps backoff: %v", err)
+	}
+
+	checkConcurrentReset(t, b, base, func(val time.Duration) bool {
+		return val == base || val == 2*time.Second
+	})
+}
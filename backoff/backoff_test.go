@@ -2,8 +2,17 @@ package backoff
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/swayne275/go-retry/internal/clock"
 )
 
 func TestWithJitter_BadValues(t *testing.T) {
@@ -72,6 +81,205 @@ func TestWithJitter(t *testing.T) {
 	}
 }
 
+func TestWithPositiveJitter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("bounds", func(t *testing.T) {
+		t.Parallel()
+
+		baseDuration := 1 * time.Second
+		backoffJitter := 250 * time.Millisecond
+		b, err := WithPositiveJitter(backoffJitter, BackoffFunc(func() (time.Duration, bool) {
+			return baseDuration, false
+		}))
+		if err != nil {
+			t.Fatalf("failed to create backoff with jitter: %v", err)
+		}
+
+		sawJitter := false
+		for i := 0; i < 100_000; i++ {
+			val, stop := b.Next()
+			if stop {
+				t.Fatal("should not stop")
+			}
+			if val != baseDuration {
+				sawJitter = true
+			}
+			if min, max := baseDuration, baseDuration+backoffJitter; val < min || val >= max {
+				t.Fatalf("expected %v to be in [%v, %v)", val, min, max)
+			}
+		}
+		if !sawJitter {
+			t.Fatal("expected to see jitter, all values were the same")
+		}
+	})
+
+	t.Run("overflow", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := WithPositiveJitter(time.Hour, BackoffFunc(func() (time.Duration, bool) {
+			return math.MaxInt64 - 1, false
+		}))
+		if err != nil {
+			t.Fatalf("failed to create backoff with jitter: %v", err)
+		}
+
+		for i := 0; i < 1000; i++ {
+			val, _ := b.Next()
+			if val < math.MaxInt64-1 {
+				t.Fatalf("expected %v not to wrap around", val)
+			}
+		}
+	})
+
+	t.Run("negative_inner", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := WithPositiveJitter(time.Second, BackoffFunc(func() (time.Duration, bool) {
+			return -1 * time.Hour, false
+		}))
+		if err != nil {
+			t.Fatalf("failed to create backoff with jitter: %v", err)
+		}
+
+		if val, _ := b.Next(); val < 0 || val >= time.Second {
+			t.Errorf("expected %v to be in [0, 1s)", val)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+
+		for _, j := range []time.Duration{0, -1 * time.Second} {
+			if _, err := WithPositiveJitter(j, BackoffFunc(func() (time.Duration, bool) { return 0, false })); !errors.Is(err, ErrInvalidJitter) {
+				t.Errorf("%v: expected %v to be %v", j, err, ErrInvalidJitter)
+			}
+		}
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		t.Parallel()
+
+		next, err := NewExponential(1 * time.Second)
+		if err != nil {
+			t.Fatalf("failed to create exponential backoff: %v", err)
+		}
+		b, err := WithPositiveJitter(time.Millisecond, next)
+		if err != nil {
+			t.Fatalf("failed to create backoff with jitter: %v", err)
+		}
+
+		b.Next()
+		b.Next()
+		b.Reset()
+		if val, _ := b.Next(); val < time.Second || val >= time.Second+time.Millisecond {
+			t.Errorf("expected %v to be in [1s, 1.001s)", val)
+		}
+	})
+
+	t.Run("seeded", func(t *testing.T) {
+		t.Parallel()
+
+		collect := func() []time.Duration {
+			b, err := WithPositiveJitterSource(time.Second, rand.NewSource(42).(rand.Source64), BackoffFunc(func() (time.Duration, bool) {
+				return time.Second, false
+			}))
+			if err != nil {
+				t.Fatalf("failed to create backoff with jitter: %v", err)
+			}
+			var out []time.Duration
+			for i := 0; i < 10; i++ {
+				val, _ := b.Next()
+				out = append(out, val)
+			}
+			return out
+		}
+		if a, b := collect(), collect(); !reflect.DeepEqual(a, b) {
+			t.Errorf("expected %v to equal %v", a, b)
+		}
+	})
+}
+
+func TestWithFullJitter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("bounds_and_spread", func(t *testing.T) {
+		t.Parallel()
+
+		baseDuration := 1 * time.Second
+		b, err := NewConstant(baseDuration)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		backoff := WithFullJitter(b)
+
+		// Count the values seen in each tenth of [0, base].
+		var deciles [10]int
+		const iterations = 100_000
+		for i := 0; i < iterations; i++ {
+			val, stop := backoff.Next()
+			if stop {
+				t.Fatal("should not stop")
+			}
+			if val < 0 || val > baseDuration {
+				t.Fatalf("expected %v to be between %v and %v", val, 0, baseDuration)
+			}
+			d := int(val * 10 / baseDuration)
+			if d == 10 {
+				d = 9
+			}
+			deciles[d]++
+		}
+
+		for i, n := range deciles {
+			if n < iterations/20 {
+				t.Errorf("expected values spread over [0, %v], decile %d only saw %d of %d", baseDuration, i, n, iterations)
+			}
+		}
+	})
+
+	t.Run("stops_and_resets", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := NewConstant(1 * time.Second)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		backoff := WithFullJitter(WithMaxRetries(2, b))
+
+		for i := 0; i < 2; i++ {
+			if _, stop := backoff.Next(); stop {
+				t.Fatal("should not stop")
+			}
+		}
+		if _, stop := backoff.Next(); !stop {
+			t.Error("should stop when next stops")
+		}
+
+		backoff.Reset()
+		if _, stop := backoff.Next(); stop {
+			t.Error("should not stop after reset")
+		}
+	})
+
+	t.Run("zero_and_max", func(t *testing.T) {
+		t.Parallel()
+
+		for _, v := range []time.Duration{0, -1 * time.Second, math.MaxInt64} {
+			v := v
+			backoff := WithFullJitter(BackoffFunc(func() (time.Duration, bool) {
+				return v, false
+			}))
+			for i := 0; i < 100; i++ {
+				val, _ := backoff.Next()
+				if val < 0 || (v > 0 && val > v) || (v <= 0 && val != 0) {
+					t.Fatalf("unexpected value %v for %v", val, v)
+				}
+			}
+		}
+	})
+}
+
 func TestWithJitterPercent_BadValues(t *testing.T) {
 	t.Parallel()
 
@@ -94,12 +302,64 @@ func TestWithJitterPercent_BadValues(t *testing.T) {
 		_, err := WithJitterPercent(backoffJitterPercent, BackoffFunc(func() (time.Duration, bool) {
 			return 0, false
 		}))
-		if err == nil {
-			t.Error("expected error, got none")
+		if err != nil {
+			t.Errorf("expected no error, got %v", err)
 		}
+	})
+}
 
-		if err != ErrInvalidJitterPercent {
-			t.Errorf("expected %v, got %v", ErrInvalidJitterPercent, err)
+func TestWithJitterPercent_over100(t *testing.T) {
+	t.Parallel()
+
+	t.Run("150", func(t *testing.T) {
+		t.Parallel()
+
+		base := 10 * time.Second
+		b, err := WithJitterPercentSource(150, rand.NewSource(1).(rand.Source64), BackoffFunc(func() (time.Duration, bool) {
+			return base, false
+		}))
+		if err != nil {
+			t.Fatalf("failed to add jitter: %v", err)
+		}
+
+		// A third of the draws fall below -100%, and are clamped to zero.
+		var zeros, above int
+		for i := 0; i < 3000; i++ {
+			val, stop := b.Next()
+			if stop {
+				t.Fatalf("stopped after %d draws", i)
+			}
+			if val < 0 || val > base*5/2 {
+				t.Fatalf("expected %v to be between 0 and %v", val, base*5/2)
+			}
+			if val == 0 {
+				zeros++
+			}
+			if val > 2*base {
+				above++
+			}
+		}
+		if zeros == 0 {
+			t.Error("expected some values to be clamped to zero")
+		}
+		if above == 0 {
+			t.Errorf("expected some values above %v", 2*base)
+		}
+	})
+
+	t.Run("huge", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := WithJitterPercent(math.MaxUint64, BackoffFunc(func() (time.Duration, bool) {
+			return time.Hour, false
+		}))
+		if err != nil {
+			t.Fatalf("failed to add jitter: %v", err)
+		}
+		for i := 0; i < 100; i++ {
+			if val, stop := b.Next(); stop || val < 0 {
+				t.Fatalf("expected (%v, %t) not to be negative or stop", val, stop)
+			}
 		}
 	})
 }
@@ -140,6 +400,150 @@ func TestWithJitterPercent(t *testing.T) {
 	}
 }
 
+func TestWithMonotonicJitter(t *testing.T) {
+	t.Parallel()
+
+	baseDuration := 1 * time.Second
+	backoffJitter := 250 * time.Millisecond
+	jitterPercent := uint64(25)
+
+	cases := []struct {
+		name   string
+		jitter func(next Backoff) (*ResettableBackoff, error)
+		bound  time.Duration
+	}{
+		{
+			name: "jitter",
+			jitter: func(next Backoff) (*ResettableBackoff, error) {
+				return WithMonotonicJitter(backoffJitter, next)
+			},
+			bound: backoffJitter,
+		},
+		{
+			name: "jitter_percent",
+			jitter: func(next Backoff) (*ResettableBackoff, error) {
+				return WithMonotonicJitterPercent(jitterPercent, next)
+			},
+			bound: time.Duration(jitterPercent) * baseDuration / 100,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			t.Run("non_decreasing_and_bounded", func(t *testing.T) {
+				t.Parallel()
+
+				seen := make(map[string]bool)
+				for seed := int64(0); seed < 500; seed++ {
+					b, err := tc.jitter(BackoffFunc(func() (time.Duration, bool) {
+						return baseDuration, false
+					}))
+					if err != nil {
+						t.Fatalf("failed to create backoff with jitter: %v", err)
+					}
+					if err := UseRandomSource(b, rand.NewSource(seed).(rand.Source64)); err != nil {
+						t.Fatalf("failed to seed backoff: %v", err)
+					}
+
+					seq := sequence(b, 20)
+					for i, val := range seq {
+						if min, max := baseDuration-tc.bound, baseDuration+tc.bound; val < min || val > max {
+							t.Errorf("seed %d: expected %v to be between %v and %v", seed, val, min, max)
+						}
+						if i > 0 && val < seq[i-1] {
+							t.Fatalf("seed %d: expected non-decreasing sequence, got %v", seed, seq)
+						}
+					}
+					seen[fmt.Sprint(seq)] = true
+				}
+
+				if len(seen) < 2 {
+					t.Fatal("expected to see jitter, all sequences were the same")
+				}
+			})
+
+			t.Run("reset_clears_previous_value", func(t *testing.T) {
+				t.Parallel()
+
+				exp, err := NewExponential(baseDuration)
+				if err != nil {
+					t.Fatalf("failed to create exponential backoff: %v", err)
+				}
+				b, err := tc.jitter(exp)
+				if err != nil {
+					t.Fatalf("failed to create backoff with jitter: %v", err)
+				}
+
+				seq := sequence(b, 5)
+				b.Reset()
+				val, _ := b.Next()
+				if max := baseDuration + tc.bound; val > max || val >= seq[len(seq)-1] {
+					t.Errorf("expected %v to restart from the beginning of the schedule, before reset it was %v", val, seq)
+				}
+			})
+
+			t.Run("capped_plateau", func(t *testing.T) {
+				t.Parallel()
+
+				exp, err := NewExponential(baseDuration)
+				if err != nil {
+					t.Fatalf("failed to create exponential backoff: %v", err)
+				}
+				b, err := tc.jitter(exp)
+				if err != nil {
+					t.Fatalf("failed to create backoff with jitter: %v", err)
+				}
+				capped := WithCappedDuration(5*time.Second, b)
+
+				seq := sequence(capped, 20)
+				for i, val := range seq {
+					if i > 0 && val < seq[i-1] {
+						t.Fatalf("expected non-decreasing sequence, got %v", seq)
+					}
+				}
+				for _, val := range seq[10:] {
+					if val != 5*time.Second {
+						t.Errorf("expected %v to be %v", val, 5*time.Second)
+					}
+				}
+			})
+
+			t.Run("concurrent_use", func(t *testing.T) {
+				t.Parallel()
+
+				b, err := tc.jitter(BackoffFunc(func() (time.Duration, bool) {
+					return baseDuration, false
+				}))
+				if err != nil {
+					t.Fatalf("failed to create backoff with jitter: %v", err)
+				}
+
+				// Every caller must see a non-decreasing sequence.
+				var wg sync.WaitGroup
+				for i := 0; i < 8; i++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+
+						seq := sequence(b, 200)
+						for i := 1; i < len(seq); i++ {
+							if seq[i] < seq[i-1] {
+								t.Errorf("expected non-decreasing sequence, got %v", seq)
+								return
+							}
+						}
+					}()
+				}
+				wg.Wait()
+			})
+		})
+	}
+}
+
 func TestWithMaxRetries(t *testing.T) {
 	t.Parallel()
 
@@ -170,6 +574,113 @@ func TestWithMaxRetries(t *testing.T) {
 	}
 }
 
+func TestWithMaxAttempts(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name  string
+		n     uint64
+		nexts int
+	}{
+		{name: "zero", n: 0, nexts: 0},
+		{name: "one", n: 1, nexts: 0},
+		{name: "four", n: 4, nexts: 3},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			b := WithMaxAttempts(tc.n, BackoffFunc(func() (time.Duration, bool) {
+				return time.Second, false
+			}))
+			for i := 0; i < tc.nexts; i++ {
+				if _, stop := b.Next(); stop {
+					t.Fatalf("stopped after %d values", i)
+				}
+			}
+			if _, stop := b.Next(); !stop {
+				t.Errorf("expected to stop after %d values", tc.nexts)
+			}
+			if !b.RetriesExhausted() {
+				t.Error("expected retries to be exhausted")
+			}
+		})
+	}
+}
+
+func TestExhausted(t *testing.T) {
+	t.Parallel()
+
+	// stopsAfter signals to stop after n values of 1s.
+	stopsAfter := func(n int) Backoff {
+		var calls int
+		return BackoffFunc(func() (time.Duration, bool) {
+			calls++
+			return time.Second, calls > n
+		})
+	}
+	drain := func(b Backoff) {
+		for {
+			if _, stop := b.Next(); stop {
+				return
+			}
+		}
+	}
+
+	cases := []struct {
+		name string
+		b    Backoff
+		exp  bool
+	}{
+		{name: "limit", b: WithMaxRetries(2, stopsAfter(5)), exp: true},
+		{name: "inner_stop", b: WithMaxRetries(5, stopsAfter(2)), exp: false},
+		{name: "decorated_limit", b: WithCappedDuration(time.Second, WithMaxRetries(2, stopsAfter(5))), exp: true},
+		{name: "nested_limit", b: WithMaxRetries(5, WithMaxRetries(2, stopsAfter(5))), exp: true},
+		{name: "no_limit", b: stopsAfter(2), exp: false},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			drain(tc.b)
+			if got := Exhausted(tc.b); got != tc.exp {
+				t.Errorf("expected %t to be %t", got, tc.exp)
+			}
+		})
+	}
+
+	t.Run("reset", func(t *testing.T) {
+		t.Parallel()
+
+		b := WithMaxRetries(1, stopsAfter(5))
+		drain(b)
+		if !b.RetriesExhausted() {
+			t.Error("expected retries to be exhausted")
+		}
+		b.Reset()
+		if b.RetriesExhausted() {
+			t.Error("expected reset to clear exhaustion")
+		}
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		t.Parallel()
+
+		if Exhausted(nil) {
+			t.Error("expected nil not to be exhausted")
+		}
+		if (&ResettableBackoff{}).RetriesExhausted() {
+			t.Error("expected zero value not to be exhausted")
+		}
+	})
+}
+
 func TestWithCappedDuration(t *testing.T) {
 	t.Parallel()
 
@@ -188,6 +699,291 @@ func TestWithCappedDuration(t *testing.T) {
 	}
 }
 
+func TestWithCappedDuration_belowCap(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		val  time.Duration
+		exp  time.Duration
+	}{
+		{name: "zero", val: 0, exp: 0},
+		{name: "negative", val: -1 * time.Second, exp: 0},
+		{name: "under_cap", val: 1 * time.Second, exp: 1 * time.Second},
+		{name: "at_cap", val: 3 * time.Second, exp: 3 * time.Second},
+		{name: "over_cap", val: 5 * time.Second, exp: 3 * time.Second},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			b := WithCappedDuration(3*time.Second, BackoffFunc(func() (time.Duration, bool) {
+				return tc.val, false
+			}))
+
+			val, stop := b.Next()
+			if stop {
+				t.Errorf("should not stop")
+			}
+			if val != tc.exp {
+				t.Errorf("expected %v to be %v", val, tc.exp)
+			}
+		})
+	}
+}
+
+func TestWithReset(t *testing.T) {
+	t.Parallel()
+
+	newCapped := func(t *testing.T) Backoff {
+		t.Helper()
+
+		b, err := NewExponential(1 * time.Second)
+		if err != nil {
+			t.Fatalf("failed to create exponential backoff: %v", err)
+		}
+		return WithCappedDuration(3*time.Second, b)
+	}
+	collect := func(b Backoff, n int) []time.Duration {
+		var out []time.Duration
+		for i := 0; i < n; i++ {
+			val, _ := b.Next()
+			out = append(out, val)
+		}
+		return out
+	}
+
+	cases := []struct {
+		name string
+		b    func(next Backoff) *ResettableBackoff
+	}{
+		{name: "nil_factory", b: func(next Backoff) *ResettableBackoff { return WithReset(nil, next) }},
+		{name: "resettable", b: Resettable},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			b := tc.b(newCapped(t))
+			exp := []time.Duration{1 * time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second}
+			if got := collect(b, 4); !reflect.DeepEqual(got, exp) {
+				t.Errorf("before reset: expected %v to be %v", got, exp)
+			}
+
+			// The cap survives the reset.
+			b.Reset()
+			if got := collect(b, 4); !reflect.DeepEqual(got, exp) {
+				t.Errorf("after reset: expected %v to be %v", got, exp)
+			}
+		})
+	}
+
+	t.Run("factory_returns_nil", func(t *testing.T) {
+		t.Parallel()
+
+		b := WithReset(func() Backoff { return nil }, newCapped(t))
+		if val, stop := b.Next(); val != 1*time.Second || stop {
+			t.Errorf("expected (%v, %t) to be (1s, false)", val, stop)
+		}
+
+		b.Reset()
+		if val, stop := b.Next(); val != 0 || !stop {
+			t.Errorf("expected (%v, %t) to be (0, true)", val, stop)
+		}
+		if err := Validate(b); !errors.Is(err, ErrNilBackoff) {
+			t.Errorf("expected %v to be %v", err, ErrNilBackoff)
+		}
+	})
+
+	t.Run("nil_backoff", func(t *testing.T) {
+		t.Parallel()
+
+		b := Resettable(nil)
+		b.Reset()
+		if val, stop := b.Next(); val != 0 || !stop {
+			t.Errorf("expected (%v, %t) to be (0, true)", val, stop)
+		}
+	})
+}
+
+func TestWithValidation(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		b    func(next Backoff) Backoff
+		val  time.Duration
+		exp  time.Duration
+		stop bool
+	}{
+		{name: "negative", b: func(next Backoff) Backoff { return WithValidation(next) }, val: -1 * time.Second, exp: 0},
+		{name: "min_duration", b: func(next Backoff) Backoff { return WithValidation(next) }, val: math.MinInt64, exp: 0},
+		{name: "valid", b: func(next Backoff) Backoff { return WithValidation(next) }, val: 1 * time.Second, exp: 1 * time.Second},
+		{name: "at_default_max", b: func(next Backoff) Backoff { return WithValidation(next) }, val: DefaultValidationMax, exp: DefaultValidationMax},
+		{name: "above_default_max", b: func(next Backoff) Backoff { return WithValidation(next) }, val: math.MaxInt64, stop: true},
+		{
+			name: "above_max",
+			b:    func(next Backoff) Backoff { return WithValidationMax(time.Minute, StopOnInvalid, next) },
+			val:  2 * time.Minute,
+			stop: true,
+		},
+		{
+			name: "no_max",
+			b:    func(next Backoff) Backoff { return WithValidationMax(0, PanicOnInvalid, next) },
+			val:  math.MaxInt64,
+			exp:  math.MaxInt64,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			b := tc.b(BackoffFunc(func() (time.Duration, bool) {
+				return tc.val, false
+			}))
+
+			val, stop := b.Next()
+			if val != tc.exp || stop != tc.stop {
+				t.Errorf("expected (%v, %t) to be (%v, %t)", val, stop, tc.exp, tc.stop)
+			}
+		})
+	}
+
+	t.Run("panics", func(t *testing.T) {
+		t.Parallel()
+
+		b := WithValidationMax(time.Minute, PanicOnInvalid, BackoffFunc(func() (time.Duration, bool) {
+			return time.Hour, false
+		}))
+
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("expected a panic")
+			}
+		}()
+		b.Next()
+	})
+
+	t.Run("inner_stop", func(t *testing.T) {
+		t.Parallel()
+
+		b := WithValidation(BackoffFunc(func() (time.Duration, bool) {
+			return -1 * time.Second, true
+		}))
+		if val, stop := b.Next(); val != 0 || !stop {
+			t.Errorf("expected (%v, %t) to be (0, true)", val, stop)
+		}
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		t.Parallel()
+
+		next, err := NewExponential(1 * time.Second)
+		if err != nil {
+			t.Fatalf("failed to create exponential backoff: %v", err)
+		}
+		b := WithValidation(next)
+		b.Next()
+		b.Next()
+		b.Reset()
+		if val, _ := b.Next(); val != 1*time.Second {
+			t.Errorf("expected %v to be %v", val, 1*time.Second)
+		}
+	})
+}
+
+func TestWithImmediateFirst(t *testing.T) {
+	t.Parallel()
+
+	newFibonacci := func(t *testing.T) Backoff {
+		t.Helper()
+
+		b, err := NewFibonacci(1 * time.Second)
+		if err != nil {
+			t.Fatalf("failed to create fibonacci backoff: %v", err)
+		}
+		return b
+	}
+	collect := func(b Backoff, n int) []time.Duration {
+		var out []time.Duration
+		for i := 0; i < n; i++ {
+			val, stop := b.Next()
+			if stop {
+				break
+			}
+			out = append(out, val)
+		}
+		return out
+	}
+
+	cases := []struct {
+		name string
+		b    func(t *testing.T) Backoff
+		exp  []time.Duration
+	}{
+		{
+			name: "immediate_then_next",
+			b:    func(t *testing.T) Backoff { return WithImmediateFirst(newFibonacci(t)) },
+			exp:  []time.Duration{0, 1 * time.Second, 2 * time.Second, 3 * time.Second},
+		},
+		{
+			name: "max_retries_counts_immediate",
+			b:    func(t *testing.T) Backoff { return WithMaxRetries(3, WithImmediateFirst(newFibonacci(t))) },
+			exp:  []time.Duration{0, 1 * time.Second, 2 * time.Second},
+		},
+		{
+			name: "capped_keeps_zero",
+			b: func(t *testing.T) Backoff {
+				return WithCappedDuration(2*time.Second, WithImmediateFirst(newFibonacci(t)))
+			},
+			exp: []time.Duration{0, 1 * time.Second, 2 * time.Second, 2 * time.Second},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := collect(tc.b(t), 4); fmt.Sprint(got) != fmt.Sprint(tc.exp) {
+				t.Errorf("expected %v to be %v", got, tc.exp)
+			}
+		})
+	}
+
+	t.Run("reset", func(t *testing.T) {
+		t.Parallel()
+
+		b := WithImmediateFirst(newFibonacci(t))
+		collect(b, 3)
+		b.Reset()
+		if got, want := collect(b, 3), []time.Duration{0, 1 * time.Second, 2 * time.Second}; fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	})
+
+	t.Run("next_stops", func(t *testing.T) {
+		t.Parallel()
+
+		b := WithImmediateFirst(BackoffFunc(func() (time.Duration, bool) {
+			return time.Second, true
+		}))
+		if got, want := collect(b, 3), []time.Duration{0}; fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	})
+}
+
 func TestWithMaxDuration(t *testing.T) {
 	t.Parallel()
 
@@ -200,6 +996,401 @@ func TestWithMaxDuration(t *testing.T) {
 	validateMaxDuration(t, backoff, maxDuration)
 }
 
+func TestWithMaxDuration_startsOnFirstNext(t *testing.T) {
+	t.Parallel()
+
+	baseDuration := 1 * time.Second
+	maxDuration := 50 * time.Millisecond
+	backoff := WithMaxDuration(maxDuration, BackoffFunc(func() (time.Duration, bool) {
+		return baseDuration, false
+	}))
+
+	// Building the backoff ahead of time must not use up its budget.
+	time.Sleep(2 * maxDuration)
+
+	val, stop := backoff.Next()
+	if stop {
+		t.Fatal("should not stop")
+	}
+	if val <= 0 || val > maxDuration {
+		t.Errorf("expected %v to be between %v and %v", val, 0, maxDuration)
+	}
+}
+
+// stepClock is a Clock whose time only moves when advanced.
+type stepClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *stepClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *stepClock) NewTimer(time.Duration) clock.Timer {
+	panic("not used")
+}
+
+func (c *stepClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+func TestWithMaxDurationClock(t *testing.T) {
+	t.Parallel()
+
+	c := &stepClock{now: time.Unix(100, 0)}
+	b := WithMaxDurationClock(10*time.Second, c, BackoffFunc(func() (time.Duration, bool) {
+		return 4 * time.Second, false
+	}))
+
+	steps := []struct {
+		advance time.Duration
+		val     time.Duration
+		stop    bool
+	}{
+		{advance: 0, val: 4 * time.Second},
+		{advance: 4 * time.Second, val: 4 * time.Second},
+		{advance: 4 * time.Second, val: 2 * time.Second},
+		{advance: 2 * time.Second, stop: true},
+	}
+	for i, step := range steps {
+		c.advance(step.advance)
+		val, stop := b.Next()
+		if val != step.val || stop != step.stop {
+			t.Errorf("step %d: expected (%v, %t) to be (%v, %t)", i, val, stop, step.val, step.stop)
+		}
+	}
+
+	// Reset starts a new budget from the next call to Next.
+	b.Reset()
+	c.advance(time.Hour)
+	if val, stop := b.Next(); val != 4*time.Second || stop {
+		t.Errorf("expected (%v, %t) to be (%v, %t)", val, stop, 4*time.Second, false)
+	}
+}
+
+func TestWithMaxSleepWindowClock(t *testing.T) {
+	t.Parallel()
+
+	forever := func() Backoff {
+		return BackoffFunc(func() (time.Duration, bool) {
+			return 4 * time.Second, false
+		})
+	}
+
+	// Each attempt takes 25s and each sleep 4s. WithMaxDuration counts the
+	// attempts, so the first slow attempt after Next uses up its budget;
+	// WithMaxSleepWindow only counts the sleeps.
+	cases := []struct {
+		name string
+		b    func(c Clock) *ResettableBackoff
+		vals []time.Duration
+	}{
+		{
+			name: "max_duration",
+			b: func(c Clock) *ResettableBackoff {
+				return WithMaxDurationClock(30*time.Second, c, forever())
+			},
+			vals: []time.Duration{4 * time.Second, 1 * time.Second},
+		},
+		{
+			name: "max_sleep_window",
+			b: func(c Clock) *ResettableBackoff {
+				return WithMaxSleepWindowClock(30*time.Second, c, forever())
+			},
+			vals: []time.Duration{4 * time.Second, 4 * time.Second, 4 * time.Second, 4 * time.Second, 4 * time.Second, 4 * time.Second, 4 * time.Second, 2 * time.Second},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := &stepClock{now: time.Unix(100, 0)}
+			b := tc.b(c)
+
+			var vals []time.Duration
+			for i := 0; i < 20; i++ {
+				AttemptStarted(b)
+				c.advance(25 * time.Second)
+				AttemptFinished(b)
+
+				val, stop := b.Next()
+				if stop {
+					break
+				}
+				vals = append(vals, val)
+				c.advance(val)
+			}
+			if !reflect.DeepEqual(vals, tc.vals) {
+				t.Errorf("expected %v to be %v", vals, tc.vals)
+			}
+		})
+	}
+
+	t.Run("remaining_time", func(t *testing.T) {
+		t.Parallel()
+
+		c := &stepClock{now: time.Unix(100, 0)}
+		b := WithMaxRetries(10, WithMaxSleepWindowClock(30*time.Second, c, forever()))
+		expect := func(exp time.Duration) {
+			t.Helper()
+			if got, ok := b.RemainingTime(); !ok || got != exp {
+				t.Errorf("expected %v, %t to be %v, true", got, ok, exp)
+			}
+		}
+
+		expect(30 * time.Second)
+		AttemptStarted(b)
+		c.advance(10 * time.Second)
+		expect(30 * time.Second)
+		AttemptFinished(b)
+		c.advance(5 * time.Second)
+		expect(25 * time.Second)
+
+		// Reset opens a new window.
+		b.Reset()
+		expect(30 * time.Second)
+	})
+
+	t.Run("without_attempt_reports", func(t *testing.T) {
+		t.Parallel()
+
+		c := &stepClock{now: time.Unix(100, 0)}
+		b := WithMaxSleepWindowClock(10*time.Second, c, forever())
+
+		steps := []struct {
+			advance time.Duration
+			val     time.Duration
+			stop    bool
+		}{
+			{advance: 0, val: 4 * time.Second},
+			{advance: 4 * time.Second, val: 4 * time.Second},
+			{advance: 4 * time.Second, val: 2 * time.Second},
+			{advance: 2 * time.Second, stop: true},
+		}
+		for i, step := range steps {
+			c.advance(step.advance)
+			val, stop := b.Next()
+			if val != step.val || stop != step.stop {
+				t.Errorf("step %d: expected (%v, %t) to be (%v, %t)", i, val, stop, step.val, step.stop)
+			}
+		}
+	})
+}
+
+func TestRemaining(t *testing.T) {
+	t.Parallel()
+
+	forever := func() Backoff {
+		return BackoffFunc(func() (time.Duration, bool) {
+			return time.Second, false
+		})
+	}
+
+	expectRemaining := func(t *testing.T, b *ResettableBackoff, exp uint64) {
+		t.Helper()
+
+		if n, ok := b.Remaining(); !ok || n != exp {
+			t.Errorf("expected (%d, %t) to be (%d, true)", n, ok, exp)
+		}
+	}
+
+	t.Run("max_retries", func(t *testing.T) {
+		t.Parallel()
+
+		b := WithMaxRetries(3, forever())
+		expectRemaining(t, b, 3)
+		for _, exp := range []uint64{2, 1, 0} {
+			if _, stop := b.Next(); stop {
+				t.Fatal("should not stop")
+			}
+			expectRemaining(t, b, exp)
+		}
+		if _, stop := b.Next(); !stop {
+			t.Fatal("should stop")
+		}
+		expectRemaining(t, b, 0)
+
+		b.Reset()
+		expectRemaining(t, b, 3)
+	})
+
+	t.Run("max_attempts", func(t *testing.T) {
+		t.Parallel()
+
+		b := WithMaxAttempts(3, forever())
+		expectRemaining(t, b, 2)
+		b.Next()
+		expectRemaining(t, b, 1)
+	})
+
+	t.Run("wrapped", func(t *testing.T) {
+		t.Parallel()
+
+		b := WithCappedDuration(time.Second, WithMaxRetries(2, forever()))
+		b.Next()
+		expectRemaining(t, b, 1)
+	})
+
+	t.Run("smallest_of_stacked", func(t *testing.T) {
+		t.Parallel()
+
+		b := WithMaxRetries(5, WithMaxRetries(2, forever()))
+		expectRemaining(t, b, 2)
+		b.Next()
+		expectRemaining(t, b, 1)
+	})
+
+	t.Run("no_limit", func(t *testing.T) {
+		t.Parallel()
+
+		b := WithCappedDuration(time.Second, forever())
+		if n, ok := b.Remaining(); ok || n != 0 {
+			t.Errorf("expected (%d, %t) to be (0, false)", n, ok)
+		}
+		if d, ok := b.RemainingTime(); ok || d != 0 {
+			t.Errorf("expected (%v, %t) to be (0, false)", d, ok)
+		}
+	})
+
+	t.Run("max_duration", func(t *testing.T) {
+		t.Parallel()
+
+		c := &stepClock{now: time.Unix(100, 0)}
+		b := WithMaxRetries(10, WithMaxDurationClock(10*time.Second, c, forever()))
+
+		expectTime := func(t *testing.T, exp time.Duration) {
+			t.Helper()
+
+			if d, ok := b.RemainingTime(); !ok || d != exp {
+				t.Errorf("expected (%v, %t) to be (%v, true)", d, ok, exp)
+			}
+		}
+
+		// The limit starts counting at the first call to Next.
+		c.advance(time.Hour)
+		expectTime(t, 10*time.Second)
+		b.Next()
+		expectTime(t, 10*time.Second)
+		c.advance(4 * time.Second)
+		expectTime(t, 6*time.Second)
+		c.advance(time.Hour)
+		expectTime(t, 0)
+
+		b.Reset()
+		expectTime(t, 10*time.Second)
+		expectRemaining(t, b, 10)
+	})
+}
+
+func TestWithMaxCumulativeSleep(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		new    func(time.Duration, Backoff) *ResettableBackoff
+		values []time.Duration
+		exp    []time.Duration
+	}{
+		{
+			name:   "stops_before_exceeding",
+			new:    WithMaxCumulativeSleep,
+			values: []time.Duration{3 * time.Second, 4 * time.Second, 4 * time.Second},
+			exp:    []time.Duration{3 * time.Second, 4 * time.Second},
+		},
+		{
+			name:   "exact_budget",
+			new:    WithMaxCumulativeSleep,
+			values: []time.Duration{5 * time.Second, 5 * time.Second, 1},
+			exp:    []time.Duration{5 * time.Second, 5 * time.Second},
+		},
+		{
+			name:   "truncates_final_sleep",
+			new:    WithTruncatedCumulativeSleep,
+			values: []time.Duration{3 * time.Second, 4 * time.Second, 4 * time.Second, 1},
+			exp:    []time.Duration{3 * time.Second, 4 * time.Second, 3 * time.Second},
+		},
+		{
+			name:   "negative_counts_as_zero",
+			new:    WithMaxCumulativeSleep,
+			values: []time.Duration{-1 * time.Second, 10 * time.Second, 1},
+			exp:    []time.Duration{0, 10 * time.Second},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			i := 0
+			backoff := tc.new(10*time.Second, BackoffFunc(func() (time.Duration, bool) {
+				val := tc.values[i%len(tc.values)]
+				i++
+				return val, false
+			}))
+
+			for _, exp := range tc.exp {
+				val, stop := backoff.Next()
+				if stop {
+					t.Fatalf("should not stop")
+				}
+				if val != exp {
+					t.Errorf("expected %v to be %v", val, exp)
+				}
+			}
+
+			val, stop := backoff.Next()
+			if !stop {
+				t.Errorf("should stop")
+			}
+			if val != 0 {
+				t.Errorf("expected %v to be %v", val, 0)
+			}
+		})
+	}
+}
+
+func TestWithMaxCumulativeSleep_concurrent(t *testing.T) {
+	t.Parallel()
+
+	budget := 1000 * time.Millisecond
+	backoff := WithMaxCumulativeSleep(budget, BackoffFunc(func() (time.Duration, bool) {
+		return 1 * time.Millisecond, false
+	}))
+
+	var total atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				val, stop := backoff.Next()
+				if stop {
+					return
+				}
+				total.Add(int64(val))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := time.Duration(total.Load()); got != budget {
+		t.Errorf("expected %v to be %v", got, budget)
+	}
+}
+
 func TestWithContext(t *testing.T) {
 	t.Parallel()
 
@@ -225,6 +1416,151 @@ func TestWithContext(t *testing.T) {
 	}
 }
 
+func TestWithDeadline(t *testing.T) {
+	t.Parallel()
+
+	// constant returns d forever, counting its calls.
+	constant := func(d time.Duration, calls *int) Backoff {
+		return BackoffFunc(func() (time.Duration, bool) {
+			*calls++
+			return d, false
+		})
+	}
+
+	t.Run("clamps_to_deadline", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+
+		var calls int
+		b := WithDeadline(ctx, constant(8*time.Second, &calls))
+		val, stop := b.Next()
+		if stop {
+			t.Fatal("should not stop")
+		}
+		if val <= 0 || val > 2*time.Second {
+			t.Errorf("expected %v to be clamped to at most %v", val, 2*time.Second)
+		}
+		if left, ok := b.RemainingTime(); !ok || left > 2*time.Second {
+			t.Errorf("expected (%v, %t) to be at most (%v, true)", left, ok, 2*time.Second)
+		}
+	})
+
+	t.Run("shorter_values_unchanged", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+		defer cancel()
+
+		var calls int
+		b := WithDeadline(ctx, constant(time.Second, &calls))
+		if val, stop := b.Next(); stop || val != time.Second {
+			t.Errorf("expected (%v, %t) to be (%v, false)", val, stop, time.Second)
+		}
+	})
+
+	t.Run("stops_at_deadline", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		var calls int
+		b := WithDeadline(ctx, constant(time.Second, &calls))
+		time.Sleep(20 * time.Millisecond)
+
+		if val, stop := b.Next(); !stop || val != 0 {
+			t.Errorf("expected (%v, %t) to be (0, true)", val, stop)
+		}
+		if calls != 0 {
+			t.Errorf("expected %d to be %d", calls, 0)
+		}
+		if left, ok := b.RemainingTime(); !ok || left != 0 {
+			t.Errorf("expected (%v, %t) to be (0, true)", left, ok)
+		}
+	})
+
+	t.Run("canceled_without_deadline", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		var calls int
+		b := WithDeadline(ctx, constant(8*time.Second, &calls))
+		if val, stop := b.Next(); stop || val != 8*time.Second {
+			t.Errorf("expected (%v, %t) to be (%v, false)", val, stop, 8*time.Second)
+		}
+		if _, ok := b.RemainingTime(); ok {
+			t.Error("expected no deadline")
+		}
+
+		cancel()
+		if _, stop := b.Next(); !stop {
+			t.Error("should stop after context cancel")
+		}
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+		defer cancel()
+
+		var calls int
+		b := WithDeadline(ctx, WithMaxRetries(1, constant(time.Second, &calls)))
+		for i := 0; i < 2; i++ {
+			if _, stop := b.Next(); stop {
+				t.Fatalf("round %d: should not stop", i)
+			}
+			if _, stop := b.Next(); !stop {
+				t.Fatalf("round %d: should stop after max retries", i)
+			}
+			b.Reset()
+		}
+	})
+}
+
+func TestWithContext_reset(t *testing.T) {
+	t.Parallel()
+
+	fib, err := NewFibonacci(1 * time.Second)
+	if err != nil {
+		t.Fatalf("failed to create fibonacci backoff: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	backoff := WithContext(ctx, WithMaxRetries(3, fib))
+
+	exhaust := func() {
+		t.Helper()
+
+		for i, want := range []time.Duration{1 * time.Second, 2 * time.Second, 3 * time.Second} {
+			val, stop := backoff.Next()
+			if stop {
+				t.Fatalf("should not stop at retry %d", i+1)
+			}
+			if val != want {
+				t.Errorf("expected %v to be %v", val, want)
+			}
+		}
+		if _, stop := backoff.Next(); !stop {
+			t.Errorf("should stop after max retries")
+		}
+	}
+
+	exhaust()
+	backoff.Reset()
+	exhaust()
+
+	backoff.Reset()
+	cancel()
+	if _, stop := backoff.Next(); !stop {
+		t.Errorf("should stop after context cancel")
+	}
+}
+
 func TestResettableBackoff(t *testing.T) {
 	var attempt uint64
 	backoff := WithReset(func() Backoff {
@@ -263,6 +1599,70 @@ func TestResettableBackoff(t *testing.T) {
 	}
 }
 
+func TestResettableBackoff_concurrentReset(t *testing.T) {
+	t.Parallel()
+
+	newFibonacci := func(t *testing.T) Backoff {
+		t.Helper()
+
+		b, err := NewFibonacci(1 * time.Millisecond)
+		if err != nil {
+			t.Fatalf("failed to create fibonacci backoff: %v", err)
+		}
+		return b
+	}
+
+	cases := []struct {
+		name string
+		new  func(t *testing.T) Backoff
+	}{
+		{name: "with_max_retries", new: func(t *testing.T) Backoff {
+			return WithMaxRetries(10, newFibonacci(t))
+		}},
+		{name: "with_reset", new: func(t *testing.T) Backoff {
+			return WithReset(func() Backoff {
+				return WithMaxRetries(10, newFibonacci(t))
+			}, WithMaxRetries(10, newFibonacci(t)))
+		}},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			b := tc.new(t)
+
+			// Ten retries of a Fibonacci backoff from 1ms never exceed 89ms, no
+			// matter how resets interleave.
+			const max = 89 * time.Millisecond
+
+			var wg sync.WaitGroup
+			for i := 0; i < 4; i++ {
+				wg.Add(2)
+				go func() {
+					defer wg.Done()
+					for j := 0; j < 1000; j++ {
+						val, stop := b.Next()
+						if !stop && (val <= 0 || val > max) {
+							t.Errorf("expected %v to be in (0, %v]", val, max)
+							return
+						}
+					}
+				}()
+				go func() {
+					defer wg.Done()
+					for j := 0; j < 1000; j++ {
+						b.Reset()
+					}
+				}()
+			}
+			wg.Wait()
+		})
+	}
+}
+
 func TestResettableBackoff_WithJitter(t *testing.T) {
 	t.Parallel()
 
@@ -424,6 +1824,35 @@ func TestResettableBackoff_WithMaxDuration(t *testing.T) {
 	validateMaxDuration(t, backoff, maxDuration)
 }
 
+func TestResettableBackoff_WithMaxCumulativeSleep(t *testing.T) {
+	t.Parallel()
+
+	baseDuration := 1 * time.Second
+	backoff := WithMaxCumulativeSleep(3*time.Second, BackoffFunc(func() (time.Duration, bool) {
+		return baseDuration, false
+	}))
+
+	for i := 0; i < 3; i++ {
+		if _, stop := backoff.Next(); stop {
+			t.Errorf("should not stop")
+		}
+	}
+	if _, stop := backoff.Next(); !stop {
+		t.Errorf("should stop")
+	}
+
+	// a reset should clear the total slept
+	backoff.Reset()
+
+	val, stop := backoff.Next()
+	if stop {
+		t.Errorf("should not stop after reset")
+	}
+	if val != baseDuration {
+		t.Errorf("expected %v to be %v", val, baseDuration)
+	}
+}
+
 // TestResettableBackoff_MultipleDecorators ensures that multiple decorators can be applied to a ResettableBackoff
 // and that the decorators are still observed after a reset.
 func TestResettableBackoff_MultipleDecorators(t *testing.T) {
@@ -524,3 +1953,178 @@ func validateMaxDuration(t *testing.T, b *ResettableBackoff, maxDuration time.Du
 		t.Errorf("expected %v to be %v", val, 0)
 	}
 }
+
+func TestReset_singlePass(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name  string
+		stack func(next Backoff) Backoff
+	}{
+		{
+			name: "retries_capped_duration",
+			stack: func(next Backoff) Backoff {
+				return WithMaxRetries(3, WithCappedDuration(time.Second, WithMaxDuration(time.Hour, next)))
+			},
+		},
+		{
+			name: "jitter",
+			stack: func(next Backoff) Backoff {
+				b, err := WithJitter(time.Millisecond, WithFullJitter(next))
+				if err != nil {
+					t.Fatalf("failed to create jitter backoff: %v", err)
+				}
+				m, err := WithMonotonicJitterPercent(5, b)
+				if err != nil {
+					t.Fatalf("failed to create monotonic jitter backoff: %v", err)
+				}
+				return m
+			},
+		},
+		{
+			name: "cumulative_sleep_context",
+			stack: func(next Backoff) Backoff {
+				return WithMaxCumulativeSleep(time.Hour, WithTruncatedCumulativeSleep(time.Hour, WithContext(context.Background(), next)))
+			},
+		},
+		{
+			name: "immediate_first_circuit_breaker",
+			stack: func(next Backoff) Backoff {
+				return WithImmediateFirst(WithCircuitBreaker(10, time.Minute, WithCappedDuration(time.Second, next)))
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			inner := &resetCounter{}
+			b := tc.stack(inner)
+			for i := int64(1); i <= 3; i++ {
+				b.Next()
+				b.Reset()
+				if got := inner.resets.Load(); got != i {
+					t.Fatalf("expected %d to be %d", got, i)
+				}
+			}
+		})
+	}
+}
+
+// resetCounter returns 1s and counts the times it is reset.
+type resetCounter struct {
+	resets atomic.Int64
+}
+
+func (b *resetCounter) Next() (time.Duration, bool) {
+	return time.Second, false
+}
+
+func (b *resetCounter) Reset() {
+	b.resets.Add(1)
+}
+
+// checkConcurrentReset hammers b with Next from several goroutines while
+// another keeps resetting it, checking that every value satisfies valid, and
+// after each round that the first value after a Reset is base. Run with -race.
+func checkConcurrentReset(t *testing.T, b Backoff, base time.Duration, valid func(val time.Duration) bool) {
+	t.Helper()
+
+	const rounds, workers, calls = 50, 4, 200
+	for round := 0; round < rounds; round++ {
+		var wg sync.WaitGroup
+		var invalid atomic.Int64
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < calls; j++ {
+					if val, _ := b.Next(); !valid(val) {
+						invalid.Store(int64(val))
+					}
+				}
+			}()
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < calls/4; j++ {
+				b.Reset()
+			}
+		}()
+		wg.Wait()
+
+		if v := invalid.Load(); v != 0 {
+			t.Fatalf("round %d: unexpected value %v", round, time.Duration(v))
+		}
+		b.Reset()
+		if val, _ := b.Next(); val != base {
+			t.Fatalf("round %d: expected %v to be %v", round, val, base)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	t.Parallel()
+
+	must := func(b Backoff, err error) Backoff {
+		t.Helper()
+
+		if err != nil {
+			t.Fatalf("failed to create backoff: %v", err)
+		}
+		return b
+	}
+	mustResettable := func(b *ResettableBackoff, err error) Backoff {
+		t.Helper()
+		return must(b, err)
+	}
+
+	exponential := must(NewExponential(time.Second))
+	cases := []struct {
+		name string
+		b    Backoff
+		exp  string
+	}{
+		{name: "constant", b: must(NewConstant(time.Second)), exp: "constant(1s)"},
+		{name: "exponential", b: exponential, exp: "exponential(1s)"},
+		{name: "exponential_limited", b: must(NewExponentialLimited(time.Second, 3)), exp: "exponential_limited(1s, 3)"},
+		{name: "fibonacci", b: must(NewFibonacci(time.Second)), exp: "fibonacci(1s)"},
+		{name: "linear", b: must(NewLinear(time.Second, 500*time.Millisecond)), exp: "linear(1s, 500ms)"},
+		{name: "random_between", b: must(NewRandomBetween(time.Second, 2*time.Second)), exp: "random_between(1s, 2s)"},
+		{name: "steps", b: must(NewSteps([]time.Duration{time.Second, 5 * time.Second}, true)), exp: "steps(1s, 5s, hold)"},
+		{name: "adaptive", b: Resettable(must(NewAdaptive(time.Second, time.Minute))), exp: "adaptive(1s, 1m0s)"},
+		{
+			name: "decorators",
+			b:    WithMaxRetries(5, WithCappedDuration(30*time.Second, exponential)),
+			exp:  "exponential(1s)|cap(30s)|max_retries(5)",
+		},
+		{
+			name: "jitter",
+			b:    WithMaxAttempts(3, mustResettable(WithJitterPercent(10, WithImmediateFirst(exponential)))),
+			exp:  "exponential(1s)|immediate_first|jitter_percent(10)|max_attempts(3)",
+		},
+		{
+			name: "chain",
+			b:    Chain(Stage{Backoff: must(NewConstant(100 * time.Millisecond)), Attempts: 5}, Stage{Backoff: WithCappedDuration(time.Minute, exponential)}),
+			exp:  "chain(constant(100ms)[5], exponential(1s)|cap(1m0s))",
+		},
+		{name: "func", b: WithMaxRetries(1, BackoffFunc(func() (time.Duration, bool) { return 0, true })), exp: "backoff.BackoffFunc|max_retries(1)"},
+		{name: "zero", b: &ResettableBackoff{}, exp: "nil"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tc.b.(fmt.Stringer).String(); got != tc.exp {
+				t.Errorf("expected %q to be %q", got, tc.exp)
+			}
+		})
+	}
+}
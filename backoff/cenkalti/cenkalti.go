@@ -0,0 +1,61 @@
+// Package cenkalti adapts backoff policies between this module and
+// github.com/cenkalti/backoff, for services migrating from one to the other.
+// It is a separate module, so the core module stays free of dependencies.
+package cenkalti
+
+import (
+	"time"
+
+	cenkalti "github.com/cenkalti/backoff/v4"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+// From returns a Backoff that takes its values from b. The backoff.Stop value
+// of b signals to stop, and Reset resets b. It is as safe for concurrent use
+// as b, which for the policies of cenkalti/backoff means it is not.
+func From(b cenkalti.BackOff) backoff.Backoff {
+	return &fromCenkalti{b: b}
+}
+
+type fromCenkalti struct {
+	b cenkalti.BackOff
+}
+
+// Next implements backoff.Backoff.
+func (f *fromCenkalti) Next() (time.Duration, bool) {
+	next := f.b.NextBackOff()
+	if next == cenkalti.Stop {
+		return 0, true
+	}
+	return next, false
+}
+
+// Reset implements backoff.Backoff.
+func (f *fromCenkalti) Reset() {
+	f.b.Reset()
+}
+
+// To returns a cenkalti/backoff BackOff that takes its values from b. When b
+// signals to stop, NextBackOff returns backoff.Stop, and Reset resets b.
+func To(b backoff.Backoff) cenkalti.BackOff {
+	return &toCenkalti{b: b}
+}
+
+type toCenkalti struct {
+	b backoff.Backoff
+}
+
+// NextBackOff implements cenkalti.BackOff.
+func (t *toCenkalti) NextBackOff() time.Duration {
+	next, stop := t.b.Next()
+	if stop {
+		return cenkalti.Stop
+	}
+	return next
+}
+
+// Reset implements cenkalti.BackOff.
+func (t *toCenkalti) Reset() {
+	t.b.Reset()
+}
@@ -0,0 +1,165 @@
+package cenkalti
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	cenkalti "github.com/cenkalti/backoff/v4"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+// newCenkaltiExponential returns a cenkalti exponential policy without
+// randomization, doubling from 1s up to 8s, with no time limit.
+func newCenkaltiExponential() *cenkalti.ExponentialBackOff {
+	return cenkalti.NewExponentialBackOff(
+		cenkalti.WithInitialInterval(1*time.Second),
+		cenkalti.WithRandomizationFactor(0),
+		cenkalti.WithMultiplier(2),
+		cenkalti.WithMaxInterval(8*time.Second),
+		cenkalti.WithMaxElapsedTime(0),
+	)
+}
+
+// newExponential returns the same policy as newCenkaltiExponential.
+func newExponential(t *testing.T) backoff.Backoff {
+	t.Helper()
+
+	b, err := backoff.NewExponential(1 * time.Second)
+	if err != nil {
+		t.Fatalf("failed to create exponential backoff: %v", err)
+	}
+	return backoff.WithCappedDuration(8*time.Second, b)
+}
+
+// sequence returns the first n values of b, with -1 for a stop.
+func sequence(b backoff.Backoff, n int) []time.Duration {
+	out := make([]time.Duration, 0, n)
+	for i := 0; i < n; i++ {
+		next, stop := b.Next()
+		if stop {
+			next = -1
+		}
+		out = append(out, next)
+	}
+	return out
+}
+
+// cenkaltiSequence returns the first n values of b.
+func cenkaltiSequence(b cenkalti.BackOff, n int) []time.Duration {
+	out := make([]time.Duration, 0, n)
+	for i := 0; i < n; i++ {
+		out = append(out, b.NextBackOff())
+	}
+	return out
+}
+
+func equal(t *testing.T, got, want []time.Duration) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("expected %v to be %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v to be %v", got, want)
+		}
+	}
+}
+
+func TestFrom(t *testing.T) {
+	t.Parallel()
+
+	want := []time.Duration{1 * time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 8 * time.Second, 8 * time.Second}
+
+	t.Run("capped_exponential", func(t *testing.T) {
+		t.Parallel()
+
+		equal(t, cenkaltiSequence(newCenkaltiExponential(), len(want)), want)
+		equal(t, sequence(From(newCenkaltiExponential()), len(want)), want)
+		equal(t, sequence(newExponential(t), len(want)), want)
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		t.Parallel()
+
+		b := From(cenkalti.WithMaxRetries(newCenkaltiExponential(), 2))
+		equal(t, sequence(b, 3), []time.Duration{1 * time.Second, 2 * time.Second, -1})
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		t.Parallel()
+
+		b := From(newCenkaltiExponential())
+		sequence(b, 3)
+		b.Reset()
+		equal(t, sequence(b, len(want)), want)
+	})
+
+	t.Run("decorated", func(t *testing.T) {
+		t.Parallel()
+
+		b := backoff.WithMaxRetries(3, From(newCenkaltiExponential()))
+		equal(t, sequence(b, 4), []time.Duration{1 * time.Second, 2 * time.Second, 4 * time.Second, -1})
+	})
+}
+
+func TestTo(t *testing.T) {
+	t.Parallel()
+
+	want := []time.Duration{1 * time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 8 * time.Second, 8 * time.Second}
+
+	t.Run("capped_exponential", func(t *testing.T) {
+		t.Parallel()
+
+		equal(t, cenkaltiSequence(To(newExponential(t)), len(want)), want)
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		t.Parallel()
+
+		b := To(backoff.WithMaxRetries(2, newExponential(t)))
+		equal(t, cenkaltiSequence(b, 3), []time.Duration{1 * time.Second, 2 * time.Second, cenkalti.Stop})
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		t.Parallel()
+
+		b := To(newExponential(t))
+		cenkaltiSequence(b, 3)
+		b.Reset()
+		equal(t, cenkaltiSequence(b, len(want)), want)
+	})
+
+	t.Run("round_trip", func(t *testing.T) {
+		t.Parallel()
+
+		equal(t, sequence(From(To(newExponential(t))), len(want)), want)
+		equal(t, cenkaltiSequence(To(From(newCenkaltiExponential())), len(want)), want)
+	})
+
+	t.Run("cenkalti_retry", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewConstant(1 * time.Millisecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		var calls int
+		err = cenkalti.Retry(func() error {
+			calls++
+			if calls < 3 {
+				return fmt.Errorf("oops")
+			}
+			return nil
+		}, To(backoff.WithMaxRetries(5, b)))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if calls != 3 {
+			t.Errorf("expected %d to be %d", calls, 3)
+		}
+	})
+}
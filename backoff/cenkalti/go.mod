@@ -0,0 +1,9 @@
+module github.com/swayne275/go-retry/backoff/cenkalti
+
+go 1.22.4
+
+require github.com/swayne275/go-retry v0.0.0
+
+require github.com/cenkalti/backoff/v4 v4.3.0
+
+replace github.com/swayne275/go-retry => ../..
@@ -0,0 +1,266 @@
+package backoff
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Stage is one strategy of a Chain, used for a number of values before the
+// chain moves on to the next stage.
+//
+// The zero Stage has no backoff, so a Chain treats it as having stopped at
+// once, and Validate rejects the Chain.
+type Stage struct {
+	// Backoff gives the values of the stage.
+	Backoff Backoff
+	// Attempts is how many values the stage gives. Zero means no limit: the
+	// stage lasts until its Backoff signals to stop, which suits the last
+	// stage.
+	Attempts uint64
+	// StopEndsChain makes the chain signal to stop when Backoff does before
+	// Attempts run out. By default the chain moves on to the next stage.
+	StopEndsChain bool
+}
+
+// chainBackoff is the backoff returned by Chain.
+type chainBackoff struct {
+	mu     sync.Mutex
+	stages []Stage
+	// stage is the index of the current stage, and used the number of values
+	// it has given.
+	stage int
+	used  uint64
+	// exhausted is whether the last stop was caused by a retry limit.
+	exhausted bool
+}
+
+// Chain returns a backoff that switches strategies as attempts go by: it gives
+// the values of each stage in turn, moving on to the next once a stage has
+// given its Attempts. For example, 5 quick constant retries followed by capped
+// exponential ones:
+//
+//	Chain(
+//		Stage{Backoff: constant, Attempts: 5},
+//		Stage{Backoff: WithCappedDuration(time.Minute, exponential)},
+//	)
+//
+// A stage whose Backoff signals to stop early hands over to the next stage,
+// unless its StopEndsChain is set. Once the last stage is done, the chain
+// signals to stop. Reset rewinds to the first stage and resets every stage's
+// Backoff. It is safe for concurrent use.
+//
+// The chain passes on what the retry loops tell it to every stage: successes
+// recorded with RecordSuccess, attempts reported with AttemptStarted and
+// AttemptFinished, and random sources set with UseRandomSource. It counts as
+// having exhausted its retries, see Exhausted, when it stops because the last
+// stage ran out of Attempts or because a stage stopped on its own retry limit.
+// Its Remaining and RemainingTime add up those of the stages left.
+func Chain(stages ...Stage) Backoff {
+	return &chainBackoff{stages: append([]Stage(nil), stages...)}
+}
+
+// Next implements Backoff.
+func (b *chainBackoff) Next() (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for b.stage < len(b.stages) {
+		s := b.stages[b.stage]
+		if s.Backoff != nil && (s.Attempts == 0 || b.used < s.Attempts) {
+			val, stop := s.Backoff.Next()
+			if !stop {
+				b.used++
+				b.exhausted = false
+				return val, false
+			}
+			b.exhausted = Exhausted(s.Backoff)
+			if s.StopEndsChain {
+				return 0, true
+			}
+		} else {
+			// The stage ran out of attempts, or has no backoff.
+			b.exhausted = s.Backoff != nil
+		}
+
+		b.stage++
+		b.used = 0
+	}
+	return 0, true
+}
+
+// Reset implements Backoff.
+func (b *chainBackoff) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.stage, b.used, b.exhausted = 0, 0, false
+	for _, s := range b.stages {
+		if s.Backoff != nil {
+			s.Backoff.Reset()
+		}
+	}
+}
+
+// Validate returns an error wrapping ErrNilBackoff if a stage has no backoff,
+// or the error Validate returns for a stage's backoff.
+func (b *chainBackoff) Validate() error {
+	for i, s := range b.stages {
+		if s.Backoff == nil {
+			return fmt.Errorf("%w: stage %d of chain has no backoff", ErrNilBackoff, i)
+		}
+		if err := Validate(s.Backoff); err != nil {
+			return fmt.Errorf("stage %d of chain: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// RetriesExhausted reports whether the chain last stopped because of a retry
+// limit: the Attempts of its last stage, or a limit of the stage that stopped.
+func (b *chainBackoff) RetriesExhausted() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.exhausted
+}
+
+// Randomized reports whether any stage draws random numbers.
+func (b *chainBackoff) Randomized() bool {
+	for _, s := range b.stages {
+		if randomized(s.Backoff) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetRandomSource makes every stage draw random numbers from src, as
+// UseRandomSource does. Layers of a stage whose source can't be replaced keep
+// their own.
+func (b *chainBackoff) SetRandomSource(src rand.Source64) {
+	for _, s := range b.stages {
+		if s.Backoff != nil {
+			_ = UseRandomSource(s.Backoff, src)
+		}
+	}
+}
+
+// RecordSuccess tells every stage that an attempt succeeded.
+func (b *chainBackoff) RecordSuccess() {
+	for _, s := range b.stages {
+		RecordSuccess(s.Backoff)
+	}
+}
+
+// AttemptStarted tells every stage that an attempt is starting.
+func (b *chainBackoff) AttemptStarted() {
+	for _, s := range b.stages {
+		AttemptStarted(s.Backoff)
+	}
+}
+
+// AttemptFinished tells every stage that an attempt has finished.
+func (b *chainBackoff) AttemptFinished() {
+	for _, s := range b.stages {
+		AttemptFinished(s.Backoff)
+	}
+}
+
+// Remaining adds up how many values the current and later stages allow. ok is
+// false if any of them has no limit, from its Attempts or its Backoff.
+func (b *chainBackoff) Remaining() (attempts uint64, ok bool) {
+	b.mu.Lock()
+	stage, used := b.stage, b.used
+	b.mu.Unlock()
+
+	for i := stage; i < len(b.stages); i++ {
+		s := b.stages[i]
+		if s.Backoff == nil {
+			continue
+		}
+
+		var n uint64
+		limited := s.Attempts > 0
+		if limited {
+			n = s.Attempts
+			if i == stage {
+				n -= used
+			}
+		}
+		var stops bool
+		if bb, isBudgeted := s.Backoff.(Budgeted); isBudgeted {
+			if m, found := bb.Remaining(); found && (!limited || m < n) {
+				n, limited, stops = m, true, true
+			}
+		}
+		if !limited {
+			return 0, false
+		}
+		attempts += n
+		if stops && s.StopEndsChain {
+			break
+		}
+	}
+	return attempts, true
+}
+
+// RemainingTime adds up how much longer the current and later stages allow
+// retrying. ok is false if any of them has no duration limit.
+func (b *chainBackoff) RemainingTime() (d time.Duration, ok bool) {
+	b.mu.Lock()
+	stage, used := b.stage, b.used
+	b.mu.Unlock()
+
+	for i := stage; i < len(b.stages); i++ {
+		s := b.stages[i]
+		if s.Backoff == nil || (i == stage && s.Attempts > 0 && used >= s.Attempts) {
+			continue
+		}
+
+		bb, isBudgeted := s.Backoff.(Budgeted)
+		if !isBudgeted {
+			return 0, false
+		}
+		left, found := bb.RemainingTime()
+		if !found {
+			return 0, false
+		}
+		d += left
+		if s.StopEndsChain && s.Attempts == 0 {
+			break
+		}
+	}
+	return d, true
+}
+
+// randomized reports whether b, or a backoff it wraps, draws random numbers.
+func randomized(b Backoff) bool {
+	for b != nil {
+		if r, ok := b.(Randomized); ok && r.Randomized() {
+			return true
+		}
+		u, ok := b.(Unwrapper)
+		if !ok {
+			return false
+		}
+		b = u.Unwrap()
+	}
+	return false
+}
+
+// String describes the chain by its stages, each followed by its Attempts in
+// brackets if it has a limit, such as
+// "chain(constant(100ms)[5], exponential(1s)|cap(1m0s))".
+func (b *chainBackoff) String() string {
+	stages := make([]string, len(b.stages))
+	for i, s := range b.stages {
+		stages[i] = describe(s.Backoff)
+		if s.Attempts > 0 {
+			stages[i] += fmt.Sprintf("[%d]", s.Attempts)
+		}
+	}
+	return "chain(" + strings.Join(stages, ", ") + ")"
+}
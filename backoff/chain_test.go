@@ -0,0 +1,306 @@
+package backoff
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestChain(t *testing.T) {
+	t.Parallel()
+
+	constant := func(t *testing.T, d time.Duration) Backoff {
+		t.Helper()
+
+		b, err := NewConstant(d)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		return b
+	}
+	exponential := func(t *testing.T, base time.Duration) Backoff {
+		t.Helper()
+
+		b, err := NewExponential(base)
+		if err != nil {
+			t.Fatalf("failed to create exponential backoff: %v", err)
+		}
+		return b
+	}
+
+	const ms = time.Millisecond
+
+	cases := []struct {
+		name   string
+		stages func(t *testing.T) []Stage
+		// exp is the start of the sequence, and stop whether the chain stops
+		// right after it.
+		exp  []time.Duration
+		stop bool
+	}{
+		{
+			name: "constant_then_capped_exponential",
+			stages: func(t *testing.T) []Stage {
+				return []Stage{
+					{Backoff: constant(t, 100*ms), Attempts: 5},
+					{Backoff: WithCappedDuration(4*time.Second, exponential(t, time.Second))},
+				}
+			},
+			exp: []time.Duration{100 * ms, 100 * ms, 100 * ms, 100 * ms, 100 * ms, 1 * time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second},
+		},
+		{
+			name: "bounded_last_stage",
+			stages: func(t *testing.T) []Stage {
+				return []Stage{
+					{Backoff: constant(t, 100*ms), Attempts: 2},
+					{Backoff: exponential(t, time.Second), Attempts: 2},
+				}
+			},
+			exp:  []time.Duration{100 * ms, 100 * ms, 1 * time.Second, 2 * time.Second},
+			stop: true,
+		},
+		{
+			name: "early_stop_advances",
+			stages: func(t *testing.T) []Stage {
+				return []Stage{
+					{Backoff: WithMaxRetries(2, constant(t, 100*ms)), Attempts: 5},
+					{Backoff: constant(t, time.Second), Attempts: 2},
+				}
+			},
+			exp:  []time.Duration{100 * ms, 100 * ms, 1 * time.Second, 1 * time.Second},
+			stop: true,
+		},
+		{
+			name: "early_stop_ends_chain",
+			stages: func(t *testing.T) []Stage {
+				return []Stage{
+					{Backoff: WithMaxRetries(2, constant(t, 100*ms)), Attempts: 5, StopEndsChain: true},
+					{Backoff: constant(t, time.Second)},
+				}
+			},
+			exp:  []time.Duration{100 * ms, 100 * ms},
+			stop: true,
+		},
+		{
+			name: "unbounded_stage_until_stop",
+			stages: func(t *testing.T) []Stage {
+				return []Stage{
+					{Backoff: WithMaxRetries(3, constant(t, 100*ms))},
+					{Backoff: constant(t, time.Second), Attempts: 1},
+				}
+			},
+			exp:  []time.Duration{100 * ms, 100 * ms, 100 * ms, 1 * time.Second},
+			stop: true,
+		},
+		{
+			name: "zero_stage_skipped",
+			stages: func(t *testing.T) []Stage {
+				return []Stage{
+					{},
+					{Backoff: constant(t, time.Second), Attempts: 1},
+				}
+			},
+			exp:  []time.Duration{1 * time.Second},
+			stop: true,
+		},
+		{
+			name:   "no_stages",
+			stages: func(t *testing.T) []Stage { return nil },
+			stop:   true,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			b := Chain(tc.stages(t)...)
+			check := func(pass string) {
+				t.Helper()
+
+				var got []time.Duration
+				for range tc.exp {
+					val, stop := b.Next()
+					if stop {
+						break
+					}
+					got = append(got, val)
+				}
+				if !reflect.DeepEqual(got, tc.exp) {
+					t.Errorf("%s: expected %v to be %v", pass, got, tc.exp)
+				}
+				if _, stop := b.Next(); stop != tc.stop {
+					t.Errorf("%s: expected stop %t to be %t", pass, stop, tc.stop)
+				}
+			}
+
+			check("before reset")
+			b.Reset()
+			check("after reset")
+
+			// A reset midway rewinds to the first stage too.
+			b.Reset()
+			b.Next()
+			b.Reset()
+			check("after midway reset")
+		})
+	}
+
+	t.Run("reset_resets_every_stage", func(t *testing.T) {
+		t.Parallel()
+
+		first, second := &resetCounter{}, &resetCounter{}
+		b := Chain(Stage{Backoff: first, Attempts: 1}, Stage{Backoff: second})
+		b.Next()
+		b.Next()
+		b.Reset()
+		if first.resets.Load() != 1 || second.resets.Load() != 1 {
+			t.Errorf("expected one reset each, got %d and %d", first.resets.Load(), second.resets.Load())
+		}
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		t.Parallel()
+
+		if err := Validate(Chain(Stage{Backoff: constant(t, time.Second)})); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		for _, b := range []Backoff{
+			Chain(Stage{Backoff: constant(t, time.Second), Attempts: 1}, Stage{}),
+			Chain(Stage{Backoff: WithMaxRetries(3, &ResettableBackoff{})}),
+		} {
+			if err := Validate(b); !errors.Is(err, ErrNilBackoff) {
+				t.Errorf("expected %v to be %v", err, ErrNilBackoff)
+			}
+		}
+	})
+
+	t.Run("use_random_source", func(t *testing.T) {
+		t.Parallel()
+
+		newChain := func() Backoff {
+			jittered, err := WithJitter(time.Second, constant(t, 2*time.Second))
+			if err != nil {
+				t.Fatalf("failed to create jitter backoff: %v", err)
+			}
+			return Chain(Stage{Backoff: constant(t, 100*ms), Attempts: 1}, Stage{Backoff: jittered})
+		}
+
+		if !newChain().(Randomized).Randomized() {
+			t.Error("expected the chain to be randomized")
+		}
+		if Chain(Stage{Backoff: constant(t, time.Second)}).(Randomized).Randomized() {
+			t.Error("expected the chain not to be randomized")
+		}
+
+		var seqs [2][]time.Duration
+		for i := range seqs {
+			b := newChain()
+			if err := UseRandomSource(b, rand.NewSource(42).(rand.Source64)); err != nil {
+				t.Fatalf("failed to set random source: %v", err)
+			}
+			for j := 0; j < 10; j++ {
+				val, _ := b.Next()
+				seqs[i] = append(seqs[i], val)
+			}
+		}
+		if !reflect.DeepEqual(seqs[0], seqs[1]) {
+			t.Errorf("expected %v to be %v", seqs[0], seqs[1])
+		}
+	})
+
+	t.Run("exhausted", func(t *testing.T) {
+		t.Parallel()
+
+		cases := []struct {
+			name string
+			b    Backoff
+			exp  bool
+		}{
+			{
+				name: "stage_retry_limit",
+				b:    Chain(Stage{Backoff: WithMaxRetries(1, constant(t, time.Second)), StopEndsChain: true}),
+				exp:  true,
+			},
+			{
+				name: "last_stage_attempts",
+				b:    Chain(Stage{Backoff: constant(t, time.Second), Attempts: 1}),
+				exp:  true,
+			},
+			{
+				name: "stage_stopped",
+				b: Chain(Stage{Backoff: BackoffFunc(func() (time.Duration, bool) {
+					return 0, true
+				})}),
+			},
+		}
+		for _, c := range cases {
+			for {
+				if _, stop := c.b.Next(); stop {
+					break
+				}
+			}
+			if got := Exhausted(c.b); got != c.exp {
+				t.Errorf("%s: expected %t to be %t", c.name, got, c.exp)
+			}
+		}
+	})
+
+	t.Run("record_success", func(t *testing.T) {
+		t.Parallel()
+
+		b := Chain(
+			Stage{Backoff: constant(t, 100*ms), Attempts: 1},
+			Stage{Backoff: WithCircuitBreakerClock(2, time.Hour, &stepClock{}, constant(t, time.Second))},
+		)
+		b.Next()
+		b.Next()
+		RecordSuccess(b)
+		if _, stop := b.Next(); stop {
+			t.Error("expected the recorded success to clear the failure")
+		}
+	})
+
+	t.Run("attempts", func(t *testing.T) {
+		t.Parallel()
+
+		c := &stepClock{now: time.Unix(100, 0)}
+		b := Chain(Stage{Backoff: WithMaxSleepWindowClock(30*time.Second, c, constant(t, time.Second))})
+		b.Next()
+		AttemptStarted(b)
+		c.advance(time.Minute)
+		AttemptFinished(b)
+		if val, stop := b.Next(); stop || val != time.Second {
+			t.Errorf("expected (%v, %t) to be (%v, false)", val, stop, time.Second)
+		}
+	})
+
+	t.Run("remaining", func(t *testing.T) {
+		t.Parallel()
+
+		c := &stepClock{now: time.Unix(100, 0)}
+		b := Chain(
+			Stage{Backoff: WithMaxDurationClock(10*time.Second, c, constant(t, 100*ms)), Attempts: 2},
+			Stage{Backoff: WithMaxDurationClock(time.Minute, c, WithMaxRetries(3, constant(t, time.Second))), Attempts: 5},
+		)
+		b.Next()
+		if got, ok := b.(Budgeted).Remaining(); !ok || got != 4 {
+			t.Errorf("expected %d, %t to be %d, true", got, ok, 4)
+		}
+		if got, ok := b.(Budgeted).RemainingTime(); !ok || got != 70*time.Second {
+			t.Errorf("expected %v, %t to be %v, true", got, ok, 70*time.Second)
+		}
+
+		unbounded := Chain(Stage{Backoff: constant(t, time.Second), Attempts: 1}, Stage{Backoff: constant(t, time.Second)})
+		if _, ok := unbounded.(Budgeted).Remaining(); ok {
+			t.Error("expected no retry limit")
+		}
+		if _, ok := unbounded.(Budgeted).RemainingTime(); ok {
+			t.Error("expected no duration limit")
+		}
+	})
+}
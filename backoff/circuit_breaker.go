@@ -0,0 +1,109 @@
+package backoff
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/swayne275/go-retry/internal/clock"
+)
+
+// circuitState is the state of a WithCircuitBreaker decorator.
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// WithCircuitBreaker wraps a backoff with a circuit breaker. Every call to
+// Next counts as a failure. While the circuit is closed, values come from
+// next; the call that records the failureThreshold-th consecutive failure
+// opens the circuit and signals to stop. While open, for coolDown, Next
+// signals to stop at once. The first call after that half-opens the circuit
+// and allows one more attempt, with the first value of next. If Next is
+// called again before a success is recorded, that attempt failed too and the
+// circuit opens for another coolDown.
+//
+// Successes are recorded with RecordSuccess, which closes the circuit and
+// clears the count of failures; retry.Do records them for the backoff it is
+// given. Reset also closes the circuit. A failureThreshold of 0 is treated as
+// 1.
+//
+// The breaker is meant to be shared by the loops calling the same dependency,
+// so that once it opens they all stop retrying for the cool-down.
+func WithCircuitBreaker(failureThreshold uint64, coolDown time.Duration, next Backoff) *ResettableBackoff {
+	return WithCircuitBreakerClock(failureThreshold, coolDown, nil, next)
+}
+
+// WithCircuitBreakerClock is like WithCircuitBreaker, but measures the
+// cool-down with c instead of the real clock. A nil c selects the real clock.
+func WithCircuitBreakerClock(failureThreshold uint64, coolDown time.Duration, c Clock, next Backoff) *ResettableBackoff {
+	c = clock.Or(c)
+	if failureThreshold == 0 {
+		failureThreshold = 1
+	}
+
+	var l sync.Mutex
+	state := circuitClosed
+	var failures uint64
+	var openedAt time.Time
+
+	// open opens the circuit. l must be held.
+	open := func() {
+		state = circuitOpen
+		openedAt = c.Now()
+		failures = 0
+		next.Reset()
+	}
+
+	nextWithCircuitBreaker := BackoffFunc(func() (time.Duration, bool) {
+		l.Lock()
+		defer l.Unlock()
+
+		switch state {
+		case circuitOpen:
+			if c.Now().Sub(openedAt) < coolDown {
+				return 0, true
+			}
+			state = circuitHalfOpen
+		case circuitHalfOpen:
+			// The one attempt allowed while half-open failed
+			open()
+			return 0, true
+		default:
+			failures++
+			if failures >= failureThreshold {
+				open()
+				return 0, true
+			}
+		}
+
+		val, stop := next.Next()
+		if stop {
+			return 0, true
+		}
+		return val, false
+	})
+
+	reset := func() Backoff {
+		l.Lock()
+		defer l.Unlock()
+		state = circuitClosed
+		failures = 0
+
+		next.Reset()
+		return nextWithCircuitBreaker
+	}
+
+	b := decorate(fmt.Sprintf("circuit_breaker(%d, %v)", failureThreshold, coolDown), reset, next, nextWithCircuitBreaker, nil)
+	b.success = func() {
+		l.Lock()
+		defer l.Unlock()
+
+		state = circuitClosed
+		failures = 0
+	}
+	return b
+}
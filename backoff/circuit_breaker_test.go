@@ -0,0 +1,180 @@
+package backoff
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithCircuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	type step struct {
+		advance time.Duration
+		success bool
+		val     time.Duration
+		stop    bool
+	}
+
+	// The wrapped backoff counts up in seconds from 1s, so each value shows
+	// whether it was reset.
+	newCounting := func() Backoff {
+		var n atomic.Int64
+		return &countingBackoff{n: &n}
+	}
+
+	cases := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "opens_after_threshold",
+			steps: []step{
+				{val: 1 * time.Second},
+				{val: 2 * time.Second},
+				{stop: true}, // third failure opens
+				{stop: true}, // still open
+				{advance: 59 * time.Second, stop: true},
+			},
+		},
+		{
+			name: "half_open_success",
+			steps: []step{
+				{val: 1 * time.Second},
+				{val: 2 * time.Second},
+				{stop: true},
+				{advance: time.Minute, val: 1 * time.Second}, // half-open trial
+				{success: true},
+				{val: 2 * time.Second}, // closed again, counting from zero
+				{val: 3 * time.Second},
+				{stop: true},
+			},
+		},
+		{
+			name: "half_open_failure",
+			steps: []step{
+				{val: 1 * time.Second},
+				{val: 2 * time.Second},
+				{stop: true},
+				{advance: time.Minute, val: 1 * time.Second}, // half-open trial
+				{stop: true},                            // the trial failed
+				{advance: 59 * time.Second, stop: true}, // a new cool-down
+				{advance: 1 * time.Second, val: 1 * time.Second},
+			},
+		},
+		{
+			name: "success_clears_failures",
+			steps: []step{
+				{val: 1 * time.Second},
+				{val: 2 * time.Second},
+				{success: true},
+				{val: 3 * time.Second},
+				{val: 4 * time.Second},
+				{stop: true},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := &stepClock{now: time.Unix(100, 0)}
+			b := WithCircuitBreakerClock(3, time.Minute, c, newCounting())
+			for i, s := range tc.steps {
+				c.advance(s.advance)
+				if s.success {
+					RecordSuccess(b)
+					continue
+				}
+				val, stop := b.Next()
+				if val != s.val || stop != s.stop {
+					t.Fatalf("step %d: expected (%v, %t) to be (%v, %t)", i, val, stop, s.val, s.stop)
+				}
+			}
+		})
+	}
+
+	t.Run("reset_closes", func(t *testing.T) {
+		t.Parallel()
+
+		b := WithCircuitBreakerClock(2, time.Hour, &stepClock{}, newCounting())
+		b.Next()
+		if _, stop := b.Next(); !stop {
+			t.Fatal("expected the circuit to open")
+		}
+		b.Reset()
+		if val, stop := b.Next(); stop {
+			t.Errorf("expected the circuit to be closed, got (%v, %t)", val, stop)
+		}
+	})
+
+	t.Run("record_success_through_decorators", func(t *testing.T) {
+		t.Parallel()
+
+		breaker := WithCircuitBreakerClock(2, time.Hour, &stepClock{}, newCounting())
+		b := WithCappedDuration(time.Hour, breaker)
+		b.Next()
+		RecordSuccess(b)
+		if _, stop := b.Next(); stop {
+			t.Error("expected the recorded success to clear the failure")
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		t.Parallel()
+
+		const threshold = 50
+		b := WithCircuitBreakerClock(threshold, time.Hour, &stepClock{}, newCounting())
+
+		var stops atomic.Int64
+		var wg sync.WaitGroup
+		for i := 0; i < 100; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				if _, stop := b.Next(); stop {
+					stops.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		// The threshold-th failure opens the circuit, and every call after it
+		// stops.
+		if got, want := stops.Load(), int64(100-threshold+1); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+
+		// Concurrent successes and failures race only on the outcome.
+		for i := 0; i < 100; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				b.Next()
+			}()
+			go func() {
+				defer wg.Done()
+				b.RecordSuccess()
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+// countingBackoff returns 1s, 2s, 3s... and starts over when reset.
+type countingBackoff struct {
+	n *atomic.Int64
+}
+
+func (b *countingBackoff) Next() (time.Duration, bool) {
+	return time.Duration(b.n.Add(1)) * time.Second, false
+}
+
+func (b *countingBackoff) Reset() {
+	b.n.Store(0)
+}
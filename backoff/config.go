@@ -0,0 +1,200 @@
+package backoff
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	// StrategyConstant selects NewConstant.
+	StrategyConstant = "constant"
+	// StrategyExponential selects NewExponential.
+	StrategyExponential = "exponential"
+	// StrategyFibonacci selects NewFibonacci.
+	StrategyFibonacci = "fibonacci"
+	// StrategyLinear selects NewLinear.
+	StrategyLinear = "linear"
+)
+
+// Config describes a backoff as plain data, so it can be loaded from
+// configuration rather than built with nested constructor calls. Zero values
+// for the optional fields mean "not set".
+//
+// Config can be decoded from JSON, with the keys in the field tags, and
+// durations written as strings such as "1.5s", as time.ParseDuration accepts,
+// or as numbers of nanoseconds:
+//
+//	{"strategy": "exponential", "base": "100ms", "cap": "5s", "max_retries": 5}
+type Config struct {
+	// Strategy is one of the Strategy constants.
+	Strategy string `json:"strategy"`
+	// Base is the starting value for the strategy.
+	Base time.Duration `json:"base"`
+	// Increment is how much StrategyLinear adds on each failure.
+	Increment time.Duration `json:"increment,omitempty"`
+	// Cap applies WithCappedDuration.
+	Cap time.Duration `json:"cap,omitempty"`
+	// Jitter applies WithJitter.
+	Jitter time.Duration `json:"jitter,omitempty"`
+	// JitterPercent applies WithJitterPercent.
+	JitterPercent uint64 `json:"jitter_percent,omitempty"`
+	// MaxRetries applies WithMaxRetries.
+	MaxRetries uint64 `json:"max_retries,omitempty"`
+	// MaxDuration applies WithMaxDuration.
+	MaxDuration time.Duration `json:"max_duration,omitempty"`
+}
+
+// configJSON is Config with durations that decode from strings.
+type configJSON struct {
+	Strategy      string   `json:"strategy"`
+	Base          Duration `json:"base"`
+	Increment     Duration `json:"increment,omitempty"`
+	Cap           Duration `json:"cap,omitempty"`
+	Jitter        Duration `json:"jitter,omitempty"`
+	JitterPercent uint64   `json:"jitter_percent,omitempty"`
+	MaxRetries    uint64   `json:"max_retries,omitempty"`
+	MaxDuration   Duration `json:"max_duration,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler, writing durations as strings.
+func (c Config) MarshalJSON() ([]byte, error) {
+	return json.Marshal(configJSON{
+		Strategy:      c.Strategy,
+		Base:          Duration(c.Base),
+		Increment:     Duration(c.Increment),
+		Cap:           Duration(c.Cap),
+		Jitter:        Duration(c.Jitter),
+		JitterPercent: c.JitterPercent,
+		MaxRetries:    c.MaxRetries,
+		MaxDuration:   Duration(c.MaxDuration),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler, reading durations as Duration
+// does.
+func (c *Config) UnmarshalJSON(data []byte) error {
+	var v configJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	*c = Config{
+		Strategy:      v.Strategy,
+		Base:          time.Duration(v.Base),
+		Increment:     time.Duration(v.Increment),
+		Cap:           time.Duration(v.Cap),
+		Jitter:        time.Duration(v.Jitter),
+		JitterPercent: v.JitterPercent,
+		MaxRetries:    v.MaxRetries,
+		MaxDuration:   time.Duration(v.MaxDuration),
+	}
+	return nil
+}
+
+// Duration is a time.Duration that is written to JSON and text as a string
+// such as "1.5s", and read from a string time.ParseDuration accepts or, in
+// JSON, a number of nanoseconds.
+type Duration time.Duration
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		return d.UnmarshalText([]byte(s))
+	}
+
+	v, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid duration %s: must be a string such as \"1s\" or a number of nanoseconds", data)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// FromConfig builds the backoff described by cfg. Decorators are applied in a
+// fixed order regardless of which fields are set: strategy, then cap, then
+// jitter, then the retry and duration limits. Errors name the field of cfg
+// that is invalid.
+func FromConfig(cfg Config) (Backoff, error) {
+	if cfg.Increment < 0 {
+		return nil, fmt.Errorf("invalid Config.Increment: must not be negative")
+	}
+	if cfg.Cap < 0 {
+		return nil, fmt.Errorf("invalid Config.Cap: must not be negative")
+	}
+	if cfg.Jitter < 0 {
+		return nil, fmt.Errorf("invalid Config.Jitter: must not be negative")
+	}
+	if cfg.MaxDuration < 0 {
+		return nil, fmt.Errorf("invalid Config.MaxDuration: must not be negative")
+	}
+
+	var b Backoff
+	var err error
+	switch cfg.Strategy {
+	case StrategyConstant:
+		b, err = NewConstant(cfg.Base)
+	case StrategyExponential:
+		b, err = NewExponential(cfg.Base)
+	case StrategyFibonacci:
+		b, err = NewFibonacci(cfg.Base)
+	case StrategyLinear:
+		b, err = NewLinear(cfg.Base, cfg.Increment)
+	default:
+		return nil, fmt.Errorf("invalid Config.Strategy: %q is not one of %q, %q, %q or %q",
+			cfg.Strategy, StrategyConstant, StrategyExponential, StrategyFibonacci, StrategyLinear)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid Config.Base: %w", err)
+	}
+	if cfg.Increment != 0 && cfg.Strategy != StrategyLinear {
+		return nil, fmt.Errorf("invalid Config.Increment: only applies to strategy %q", StrategyLinear)
+	}
+
+	if cfg.Cap > 0 {
+		b = WithCappedDuration(cfg.Cap, b)
+	}
+	if cfg.Jitter > 0 {
+		if b, err = WithJitter(cfg.Jitter, b); err != nil {
+			return nil, fmt.Errorf("invalid Config.Jitter: %w", err)
+		}
+	}
+	if cfg.JitterPercent > 0 {
+		if b, err = WithJitterPercent(cfg.JitterPercent, b); err != nil {
+			return nil, fmt.Errorf("invalid Config.JitterPercent: %w", err)
+		}
+	}
+	if cfg.MaxRetries > 0 {
+		b = WithMaxRetries(cfg.MaxRetries, b)
+	}
+	if cfg.MaxDuration > 0 {
+		b = WithMaxDuration(cfg.MaxDuration, b)
+	}
+
+	return b, nil
+}
@@ -0,0 +1,212 @@
+package backoff
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFromConfig(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name  string
+		cfg   Config
+		tries int
+		exp   []time.Duration
+		err   string
+	}{
+		{
+			name:  "constant",
+			cfg:   Config{Strategy: StrategyConstant, Base: 2 * time.Second},
+			tries: 3,
+			exp:   []time.Duration{2 * time.Second, 2 * time.Second, 2 * time.Second},
+		},
+		{
+			name:  "exponential_capped",
+			cfg:   Config{Strategy: StrategyExponential, Base: 1 * time.Second, Cap: 3 * time.Second},
+			tries: 4,
+			exp:   []time.Duration{1 * time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second},
+		},
+		{
+			name:  "fibonacci_max_retries",
+			cfg:   Config{Strategy: StrategyFibonacci, Base: 1 * time.Second, MaxRetries: 3},
+			tries: 3,
+			exp:   []time.Duration{1 * time.Second, 2 * time.Second, 3 * time.Second},
+		},
+		{
+			name:  "linear",
+			cfg:   Config{Strategy: StrategyLinear, Base: 1 * time.Second, Increment: 500 * time.Millisecond},
+			tries: 3,
+			exp:   []time.Duration{1 * time.Second, 1500 * time.Millisecond, 2 * time.Second},
+		},
+		{
+			name: "unknown_strategy",
+			cfg:  Config{Strategy: "linear-ish", Base: 1 * time.Second},
+			err:  "Config.Strategy",
+		},
+		{
+			name: "bad_base",
+			cfg:  Config{Strategy: StrategyConstant},
+			err:  "Config.Base",
+		},
+		{
+			name: "negative_cap",
+			cfg:  Config{Strategy: StrategyConstant, Base: 1 * time.Second, Cap: -1},
+			err:  "Config.Cap",
+		},
+		{
+			name: "negative_jitter",
+			cfg:  Config{Strategy: StrategyConstant, Base: 1 * time.Second, Jitter: -1},
+			err:  "Config.Jitter",
+		},
+		{
+			name: "negative_increment",
+			cfg:  Config{Strategy: StrategyLinear, Base: 1 * time.Second, Increment: -1},
+			err:  "Config.Increment",
+		},
+		{
+			name: "increment_without_linear",
+			cfg:  Config{Strategy: StrategyConstant, Base: 1 * time.Second, Increment: 1},
+			err:  "Config.Increment",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			b, err := FromConfig(tc.cfg)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expected an error naming %s, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			got := make([]time.Duration, 0, tc.tries)
+			for i := 0; i < tc.tries; i++ {
+				val, stop := b.Next()
+				if stop {
+					t.Fatalf("stopped after %d tries", i)
+				}
+				got = append(got, val)
+			}
+			if !reflect.DeepEqual(got, tc.exp) {
+				t.Errorf("expected %v to be %v", got, tc.exp)
+			}
+		})
+	}
+
+	t.Run("max_retries_stops", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := FromConfig(Config{Strategy: StrategyConstant, Base: 1 * time.Second, MaxRetries: 1})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if _, stop := b.Next(); stop {
+			t.Fatal("expected first Next not to stop")
+		}
+		if _, stop := b.Next(); !stop {
+			t.Error("expected second Next to stop")
+		}
+	})
+}
+
+func TestConfig_json(t *testing.T) {
+	t.Parallel()
+
+	t.Run("sequence", func(t *testing.T) {
+		t.Parallel()
+
+		doc := `{
+			"strategy": "exponential",
+			"base": "100ms",
+			"cap": "1s",
+			"max_retries": 5,
+			"max_duration": "1m"
+		}`
+
+		var cfg Config
+		if err := json.Unmarshal([]byte(doc), &cfg); err != nil {
+			t.Fatalf("failed to unmarshal config: %v", err)
+		}
+		b, err := FromConfig(cfg)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		exp := []time.Duration{
+			100 * time.Millisecond,
+			200 * time.Millisecond,
+			400 * time.Millisecond,
+			800 * time.Millisecond,
+			1 * time.Second,
+		}
+		for i, want := range exp {
+			val, stop := b.Next()
+			if stop {
+				t.Fatalf("stopped after %d tries", i)
+			}
+			if val != want {
+				t.Errorf("try %d: expected %v to be %v", i, val, want)
+			}
+		}
+		if _, stop := b.Next(); !stop {
+			t.Error("expected to stop after max_retries")
+		}
+	})
+
+	t.Run("nanoseconds", func(t *testing.T) {
+		t.Parallel()
+
+		var cfg Config
+		if err := json.Unmarshal([]byte(`{"strategy": "linear", "base": 1000, "increment": "1us"}`), &cfg); err != nil {
+			t.Fatalf("failed to unmarshal config: %v", err)
+		}
+		exp := Config{Strategy: StrategyLinear, Base: time.Microsecond, Increment: time.Microsecond}
+		if cfg != exp {
+			t.Errorf("expected %+v to be %+v", cfg, exp)
+		}
+	})
+
+	t.Run("round_trip", func(t *testing.T) {
+		t.Parallel()
+
+		cfg := Config{Strategy: StrategyFibonacci, Base: 250 * time.Millisecond, Cap: 10 * time.Second, JitterPercent: 10}
+		data, err := json.Marshal(cfg)
+		if err != nil {
+			t.Fatalf("failed to marshal config: %v", err)
+		}
+		if !strings.Contains(string(data), `"base":"250ms"`) {
+			t.Errorf("expected %s to write base as a string", data)
+		}
+
+		var got Config
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatalf("failed to unmarshal config: %v", err)
+		}
+		if got != cfg {
+			t.Errorf("expected %+v to be %+v", got, cfg)
+		}
+	})
+
+	t.Run("bad_duration", func(t *testing.T) {
+		t.Parallel()
+
+		for _, doc := range []string{`{"base": "soon"}`, `{"base": 1.5}`, `{"base": true}`} {
+			var cfg Config
+			if err := json.Unmarshal([]byte(doc), &cfg); err == nil {
+				t.Errorf("expected an error for %s", doc)
+			}
+		}
+	})
+}
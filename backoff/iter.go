@@ -0,0 +1,45 @@
+//go:build go1.23
+
+package backoff
+
+import (
+	"context"
+	"iter"
+	"time"
+)
+
+// All returns an iterator over the values of b, for loops that do their own
+// sleeping:
+//
+//	for d := range backoff.All(b) {
+//		time.Sleep(d)
+//	}
+//
+// The iterator ends when b signals to stop. It calls Next once per value, and
+// not again after the loop breaks, so ending a loop early doesn't consume a
+// retry from decorators such as WithMaxRetries. Like Next, it does not reset
+// b, so a second loop over the same b continues where the first left off.
+func All(b Backoff) iter.Seq[time.Duration] {
+	return func(yield func(time.Duration) bool) {
+		for {
+			next, stop := b.Next()
+			if stop || !yield(next) {
+				return
+			}
+		}
+	}
+}
+
+// AllContext is like All, but also ends the iteration once ctx is done. ctx
+// is checked before each call to Next, so no value is drawn from b after ctx
+// is done.
+func AllContext(ctx context.Context, b Backoff) iter.Seq[time.Duration] {
+	return func(yield func(time.Duration) bool) {
+		for ctx.Err() == nil {
+			next, stop := b.Next()
+			if stop || !yield(next) {
+				return
+			}
+		}
+	}
+}
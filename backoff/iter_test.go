@@ -0,0 +1,135 @@
+//go:build go1.23
+
+package backoff
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAll(t *testing.T) {
+	t.Parallel()
+
+	// counting returns 1s, 2s, 3s, ... and counts its calls.
+	counting := func() (Backoff, *int) {
+		var calls int
+		return BackoffFunc(func() (time.Duration, bool) {
+			calls++
+			return time.Duration(calls) * time.Second, false
+		}), &calls
+	}
+
+	t.Run("stops", func(t *testing.T) {
+		t.Parallel()
+
+		inner, calls := counting()
+		b := WithMaxRetries(3, inner)
+
+		var got []time.Duration
+		for d := range All(b) {
+			got = append(got, d)
+		}
+		if want := []time.Duration{1 * time.Second, 2 * time.Second, 3 * time.Second}; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+		if *calls != 3 {
+			t.Errorf("expected %d to be %d", *calls, 3)
+		}
+
+		// A stopped backoff yields nothing until it is reset.
+		for d := range All(b) {
+			t.Errorf("unexpected value %v", d)
+		}
+		b.Reset()
+		for range All(b) {
+			break
+		}
+	})
+
+	t.Run("break", func(t *testing.T) {
+		t.Parallel()
+
+		inner, calls := counting()
+		b := WithMaxRetries(3, inner)
+
+		for d := range All(b) {
+			if d == 2*time.Second {
+				break
+			}
+		}
+		if *calls != 2 {
+			t.Errorf("expected %d to be %d", *calls, 2)
+		}
+
+		// Breaking doesn't consume a retry, so one is left.
+		var got []time.Duration
+		for d := range All(b) {
+			got = append(got, d)
+		}
+		if want := []time.Duration{3 * time.Second}; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	})
+}
+
+func TestAllContext(t *testing.T) {
+	t.Parallel()
+
+	t.Run("stops", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := NewConstant(1 * time.Second)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		var n int
+		for range AllContext(context.Background(), WithMaxRetries(5, b)) {
+			n++
+		}
+		if n != 5 {
+			t.Errorf("expected %d to be %d", n, 5)
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int
+		b := WithMaxRetries(10, BackoffFunc(func() (time.Duration, bool) {
+			calls++
+			return time.Second, false
+		}))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var n int
+		for range AllContext(ctx, b) {
+			n++
+			if n == 3 {
+				cancel()
+			}
+		}
+		if n != 3 || calls != 3 {
+			t.Errorf("expected %d values from %d calls, got %d from %d", 3, 3, n, calls)
+		}
+	})
+
+	t.Run("already_canceled", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		b := BackoffFunc(func() (time.Duration, bool) {
+			t.Error("unexpected call to Next")
+			return 0, true
+		})
+		for d := range AllContext(ctx, b) {
+			t.Errorf("unexpected value %v", d)
+		}
+	})
+}
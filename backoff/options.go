@@ -0,0 +1,128 @@
+package backoff
+
+import (
+	"fmt"
+	"time"
+)
+
+// Option configures the backoff built by New.
+type Option func(*builder)
+
+// builder collects the options given to New.
+type builder struct {
+	cfg Config
+	// strategies holds every strategy given, so New can reject more than one.
+	strategies []string
+	// noRetries is set by WithMaxRetriesOpt(0), which Config can't express.
+	noRetries bool
+}
+
+func (b *builder) strategy(name string, base time.Duration) {
+	b.strategies = append(b.strategies, name)
+	b.cfg.Strategy = name
+	b.cfg.Base = base
+}
+
+// WithConstantBase selects NewConstant with base as the strategy of New.
+func WithConstantBase(base time.Duration) Option {
+	return func(b *builder) {
+		b.strategy(StrategyConstant, base)
+	}
+}
+
+// WithExponentialBase selects NewExponential with base as the strategy of New.
+func WithExponentialBase(base time.Duration) Option {
+	return func(b *builder) {
+		b.strategy(StrategyExponential, base)
+	}
+}
+
+// WithFibonacciBase selects NewFibonacci with base as the strategy of New.
+func WithFibonacciBase(base time.Duration) Option {
+	return func(b *builder) {
+		b.strategy(StrategyFibonacci, base)
+	}
+}
+
+// WithLinearBase selects NewLinear with base and increment as the strategy of
+// New.
+func WithLinearBase(base, increment time.Duration) Option {
+	return func(b *builder) {
+		b.strategy(StrategyLinear, base)
+		b.cfg.Increment = increment
+	}
+}
+
+// WithCap makes New apply WithCappedDuration.
+func WithCap(cap time.Duration) Option {
+	return func(b *builder) {
+		b.cfg.Cap = cap
+	}
+}
+
+// WithJitterOpt makes New apply WithJitter.
+func WithJitterOpt(j time.Duration) Option {
+	return func(b *builder) {
+		b.cfg.Jitter = j
+	}
+}
+
+// WithJitterPercentOpt makes New apply WithJitterPercent.
+func WithJitterPercentOpt(j uint64) Option {
+	return func(b *builder) {
+		b.cfg.JitterPercent = j
+	}
+}
+
+// WithMaxRetriesOpt makes New apply WithMaxRetries. Unlike the MaxRetries
+// field of Config, a max of 0 is applied, and allows no retries.
+func WithMaxRetriesOpt(max uint64) Option {
+	return func(b *builder) {
+		b.cfg.MaxRetries = max
+		b.noRetries = max == 0
+	}
+}
+
+// WithMaxDurationOpt makes New apply WithMaxDuration.
+func WithMaxDurationOpt(timeout time.Duration) Option {
+	return func(b *builder) {
+		b.cfg.MaxDuration = timeout
+	}
+}
+
+// New builds a backoff from opts in one call, instead of nesting constructor
+// and decorator calls. Exactly one strategy option, such as
+// WithExponentialBase, must be given. The decorators are applied in the same
+// fixed order as FromConfig, regardless of the order of opts: strategy, then
+// cap, then jitter, then the retry and duration limits. If an option is given
+// more than once, the last one wins.
+//
+// It returns an error if no strategy or more than one is given, or if any
+// value is invalid.
+func New(opts ...Option) (Backoff, error) {
+	var b builder
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&b)
+		}
+	}
+
+	switch len(b.strategies) {
+	case 0:
+		return nil, fmt.Errorf("invalid options: no strategy given, use one of WithConstantBase, WithExponentialBase, WithFibonacciBase or WithLinearBase")
+	case 1:
+	default:
+		return nil, fmt.Errorf("invalid options: more than one strategy given: %q", b.strategies)
+	}
+
+	bo, err := FromConfig(b.cfg)
+	if err != nil {
+		return nil, err
+	}
+	if b.noRetries {
+		// The limits are the outermost layers, so this is where FromConfig
+		// would have applied it.
+		bo = WithMaxRetries(0, bo)
+	}
+	return bo, nil
+}
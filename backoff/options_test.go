@@ -0,0 +1,129 @@
+package backoff
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name  string
+		opts  []Option
+		tries int
+		exp   []time.Duration
+		// stops is set if the backoff should stop after exp.
+		stops bool
+		err   string
+	}{
+		{
+			name:  "constant",
+			opts:  []Option{WithConstantBase(2 * time.Second)},
+			tries: 3,
+			exp:   []time.Duration{2 * time.Second, 2 * time.Second, 2 * time.Second},
+		},
+		{
+			name:  "exponential_capped_limited",
+			opts:  []Option{WithMaxRetriesOpt(4), WithCap(3 * time.Second), WithExponentialBase(1 * time.Second)},
+			tries: 4,
+			exp:   []time.Duration{1 * time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second},
+			stops: true,
+		},
+		{
+			name:  "fibonacci_max_duration",
+			opts:  []Option{WithFibonacciBase(1 * time.Second), WithMaxDurationOpt(time.Hour)},
+			tries: 3,
+			exp:   []time.Duration{1 * time.Second, 2 * time.Second, 3 * time.Second},
+		},
+		{
+			name:  "linear",
+			opts:  []Option{WithLinearBase(1*time.Second, 1*time.Second)},
+			tries: 3,
+			exp:   []time.Duration{1 * time.Second, 2 * time.Second, 3 * time.Second},
+		},
+		{
+			name:  "no_retries",
+			opts:  []Option{WithConstantBase(1 * time.Second), WithMaxRetriesOpt(0)},
+			exp:   []time.Duration{},
+			stops: true,
+		},
+		{
+			name:  "last_wins",
+			opts:  []Option{WithConstantBase(1 * time.Second), WithCap(time.Millisecond), WithCap(time.Hour)},
+			tries: 1,
+			exp:   []time.Duration{1 * time.Second},
+		},
+		{
+			name: "no_strategy",
+			opts: []Option{WithCap(time.Second)},
+			err:  "no strategy given, use one of WithConstantBase, WithExponentialBase, WithFibonacciBase or WithLinearBase",
+		},
+		{
+			name: "two_strategies",
+			opts: []Option{WithExponentialBase(time.Second), WithFibonacciBase(time.Second)},
+			err:  `more than one strategy given: ["exponential" "fibonacci"]`,
+		},
+		{
+			name: "bad_base",
+			opts: []Option{WithExponentialBase(0)},
+			err:  "invalid Config.Base",
+		},
+		{
+			name: "bad_jitter",
+			opts: []Option{WithConstantBase(time.Second), WithJitterOpt(-1)},
+			err:  "invalid Config.Jitter",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			b, err := New(tc.opts...)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) || b != nil {
+					t.Fatalf("expected an error containing %q and no backoff, got %v and %v", tc.err, err, b)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			got := make([]time.Duration, 0, tc.tries)
+			for i := 0; i < tc.tries; i++ {
+				val, stop := b.Next()
+				if stop {
+					t.Fatalf("stopped after %d tries", i)
+				}
+				got = append(got, val)
+			}
+			if !reflect.DeepEqual(got, tc.exp) {
+				t.Errorf("expected %v to be %v", got, tc.exp)
+			}
+			if _, stop := b.Next(); stop != tc.stops {
+				t.Errorf("expected stop %t to be %t", stop, tc.stops)
+			}
+		})
+	}
+
+	t.Run("jitter", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := New(WithConstantBase(1*time.Second), WithJitterOpt(100*time.Millisecond), WithJitterPercentOpt(10))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		for i := 0; i < 100; i++ {
+			val, _ := b.Next()
+			if min, max := 810*time.Millisecond, 1210*time.Millisecond; val < min || val > max {
+				t.Errorf("expected %v to be between %v and %v", val, min, max)
+			}
+		}
+	})
+}
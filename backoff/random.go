@@ -0,0 +1,84 @@
+package backoff
+
+import (
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/swayne275/go-retry/internal/label"
+	"github.com/swayne275/go-retry/internal/random"
+)
+
+// ErrRandomSourceNotSupported is returned by UseRandomSource when a backoff in
+// the chain draws random numbers from a source that can't be replaced. Its
+// label is "random_source_not_supported".
+var ErrRandomSourceNotSupported = label.New("backoff draws random numbers from a source that can't be replaced", "random_source_not_supported")
+
+// Unwrapper is implemented by decorators that expose the backoff they wrap, so
+// a chain of decorators can be walked.
+type Unwrapper interface {
+	Unwrap() Backoff
+}
+
+// Randomized is implemented by backoffs that can report whether their values
+// depend on random draws. Backoffs that do should also implement
+// RandomSourceSetter, so their draws can be made reproducible.
+type Randomized interface {
+	Randomized() bool
+}
+
+// RandomSourceSetter is implemented by backoffs whose random draws come from a
+// replaceable source.
+type RandomSourceSetter interface {
+	SetRandomSource(src rand.Source64)
+}
+
+// UseRandomSource walks the chain of decorators starting at b and makes every
+// layer that draws random numbers use src instead of its own source. Sharing
+// one seeded source across the chain makes the whole sequence reproducible.
+//
+// It returns an error wrapping ErrRandomSourceNotSupported if a layer reports
+// that it is Randomized but does not implement RandomSourceSetter. Layers that
+// implement neither interface are assumed to be deterministic.
+func UseRandomSource(b Backoff, src rand.Source64) error {
+	for b != nil {
+		if s, ok := b.(RandomSourceSetter); ok {
+			s.SetRandomSource(src)
+		} else if r, ok := b.(Randomized); ok && r.Randomized() {
+			return fmt.Errorf("%w: %T", ErrRandomSourceNotSupported, b)
+		}
+
+		u, ok := b.(Unwrapper)
+		if !ok {
+			return nil
+		}
+		b = u.Unwrap()
+	}
+	return nil
+}
+
+// source is a random source that can be swapped while in use.
+type source struct {
+	r atomic.Pointer[random.LockedSource]
+}
+
+// newSource returns a source drawing from src, or a self-seeded one if src is
+// nil.
+func newSource(src rand.Source64) *source {
+	s := &source{}
+	if src == nil {
+		s.r.Store(random.NewLockedRandom(time.Now().UnixNano()))
+	} else {
+		s.set(src)
+	}
+	return s
+}
+
+func (s *source) Int63n(n int64) int64 {
+	return s.r.Load().Int63n(n)
+}
+
+func (s *source) set(src rand.Source64) {
+	s.r.Store(random.Wrap(src))
+}
@@ -0,0 +1,216 @@
+package backoff
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// opaqueJitter draws random numbers but doesn't let callers replace its source.
+type opaqueJitter struct {
+	Backoff
+}
+
+func (opaqueJitter) Randomized() bool { return true }
+
+func jitteredChain(t *testing.T) Backoff {
+	t.Helper()
+
+	b, err := NewExponential(1 * time.Second)
+	if err != nil {
+		t.Fatalf("failed to create exponential backoff: %v", err)
+	}
+	b, err = WithJitter(500*time.Millisecond, b)
+	if err != nil {
+		t.Fatalf("failed to add jitter: %v", err)
+	}
+	b = WithCappedDuration(10*time.Second, b)
+	b, err = WithJitterPercent(10, b)
+	if err != nil {
+		t.Fatalf("failed to add jitter: %v", err)
+	}
+	return WithMaxRetries(20, b)
+}
+
+func sequence(b Backoff, n int) []time.Duration {
+	var out []time.Duration
+	for i := 0; i < n; i++ {
+		val, stop := b.Next()
+		if stop {
+			break
+		}
+		out = append(out, val)
+	}
+	return out
+}
+
+func TestUseRandomSource(t *testing.T) {
+	t.Parallel()
+
+	t.Run("same_seed_same_sequence", func(t *testing.T) {
+		t.Parallel()
+
+		a, b := jitteredChain(t), jitteredChain(t)
+		if err := UseRandomSource(a, rand.NewSource(7).(rand.Source64)); err != nil {
+			t.Fatalf("failed to set source: %v", err)
+		}
+		if err := UseRandomSource(b, rand.NewSource(7).(rand.Source64)); err != nil {
+			t.Fatalf("failed to set source: %v", err)
+		}
+
+		seqA, seqB := sequence(a, 20), sequence(b, 20)
+		for i := range seqA {
+			if seqA[i] != seqB[i] {
+				t.Fatalf("expected %v to be %v", seqA, seqB)
+			}
+		}
+	})
+
+	t.Run("different_seed_different_sequence", func(t *testing.T) {
+		t.Parallel()
+
+		a, b := jitteredChain(t), jitteredChain(t)
+		if err := UseRandomSource(a, rand.NewSource(7).(rand.Source64)); err != nil {
+			t.Fatalf("failed to set source: %v", err)
+		}
+		if err := UseRandomSource(b, rand.NewSource(8).(rand.Source64)); err != nil {
+			t.Fatalf("failed to set source: %v", err)
+		}
+
+		seqA, seqB := sequence(a, 20), sequence(b, 20)
+		same := true
+		for i := range seqA {
+			if seqA[i] != seqB[i] {
+				same = false
+			}
+		}
+		if same {
+			t.Errorf("expected %v to differ from %v", seqA, seqB)
+		}
+	})
+
+	t.Run("opaque_random_layer", func(t *testing.T) {
+		t.Parallel()
+
+		b := WithMaxRetries(3, opaqueJitter{jitteredChain(t)})
+		err := UseRandomSource(b, rand.NewSource(7).(rand.Source64))
+		if !errors.Is(err, ErrRandomSourceNotSupported) {
+			t.Errorf("expected %q to be %q", err, ErrRandomSourceNotSupported)
+		}
+	})
+
+	t.Run("deterministic_chain", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := NewFibonacci(1 * time.Second)
+		if err != nil {
+			t.Fatalf("failed to create fibonacci backoff: %v", err)
+		}
+		b = WithMaxRetries(3, WithCappedDuration(2*time.Second, b))
+		if err := UseRandomSource(b, rand.NewSource(7).(rand.Source64)); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+}
+
+func TestWithJitterSource(t *testing.T) {
+	t.Parallel()
+
+	const seed = 42
+	base := 10 * time.Second
+	constant := func(t *testing.T) Backoff {
+		t.Helper()
+
+		b, err := NewConstant(base)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		return b
+	}
+
+	t.Run("jitter", func(t *testing.T) {
+		t.Parallel()
+
+		j := 2 * time.Second
+		b, err := WithJitterSource(j, rand.NewSource(seed).(rand.Source64), constant(t))
+		if err != nil {
+			t.Fatalf("failed to create jitter backoff: %v", err)
+		}
+
+		want := rand.New(rand.NewSource(seed))
+		for i := 0; i < 10; i++ {
+			val, stop := b.Next()
+			if stop {
+				t.Fatal("should not stop")
+			}
+			if exp := base + time.Duration(want.Int63n(int64(j)*2)-int64(j)); val != exp {
+				t.Errorf("step %d: expected %v to be %v", i, val, exp)
+			}
+		}
+	})
+
+	t.Run("jitter_percent", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := WithJitterPercentSource(5, rand.NewSource(seed).(rand.Source64), constant(t))
+		if err != nil {
+			t.Fatalf("failed to create jitter percent backoff: %v", err)
+		}
+
+		want := rand.New(rand.NewSource(seed))
+		for i := 0; i < 10; i++ {
+			val, stop := b.Next()
+			if stop {
+				t.Fatal("should not stop")
+			}
+			pct := 1 - float64(want.Int63n(10)-5)/100.0
+			if exp := time.Duration(float64(base) * pct); val != exp {
+				t.Errorf("step %d: expected %v to be %v", i, val, exp)
+			}
+		}
+	})
+
+	t.Run("same_seed_same_sequence", func(t *testing.T) {
+		t.Parallel()
+
+		a, err := WithJitterSource(time.Second, rand.NewSource(seed).(rand.Source64), constant(t))
+		if err != nil {
+			t.Fatalf("failed to create jitter backoff: %v", err)
+		}
+		b, err := WithJitterSource(time.Second, rand.NewSource(seed).(rand.Source64), constant(t))
+		if err != nil {
+			t.Fatalf("failed to create jitter backoff: %v", err)
+		}
+
+		seqA, seqB := sequence(a, 20), sequence(b, 20)
+		for i := range seqA {
+			if seqA[i] != seqB[i] {
+				t.Fatalf("expected %v to be %v", seqA, seqB)
+			}
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+
+		if _, err := WithJitterSource(0, nil, constant(t)); !errors.Is(err, ErrInvalidJitter) {
+			t.Errorf("expected %q to be %q", err, ErrInvalidJitter)
+		}
+		if _, err := WithJitterPercentSource(0, nil, constant(t)); !errors.Is(err, ErrInvalidJitterPercent) {
+			t.Errorf("expected %q to be %q", err, ErrInvalidJitterPercent)
+		}
+	})
+
+	t.Run("nil_source", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := WithJitterSource(time.Second, nil, constant(t))
+		if err != nil {
+			t.Fatalf("failed to create jitter backoff: %v", err)
+		}
+		if val, _ := b.Next(); val < base-time.Second || val > base+time.Second {
+			t.Errorf("expected %v to be between %v and %v", val, base-time.Second, base+time.Second)
+		}
+	})
+}
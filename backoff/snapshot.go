@@ -0,0 +1,215 @@
+package backoff
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/swayne275/go-retry/internal/label"
+)
+
+// ErrSnapshotNotSupported is returned by Snapshot and Restore when a backoff
+// in the chain can't save its position, such as a BackoffFunc or a decorator
+// that measures time, like WithMaxDuration. Its label is
+// "snapshot_not_supported".
+var ErrSnapshotNotSupported = label.New("backoff can't be snapshotted", "snapshot_not_supported")
+
+// ErrInvalidSnapshot is returned by Restore when the state is corrupted or
+// was taken from a different backoff. Its label is "invalid_snapshot".
+var ErrInvalidSnapshot = label.New("invalid backoff snapshot", "invalid_snapshot")
+
+// Snapshotter is implemented by backoffs whose position in their schedule can
+// be saved and restored, such as to hand a loop over to another process. The
+// built-in strategies implement it, as do the decorators that keep no state
+// or only count what they have returned.
+type Snapshotter interface {
+	// Snapshot returns the state of the backoff itself, leaving out any
+	// backoff it wraps.
+	Snapshot() ([]byte, error)
+	// Restore sets the state of the backoff itself to one from Snapshot.
+	Restore(state []byte) error
+}
+
+// snapshot is the encoding of the state of a chain, from Snapshot.
+type snapshot struct {
+	// Policy describes the chain, so a state is only restored into the same
+	// one.
+	Policy string `json:"policy"`
+	// Layers holds the state of each layer, outermost first.
+	Layers [][]byte `json:"layers"`
+}
+
+// Snapshot walks the chain of decorators starting at b and returns the state
+// of every layer, for Restore to set a backoff built the same way to the same
+// position. b must not be in use while it is snapshotted.
+//
+// It returns an error wrapping ErrSnapshotNotSupported if a layer doesn't
+// implement Snapshotter or can't save its state.
+func Snapshot(b Backoff) ([]byte, error) {
+	if b == nil {
+		return nil, ErrNilBackoff
+	}
+
+	layers, err := snapshotters(b)
+	if err != nil {
+		return nil, err
+	}
+	s := snapshot{Policy: describe(b)}
+	for _, l := range layers {
+		state, err := l.Snapshot()
+		if err != nil {
+			return nil, err
+		}
+		s.Layers = append(s.Layers, state)
+	}
+	return json.Marshal(s)
+}
+
+// Restore sets the chain of decorators starting at b to the position saved by
+// Snapshot. b must be built the same way as the backoff that was snapshotted,
+// and must not be in use while it is restored.
+//
+// It returns an error wrapping ErrSnapshotNotSupported if a layer doesn't
+// implement Snapshotter, and one wrapping ErrInvalidSnapshot if state is
+// corrupted or was taken from a different backoff. A failed Restore may leave
+// b partly restored; Reset it before using it.
+func Restore(b Backoff, state []byte) error {
+	if b == nil {
+		return ErrNilBackoff
+	}
+
+	layers, err := snapshotters(b)
+	if err != nil {
+		return err
+	}
+	var s snapshot
+	if err := json.Unmarshal(state, &s); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+	}
+	if policy := describe(b); s.Policy != policy {
+		return fmt.Errorf("%w: taken from %q, not %q", ErrInvalidSnapshot, s.Policy, policy)
+	}
+	if len(s.Layers) != len(layers) {
+		return fmt.Errorf("%w: %d layers, not %d", ErrInvalidSnapshot, len(s.Layers), len(layers))
+	}
+
+	for i, l := range layers {
+		if err := l.Restore(s.Layers[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// snapshotters returns every layer of the chain starting at b, outermost
+// first, or an error wrapping ErrSnapshotNotSupported if one of them can't be
+// snapshotted.
+func snapshotters(b Backoff) ([]Snapshotter, error) {
+	var layers []Snapshotter
+	for b != nil {
+		sn, ok := b.(Snapshotter)
+		if !ok {
+			return nil, fmt.Errorf("%w: %T", ErrSnapshotNotSupported, b)
+		}
+		if r, ok := b.(*ResettableBackoff); ok && r.decorated && r.save == nil {
+			return nil, fmt.Errorf("%w: %s", ErrSnapshotNotSupported, r.name)
+		}
+		layers = append(layers, sn)
+
+		u, ok := b.(Unwrapper)
+		if !ok {
+			break
+		}
+		b = u.Unwrap()
+	}
+	return layers, nil
+}
+
+// Snapshot implements Snapshotter. A decorator that measures time, such as
+// WithMaxDuration, can't be snapshotted; a ResettableBackoff that is not a
+// decorator has no state of its own.
+func (b *ResettableBackoff) Snapshot() ([]byte, error) {
+	if !b.decorated {
+		return nil, nil
+	}
+	if b.save == nil {
+		return nil, fmt.Errorf("%w: %s", ErrSnapshotNotSupported, b.name)
+	}
+	return b.save()
+}
+
+// Restore implements Snapshotter.
+func (b *ResettableBackoff) Restore(state []byte) error {
+	if !b.decorated {
+		return noState(state)
+	}
+	if b.load == nil {
+		return fmt.Errorf("%w: %s", ErrSnapshotNotSupported, b.name)
+	}
+	return b.load(state)
+}
+
+// stateless makes b, a decorator that keeps no state, snapshottable.
+func (b *ResettableBackoff) stateless() *ResettableBackoff {
+	b.save = func() ([]byte, error) { return nil, nil }
+	b.load = noState
+	return b
+}
+
+// noState restores the state of a backoff that has none.
+func noState(state []byte) error {
+	if len(state) != 0 {
+		return fmt.Errorf("%w: unexpected state", ErrInvalidSnapshot)
+	}
+	return nil
+}
+
+// encodeInts encodes the state of a backoff made of integers.
+func encodeInts(ns ...int64) []byte {
+	var buf []byte
+	for _, n := range ns {
+		buf = binary.AppendVarint(buf, n)
+	}
+	return buf
+}
+
+// decodeInts decodes a state from encodeInts into ns.
+func decodeInts(state []byte, ns ...*int64) error {
+	for _, n := range ns {
+		v, size := binary.Varint(state)
+		if size <= 0 {
+			return fmt.Errorf("%w: truncated state", ErrInvalidSnapshot)
+		}
+		*n, state = v, state[size:]
+	}
+	if len(state) != 0 {
+		return fmt.Errorf("%w: unexpected state", ErrInvalidSnapshot)
+	}
+	return nil
+}
+
+// decodeCount decodes a count from encodeInts and checks it is at most max.
+func decodeCount(state []byte, max uint64) (uint64, error) {
+	var n int64
+	if err := decodeInts(state, &n); err != nil {
+		return 0, err
+	}
+	if n < 0 || uint64(n) > max {
+		return 0, fmt.Errorf("%w: count %d out of range", ErrInvalidSnapshot, n)
+	}
+	return uint64(n), nil
+}
+
+// decodeDuration decodes a duration from encodeInts and checks it is between
+// min and max.
+func decodeDuration(state []byte, min, max time.Duration) (time.Duration, error) {
+	var n int64
+	if err := decodeInts(state, &n); err != nil {
+		return 0, err
+	}
+	if d := time.Duration(n); d < min || d > max {
+		return 0, fmt.Errorf("%w: duration %v out of range", ErrInvalidSnapshot, d)
+	}
+	return time.Duration(n), nil
+}
@@ -0,0 +1,163 @@
+package backoff
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestSnapshot(t *testing.T) {
+	t.Parallel()
+
+	must := func(b Backoff, err error) Backoff {
+		t.Helper()
+
+		if err != nil {
+			t.Fatalf("failed to create backoff: %v", err)
+		}
+		return b
+	}
+
+	cases := []struct {
+		name string
+		// build returns the same backoff every time it is called, from
+		// scratch.
+		build func() Backoff
+	}{
+		{
+			name:  "constant",
+			build: func() Backoff { return must(NewConstant(1 * time.Second)) },
+		},
+		{
+			name: "exponential",
+			build: func() Backoff {
+				return WithMaxRetries(10, WithCappedDuration(1*time.Minute, must(NewExponential(1*time.Second))))
+			},
+		},
+		{
+			name:  "fibonacci",
+			build: func() Backoff { return WithImmediateFirst(must(NewFibonacci(1 * time.Second))) },
+		},
+		{
+			name: "linear",
+			build: func() Backoff {
+				return WithMaxCumulativeSleep(1*time.Hour, must(NewLinear(1*time.Second, 500*time.Millisecond)))
+			},
+		},
+		{
+			name: "steps",
+			build: func() Backoff {
+				return must(NewSteps([]time.Duration{1 * time.Second, 5 * time.Second, 30 * time.Second}, true))
+			},
+		},
+		{
+			name: "jitter",
+			build: func() Backoff {
+				b := must(WithMonotonicJitter(1*time.Second, must(NewExponential(1*time.Second))))
+				if err := UseRandomSource(b, rand.NewSource(1).(rand.Source64)); err != nil {
+					t.Fatalf("failed to set random source: %v", err)
+				}
+				return b
+			},
+		},
+		{
+			name: "adaptive",
+			build: func() Backoff {
+				a, err := NewAdaptive(1*time.Second, 1*time.Minute)
+				if err != nil {
+					t.Fatalf("failed to create adaptive backoff: %v", err)
+				}
+				a.Slower()
+				a.Slower()
+				return Resettable(a)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			// The snapshotted backoff goes on from where it was; a fresh one
+			// restored from the snapshot must return the same values.
+			b := tc.build()
+			for i := 0; i < 3; i++ {
+				b.Next()
+			}
+			state, err := Snapshot(b)
+			if err != nil {
+				t.Fatalf("failed to snapshot: %v", err)
+			}
+
+			restored := tc.build()
+			if err := Restore(restored, state); err != nil {
+				t.Fatalf("failed to restore: %v", err)
+			}
+			// The random source is not part of the state, so draw from the
+			// same one from here on.
+			if err := UseRandomSource(b, rand.NewSource(2).(rand.Source64)); err != nil {
+				t.Fatalf("failed to set random source: %v", err)
+			}
+			if err := UseRandomSource(restored, rand.NewSource(2).(rand.Source64)); err != nil {
+				t.Fatalf("failed to set random source: %v", err)
+			}
+
+			for i := 0; i < 5; i++ {
+				want, wantStop := b.Next()
+				got, gotStop := restored.Next()
+				if got != want || gotStop != wantStop {
+					t.Errorf("value %d: expected %v, %t to be %v, %t", i, got, gotStop, want, wantStop)
+				}
+			}
+		})
+	}
+
+	t.Run("not_supported", func(t *testing.T) {
+		t.Parallel()
+
+		for _, b := range []Backoff{
+			WithMaxDuration(1*time.Minute, must(NewConstant(1*time.Second))),
+			WithCappedDuration(1*time.Minute, BackoffFunc(func() (time.Duration, bool) { return 1 * time.Second, false })),
+		} {
+			if _, err := Snapshot(b); !errors.Is(err, ErrSnapshotNotSupported) {
+				t.Errorf("expected %v to be %v", err, ErrSnapshotNotSupported)
+			}
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+
+		b := WithMaxRetries(3, must(NewSteps([]time.Duration{1 * time.Second}, false)))
+		state, err := Snapshot(b)
+		if err != nil {
+			t.Fatalf("failed to snapshot: %v", err)
+		}
+
+		for name, state := range map[string][]byte{
+			"corrupted":      state[:len(state)/2],
+			"other_policy":   []byte(`{"policy":"constant(1s)","layers":[null]}`),
+			"missing_layer":  []byte(`{"policy":"steps(1s)|max_retries(3)","layers":[null]}`),
+			"count_too_high": []byte(`{"policy":"steps(1s)|max_retries(3)","layers":["CA==","AA=="]}`),
+			"truncated":      []byte(`{"policy":"steps(1s)|max_retries(3)","layers":["",""]}`),
+		} {
+			if err := Restore(WithMaxRetries(3, must(NewSteps([]time.Duration{1 * time.Second}, false))), state); !errors.Is(err, ErrInvalidSnapshot) {
+				t.Errorf("%s: expected %v to be %v", name, err, ErrInvalidSnapshot)
+			}
+		}
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		t.Parallel()
+
+		if _, err := Snapshot(nil); !errors.Is(err, ErrNilBackoff) {
+			t.Errorf("expected %v to be %v", err, ErrNilBackoff)
+		}
+		if err := Restore(nil, nil); !errors.Is(err, ErrNilBackoff) {
+			t.Errorf("expected %v to be %v", err, ErrNilBackoff)
+		}
+	})
+}
@@ -0,0 +1,113 @@
+package backoff
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/swayne275/go-retry/internal/label"
+)
+
+// ErrNilBackoff is returned by Validate when a backoff in the chain is missing.
+// Its label is "nil_backoff".
+var ErrNilBackoff = label.New("backoff is nil", "nil_backoff")
+
+// Validate walks the chain of decorators starting at b, as UseRandomSource
+// does, and returns an error wrapping ErrNilBackoff if b or any backoff it
+// wraps is nil, a nil BackoffFunc or *Adaptive, a ResettableBackoff that
+// wraps nothing, such as the zero value, or a Chain with a stage that has no
+// backoff. A backoff in the chain that implements Validator is rejected with
+// the error of its Validate method. The loops of the retry and repeat packages
+// call it before their first attempt, so a missing backoff fails fast instead
+// of panicking when the first retry is scheduled.
+func Validate(b Backoff) error {
+	for {
+		if v, ok := b.(Validator); ok {
+			if err := v.Validate(); err != nil {
+				return err
+			}
+		}
+
+		switch v := b.(type) {
+		case nil:
+			return ErrNilBackoff
+		case BackoffFunc:
+			if v == nil {
+				return ErrNilBackoff
+			}
+		case *Adaptive:
+			if v == nil {
+				return fmt.Errorf("%w: %T is nil", ErrNilBackoff, b)
+			}
+		case *ResettableBackoff:
+			if v == nil || v.current() == nil {
+				return fmt.Errorf("%w: %T wraps no backoff", ErrNilBackoff, b)
+			}
+		}
+
+		u, ok := b.(Unwrapper)
+		if !ok {
+			return nil
+		}
+		b = u.Unwrap()
+	}
+}
+
+// Validator is implemented by backoffs that can tell whether they are usable,
+// beyond what Validate checks itself. Validate returns a non-nil error from
+// Validate, such as the one of a backoff built for a retry.PolicySet policy
+// that doesn't exist.
+type Validator interface {
+	Validate() error
+}
+
+// DefaultValidationMax is the largest value WithValidation lets through.
+const DefaultValidationMax = 24 * time.Hour
+
+// InvalidAction tells WithValidationMax what to do when the backoff it wraps
+// returns a value above the bound.
+type InvalidAction int
+
+const (
+	// StopOnInvalid signals to stop, as if the wrapped backoff had.
+	StopOnInvalid InvalidAction = iota
+	// PanicOnInvalid panics, to make a buggy backoff fail loudly, in tests
+	// for example.
+	PanicOnInvalid
+)
+
+// WithValidation guards against a buggy backoff, such as a custom BackoffFunc:
+// negative values from next become zero, and a value above
+// DefaultValidationMax signals to stop. See WithValidationMax.
+func WithValidation(next Backoff) *ResettableBackoff {
+	return WithValidationMax(DefaultValidationMax, StopOnInvalid, next)
+}
+
+// WithValidationMax is like WithValidation, but a value from next above max is
+// handled as action says. A non-positive max disables the bound, so only
+// negative values are clamped.
+func WithValidationMax(max time.Duration, action InvalidAction, next Backoff) *ResettableBackoff {
+	nextWithValidation := BackoffFunc(func() (time.Duration, bool) {
+		val, stop := next.Next()
+		if stop {
+			return 0, true
+		}
+
+		switch {
+		case val < 0:
+			val = 0
+		case max > 0 && val > max:
+			if action == PanicOnInvalid {
+				panic(fmt.Sprintf("backoff: %v returned by %T is above the bound of %v", val, next, max))
+			}
+			return 0, true
+		}
+		return val, false
+	})
+
+	reset := func() Backoff {
+		next.Reset()
+		return nextWithValidation
+	}
+
+	return decorate(fmt.Sprintf("validation(%v)", max), reset, next, nextWithValidation, nil).stateless()
+}
@@ -0,0 +1,187 @@
+package backoff
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/internal/apitest"
+)
+
+func TestZeroValues(t *testing.T) {
+	t.Parallel()
+
+	apitest.CheckZeroValues(t, map[string]func(t *testing.T){
+		"Adaptive": func(t *testing.T) {
+			var a Adaptive
+			a.Slower()
+			a.Faster()
+			a.Reset()
+			if val, stop := a.Next(); !stop || val != 0 {
+				t.Errorf("expected %v, %t to be 0, true", val, stop)
+			}
+		},
+		"Config": func(t *testing.T) {
+			if b, err := FromConfig(Config{}); err == nil || b != nil {
+				t.Errorf("expected an error and no backoff, got %v and %v", err, b)
+			}
+		},
+		"Stage": func(t *testing.T) {
+			b := Chain(Stage{})
+			b.Reset()
+			if val, stop := b.Next(); !stop || val != 0 {
+				t.Errorf("expected %v, %t to be 0, true", val, stop)
+			}
+			if err := Validate(b); !errors.Is(err, ErrNilBackoff) {
+				t.Errorf("expected %v to be %v", err, ErrNilBackoff)
+			}
+		},
+		"ResettableBackoff": func(t *testing.T) {
+			var b ResettableBackoff
+			b.Reset()
+			if val, stop := b.Next(); !stop || val != 0 {
+				t.Errorf("expected %v, %t to be 0, true", val, stop)
+			}
+			if b.Unwrap() != nil || b.Randomized() {
+				t.Error("expected zero backoff to wrap nothing")
+			}
+			b.SetRandomSource(nil)
+			b.AttemptStarted()
+			b.AttemptFinished()
+			if _, ok := b.Remaining(); ok {
+				t.Error("expected zero backoff to have no retry limit")
+			}
+			if _, ok := b.RemainingTime(); ok {
+				t.Error("expected zero backoff to have no duration limit")
+			}
+			if err := Validate(&b); !errors.Is(err, ErrNilBackoff) {
+				t.Errorf("expected %v to be %v", err, ErrNilBackoff)
+			}
+		},
+	})
+}
+
+var errInvalid = errors.New("invalid")
+
+// invalidBackoff is a Backoff whose Validate method returns err.
+type invalidBackoff struct {
+	BackoffFunc
+	err error
+}
+
+func (b invalidBackoff) Validate() error {
+	return b.err
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	base, err := NewConstant(1 * time.Second)
+	if err != nil {
+		t.Fatalf("failed to create constant backoff: %v", err)
+	}
+	var nilResettable *ResettableBackoff
+
+	cases := []struct {
+		name string
+		b    Backoff
+		err  error
+	}{
+		{name: "valid", b: base},
+		{name: "decorated", b: WithMaxRetries(3, WithCappedDuration(time.Second, base))},
+		{name: "func", b: BackoffFunc(func() (time.Duration, bool) { return 0, true })},
+		{name: "nil", b: nil, err: ErrNilBackoff},
+		{name: "nil_func", b: BackoffFunc(nil), err: ErrNilBackoff},
+		{name: "nil_resettable", b: nilResettable, err: ErrNilBackoff},
+		{name: "nil_adaptive", b: (*Adaptive)(nil), err: ErrNilBackoff},
+		{name: "zero_resettable", b: &ResettableBackoff{}, err: ErrNilBackoff},
+		{name: "decorated_zero", b: WithMaxRetries(3, &ResettableBackoff{}), err: ErrNilBackoff},
+		{name: "decorated_nil", b: WithCappedDuration(time.Second, nil), err: ErrNilBackoff},
+		{name: "validator", b: WithMaxRetries(3, invalidBackoff{err: errInvalid}), err: errInvalid},
+		{name: "valid_validator", b: invalidBackoff{}},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := Validate(tc.b); !errors.Is(err, tc.err) || (tc.err == nil && err != nil) {
+				t.Errorf("expected %v to be %v", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestConstructors_errorsReturnNil(t *testing.T) {
+	t.Parallel()
+
+	base, err := NewConstant(1 * time.Second)
+	if err != nil {
+		t.Fatalf("failed to create constant backoff: %v", err)
+	}
+
+	// Each case reports whether the constructor returned nil, and its error.
+	cases := []struct {
+		name string
+		new  func() (bool, error)
+	}{
+		{name: "constant", new: func() (bool, error) {
+			b, err := NewConstant(0)
+			return b == nil, err
+		}},
+		{name: "exponential", new: func() (bool, error) {
+			b, err := NewExponential(0)
+			return b == nil, err
+		}},
+		{name: "fibonacci", new: func() (bool, error) {
+			b, err := NewFibonacci(0)
+			return b == nil, err
+		}},
+		{name: "linear", new: func() (bool, error) {
+			b, err := NewLinear(time.Second, -1)
+			return b == nil, err
+		}},
+		{name: "adaptive", new: func() (bool, error) {
+			b, err := NewAdaptive(0, time.Second)
+			return b == nil, err
+		}},
+		{name: "steps", new: func() (bool, error) {
+			b, err := NewSteps(nil, false)
+			return b == nil, err
+		}},
+		{name: "config", new: func() (bool, error) {
+			b, err := FromConfig(Config{Strategy: "nope"})
+			return b == nil, err
+		}},
+		{name: "jitter", new: func() (bool, error) {
+			b, err := WithJitter(-1, base)
+			return b == nil, err
+		}},
+		{name: "jitter_percent", new: func() (bool, error) {
+			b, err := WithJitterPercent(0, base)
+			return b == nil, err
+		}},
+		{name: "monotonic_jitter", new: func() (bool, error) {
+			b, err := WithMonotonicJitter(-1, base)
+			return b == nil, err
+		}},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			isNil, err := tc.new()
+			if err == nil {
+				t.Fatal("expected err")
+			}
+			if !isNil {
+				t.Errorf("expected no backoff with %v", err)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package benchmark
 
 import (
 	"context"
+	"errors"
 	"math"
 	"testing"
 	"time"
@@ -10,6 +11,8 @@ import (
 	lestrrat "github.com/lestrrat-go/backoff"
 	sethvargo "github.com/sethvargo/go-retry"
 	swayne275 "github.com/swayne275/go-retry/backoff"
+	"github.com/swayne275/go-retry/repeat"
+	"github.com/swayne275/go-retry/retry"
 )
 
 func Benchmark(b *testing.B) {
@@ -63,3 +66,121 @@ func Benchmark(b *testing.B) {
 		}
 	})
 }
+
+// BenchmarkDo measures the overhead of the retry and repeat loops themselves,
+// sleeping a nanosecond between attempts so the loop dominates.
+func BenchmarkDo(b *testing.B) {
+	const attempts = 10
+
+	newBackoff := func(b *testing.B) swayne275.Backoff {
+		b.Helper()
+
+		backoff, err := swayne275.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			b.Fatalf("failed to create backoff: %v", err)
+		}
+		return swayne275.WithMaxRetries(attempts-1, backoff)
+	}
+
+	b.Run("retry", func(b *testing.B) {
+		ctx := context.Background()
+		errRetry := retry.RetryableError(errors.New("retry"))
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			backoff := newBackoff(b)
+			b.StartTimer()
+
+			_ = retry.Do(ctx, backoff, func(_ context.Context) error {
+				return errRetry
+			})
+		}
+	})
+
+	// A nil Metrics must cost nothing over the plain loop above.
+	b.Run("retry_nil_metrics", func(b *testing.B) {
+		ctx := context.Background()
+		errRetry := retry.RetryableError(errors.New("retry"))
+		opt := retry.WithMetrics(nil)
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			backoff := newBackoff(b)
+			b.StartTimer()
+
+			_ = retry.DoWithOptions(ctx, backoff, func(_ context.Context) error {
+				return errRetry
+			}, opt)
+		}
+	})
+
+	b.Run("repeat", func(b *testing.B) {
+		ctx := context.Background()
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			backoff := newBackoff(b)
+			b.StartTimer()
+
+			_ = repeat.Do(ctx, backoff, func(_ context.Context) bool {
+				return true
+			})
+		}
+	})
+}
+
+// BenchmarkConstant compares ConstantRetry, which builds a constant backoff and
+// runs the full retry loop, with the DoConstant fast path, over ten attempts
+// with nanosecond sleeps.
+func BenchmarkConstant(b *testing.B) {
+	const attempts = 10
+
+	ctx := context.Background()
+	errRetry := retry.RetryableError(errors.New("retry"))
+	succeedLast := func() retry.RetryFunc {
+		var calls int
+		return func(_ context.Context) error {
+			calls++
+			if calls < attempts {
+				return errRetry
+			}
+			return nil
+		}
+	}
+
+	b.Run("constant_retry", func(b *testing.B) {
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			f := succeedLast()
+			b.StartTimer()
+
+			if err := retry.ConstantRetry(ctx, 1*time.Nanosecond, f); err != nil {
+				b.Fatalf("expected no error, got %v", err)
+			}
+		}
+	})
+
+	b.Run("do_constant", func(b *testing.B) {
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			f := succeedLast()
+			b.StartTimer()
+
+			if err := retry.DoConstant(ctx, 1*time.Nanosecond, f); err != nil {
+				b.Fatalf("expected no error, got %v", err)
+			}
+		}
+	})
+}
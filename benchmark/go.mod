@@ -15,3 +15,5 @@ require (
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/stretchr/testify v1.8.1 // indirect
 )
+
+replace github.com/swayne275/go-retry => ../
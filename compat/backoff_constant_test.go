@@ -0,0 +1,117 @@
+// Ported unchanged from the tests of github.com/sethvargo/go-retry v0.2.4, to
+// prove the compat package behaves the same.
+
+package compat_test
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	retry "github.com/swayne275/go-retry/compat"
+)
+
+func TestConstantBackoff(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name  string
+		base  time.Duration
+		tries int
+		exp   []time.Duration
+	}{
+		{
+			name:  "single",
+			base:  1 * time.Nanosecond,
+			tries: 1,
+			exp: []time.Duration{
+				1 * time.Nanosecond,
+			},
+		},
+		{
+			name:  "max",
+			base:  10 * time.Millisecond,
+			tries: 5,
+			exp: []time.Duration{
+				10 * time.Millisecond,
+				10 * time.Millisecond,
+				10 * time.Millisecond,
+				10 * time.Millisecond,
+				10 * time.Millisecond,
+			},
+		},
+		{
+			name:  "many",
+			base:  1 * time.Nanosecond,
+			tries: 14,
+			exp: []time.Duration{
+				1 * time.Nanosecond,
+				1 * time.Nanosecond,
+				1 * time.Nanosecond,
+				1 * time.Nanosecond,
+				1 * time.Nanosecond,
+				1 * time.Nanosecond,
+				1 * time.Nanosecond,
+				1 * time.Nanosecond,
+				1 * time.Nanosecond,
+				1 * time.Nanosecond,
+				1 * time.Nanosecond,
+				1 * time.Nanosecond,
+				1 * time.Nanosecond,
+				1 * time.Nanosecond,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			b := retry.NewConstant(tc.base)
+
+			resultsCh := make(chan time.Duration, tc.tries)
+			for i := 0; i < tc.tries; i++ {
+				go func() {
+					r, _ := b.Next()
+					resultsCh <- r
+				}()
+			}
+
+			results := make([]time.Duration, tc.tries)
+			for i := 0; i < tc.tries; i++ {
+				select {
+				case val := <-resultsCh:
+					results[i] = val
+				case <-time.After(5 * time.Second):
+					t.Fatal("timeout")
+				}
+			}
+			sort.Slice(results, func(i, j int) bool {
+				return results[i] < results[j]
+			})
+
+			if !reflect.DeepEqual(results, tc.exp) {
+				t.Errorf("expected \n\n%v\n\n to be \n\n%v\n\n", results, tc.exp)
+			}
+		})
+	}
+}
+
+func ExampleNewConstant() {
+	b := retry.NewConstant(1 * time.Second)
+
+	for i := 0; i < 5; i++ {
+		val, _ := b.Next()
+		fmt.Printf("%v\n", val)
+	}
+	// Output:
+	// 1s
+	// 1s
+	// 1s
+	// 1s
+	// 1s
+}
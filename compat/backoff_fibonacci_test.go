@@ -0,0 +1,135 @@
+// Ported unchanged from the tests of github.com/sethvargo/go-retry v0.2.4, to
+// prove the compat package behaves the same.
+
+package compat_test
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	retry "github.com/swayne275/go-retry/compat"
+)
+
+func TestFibonacciBackoff(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name  string
+		base  time.Duration
+		tries int
+		exp   []time.Duration
+	}{
+		{
+			name:  "single",
+			base:  1 * time.Nanosecond,
+			tries: 1,
+			exp: []time.Duration{
+				1 * time.Nanosecond,
+			},
+		},
+		{
+			name:  "max",
+			base:  10 * time.Millisecond,
+			tries: 5,
+			exp: []time.Duration{
+				10 * time.Millisecond,
+				20 * time.Millisecond,
+				30 * time.Millisecond,
+				50 * time.Millisecond,
+				80 * time.Millisecond,
+			},
+		},
+		{
+			name:  "many",
+			base:  1 * time.Nanosecond,
+			tries: 14,
+			exp: []time.Duration{
+				1 * time.Nanosecond,
+				2 * time.Nanosecond,
+				3 * time.Nanosecond,
+				5 * time.Nanosecond,
+				8 * time.Nanosecond,
+				13 * time.Nanosecond,
+				21 * time.Nanosecond,
+				34 * time.Nanosecond,
+				55 * time.Nanosecond,
+				89 * time.Nanosecond,
+				144 * time.Nanosecond,
+				233 * time.Nanosecond,
+				377 * time.Nanosecond,
+				610 * time.Nanosecond,
+			},
+		},
+		{
+			name:  "overflow",
+			base:  100_000 * time.Hour,
+			tries: 10,
+			exp: []time.Duration{
+				100_000 * time.Hour,
+				200_000 * time.Hour,
+				300_000 * time.Hour,
+				500_000 * time.Hour,
+				800_000 * time.Hour,
+				1_300_000 * time.Hour,
+				2_100_000 * time.Hour,
+				math.MaxInt64,
+				math.MaxInt64,
+				math.MaxInt64,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			b := retry.NewFibonacci(tc.base)
+
+			resultsCh := make(chan time.Duration, tc.tries)
+			for i := 0; i < tc.tries; i++ {
+				go func() {
+					r, _ := b.Next()
+					resultsCh <- r
+				}()
+			}
+
+			results := make([]time.Duration, tc.tries)
+			for i := 0; i < tc.tries; i++ {
+				select {
+				case val := <-resultsCh:
+					results[i] = val
+				case <-time.After(5 * time.Second):
+					t.Fatal("timeout")
+				}
+			}
+			sort.Slice(results, func(i, j int) bool {
+				return results[i] < results[j]
+			})
+
+			if !reflect.DeepEqual(results, tc.exp) {
+				t.Errorf("expected \n\n%v\n\n to be \n\n%v\n\n", results, tc.exp)
+			}
+		})
+	}
+}
+
+func ExampleNewFibonacci() {
+	b := retry.NewFibonacci(1 * time.Second)
+
+	for i := 0; i < 5; i++ {
+		val, _ := b.Next()
+		fmt.Printf("%v\n", val)
+	}
+	// Output:
+	// 1s
+	// 2s
+	// 3s
+	// 5s
+	// 8s
+}
@@ -0,0 +1,223 @@
+// Package compat is a drop-in replacement for the API of
+// github.com/sethvargo/go-retry v0.2, which this module was forked from,
+// implemented with the backoff and retry packages. Import it under the old
+// package name to migrate without other changes:
+//
+//	import retry "github.com/swayne275/go-retry/compat"
+//
+// It keeps the old semantics where the current packages differ:
+//
+//   - Constructors panic on invalid input instead of returning an error.
+//   - Do returns the error of f as is when it isn't retryable, and unwrapped
+//     from RetryableError when the backoff stops, instead of wrapping it in
+//     retry.ErrNonRetryable or retry.ErrBackoffSignaledToStop.
+//   - WithCappedDuration replaces values of zero or less with the cap.
+//   - WithMaxDuration starts counting when it is created, not when Next is
+//     first called.
+//
+// Unlike the old package, WithJitter and WithJitterPercent panic when they
+// are created with a jitter of zero, which they can't apply. The old package
+// accepted it and panicked later.
+package compat
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+	"github.com/swayne275/go-retry/retry"
+)
+
+// Backoff is an interface that backs off.
+type Backoff interface {
+	// Next returns the time duration to wait and whether to stop.
+	Next() (next time.Duration, stop bool)
+}
+
+var _ Backoff = (BackoffFunc)(nil)
+
+// BackoffFunc is a backoff expressed as a function.
+type BackoffFunc func() (time.Duration, bool)
+
+// Next implements Backoff.
+func (b BackoffFunc) Next() (time.Duration, bool) {
+	return b()
+}
+
+// resettable returns b as a backoff.Backoff, which the current packages take.
+func resettable(b Backoff) backoff.Backoff {
+	if rb, ok := b.(backoff.Backoff); ok {
+		return rb
+	}
+	if b == nil {
+		return nil
+	}
+	return backoff.BackoffFunc(b.Next)
+}
+
+// must returns b, panicking if err is set, as the old constructors did.
+func must(b Backoff, err error) Backoff {
+	if err != nil {
+		panic(err.Error())
+	}
+	return b
+}
+
+// NewConstant creates a new constant backoff using the value t. The wait time
+// is the provided constant value. It panics if the given base is less than
+// zero.
+func NewConstant(t time.Duration) Backoff {
+	return must(backoff.NewConstant(t))
+}
+
+// NewExponential creates a new exponential backoff using the starting value of
+// base and doubling on each failure (1, 2, 4, 8, 16, 32, 64...).
+//
+// Once it overflows, the function constantly returns the maximum time.Duration
+// for a 64-bit integer.
+//
+// It panics if the given base is less than zero.
+func NewExponential(base time.Duration) Backoff {
+	return must(backoff.NewExponential(base))
+}
+
+// NewFibonacci creates a new Fibonacci backoff using the starting value of
+// base. The wait time is the sum of the previous two wait times on each failed
+// attempt (1, 1, 2, 3, 5, 8, 13...).
+//
+// Once it overflows, the function constantly returns the maximum time.Duration
+// for a 64-bit integer.
+//
+// It panics if the given base is less than zero.
+func NewFibonacci(base time.Duration) Backoff {
+	return must(backoff.NewFibonacci(base))
+}
+
+// WithJitter wraps a backoff function and adds the specified jitter. j can be
+// interpreted as "+/- j". For example, if j were 5 seconds and the backoff
+// returned 20s, the value could be between 15 and 25 seconds. The value can
+// never be less than 0. It panics if j is not greater than 0.
+func WithJitter(j time.Duration, next Backoff) Backoff {
+	return must(backoff.WithJitter(j, resettable(next)))
+}
+
+// WithJitterPercent wraps a backoff function and adds the specified jitter
+// percentage. j can be interpreted as "+/- j%". For example, if j were 5 and
+// the backoff returned 20s, the value could be between 19 and 21 seconds. It
+// panics if j is 0.
+func WithJitterPercent(j uint64, next Backoff) Backoff {
+	return must(backoff.WithJitterPercent(j, resettable(next)))
+}
+
+// WithMaxRetries executes the backoff function up until the maximum attempts.
+func WithMaxRetries(max uint64, next Backoff) Backoff {
+	return backoff.WithMaxRetries(max, resettable(next))
+}
+
+// WithCappedDuration sets a maximum on the duration returned from the next
+// backoff. This is NOT a total backoff time, but rather a cap on the maximum
+// value a backoff can return. Values of zero or less are replaced with the
+// cap. Without another middleware, the backoff will continue infinitely.
+func WithCappedDuration(cap time.Duration, next Backoff) Backoff {
+	capped := backoff.WithCappedDuration(cap, resettable(next))
+	return BackoffFunc(func() (time.Duration, bool) {
+		val, stop := capped.Next()
+		if stop {
+			return 0, true
+		}
+		if val <= 0 {
+			val = cap
+		}
+		return val, false
+	})
+}
+
+// WithMaxDuration sets a maximum on the total amount of time a backoff should
+// execute, counted from when it is created. It's best-effort, and should not
+// be used to guarantee an exact amount of time.
+func WithMaxDuration(timeout time.Duration, next Backoff) Backoff {
+	start := time.Now()
+	return BackoffFunc(func() (time.Duration, bool) {
+		diff := timeout - time.Since(start)
+		if diff <= 0 {
+			return 0, true
+		}
+
+		val, stop := next.Next()
+		if stop {
+			return 0, true
+		}
+
+		if val <= 0 || val > diff {
+			val = diff
+		}
+		return val, false
+	})
+}
+
+// RetryFunc is a function passed to retry.
+type RetryFunc func(ctx context.Context) error
+
+// retryableError marks an error made retryable by RetryableError, so Do can
+// return it unwrapped.
+type retryableError struct {
+	err error
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+func (e *retryableError) Unwrap() error { return e.err }
+
+// RetryableError marks an error as retryable.
+func RetryableError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return retry.RetryableError(&retryableError{err: err})
+}
+
+// Do wraps a function with a backoff to retry. The provided context is the same
+// context passed to the RetryFunc.
+//
+// If f returns an error that isn't retryable, Do returns it as is. If the
+// backoff stops, Do returns the last error of f without its RetryableError
+// marker.
+func Do(ctx context.Context, b Backoff, f RetryFunc) error {
+	// last is the error of the latest call of f.
+	var last error
+	err := retry.Do(ctx, resettable(b), func(ctx context.Context) error {
+		last = f(ctx)
+		return last
+	})
+
+	switch {
+	case errors.Is(err, retry.ErrNonRetryable):
+		return last
+	case errors.Is(err, retry.ErrBackoffSignaledToStop):
+		var rerr *retryableError
+		if errors.As(last, &rerr) {
+			return rerr.err
+		}
+		return last
+	default:
+		return err
+	}
+}
+
+// Constant is a wrapper around Retry that uses a constant backoff. It panics if
+// the given base is less than zero.
+func Constant(ctx context.Context, t time.Duration, f RetryFunc) error {
+	return Do(ctx, NewConstant(t), f)
+}
+
+// Exponential is a wrapper around Retry that uses an exponential backoff. See
+// NewExponential.
+func Exponential(ctx context.Context, base time.Duration, f RetryFunc) error {
+	return Do(ctx, NewExponential(base), f)
+}
+
+// Fibonacci is a wrapper around Retry that uses a Fibonacci backoff. See
+// NewFibonacci.
+func Fibonacci(ctx context.Context, base time.Duration, f RetryFunc) error {
+	return Do(ctx, NewFibonacci(base), f)
+}
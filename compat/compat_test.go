@@ -0,0 +1,111 @@
+package compat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+// The tests ported from the old package are in the other test files; these
+// cover where the current packages behave differently.
+
+func TestConstructors_panic(t *testing.T) {
+	t.Parallel()
+
+	base := NewConstant(1 * time.Second)
+	cases := []struct {
+		name string
+		new  func()
+	}{
+		{name: "constant", new: func() { NewConstant(0) }},
+		{name: "exponential", new: func() { NewExponential(-1) }},
+		{name: "fibonacci", new: func() { NewFibonacci(0) }},
+		{name: "jitter", new: func() { WithJitter(0, base) }},
+		{name: "jitter_percent", new: func() { WithJitterPercent(0, base) }},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			defer func() {
+				if r := recover(); r == nil {
+					t.Error("expected a panic")
+				}
+			}()
+			tc.new()
+		})
+	}
+}
+
+func TestWithCappedDuration_zero(t *testing.T) {
+	t.Parallel()
+
+	b := WithCappedDuration(3*time.Second, BackoffFunc(func() (time.Duration, bool) {
+		return 0, false
+	}))
+	if val, stop := b.Next(); stop || val != 3*time.Second {
+		t.Errorf("expected (%v, %t) to be (%v, false)", val, stop, 3*time.Second)
+	}
+}
+
+func TestWithMaxDuration_startsWhenCreated(t *testing.T) {
+	t.Parallel()
+
+	b := WithMaxDuration(50*time.Millisecond, NewConstant(1*time.Second))
+	time.Sleep(60 * time.Millisecond)
+	if _, stop := b.Next(); !stop {
+		t.Error("expected the time to have run out before the first Next")
+	}
+}
+
+func TestDo_errorsAsIs(t *testing.T) {
+	t.Parallel()
+
+	errFoo := fmt.Errorf("foo")
+
+	t.Run("non_retryable", func(t *testing.T) {
+		t.Parallel()
+
+		err := Do(context.Background(), NewConstant(1*time.Nanosecond), func(_ context.Context) error {
+			return errFoo
+		})
+		if err != errFoo {
+			t.Errorf("expected %#v to be %#v", err, errFoo)
+		}
+	})
+
+	t.Run("retryable_wrapped", func(t *testing.T) {
+		t.Parallel()
+
+		wrapped := fmt.Errorf("bar: %w", RetryableError(errFoo))
+		err := Do(context.Background(), WithMaxRetries(1, NewConstant(1*time.Nanosecond)), func(_ context.Context) error {
+			return wrapped
+		})
+		if err != errFoo {
+			t.Errorf("expected %#v to be %#v", err, errFoo)
+		}
+	})
+
+	t.Run("current_backoff", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		err = Do(context.Background(), backoff.WithMaxRetries(1, b), func(_ context.Context) error {
+			return RetryableError(errFoo)
+		})
+		if !errors.Is(err, errFoo) || err != errFoo {
+			t.Errorf("expected %#v to be %#v", err, errFoo)
+		}
+	})
+}
@@ -0,0 +1,172 @@
+// Package connloop maintains a long-lived connection, such as a websocket, a
+// database listener or a gRPC stream, reconnecting with backoff whenever it
+// drops.
+package connloop
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+	"github.com/swayne275/go-retry/internal/clock"
+	"github.com/swayne275/go-retry/retry"
+)
+
+// State is a stage of the connection's life, reported to a hook set with
+// WithStateHook.
+type State int
+
+const (
+	// StateConnecting is entered before each dial.
+	StateConnecting State = iota
+	// StateConnected is entered once a dial succeeds, before serving starts.
+	StateConnected
+	// StateDisconnected is entered when a dial fails or a connection stops
+	// serving. Its cause is the error from dial or serve, or nil after a
+	// clean shutdown.
+	StateDisconnected
+)
+
+func (s State) String() string {
+	switch s {
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	case StateDisconnected:
+		return "disconnected"
+	default:
+		return "unknown"
+	}
+}
+
+// Option configures Run.
+type Option func(*options)
+
+type options struct {
+	clock retry.Clock
+	hook  func(state State, cause error)
+}
+
+// WithClock makes Run use c to wait between dials and to measure how long
+// connections stay up, instead of the real clock. A nil c selects the real
+// clock.
+func WithClock(c retry.Clock) Option {
+	return func(o *options) {
+		o.clock = clock.Or(c)
+	}
+}
+
+// WithStateHook makes Run call hook on every state transition, for example to
+// export the connection's state as a metric. It is called synchronously from
+// Run's goroutine, so it should return quickly.
+func WithStateHook(hook func(state State, cause error)) Option {
+	return func(o *options) {
+		o.hook = hook
+	}
+}
+
+// Run keeps a connection up until ctx is done or serve shuts it down cleanly.
+//
+// Run dials a connection with dial, then hands it to serve until serve
+// returns. If dial fails, or serve fails, Run waits for the next value of a
+// backoff created once by dialPolicy and dials again, so a dependency that
+// keeps failing is backed off from across reconnects. Once a connection has
+// served for at least healthyAfter, the policy is reset and the next drop
+// reconnects at once. A healthyAfter of zero or less treats every connection
+// as healthy.
+//
+// If serve returns nil, Run returns nil. If the policy stops, Run returns an
+// error wrapping retry.ErrBackoffSignaledToStop and the last error from dial
+// or serve. When ctx is done, Run returns ctx.Err() after the call in progress
+// returns; dial and serve must honor their ctx. To unblock a serve stuck on
+// I/O that doesn't, Run also closes the connection when ctx is done.
+//
+// Every connection is closed exactly once before Run moves on, whatever serve
+// returns, and even if it panics.
+func Run(ctx context.Context, dialPolicy func() backoff.Backoff, healthyAfter time.Duration, dial func(ctx context.Context) (io.Closer, error), serve func(ctx context.Context, conn io.Closer) error, opts ...Option) error {
+	o := options{clock: clock.Real}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+
+	if dialPolicy == nil {
+		return backoff.ErrNilBackoff
+	}
+	b := dialPolicy()
+	if err := backoff.Validate(b); err != nil {
+		return err
+	}
+
+	sleeper := clock.NewSleeper(o.clock)
+	for {
+		// Return immediately if ctx is canceled
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		o.transition(StateConnecting, nil)
+		conn, err := dial(ctx)
+		if err == nil {
+			o.transition(StateConnected, nil)
+			start := o.clock.Now()
+			err = runConn(ctx, conn, serve)
+			o.transition(StateDisconnected, err)
+			if err == nil {
+				return nil
+			}
+			if healthyAfter <= 0 || o.clock.Now().Sub(start) >= healthyAfter {
+				b.Reset()
+				continue
+			}
+		} else {
+			o.transition(StateDisconnected, err)
+		}
+
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+
+		next, stop := b.Next()
+		if stop {
+			return fmt.Errorf("%w: %w", retry.ErrBackoffSignaledToStop, err)
+		}
+
+		if err := sleeper.Sleep(ctx, next, nil); err != nil {
+			return err
+		}
+	}
+}
+
+// runConn serves conn, closing it exactly once when serve returns or ctx is
+// done, whichever is first.
+func runConn(ctx context.Context, conn io.Closer, serve func(ctx context.Context, conn io.Closer) error) error {
+	var once sync.Once
+	closeConn := func() {
+		once.Do(func() {
+			if conn != nil {
+				conn.Close()
+			}
+		})
+	}
+	stop := context.AfterFunc(ctx, closeConn)
+	defer func() {
+		stop()
+		closeConn()
+	}()
+
+	return serve(ctx, conn)
+}
+
+func (o *options) transition(state State, cause error) {
+	if o.hook != nil {
+		o.hook(state, cause)
+	}
+}
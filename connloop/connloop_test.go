@@ -0,0 +1,369 @@
+package connloop
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+	"github.com/swayne275/go-retry/retry"
+	"github.com/swayne275/go-retry/retrytest"
+)
+
+// fakeConn counts how often it is closed.
+type fakeConn struct {
+	closes int64
+	done   chan struct{}
+}
+
+func newFakeConn() *fakeConn {
+	return &fakeConn{done: make(chan struct{})}
+}
+
+func (c *fakeConn) Close() error {
+	if atomic.AddInt64(&c.closes, 1) == 1 {
+		close(c.done)
+	}
+	return nil
+}
+
+func (c *fakeConn) Closes() int64 {
+	return atomic.LoadInt64(&c.closes)
+}
+
+// script drives dial and serve through a fixed list of outcomes, one per
+// connection attempt.
+type script struct {
+	t     *testing.T
+	steps []step
+
+	mu    sync.Mutex
+	i     int
+	conns []*fakeConn
+}
+
+type step struct {
+	// dialErr, if set, fails the dial.
+	dialErr error
+	// serve runs the connection if the dial succeeds.
+	serve func(ctx context.Context, conn *fakeConn) error
+}
+
+func (s *script) dial(_ context.Context) (io.Closer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.i >= len(s.steps) {
+		s.t.Fatalf("unexpected dial %d", s.i+1)
+	}
+	st := s.steps[s.i]
+	if st.dialErr != nil {
+		s.i++
+		return nil, st.dialErr
+	}
+	c := newFakeConn()
+	s.conns = append(s.conns, c)
+	return c, nil
+}
+
+func (s *script) serve(ctx context.Context, conn io.Closer) error {
+	s.mu.Lock()
+	st := s.steps[s.i]
+	s.i++
+	s.mu.Unlock()
+
+	return st.serve(ctx, conn.(*fakeConn))
+}
+
+// checkClosed fails t unless every connection was closed exactly once.
+func (s *script) checkClosed() {
+	s.t.Helper()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, c := range s.conns {
+		if got := c.Closes(); got != 1 {
+			s.t.Errorf("expected connection %d to be closed once, closed %d times", i, got)
+		}
+	}
+}
+
+// counting is a policy of 1s, 2s, 3s, ... that counts its resets.
+type counting struct {
+	n      time.Duration
+	resets int
+}
+
+func (b *counting) Next() (time.Duration, bool) {
+	b.n++
+	return b.n * time.Second, false
+}
+
+func (b *counting) Reset() {
+	b.n = 0
+	b.resets++
+}
+
+func failWith(err error) func(context.Context, *fakeConn) error {
+	return func(context.Context, *fakeConn) error {
+		return err
+	}
+}
+
+func shutdown(context.Context, *fakeConn) error {
+	return nil
+}
+
+type transition struct {
+	state State
+	cause error
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	errDial := fmt.Errorf("connection refused")
+	errDrop := fmt.Errorf("connection reset")
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("flapping", func(t *testing.T) {
+		t.Parallel()
+
+		s := &script{t: t, steps: []step{
+			{serve: failWith(errDrop)},
+			{dialErr: errDial},
+			{serve: failWith(errDrop)},
+			{serve: shutdown},
+		}}
+		c := retrytest.NewInstantClock(start)
+		b := &counting{}
+		var transitions []transition
+
+		err := Run(context.Background(), func() backoff.Backoff { return b }, time.Minute, s.dial, s.serve,
+			WithClock(c), WithStateHook(func(state State, cause error) {
+				transitions = append(transitions, transition{state, cause})
+			}))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		// Connections that drop before they are healthy keep backing off.
+		if got, want := c.Sleeps(), []time.Duration{1 * time.Second, 2 * time.Second, 3 * time.Second}; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+		if b.resets != 0 {
+			t.Errorf("expected %d to be %d", b.resets, 0)
+		}
+		s.checkClosed()
+
+		want := []transition{
+			{StateConnecting, nil}, {StateConnected, nil}, {StateDisconnected, errDrop},
+			{StateConnecting, nil}, {StateDisconnected, errDial},
+			{StateConnecting, nil}, {StateConnected, nil}, {StateDisconnected, errDrop},
+			{StateConnecting, nil}, {StateConnected, nil}, {StateDisconnected, nil},
+		}
+		if !reflect.DeepEqual(transitions, want) {
+			t.Errorf("expected %v to be %v", transitions, want)
+		}
+	})
+
+	t.Run("healthy_then_drop_resets", func(t *testing.T) {
+		t.Parallel()
+
+		c := retrytest.NewInstantClock(start)
+		s := &script{t: t, steps: []step{
+			{dialErr: errDial},
+			{dialErr: errDial},
+			{serve: func(context.Context, *fakeConn) error {
+				c.Advance(time.Minute)
+				return errDrop
+			}},
+			{dialErr: errDial},
+			{serve: shutdown},
+		}}
+		b := &counting{}
+
+		if err := Run(context.Background(), func() backoff.Backoff { return b }, time.Minute, s.dial, s.serve, WithClock(c)); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		// The healthy connection reconnects at once, and the dial failure after
+		// it starts the schedule over.
+		if got, want := c.Sleeps(), []time.Duration{1 * time.Second, 2 * time.Second, 1 * time.Second}; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+		if b.resets != 1 {
+			t.Errorf("expected %d to be %d", b.resets, 1)
+		}
+		s.checkClosed()
+	})
+
+	t.Run("policy_exhausted", func(t *testing.T) {
+		t.Parallel()
+
+		s := &script{t: t, steps: []step{
+			{dialErr: errDial},
+			{serve: failWith(errDrop)},
+			{dialErr: errDial},
+		}}
+		policy := func() backoff.Backoff {
+			return backoff.WithMaxRetries(2, &counting{})
+		}
+
+		err := Run(context.Background(), policy, time.Minute, s.dial, s.serve, WithClock(retrytest.NewInstantClock(start)))
+		if !errors.Is(err, retry.ErrBackoffSignaledToStop) || !errors.Is(err, errDial) {
+			t.Errorf("expected %v to be %v and %v", err, retry.ErrBackoffSignaledToStop, errDial)
+		}
+		s.checkClosed()
+	})
+
+	t.Run("clean_shutdown", func(t *testing.T) {
+		t.Parallel()
+
+		s := &script{t: t, steps: []step{{serve: shutdown}}}
+		if err := Run(context.Background(), func() backoff.Backoff { return &counting{} }, time.Minute, s.dial, s.serve); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		s.checkClosed()
+	})
+
+	t.Run("serve_panics", func(t *testing.T) {
+		t.Parallel()
+
+		s := &script{t: t, steps: []step{{serve: func(context.Context, *fakeConn) error {
+			panic("boom")
+		}}}}
+
+		func() {
+			defer func() {
+				if r := recover(); r != "boom" {
+					t.Errorf("expected %v to be %v", r, "boom")
+				}
+			}()
+			_ = Run(context.Background(), func() backoff.Backoff { return &counting{} }, time.Minute, s.dial, s.serve)
+		}()
+		s.checkClosed()
+	})
+
+	t.Run("canceled_while_dialing", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var dials int64
+		dial := func(ctx context.Context) (io.Closer, error) {
+			atomic.AddInt64(&dials, 1)
+			cancel()
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+
+		err := Run(ctx, func() backoff.Backoff { return &counting{} }, time.Minute, dial, nil)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected %v to be %v", err, context.Canceled)
+		}
+		if got := atomic.LoadInt64(&dials); got != 1 {
+			t.Errorf("expected %d to be %d", got, 1)
+		}
+	})
+
+	t.Run("canceled_while_backing_off", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		c := &stuckClock{started: make(chan struct{})}
+		go func() {
+			<-c.started
+			cancel()
+		}()
+
+		s := &script{t: t, steps: []step{{dialErr: errDial}}}
+		err := Run(ctx, func() backoff.Backoff { return &counting{} }, time.Minute, s.dial, s.serve, WithClock(c))
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected %v to be %v", err, context.Canceled)
+		}
+	})
+
+	t.Run("canceled_while_serving", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		// serve ignores ctx, as a blocking read would; closing the connection
+		// is what unblocks it.
+		s := &script{t: t, steps: []step{{serve: func(_ context.Context, conn *fakeConn) error {
+			cancel()
+			<-conn.done
+			return io.ErrClosedPipe
+		}}}}
+
+		err := Run(ctx, func() backoff.Backoff { return &counting{} }, time.Minute, s.dial, s.serve)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected %v to be %v", err, context.Canceled)
+		}
+		s.checkClosed()
+	})
+
+	t.Run("nil_policy", func(t *testing.T) {
+		t.Parallel()
+
+		for _, policy := range []func() backoff.Backoff{nil, func() backoff.Backoff { return nil }} {
+			if err := Run(context.Background(), policy, time.Minute, nil, nil); !errors.Is(err, backoff.ErrNilBackoff) {
+				t.Errorf("expected %v to be %v", err, backoff.ErrNilBackoff)
+			}
+		}
+	})
+}
+
+// stuckClock is a clock whose timers never fire. It closes started when the
+// first timer is created.
+type stuckClock struct {
+	once    sync.Once
+	started chan struct{}
+}
+
+func (c *stuckClock) Now() time.Time {
+	return time.Time{}
+}
+
+func (c *stuckClock) NewTimer(time.Duration) retry.Timer {
+	c.once.Do(func() { close(c.started) })
+	return stuckTimer{}
+}
+
+type stuckTimer struct{}
+
+func (stuckTimer) C() <-chan time.Time {
+	return nil
+}
+
+func (stuckTimer) Stop() bool {
+	return true
+}
+
+func TestState_String(t *testing.T) {
+	t.Parallel()
+
+	cases := map[State]string{
+		StateConnecting:   "connecting",
+		StateConnected:    "connected",
+		StateDisconnected: "disconnected",
+		State(42):         "unknown",
+	}
+	for s, want := range cases {
+		if got := s.String(); got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+	}
+}
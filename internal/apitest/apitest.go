@@ -0,0 +1,92 @@
+// Package apitest inspects the exported API of a package, for tests that must
+// cover every exported type.
+package apitest
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// StructTypes type-checks the package whose source is in dir, ignoring its
+// tests, and returns the sorted names of its exported types that are structs,
+// including aliases of structs declared in other packages.
+func StructTypes(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	pkg, err := conf.Check(dir, fset, files, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, name := range pkg.Scope().Names() {
+		tn, ok := pkg.Scope().Lookup(name).(*types.TypeName)
+		if !ok || !tn.Exported() {
+			continue
+		}
+		if _, ok := tn.Type().Underlying().(*types.Struct); ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// CheckZeroValues runs the check for every exported struct type of the package
+// in the working directory, as a parallel subtest named after the type. It
+// fails t for types without a check and for checks that panic, so adding an
+// exported type without deciding what its zero value does fails the tests.
+func CheckZeroValues(t *testing.T, checks map[string]func(t *testing.T)) {
+	t.Helper()
+
+	names, err := StructTypes(".")
+	if err != nil {
+		t.Fatalf("failed to list exported types: %v", err)
+	}
+	for _, name := range names {
+		if _, ok := checks[name]; !ok {
+			t.Errorf("no zero value check for %s", name)
+		}
+	}
+
+	for name, check := range checks {
+		name, check := name, check
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("zero %s panicked: %v", name, r)
+				}
+			}()
+			check(t)
+		})
+	}
+}
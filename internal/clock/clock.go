@@ -0,0 +1,127 @@
+// Package clock abstracts the passage of time so retry loops can be driven
+// deterministically in tests.
+package clock
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Clock tells the time and creates timers.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// NewTimer creates a Timer that fires once after d.
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a single-shot timer, mirroring time.Timer.
+type Timer interface {
+	// C returns the channel on which the time is delivered when the timer fires.
+	C() <-chan time.Time
+	// Stop prevents the timer from firing. It returns false if the timer has
+	// already fired or been stopped.
+	Stop() bool
+}
+
+// Real is the Clock backed by the time package.
+var Real Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+type realTimer struct {
+	t *time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.t.C
+}
+
+func (t realTimer) Stop() bool {
+	return t.t.Stop()
+}
+
+// Reset rearms the timer to fire after d, discarding a pending fire.
+func (t realTimer) Reset(d time.Duration) {
+	if !t.t.Stop() {
+		select {
+		case <-t.t.C:
+		default:
+		}
+	}
+	t.t.Reset(d)
+}
+
+// resetter is implemented by timers that can be rearmed.
+type resetter interface {
+	Reset(d time.Duration)
+}
+
+// Sleeper creates the timers for the successive sleeps of a loop. When the
+// clock's timers can be rearmed, as the real clock's can, it reuses a single
+// timer instead of allocating one per sleep. Timers of other clocks, such as
+// fakes, are created afresh each time, so the fakes see every sleep.
+//
+// A Sleeper is not safe for concurrent use.
+type Sleeper struct {
+	c Clock
+	t Timer
+}
+
+// NewSleeper returns a Sleeper whose timers come from c, or Real if c is nil.
+func NewSleeper(c Clock) Sleeper {
+	return Sleeper{c: Or(c)}
+}
+
+// Timer returns a timer that fires after d, or right away if d is negative.
+// The timer returned by the previous call must not be used again.
+func (s *Sleeper) Timer(d time.Duration) Timer {
+	if d < 0 {
+		d = 0
+	}
+	if r, ok := s.t.(resetter); ok {
+		r.Reset(d)
+		return s.t
+	}
+	s.t = s.c.NewTimer(d)
+	return s.t
+}
+
+// ErrInterrupted is returned by Sleeper.Sleep when its interrupt channel wakes
+// it before the sleep is over.
+var ErrInterrupted = errors.New("sleep interrupted")
+
+// Sleep sleeps for d on a timer from Timer. It returns nil once d has passed,
+// ctx.Err() if ctx is done first, and ErrInterrupted if interrupt is closed or
+// receives first; a nil interrupt never does. The timer is stopped on every
+// early return.
+func (s *Sleeper) Sleep(ctx context.Context, d time.Duration, interrupt <-chan struct{}) error {
+	t := s.Timer(d)
+	select {
+	case <-ctx.Done():
+		t.Stop()
+		return ctx.Err()
+	case <-interrupt:
+		t.Stop()
+		return ErrInterrupted
+	case <-t.C():
+		return nil
+	}
+}
+
+// Or returns c, or Real if c is nil.
+func Or(c Clock) Clock {
+	if c == nil {
+		return Real
+	}
+	return c
+}
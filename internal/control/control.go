@@ -0,0 +1,108 @@
+// Package control implements process-wide switches that make loops stop
+// retrying.
+package control
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Switch can be flipped to disable retries, and back. It is safe for
+// concurrent use. Use NewSwitch to create one.
+type Switch struct {
+	mu    sync.Mutex
+	state atomic.Pointer[State]
+}
+
+// State is one setting of a Switch. It never changes; flipping the Switch
+// replaces it.
+type State struct {
+	// Disabled is set when retries are disabled, for Reason.
+	Disabled bool
+	Reason   string
+	// Changed is closed when the Switch is next disabled. It is nil for a
+	// disabled State.
+	Changed chan struct{}
+}
+
+// NewSwitch creates an enabled Switch.
+func NewSwitch() *Switch {
+	s := &Switch{}
+	s.state.Store(&State{Changed: make(chan struct{})})
+	return s
+}
+
+// Load returns the current setting of s.
+func (s *Switch) Load() *State {
+	return s.state.Load()
+}
+
+// Disable disables retries for reason, waking loops waiting on Changed. If
+// retries are already disabled, only the reason changes.
+func (s *Switch) Disable(reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old := s.state.Load()
+	s.state.Store(&State{Disabled: true, Reason: reason})
+	if old.Changed != nil {
+		close(old.Changed)
+	}
+}
+
+// Enable enables retries again.
+func (s *Switch) Enable() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.state.Load().Disabled {
+		s.state.Store(&State{Changed: make(chan struct{})})
+	}
+}
+
+// Control holds the switches for the loops of the retry and repeat packages.
+type Control struct {
+	Retries *Switch
+	Repeats *Switch
+}
+
+// New creates a Control with both switches enabled.
+func New() *Control {
+	return &Control{Retries: NewSwitch(), Repeats: NewSwitch()}
+}
+
+// Global is the Control loops consult unless told otherwise.
+var Global = New()
+
+// DisabledError is returned by a loop that stopped because its Switch was
+// disabled. It wraps the sentinel of the package that returned it and the last
+// error from the loop's function, if any.
+type DisabledError struct {
+	Sentinel error
+	Reason   string
+	Err      error
+}
+
+// Error returns the error string.
+func (e *DisabledError) Error() string {
+	msg := "disabled"
+	if e.Sentinel != nil {
+		msg = e.Sentinel.Error()
+	}
+	msg += " (" + e.Reason + ")"
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap implements error wrapping.
+func (e *DisabledError) Unwrap() []error {
+	var errs []error
+	for _, err := range []error{e.Sentinel, e.Err} {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
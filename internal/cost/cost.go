@@ -0,0 +1,201 @@
+// Package cost aggregates the time retry loops spend, per caller-chosen key.
+package cost
+
+import (
+	"hash/maphash"
+	"sync"
+	"time"
+
+	"github.com/swayne275/go-retry/internal/clock"
+)
+
+// Cost is the time spent by one or more loops.
+type Cost struct {
+	// Attempts is the number of times the function was called.
+	Attempts uint64
+	// Sleep is the time spent waiting between attempts.
+	Sleep time.Duration
+	// Execution is the time spent in the function.
+	Execution time.Duration
+}
+
+func (c *Cost) add(o Cost) {
+	c.Attempts += o.Attempts
+	c.Sleep += o.Sleep
+	c.Execution += o.Execution
+}
+
+// shards is the number of independently locked parts of an Accumulator. Loops
+// with different keys rarely contend on the same lock.
+const shards = 32
+
+type shard struct {
+	mu    sync.Mutex
+	costs map[string]*Cost
+}
+
+// Accumulator sums Costs per key. It is safe for concurrent use. The zero
+// Accumulator is empty and ready to use.
+type Accumulator struct {
+	once   sync.Once
+	seed   maphash.Seed
+	shards [shards]shard
+}
+
+// New creates an empty Accumulator.
+func New() *Accumulator {
+	a := &Accumulator{seed: maphash.MakeSeed()}
+	for i := range a.shards {
+		a.shards[i].costs = make(map[string]*Cost)
+	}
+	return a
+}
+
+func (a *Accumulator) shard(key string) *shard {
+	a.once.Do(func() {
+		if a.seed == (maphash.Seed{}) {
+			a.seed = maphash.MakeSeed()
+		}
+	})
+	return &a.shards[maphash.String(a.seed, key)%shards]
+}
+
+// Add adds c to the aggregate for key.
+func (a *Accumulator) Add(key string, c Cost) {
+	s := a.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	agg, ok := s.costs[key]
+	if !ok {
+		if s.costs == nil {
+			s.costs = make(map[string]*Cost)
+		}
+		agg = &Cost{}
+		s.costs[key] = agg
+	}
+	agg.add(c)
+}
+
+// Snapshot returns the aggregates for every key.
+func (a *Accumulator) Snapshot() map[string]Cost {
+	out := make(map[string]Cost)
+	for i := range a.shards {
+		s := &a.shards[i]
+		s.mu.Lock()
+		for k, c := range s.costs {
+			out[k] = *c
+		}
+		s.mu.Unlock()
+	}
+	return out
+}
+
+// Drain returns the aggregates for every key and clears them. Every Add is
+// reflected, in full, in exactly one Drain.
+func (a *Accumulator) Drain() map[string]Cost {
+	out := make(map[string]Cost)
+	for i := range a.shards {
+		s := &a.shards[i]
+		s.mu.Lock()
+		costs := s.costs
+		s.costs = make(map[string]*Cost)
+		s.mu.Unlock()
+
+		for k, c := range costs {
+			out[k] = *c
+		}
+	}
+	return out
+}
+
+// Meter measures the cost of a single loop and adds it to an Accumulator when
+// the loop ends, and records each sleep in a Histogram. Either may be nil. All
+// methods of a nil *Meter do nothing, so loops that don't collect costs don't
+// read the clock.
+type Meter struct {
+	acc     *Accumulator
+	key     string
+	hist    *Histogram
+	clock   clock.Clock
+	cost    Cost
+	begin   time.Time
+	start   time.Time
+	elapsed time.Duration
+}
+
+// NewMeter returns a Meter adding to acc under key and recording sleeps in
+// hist, timing with c. It returns nil if acc and hist are both nil, unless
+// always is set, for loops that only read the Meter themselves.
+func NewMeter(acc *Accumulator, key string, hist *Histogram, always bool, c clock.Clock) *Meter {
+	if acc == nil && hist == nil && !always {
+		return nil
+	}
+	c = clock.Or(c)
+	return &Meter{acc: acc, key: key, hist: hist, clock: c, begin: c.Now()}
+}
+
+// Start marks the start of an attempt or a sleep.
+func (m *Meter) Start() {
+	if m == nil {
+		return
+	}
+	m.start = m.clock.Now()
+}
+
+// Attempted records an attempt that started at the last Start.
+func (m *Meter) Attempted() {
+	if m == nil {
+		return
+	}
+	m.cost.Attempts++
+	m.cost.Execution += m.clock.Now().Sub(m.start)
+}
+
+// Slept records a sleep that started at the last Start.
+func (m *Meter) Slept() {
+	if m == nil {
+		return
+	}
+	d := m.clock.Now().Sub(m.start)
+	m.cost.Sleep += d
+	if m.hist != nil {
+		m.hist.Observe(d)
+	}
+}
+
+// Done ends the loop, adding the measured cost to the Accumulator.
+func (m *Meter) Done() {
+	if m == nil {
+		return
+	}
+	m.elapsed = m.clock.Now().Sub(m.begin)
+	if m.acc != nil {
+		m.acc.Add(m.key, m.cost)
+	}
+}
+
+// Cost returns the cost measured so far.
+func (m *Meter) Cost() Cost {
+	if m == nil {
+		return Cost{}
+	}
+	return m.cost
+}
+
+// Running returns the time since the creation of the Meter, for loops that
+// report it before Done.
+func (m *Meter) Running() time.Duration {
+	if m == nil {
+		return 0
+	}
+	return m.clock.Now().Sub(m.begin)
+}
+
+// Elapsed returns the time from the creation of the Meter to Done.
+func (m *Meter) Elapsed() time.Duration {
+	if m == nil {
+		return 0
+	}
+	return m.elapsed
+}
@@ -0,0 +1,105 @@
+package cost
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/swayne275/go-retry/internal/label"
+)
+
+// ErrInvalidHistogram is returned when histogram buckets are invalid. Its
+// label is "invalid_histogram".
+var ErrInvalidHistogram = label.New("invalid histogram buckets", "invalid_histogram")
+
+// Histogram counts durations in fixed buckets. It is safe for concurrent use.
+// The zero Histogram has no buckets, not even the overflow one, and only
+// records the sum.
+type Histogram struct {
+	bounds []time.Duration
+	// counts has one counter per bound, plus one for overflow.
+	counts []atomic.Uint64
+	sum    atomic.Int64
+}
+
+// HistogramSnapshot is a point-in-time copy of a Histogram.
+type HistogramSnapshot struct {
+	// Buckets are the inclusive upper bounds of the buckets, in increasing
+	// order.
+	Buckets []time.Duration
+	// Counts holds the number of durations in each bucket: Counts[i] counts
+	// durations d with Buckets[i-1] < d <= Buckets[i]. The extra last count is
+	// the overflow bucket, for durations above every bound.
+	Counts []uint64
+	// Sum is the sum of every recorded duration.
+	Sum time.Duration
+}
+
+// NewHistogram creates a Histogram with the given bucket upper bounds, which
+// must be non-negative and strictly increasing.
+func NewHistogram(bounds []time.Duration) (*Histogram, error) {
+	if len(bounds) == 0 {
+		return nil, fmt.Errorf("%w: no buckets", ErrInvalidHistogram)
+	}
+	for i, b := range bounds {
+		if b < 0 {
+			return nil, fmt.Errorf("%w: bucket %v is negative", ErrInvalidHistogram, b)
+		}
+		if i > 0 && b <= bounds[i-1] {
+			return nil, fmt.Errorf("%w: buckets are not strictly increasing at %v", ErrInvalidHistogram, b)
+		}
+	}
+
+	return &Histogram{
+		bounds: append([]time.Duration(nil), bounds...),
+		counts: make([]atomic.Uint64, len(bounds)+1),
+	}, nil
+}
+
+// Observe records d.
+func (h *Histogram) Observe(d time.Duration) {
+	i := 0
+	for i < len(h.bounds) && d > h.bounds[i] {
+		i++
+	}
+	if i < len(h.counts) {
+		h.counts[i].Add(1)
+	}
+	h.sum.Add(int64(d))
+}
+
+// Snapshot returns the current counts. Counts recorded concurrently may or may
+// not be included.
+func (h *Histogram) Snapshot() HistogramSnapshot {
+	s := HistogramSnapshot{
+		Buckets: append([]time.Duration(nil), h.bounds...),
+		Counts:  make([]uint64, len(h.counts)),
+		Sum:     time.Duration(h.sum.Load()),
+	}
+	for i := range h.counts {
+		s.Counts[i] = h.counts[i].Load()
+	}
+	return s
+}
+
+// Merge adds the counts of other to h. Both must have the same buckets, and
+// other must not be nil.
+func (h *Histogram) Merge(other *Histogram) error {
+	if other == nil {
+		return fmt.Errorf("%w: merging a nil histogram", ErrInvalidHistogram)
+	}
+	if len(h.bounds) != len(other.bounds) {
+		return fmt.Errorf("%w: merging histograms with different buckets", ErrInvalidHistogram)
+	}
+	for i := range h.bounds {
+		if h.bounds[i] != other.bounds[i] {
+			return fmt.Errorf("%w: merging histograms with different buckets", ErrInvalidHistogram)
+		}
+	}
+
+	for i := range other.counts {
+		h.counts[i].Add(other.counts[i].Load())
+	}
+	h.sum.Add(other.sum.Load())
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/swayne275/go-retry/backoff"
 	"github.com/swayne275/go-retry/retry"
+	"github.com/swayne275/go-retry/retryhttp"
 )
 
 func ExampleBackoffFunc() {
@@ -39,6 +40,29 @@ func ExampleBackoffFunc() {
 	}
 }
 
+func ExampleNew() {
+	ctx := context.Background()
+
+	// Instead of nesting NewExponential, WithCappedDuration, WithJitterPercent
+	// and WithMaxRetries, and checking two errors along the way:
+	b, err := backoff.New(
+		backoff.WithExponentialBase(100*time.Millisecond),
+		backoff.WithCap(5*time.Second),
+		backoff.WithJitterPercentOpt(10),
+		backoff.WithMaxRetriesOpt(5),
+	)
+	if err != nil {
+		// handle the error here, likely from bad input
+	}
+
+	if err := retry.Do(ctx, b, func(_ context.Context) error {
+		// your retry logic here
+		return nil
+	}); err != nil {
+		// handle the error here
+	}
+}
+
 func ExampleWithJitter() {
 	ctx := context.Background()
 
@@ -113,6 +137,25 @@ func ExampleWithCappedDuration() {
 	}
 }
 
+func ExampleWithImmediateFirst() {
+	ctx := context.Background()
+
+	b, err := backoff.NewFibonacci(1 * time.Second)
+	if err != nil {
+		// handle err
+	}
+	// Retry right away after the first failure, then back off 1s, 2s, 3s, ...
+	// The immediate retry is the first of the 5.
+	b = backoff.WithMaxRetries(5, backoff.WithImmediateFirst(b))
+
+	if err := retry.Do(ctx, b, func(_ context.Context) error {
+		// your retry logic here
+		return nil
+	}); err != nil {
+		// handle the error here
+	}
+}
+
 func ExampleWithMaxDuration() {
 	ctx := context.Background()
 
@@ -258,3 +301,24 @@ func ExampleDo_customRetry() {
 		// handle error
 	}
 }
+
+func ExampleNewTransport() {
+	// This example demonstrates the same retries as ExampleDo_customRetry,
+	// applied to every request made by a client.
+	client := &http.Client{
+		Transport: retryhttp.NewTransport(nil, func() backoff.Backoff {
+			b, err := backoff.NewFibonacci(1 * time.Nanosecond)
+			if err != nil {
+				// handle error
+			}
+			return b
+		}, retryhttp.WithMaxRetries(3)),
+	}
+
+	resp, err := client.Get("https://google.com/")
+	if err != nil {
+		// handle error
+		return
+	}
+	defer resp.Body.Close()
+}
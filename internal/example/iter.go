@@ -0,0 +1,53 @@
+//go:build go1.23
+
+package example
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+func ExampleAll() {
+	b, err := backoff.NewFibonacci(1 * time.Second)
+	if err != nil {
+		// handle the error here, likely from bad input
+	}
+
+	// Range over the schedule to drive a loop of your own. The loop ends after
+	// the fifth value because of WithMaxRetries.
+	for d := range backoff.All(backoff.WithMaxRetries(5, b)) {
+		fmt.Println(d)
+	}
+
+	// Output:
+	// 1s
+	// 2s
+	// 3s
+	// 5s
+	// 8s
+}
+
+func ExampleAllContext() {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	b, err := backoff.NewExponential(100 * time.Millisecond)
+	if err != nil {
+		// handle the error here, likely from bad input
+	}
+
+	// The loop ends once ctx is done, even though the backoff never stops.
+	for d := range backoff.AllContext(ctx, b) {
+		if err := connect(ctx); err == nil {
+			break
+		}
+		time.Sleep(d)
+	}
+}
+
+func connect(_ context.Context) error {
+	return nil
+}
@@ -0,0 +1,85 @@
+// Package label attaches stable, machine-readable labels to the sentinel
+// errors of the public packages, so callers can group errors without parsing
+// their messages.
+package label
+
+import (
+	"context"
+	"errors"
+)
+
+// Unknown is the label of errors that carry none.
+const Unknown = "unknown"
+
+// Labeled is implemented by errors that carry a label.
+type Labeled interface {
+	RetryLabel() string
+}
+
+type sentinel struct {
+	msg   string
+	label string
+}
+
+// New returns a sentinel error with the given message and label.
+func New(msg, label string) error {
+	return &sentinel{msg: msg, label: label}
+}
+
+// Error returns the error message.
+func (e *sentinel) Error() string {
+	return e.msg
+}
+
+// RetryLabel returns the error's label.
+func (e *sentinel) RetryLabel() string {
+	return e.label
+}
+
+// Of returns the label of the first Labeled error in err's tree, in the order
+// errors.As walks it. Context cancellation and deadline errors, which carry no
+// label of their own, are labeled "context_canceled" and
+// "context_deadline_exceeded". It returns Unknown if no label is found.
+func Of(err error) string {
+	var l Labeled
+	switch {
+	case errors.As(err, &l):
+		return l.RetryLabel()
+	case errors.Is(err, context.Canceled):
+		return "context_canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "context_deadline_exceeded"
+	default:
+		return Unknown
+	}
+}
+
+// Classify returns the first of sentinels found in err's tree, in the order
+// errors.As walks it, or nil if err matches none of them. Taking the first
+// match means an error wrapping several sentinels, such as a loop giving up
+// on an error that itself wraps a sentinel, is classified by its outermost one.
+func Classify(err error, sentinels []error) error {
+	if err == nil {
+		return nil
+	}
+	for _, s := range sentinels {
+		if err == s {
+			return s
+		}
+		if x, ok := err.(interface{ Is(error) bool }); ok && x.Is(s) {
+			return s
+		}
+	}
+
+	switch x := err.(type) {
+	case interface{ Unwrap() error }:
+		return Classify(x.Unwrap(), sentinels)
+	case interface{ Unwrap() []error }:
+		for _, err := range x.Unwrap() {
+			if s := Classify(err, sentinels); s != nil {
+				return s
+			}
+		}
+	}
+	return nil
+}
@@ -17,6 +17,16 @@ func NewLockedRandom(seed int64) *LockedSource {
 	return &LockedSource{src: rand.New(rand.NewSource(seed))}
 }
 
+// Wrap returns a LockedSource drawing from src. If src is already a
+// LockedSource it is returned as is, so sharing one stream between several
+// users keeps a single lock around it.
+func Wrap(src rand.Source64) *LockedSource {
+	if l, ok := src.(*LockedSource); ok {
+		return l
+	}
+	return &LockedSource{src: rand.New(src)}
+}
+
 // Int63 mimics math/rand.(*Rand).Int63 with mutex locked.
 func (r *LockedSource) Int63() int64 {
 	r.mu.Lock()
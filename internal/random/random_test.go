@@ -1,6 +1,7 @@
 package random
 
 import (
+	"math/rand"
 	"testing"
 )
 
@@ -60,3 +61,19 @@ func TestInt63nConcurrency(t *testing.T) {
 		}
 	}
 }
+
+// TestWrap tests that Wrap draws from the wrapped source and reuses LockedSources.
+func TestWrap(t *testing.T) {
+	l := NewLockedRandom(1)
+	if got := Wrap(l); got != l {
+		t.Errorf("expected Wrap to return the same LockedSource")
+	}
+
+	a := Wrap(rand.NewSource(42).(rand.Source64))
+	b := rand.New(rand.NewSource(42))
+	for i := 0; i < 10; i++ {
+		if got, want := a.Int63(), b.Int63(); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	}
+}
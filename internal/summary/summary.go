@@ -0,0 +1,75 @@
+// Package summary logs one record per loop describing how it ended.
+package summary
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/swayne275/go-retry/backoff"
+	"github.com/swayne275/go-retry/internal/cost"
+)
+
+// Config says where and how to log summaries. The zero value logs nothing.
+type Config struct {
+	Logger    *slog.Logger
+	Level     slog.Level
+	Operation string
+	// EarlyCancel logs loops that ended by cancellation before making any
+	// attempt, which are skipped otherwise.
+	EarlyCancel bool
+	// Suppress, if set, decides whether the summary of a loop that ended with
+	// an error is logged, and how many were suppressed before it.
+	Suppress func(err error) (bool, int)
+}
+
+// Enabled reports whether summaries are logged.
+func (c Config) Enabled() bool {
+	return c.Logger != nil
+}
+
+// Log emits the summary of a loop that ended with err, as measured by m, with
+// outcome describing how it ended. It does nothing if c is not enabled.
+func (c Config) Log(ctx context.Context, msg string, m *cost.Meter, policy backoff.Backoff, outcome string, err error) {
+	if !c.Enabled() {
+		return
+	}
+
+	spent := m.Cost()
+	if spent.Attempts == 0 && !c.EarlyCancel && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+		return
+	}
+
+	var errMsg string
+	if err != nil {
+		errMsg = err.Error()
+	}
+
+	attrs := []slog.Attr{
+		slog.String("operation", c.Operation),
+		slog.String("outcome", outcome),
+		slog.Uint64("attempts", spent.Attempts),
+		slog.Int64("total_sleep_ms", spent.Sleep.Milliseconds()),
+		slog.Int64("elapsed_ms", m.Elapsed().Milliseconds()),
+		slog.String("error", errMsg),
+		slog.String("policy", Describe(policy)),
+	}
+	if c.Suppress != nil {
+		ok, suppressed := c.Suppress(err)
+		if !ok {
+			return
+		}
+		attrs = append(attrs, slog.Int("suppressed", suppressed))
+	}
+	c.Logger.LogAttrs(ctx, c.Level, msg, attrs...)
+}
+
+// Describe returns a description of b: its String method if it has one, and
+// its type otherwise.
+func Describe(b backoff.Backoff) string {
+	if s, ok := b.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("%T", b)
+}
@@ -0,0 +1,29 @@
+package repeat
+
+import (
+	"context"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+// AdaptiveFunc is the function repeated by DoAdaptive. activity reports
+// whether the call found anything to do, and cont whether to keep repeating.
+type AdaptiveFunc func(ctx context.Context) (activity bool, cont bool)
+
+// DoAdaptive repeats f as long as it returns cont, like Do, waiting a's
+// interval between calls. After each call it calls a.Faster if f reported
+// activity and a.Slower otherwise, so a poller slows down while nothing
+// changes and speeds back up toward the base interval when something does.
+// When f stops, it returns ErrFunctionSignaledToStop, or nil with
+// WithStopIsSuccess.
+func DoAdaptive(ctx context.Context, a *backoff.Adaptive, f AdaptiveFunc, opts ...Option) error {
+	return DoWithOptions(ctx, a, func(ctx context.Context) bool {
+		activity, cont := f(ctx)
+		if activity {
+			a.Faster()
+		} else {
+			a.Slower()
+		}
+		return cont
+	}, opts...)
+}
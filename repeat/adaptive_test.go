@@ -0,0 +1,76 @@
+package repeat
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+func TestDoAdaptive(t *testing.T) {
+	t.Parallel()
+
+	t.Run("trajectory", func(t *testing.T) {
+		t.Parallel()
+
+		a, err := backoff.NewAdaptive(1*time.Second, 8*time.Second)
+		if err != nil {
+			t.Fatalf("failed to create adaptive backoff: %v", err)
+		}
+
+		// The activity reported by each call, and the sleep that follows it.
+		steps := []struct {
+			activity bool
+			sleep    time.Duration
+		}{
+			{activity: false, sleep: 2 * time.Second},
+			{activity: false, sleep: 4 * time.Second},
+			{activity: true, sleep: 2 * time.Second},
+			{activity: false, sleep: 4 * time.Second},
+			{activity: true, sleep: 2 * time.Second},
+			{activity: true, sleep: 1 * time.Second},
+			{activity: false, sleep: 2 * time.Second},
+			{activity: false, sleep: 4 * time.Second},
+			{activity: false, sleep: 8 * time.Second},
+			{activity: false, sleep: 8 * time.Second},
+		}
+
+		c := newManualClock()
+		errc := make(chan error, 1)
+		var calls int
+		go func() {
+			errc <- DoAdaptive(context.Background(), a, func(_ context.Context) (bool, bool) {
+				calls++
+				if calls > len(steps) {
+					return false, false
+				}
+				return steps[calls-1].activity, true
+			}, WithClock(c))
+		}()
+
+		for i, s := range steps {
+			if got := c.waitTimer(t); got != s.sleep {
+				t.Errorf("step %d: expected %v to be %v", i, got, s.sleep)
+			}
+			c.Advance(s.sleep)
+		}
+
+		if err := <-errc; !errors.Is(err, ErrFunctionSignaledToStop) {
+			t.Errorf("expected %q to be %q", err, ErrFunctionSignaledToStop)
+		}
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		t.Parallel()
+
+		err := DoAdaptive(context.Background(), nil, func(_ context.Context) (bool, bool) {
+			t.Error("expected f not to be called")
+			return false, false
+		})
+		if !errors.Is(err, backoff.ErrNilBackoff) {
+			t.Errorf("expected %q to be %q", err, backoff.ErrNilBackoff)
+		}
+	})
+}
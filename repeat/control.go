@@ -0,0 +1,23 @@
+package repeat
+
+import (
+	"github.com/swayne275/go-retry/internal/control"
+	"github.com/swayne275/go-retry/internal/label"
+)
+
+// ErrRepeatsDisabled is wrapped, with the reason and the last error from f if
+// there was one, in the DisabledError returned by loops that stopped repeating
+// because repeats were disabled with the retry package's
+// GlobalControl().DisableRepeats. Its label is "repeats_disabled".
+var ErrRepeatsDisabled = label.New("repeats disabled", "repeats_disabled")
+
+// DisabledError is returned by a loop that stopped because repeats were
+// disabled. Reason is the reason given when disabling them; the error wraps
+// ErrRepeatsDisabled and the last error from f, if any.
+type DisabledError = control.DisabledError
+
+// repeatsDisabled returns the error for a loop stopped by st, which disabled
+// repeats, after f last returned lastErr.
+func repeatsDisabled(st *control.State, lastErr error) error {
+	return &DisabledError{Sentinel: ErrRepeatsDisabled, Reason: st.Reason, Err: lastErr}
+}
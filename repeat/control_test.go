@@ -0,0 +1,86 @@
+package repeat
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+	"github.com/swayne275/go-retry/internal/control"
+)
+
+// withRepeats makes the loop consult sw instead of the global switch, so tests
+// can disable repeats without affecting each other.
+func withRepeats(sw *control.Switch) Option {
+	return func(o *options) {
+		o.repeats = sw
+	}
+}
+
+func disabledSwitch(reason string) *control.Switch {
+	sw := control.NewSwitch()
+	sw.Disable(reason)
+	return sw
+}
+
+func TestRepeatsDisabled(t *testing.T) {
+	t.Parallel()
+
+	t.Run("first_run_still_happens", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		var runs int
+		err = DoWithOptions(context.Background(), b, func(_ context.Context) bool {
+			runs++
+			return true
+		}, withRepeats(disabledSwitch("incident")))
+
+		var derr *DisabledError
+		if !errors.As(err, &derr) || derr.Reason != "incident" {
+			t.Errorf("expected %q to be a DisabledError for %q", err, "incident")
+		}
+		if !errors.Is(err, ErrRepeatsDisabled) {
+			t.Errorf("expected %q to be %q", err, ErrRepeatsDisabled)
+		}
+		if runs != 1 {
+			t.Errorf("expected %v to be %v", runs, 1)
+		}
+	})
+
+	t.Run("wakes_sleeping_loop", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewConstant(1 * time.Hour)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		sw := control.NewSwitch()
+		c := newManualClock()
+		errc := make(chan error, 1)
+		go func() {
+			errc <- DoOnSignal(context.Background(), nil, b, func(_ context.Context) error {
+				return errors.New("oops")
+			}, WithClock(c), withRepeats(sw))
+		}()
+
+		c.waitTimer(t)
+		sw.Disable("incident")
+
+		select {
+		case err := <-errc:
+			var derr *DisabledError
+			if !errors.As(err, &derr) || derr.Reason != "incident" {
+				t.Errorf("expected %q to be a DisabledError for %q", err, "incident")
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("sleeping loop did not wake")
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package repeat
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+func TestWithCostKey(t *testing.T) {
+	t.Parallel()
+
+	newBackoff := func(t *testing.T, maxRetries uint64) backoff.Backoff {
+		t.Helper()
+
+		b, err := backoff.NewConstant(1 * time.Millisecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		return backoff.WithMaxRetries(maxRetries, b)
+	}
+
+	acc := NewCostAccumulator()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			_ = DoWithOptions(context.Background(), newBackoff(t, 2), func(_ context.Context) bool {
+				return true
+			}, WithCostKey(acc, "do"))
+		}()
+		go func() {
+			defer wg.Done()
+			_ = DoUntilErrorWithOptions(context.Background(), newBackoff(t, 10), func(_ context.Context) error {
+				return fmt.Errorf("oops")
+			}, WithCostKey(acc, "do_until_error"))
+		}()
+		go func() {
+			defer wg.Done()
+			_ = DoOnSignal(context.Background(), nil, newBackoff(t, 1), func(_ context.Context) error {
+				return fmt.Errorf("oops")
+			}, WithCostKey(acc, "do_on_signal"))
+		}()
+	}
+	wg.Wait()
+
+	got := acc.Drain()
+	cases := []struct {
+		key      string
+		attempts uint64
+		slept    bool
+	}{
+		{key: "do", attempts: 30, slept: true},
+		{key: "do_until_error", attempts: 10, slept: false},
+		{key: "do_on_signal", attempts: 20, slept: true},
+	}
+	for _, tc := range cases {
+		c := got[tc.key]
+		if c.Attempts != tc.attempts {
+			t.Errorf("%s: expected %d to be %d", tc.key, c.Attempts, tc.attempts)
+		}
+		if slept := c.Sleep > 0; slept != tc.slept {
+			t.Errorf("%s: expected sleep %v to be positive: %v", tc.key, c.Sleep, tc.slept)
+		}
+	}
+
+	if got := acc.Snapshot(); len(got) != 0 {
+		t.Errorf("expected %v to be empty", got)
+	}
+}
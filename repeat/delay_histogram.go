@@ -0,0 +1,37 @@
+package repeat
+
+import (
+	"time"
+
+	"github.com/swayne275/go-retry/internal/cost"
+)
+
+// ErrInvalidDelayHistogram is returned when the buckets of a DelayHistogram
+// are empty, negative or not strictly increasing, or when merging histograms
+// with different buckets or a nil one. Its label is "invalid_histogram".
+var ErrInvalidDelayHistogram = cost.ErrInvalidHistogram
+
+// DelayHistogram counts the delays actually slept between attempts in fixed
+// buckets. It is safe for concurrent use and can be shared with the loops of
+// the retry package.
+type DelayHistogram = cost.Histogram
+
+// DelayHistogramSnapshot is a point-in-time copy of a DelayHistogram.
+type DelayHistogramSnapshot = cost.HistogramSnapshot
+
+// NewDelayHistogram creates a DelayHistogram with the given inclusive bucket
+// upper bounds, which must be non-negative and strictly increasing. Delays
+// above the last bound are counted in an overflow bucket.
+func NewDelayHistogram(buckets []time.Duration) (*DelayHistogram, error) {
+	return cost.NewHistogram(buckets)
+}
+
+// WithDelayHistogram makes the loop record every sleep between runs in h. The
+// duration recorded is the time actually slept, measured with the loop's
+// clock, so a sleep cut short by a signal or cancellation records how long it
+// lasted rather than the backoff's value.
+func WithDelayHistogram(h *DelayHistogram) Option {
+	return func(o *options) {
+		o.delayHistogram = h
+	}
+}
@@ -0,0 +1,58 @@
+package repeat
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+func TestWithDelayHistogram(t *testing.T) {
+	t.Parallel()
+
+	t.Run("records_shortened_sleep", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewConstant(1 * time.Hour)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		h, err := NewDelayHistogram([]time.Duration{time.Minute, time.Hour})
+		if err != nil {
+			t.Fatalf("failed to create histogram: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		c := newManualClock()
+		signal := make(chan struct{}, 1)
+		runs := make(chan int, 10)
+		errc := make(chan error, 1)
+		var n int
+		go func() {
+			errc <- DoOnSignal(ctx, signal, b, func(_ context.Context) error {
+				n++
+				runs <- n
+				return nil
+			}, WithClock(c), WithDelayHistogram(h))
+		}()
+
+		expectRun(t, runs, 1)
+		c.waitTimer(t)
+		c.Advance(10 * time.Minute)
+		signal <- struct{}{}
+		expectRun(t, runs, 2)
+		c.waitTimer(t)
+		cancel()
+		<-errc
+
+		got := h.Snapshot()
+		if exp := []uint64{1, 1, 0}; !reflect.DeepEqual(got.Counts, exp) {
+			t.Errorf("expected %v to be %v", got.Counts, exp)
+		}
+		if exp := 10 * time.Minute; got.Sum != exp {
+			t.Errorf("expected %v to be %v", got.Sum, exp)
+		}
+	})
+}
@@ -0,0 +1,302 @@
+package repeat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+	"github.com/swayne275/go-retry/internal/label"
+	"github.com/swayne275/go-retry/internal/summary"
+)
+
+// ErrInvalidState is returned by StartFromState when the state is corrupted,
+// was not written by ExportState, or was written by a newer version of this
+// package that changed the encoding incompatibly. Its label is
+// "invalid_state".
+var ErrInvalidState = label.New("invalid repeat state", "invalid_state")
+
+// stateVersion is the version of the encoding written by ExportState.
+//
+// It only changes when older versions could no longer read the encoding, and
+// StartFromState rejects states from a newer version. Fields added in a way
+// older versions can do without keep the version: StartFromState ignores
+// fields it doesn't know, and treats missing ones as zero.
+const stateVersion = 1
+
+// loopState is the encoding of a loop, written by ExportState.
+type loopState struct {
+	Version int    `json:"version"`
+	Key     string `json:"key"`
+	// Backoff is the snapshot of the loop's backoff, from backoff.Snapshot.
+	Backoff []byte `json:"backoff"`
+	// LastRun is when the last run of f ended, or zero if f hasn't run, and
+	// Delay is how long the loop planned to wait after it.
+	LastRun time.Time     `json:"last_run"`
+	Delay   time.Duration `json:"delay_ns"`
+	// Streak is the failure streak, from Handle.Streak.
+	Streak uint64 `json:"streak"`
+}
+
+// Handle is a loop started by Start or StartFromState. It can be stopped and,
+// with ExportState, handed over to another process, which carries on with
+// StartFromState where the loop left off instead of starting from scratch.
+//
+// The zero Handle is a loop that has already ended without an error, and has
+// no state to export.
+type Handle struct {
+	key    string
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
+
+	// mu guards the fields below, which describe the loop between runs.
+	mu      sync.Mutex
+	b       backoff.Backoff
+	clock   Clock
+	lastRun time.Time
+	delay   time.Duration
+	streak  uint64
+	// resets counts calls of Reset, so a run can tell it reset the loop.
+	resets uint64
+}
+
+// Start repeats f in a new goroutine as DoWithOptions does, with a backoff
+// from b, and returns a Handle to stop it or export its state. key identifies
+// the loop, such as the customer it syncs, so the process taking it over can
+// tell which loop a state belongs to.
+//
+// If b is nil or builds an invalid backoff, the loop ends at once and Wait
+// returns an error wrapping backoff.ErrNilBackoff.
+func Start(ctx context.Context, key string, b func() backoff.Backoff, f RepeatFunc, opts ...Option) *Handle {
+	h := &Handle{key: key, clock: newOptions(opts).clock}
+	bo, err := build(b)
+	if err != nil {
+		h.done, h.err = closedChan, err
+		return h
+	}
+	h.b = bo
+	h.start(ctx, 0, f, opts)
+	return h
+}
+
+// StartFromState is like Start, but carries on with the loop whose state
+// ExportState returned, such as in a new process taking over from an old one
+// during a deploy. The backoff from b, which must be built as the exported
+// loop's was, is restored to where the exported loop's had got to, and the
+// failure streak goes on from the exported one.
+//
+// The first run is due when the exported loop's next run was: if that time
+// has passed, f runs at once; otherwise the loop waits for what is left of the
+// delay. The processes' clocks are assumed to agree.
+//
+// It returns an error wrapping backoff.ErrNilBackoff if b is nil or builds an
+// invalid backoff, one wrapping backoff.ErrSnapshotNotSupported if the backoff
+// can't be restored, and one wrapping ErrInvalidState if state is corrupted or
+// was written by a newer, incompatible version.
+func StartFromState(ctx context.Context, state []byte, b func() backoff.Backoff, f RepeatFunc, opts ...Option) (*Handle, error) {
+	var s loopState
+	if err := json.Unmarshal(state, &s); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidState, err)
+	}
+	if s.Version < 1 || s.Version > stateVersion {
+		return nil, fmt.Errorf("%w: version %d is not supported, only up to %d", ErrInvalidState, s.Version, stateVersion)
+	}
+
+	bo, err := build(b)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &Handle{
+		key:     s.Key,
+		b:       bo,
+		clock:   newOptions(opts).clock,
+		lastRun: s.LastRun,
+		delay:   s.Delay,
+		streak:  s.Streak,
+	}
+	if err := backoff.Restore(h.b, s.Backoff); err != nil {
+		if errors.Is(err, backoff.ErrInvalidSnapshot) {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidState, err)
+		}
+		return nil, err
+	}
+
+	var wait time.Duration
+	if !s.LastRun.IsZero() {
+		wait = s.LastRun.Add(s.Delay).Sub(h.clock.Now())
+	}
+	h.start(ctx, wait, f, opts)
+	return h, nil
+}
+
+// ExportState returns the state of the loop h, for StartFromState to carry it
+// on: its key, the snapshot of its backoff, when f last ran and the delay
+// planned after it, and its failure streak. Stop the loop first to hand it
+// over, so the two processes don't both run it. A run that is in progress when
+// the state is exported is not part of it, so the loop carrying on runs f
+// again.
+//
+// It returns an error wrapping backoff.ErrSnapshotNotSupported if the backoff
+// of the loop can't be snapshotted.
+func ExportState(h *Handle) ([]byte, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	b, err := backoff.Snapshot(h.b)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(loopState{
+		Version: stateVersion,
+		Key:     h.key,
+		Backoff: b,
+		LastRun: h.lastRun,
+		Delay:   h.delay,
+		Streak:  h.streak,
+	})
+}
+
+// start runs the loop of h in a new goroutine, after waiting for wait.
+func (h *Handle) start(ctx context.Context, wait time.Duration, f RepeatFunc, opts []Option) {
+	ctx, h.cancel = context.WithCancel(ctx)
+	h.done = make(chan struct{})
+
+	go func() {
+		defer close(h.done)
+		defer h.cancel()
+
+		o := newOptions(opts)
+		if wait > 0 {
+			t := o.clock.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				h.err = ctx.Err()
+				return
+			case <-t.C():
+			}
+		}
+
+		h.err = run(ctx, &handleBackoff{h}, o, func(ctx context.Context) error {
+			h.mu.Lock()
+			resets := h.resets
+			h.mu.Unlock()
+
+			cont := f(ctx)
+
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			// A run that reset the loop ended the streak rather than adding
+			// to it.
+			if h.resets == resets {
+				h.streak++
+			}
+			h.lastRun, h.delay = h.clock.Now(), 0
+			if !cont {
+				return ErrFunctionSignaledToStop
+			}
+			return nil
+		})
+	}()
+}
+
+// Key returns the key that identifies the loop.
+func (h *Handle) Key() string {
+	return h.key
+}
+
+// Streak returns the loop's failure streak: how many runs of f in a row ended
+// without calling Reset, counting the runs before the loop was handed over.
+// While it grows, so does the backoff's delay.
+func (h *Handle) Streak() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.streak
+}
+
+// Reset resets the loop's backoff and ends its failure streak, such as when
+// the dependency it was backing off from is healthy again. A run of f that
+// calls it doesn't count toward the streak. It is safe to call while the loop
+// runs.
+func (h *Handle) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.b != nil {
+		h.b.Reset()
+	}
+	h.streak = 0
+	h.resets++
+}
+
+// Stop stops the loop and waits for it to return. It is safe to call more
+// than once.
+func (h *Handle) Stop() {
+	if h.cancel != nil {
+		h.cancel()
+	}
+	h.Wait()
+}
+
+// Wait waits for the loop to return, and returns its error as DoWithOptions
+// would, or ctx.Err() if the loop was stopped.
+func (h *Handle) Wait() error {
+	if h.done == nil {
+		return nil
+	}
+	<-h.done
+	return h.err
+}
+
+// Done returns a channel that is closed when the loop has returned.
+func (h *Handle) Done() <-chan struct{} {
+	if h.done == nil {
+		return closedChan
+	}
+	return h.done
+}
+
+// closedChan is the Done channel of a loop that never started.
+var closedChan = func() chan struct{} {
+	c := make(chan struct{})
+	close(c)
+	return c
+}()
+
+// handleBackoff is the backoff of a Handle's loop. It records the delay the
+// loop plans after each run of f.
+type handleBackoff struct {
+	h *Handle
+}
+
+// Next implements backoff.Backoff.
+func (b *handleBackoff) Next() (time.Duration, bool) {
+	h := b.h
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	next, stop := h.b.Next()
+	h.delay = next
+	return next, stop
+}
+
+// Reset implements backoff.Backoff.
+func (b *handleBackoff) Reset() {
+	b.h.Reset()
+}
+
+// Unwrap implements backoff.Unwrapper.
+func (b *handleBackoff) Unwrap() backoff.Backoff {
+	return b.h.b
+}
+
+// String describes the loop's backoff.
+func (b *handleBackoff) String() string {
+	return summary.Describe(b.h.b)
+}
@@ -0,0 +1,250 @@
+package repeat
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+func TestHandoff(t *testing.T) {
+	t.Parallel()
+
+	newBackoff := func(t *testing.T) func() backoff.Backoff {
+		return func() backoff.Backoff {
+			b, err := backoff.NewExponential(1 * time.Second)
+			if err != nil {
+				t.Fatalf("failed to create exponential backoff: %v", err)
+			}
+			return backoff.WithMaxRetries(10, b)
+		}
+	}
+
+	stop := func(context.Context) bool { return false }
+
+	// recorder returns f recording when it runs, relative to the clock's
+	// start, on the returned channel.
+	recorder := func(c *manualClock) (RepeatFunc, chan time.Duration) {
+		start := c.Now()
+		runs := make(chan time.Duration, 16)
+		return func(_ context.Context) bool {
+			runs <- c.Now().Sub(start)
+			return true
+		}, runs
+	}
+
+	// Without a handoff, runs are 1s, 2s, 4s and 8s apart.
+	want := []time.Duration{0, 1 * time.Second, 3 * time.Second, 7 * time.Second, 15 * time.Second}
+
+	cases := []struct {
+		name string
+		// gap is how long after the old loop stops the new one starts.
+		gap  time.Duration
+		runs []time.Duration
+	}{
+		{
+			name: "waits_the_remainder",
+			gap:  0,
+			runs: want,
+		},
+		{
+			name: "overdue",
+			gap:  10 * time.Second,
+			runs: []time.Duration{0, 1 * time.Second, 3 * time.Second, 14 * time.Second, 22 * time.Second},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := newManualClock()
+			f, runs := recorder(c)
+
+			old := Start(context.Background(), "customer-1", newBackoff(t), f, WithClock(c))
+			for _, d := range []time.Duration{1 * time.Second, 2 * time.Second} {
+				if got := c.waitTimer(t); got != d {
+					t.Fatalf("expected %v to be %v", got, d)
+				}
+				c.Advance(d)
+			}
+
+			// Hand over a second into the 4s backoff.
+			if got := c.waitTimer(t); got != 4*time.Second {
+				t.Fatalf("expected %v to be %v", got, 4*time.Second)
+			}
+			c.Advance(1 * time.Second)
+			old.Stop()
+			state, err := ExportState(old)
+			if err != nil {
+				t.Fatalf("failed to export state: %v", err)
+			}
+			c.Advance(tc.gap)
+
+			h, err := StartFromState(context.Background(), state, newBackoff(t), f, WithClock(c))
+			if err != nil {
+				t.Fatalf("failed to start from state: %v", err)
+			}
+			defer h.Stop()
+			if got := h.Key(); got != "customer-1" {
+				t.Errorf("expected %q to be %q", got, "customer-1")
+			}
+
+			for len(runs) < len(tc.runs) {
+				c.Advance(c.waitTimer(t))
+			}
+			for i, want := range tc.runs {
+				if got := <-runs; got != want {
+					t.Errorf("run %d: expected %v to be %v", i, got, want)
+				}
+			}
+			if got, want := h.Streak(), uint64(len(tc.runs)); got != want {
+				t.Errorf("expected %d to be %d", got, want)
+			}
+		})
+	}
+
+	t.Run("stops_with_the_backoff", func(t *testing.T) {
+		t.Parallel()
+
+		b := func() backoff.Backoff { return backoff.WithMaxRetries(2, newBackoff(t)()) }
+		c := newManualClock()
+		f, _ := recorder(c)
+
+		old := Start(context.Background(), "k", b, f, WithClock(c))
+		c.waitTimer(t)
+		old.Stop()
+		state, err := ExportState(old)
+		if err != nil {
+			t.Fatalf("failed to export state: %v", err)
+		}
+
+		// One retry is left after the one the old loop was waiting for: the
+		// new loop runs twice and stops.
+		h, err := StartFromState(context.Background(), state, b, f, WithClock(c))
+		if err != nil {
+			t.Fatalf("failed to start from state: %v", err)
+		}
+		c.Advance(c.waitTimer(t))
+		c.Advance(c.waitTimer(t))
+		if err := h.Wait(); err != ErrBackoffSignaledToStop {
+			t.Errorf("expected %q to be %q", err, ErrBackoffSignaledToStop)
+		}
+		if got := h.Streak(); got != 3 {
+			t.Errorf("expected %d to be %d", got, 3)
+		}
+	})
+
+	t.Run("streak", func(t *testing.T) {
+		t.Parallel()
+
+		c := newManualClock()
+		var old *Handle
+		var runs int
+		old = Start(context.Background(), "k", newBackoff(t), func(_ context.Context) bool {
+			// The second run finds the dependency healthy again.
+			if runs++; runs == 2 {
+				old.Reset()
+			}
+			return true
+		}, WithClock(c))
+		for _, d := range []time.Duration{1 * time.Second, 1 * time.Second, 2 * time.Second} {
+			if got := c.waitTimer(t); got != d {
+				t.Fatalf("expected %v to be %v", got, d)
+			}
+			if d == 2*time.Second {
+				break
+			}
+			c.Advance(d)
+		}
+		old.Stop()
+		if got := old.Streak(); got != 1 {
+			t.Errorf("expected %d to be %d", got, 1)
+		}
+		state, err := ExportState(old)
+		if err != nil {
+			t.Fatalf("failed to export state: %v", err)
+		}
+
+		h, err := StartFromState(context.Background(), state, newBackoff(t), stop, WithClock(c))
+		if err != nil {
+			t.Fatalf("failed to start from state: %v", err)
+		}
+		c.Advance(c.waitTimer(t))
+		if err := h.Wait(); err != ErrFunctionSignaledToStop {
+			t.Errorf("expected %q to be %q", err, ErrFunctionSignaledToStop)
+		}
+		if got := h.Streak(); got != 2 {
+			t.Errorf("expected %d to be %d", got, 2)
+		}
+	})
+
+	t.Run("not_snapshottable", func(t *testing.T) {
+		t.Parallel()
+
+		b := func() backoff.Backoff { return backoff.WithMaxDuration(1*time.Minute, newBackoff(t)()) }
+		h := Start(context.Background(), "k", b, stop)
+		h.Wait()
+		if _, err := ExportState(h); !errors.Is(err, backoff.ErrSnapshotNotSupported) {
+			t.Errorf("expected %v to be %v", err, backoff.ErrSnapshotNotSupported)
+		}
+
+		h = Start(context.Background(), "k", newBackoff(t), stop)
+		h.Wait()
+		state, err := ExportState(h)
+		if err != nil {
+			t.Fatalf("failed to export state: %v", err)
+		}
+		if _, err := StartFromState(context.Background(), state, b, stop); !errors.Is(err, backoff.ErrSnapshotNotSupported) {
+			t.Errorf("expected %v to be %v", err, backoff.ErrSnapshotNotSupported)
+		}
+	})
+
+	t.Run("invalid_state", func(t *testing.T) {
+		t.Parallel()
+
+		h := Start(context.Background(), "k", newBackoff(t), stop)
+		h.Wait()
+		state, err := ExportState(h)
+		if err != nil {
+			t.Fatalf("failed to export state: %v", err)
+		}
+
+		for name, state := range map[string][]byte{
+			"corrupted":      state[:len(state)/2],
+			"newer_version":  []byte(`{"version":2,"key":"k"}`),
+			"no_version":     []byte(`{"key":"k"}`),
+			"corrupted_blob": []byte(`{"version":1,"key":"k","backoff":"bm90IGpzb24="}`),
+			"other_backoff":  []byte(`{"version":1,"key":"k","backoff":"eyJwb2xpY3kiOiJjb25zdGFudCgxcykiLCJsYXllcnMiOltudWxsXX0="}`),
+		} {
+			if _, err := StartFromState(context.Background(), state, newBackoff(t), stop); !errors.Is(err, ErrInvalidState) {
+				t.Errorf("%s: expected %v to be %v", name, err, ErrInvalidState)
+			}
+		}
+	})
+
+	t.Run("unknown_fields", func(t *testing.T) {
+		t.Parallel()
+
+		h := Start(context.Background(), "k", newBackoff(t), stop)
+		h.Wait()
+		state, err := ExportState(h)
+		if err != nil {
+			t.Fatalf("failed to export state: %v", err)
+		}
+
+		// A field added by a later, compatible version is ignored.
+		state = append([]byte(`{"added_later":true,`), state[1:]...)
+		h, err = StartFromState(context.Background(), state, newBackoff(t), stop)
+		if err != nil {
+			t.Fatalf("failed to start from state: %v", err)
+		}
+		if err := h.Wait(); err != ErrFunctionSignaledToStop {
+			t.Errorf("expected %q to be %q", err, ErrFunctionSignaledToStop)
+		}
+	})
+}
@@ -0,0 +1,192 @@
+package repeat
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+func TestLabelOf(t *testing.T) {
+	t.Parallel()
+
+	errFoo := fmt.Errorf("foo")
+
+	// Labels are a public contract: changing any of these is a breaking change.
+	cases := []struct {
+		name string
+		err  error
+		exp  string
+	}{
+		{name: "function_signaled_to_stop", err: ErrFunctionSignaledToStop, exp: "function_signaled_to_stop"},
+		{name: "backoff_signaled_to_stop", err: ErrBackoffSignaledToStop, exp: "backoff_signaled_to_stop"},
+		{name: "repeats_disabled", err: ErrRepeatsDisabled, exp: "repeats_disabled"},
+		{name: "nil_backoff", err: backoff.ErrNilBackoff, exp: "nil_backoff"},
+		{name: "invalid_state", err: ErrInvalidState, exp: "invalid_state"},
+		{name: "context_canceled", err: context.Canceled, exp: "context_canceled"},
+		{name: "context_deadline_exceeded", err: context.DeadlineExceeded, exp: "context_deadline_exceeded"},
+		{name: "wrapped", err: fmt.Errorf("%w: %w", ErrFunctionSignaledToStop, errFoo), exp: "function_signaled_to_stop"},
+		{name: "nested", err: fmt.Errorf("outer: %w", fmt.Errorf("%w: %w", ErrBackoffSignaledToStop, errFoo)), exp: "backoff_signaled_to_stop"},
+		{name: "unlabeled", err: errFoo, exp: "unknown"},
+		{name: "nil", err: nil, exp: "unknown"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := LabelOf(tc.err); got != tc.exp {
+				t.Errorf("expected %q to be %q", got, tc.exp)
+			}
+		})
+	}
+}
+
+func TestLabelOf_terminalPaths(t *testing.T) {
+	t.Parallel()
+
+	// Every way the loops can return an error must carry a label, so a new
+	// exit path can't ship unlabeled.
+	for _, tc := range terminalPaths() {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.run(t)
+			if err == nil {
+				t.Fatal("expected err")
+			}
+			if got := LabelOf(err); got == "unknown" {
+				t.Errorf("expected a label for %q", err)
+			}
+		})
+	}
+}
+
+// terminalPath is one way a loop can return an error, and the sentinel that
+// classifies it.
+type terminalPath struct {
+	name  string
+	class error
+	run   func(t *testing.T) error
+}
+
+// terminalPaths lists every way the loops can return an error. Add new exit
+// paths here so they are checked for labels and classification.
+func terminalPaths() []terminalPath {
+	newBackoff := func(t *testing.T, maxRetries uint64) backoff.Backoff {
+		t.Helper()
+
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		return backoff.WithMaxRetries(maxRetries, b)
+	}
+
+	return []terminalPath{
+		{
+			name:  "do_function_stops",
+			class: ErrFunctionSignaledToStop,
+			run: func(t *testing.T) error {
+				return Do(context.Background(), newBackoff(t, 3), func(_ context.Context) bool {
+					return false
+				})
+			},
+		},
+		{
+			name:  "do_backoff_stops",
+			class: ErrBackoffSignaledToStop,
+			run: func(t *testing.T) error {
+				return Do(context.Background(), newBackoff(t, 3), func(_ context.Context) bool {
+					return true
+				})
+			},
+		},
+		{
+			name:  "do_canceled",
+			class: context.Canceled,
+			run: func(t *testing.T) error {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return Do(ctx, newBackoff(t, 3), func(_ context.Context) bool {
+					return true
+				})
+			},
+		},
+		{
+			name:  "do_deadline_while_sleeping",
+			class: context.DeadlineExceeded,
+			run: func(t *testing.T) error {
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+				defer cancel()
+				b, err := backoff.NewConstant(1 * time.Hour)
+				if err != nil {
+					t.Fatalf("failed to create constant backoff: %v", err)
+				}
+				return Do(ctx, b, func(_ context.Context) bool {
+					return true
+				})
+			},
+		},
+		{
+			name:  "do_until_error_function_stops",
+			class: ErrFunctionSignaledToStop,
+			run: func(t *testing.T) error {
+				return DoUntilError(context.Background(), newBackoff(t, 3), func(_ context.Context) error {
+					return fmt.Errorf("%w: oops", ErrFunctionSignaledToStop)
+				})
+			},
+		},
+		{
+			name:  "do_until_error_backoff_stops",
+			class: ErrBackoffSignaledToStop,
+			run: func(t *testing.T) error {
+				return DoUntilError(context.Background(), newBackoff(t, 3), func(_ context.Context) error {
+					return nil
+				})
+			},
+		},
+		{
+			name:  "do_on_signal_backoff_stops",
+			class: ErrBackoffSignaledToStop,
+			run: func(t *testing.T) error {
+				return DoOnSignal(context.Background(), nil, newBackoff(t, 3), func(_ context.Context) error {
+					return fmt.Errorf("oops")
+				})
+			},
+		},
+		{
+			name:  "nil_backoff",
+			class: backoff.ErrNilBackoff,
+			run: func(t *testing.T) error {
+				return Do(context.Background(), nil, func(_ context.Context) bool {
+					return true
+				})
+			},
+		},
+		{
+			name:  "do_on_signal_nil_backoff",
+			class: backoff.ErrNilBackoff,
+			run: func(t *testing.T) error {
+				return DoOnSignal(context.Background(), nil, &backoff.ResettableBackoff{}, func(_ context.Context) error {
+					return nil
+				})
+			},
+		},
+		{
+			name:  "repeats_disabled",
+			class: ErrRepeatsDisabled,
+			run: func(t *testing.T) error {
+				return DoWithOptions(context.Background(), newBackoff(t, 3), func(_ context.Context) bool {
+					return true
+				}, withRepeats(disabledSwitch("incident")))
+			},
+		},
+	}
+}
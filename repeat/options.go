@@ -0,0 +1,98 @@
+package repeat
+
+import (
+	"github.com/swayne275/go-retry/internal/clock"
+	"github.com/swayne275/go-retry/internal/control"
+	"github.com/swayne275/go-retry/internal/summary"
+)
+
+// Clock tells the time and creates timers for the repeat loops. It exists so
+// tests can substitute a fake; see the retrytest package.
+type Clock = clock.Clock
+
+// Timer is a single-shot timer created by a Clock.
+type Timer = clock.Timer
+
+// Option configures the behavior of DoWithOptions and DoUntilErrorWithOptions.
+type Option func(*options)
+
+type options struct {
+	clock Clock
+
+	// fixedRate subtracts the time f took from each sleep.
+	fixedRate bool
+
+	// stopIsSuccess makes a stop signaled by f end the loop with nil.
+	stopIsSuccess bool
+
+	// signalsWhileFailing lets DoOnSignal wake on signals while it is backing
+	// off after a failure.
+	signalsWhileFailing bool
+
+	// costAcc, if set, is charged the cost of the loop under costKey.
+	costAcc *CostAccumulator
+	costKey string
+
+	// delayHistogram, if set, records every sleep between attempts.
+	delayHistogram *DelayHistogram
+
+	// repeats can disable repeats.
+	repeats *control.Switch
+
+	// summary, if enabled, logs one record when the loop ends.
+	summary summary.Config
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		clock:   clock.Real,
+		repeats: control.Global.Repeats,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+	return o
+}
+
+// WithClock makes the loop use c for all of its sleeps instead of the real
+// clock. A nil c selects the real clock.
+func WithClock(c Clock) Option {
+	return func(o *options) {
+		o.clock = clock.Or(c)
+	}
+}
+
+// WithFixedRate makes the loop repeat f at a fixed rate instead of with a
+// fixed delay: the time f took is subtracted from the next backoff value, so
+// the backoff sets the period from the start of one call to the start of the
+// next. When f overruns the period the loop doesn't sleep, but still checks
+// ctx before calling f again.
+func WithFixedRate() Option {
+	return func(o *options) {
+		o.fixedRate = true
+	}
+}
+
+// WithStopIsSuccess makes the loop return nil instead of
+// ErrFunctionSignaledToStop when f signals a clean stop, for loops where f
+// stopping means the work is done. For DoWithOptions that is f returning false;
+// for DoUntilErrorWithOptions it is f returning an error that matches
+// ErrFunctionSignaledToStop, while any other error still ends the loop wrapped
+// in ErrFunctionSignaledToStop. The backoff stopping and ctx being done are
+// reported as usual.
+func WithStopIsSuccess() Option {
+	return func(o *options) {
+		o.stopIsSuccess = true
+	}
+}
+
+// WithSignalsWhileFailing makes DoOnSignal keep waking on signals while it is
+// backing off after a failed run, instead of ignoring them until a run
+// succeeds.
+func WithSignalsWhileFailing() Option {
+	return func(o *options) {
+		o.signalsWhileFailing = true
+	}
+}
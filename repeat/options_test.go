@@ -0,0 +1,200 @@
+package repeat
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+// manualClock is a Clock whose timers only fire when the test advances it.
+// Every timer created is announced on timers.
+type manualClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	pending []*manualTimer
+	timers  chan time.Duration
+}
+
+func newManualClock() *manualClock {
+	return &manualClock{
+		now:    time.Unix(0, 0),
+		timers: make(chan time.Duration, 1024),
+	}
+}
+
+func (c *manualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *manualClock) NewTimer(d time.Duration) Timer {
+	c.mu.Lock()
+	t := &manualTimer{clock: c, c: make(chan time.Time, 1), at: c.now.Add(d)}
+	if d <= 0 {
+		t.fired = true
+		t.c <- c.now
+	} else {
+		c.pending = append(c.pending, t)
+	}
+	c.mu.Unlock()
+
+	c.timers <- d
+	return t
+}
+
+// Advance moves the clock forward by d, firing any timers that come due.
+func (c *manualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	pending := c.pending[:0]
+	for _, t := range c.pending {
+		switch {
+		case t.stopped:
+		case !t.at.After(c.now):
+			t.fired = true
+			t.c <- c.now
+		default:
+			pending = append(pending, t)
+		}
+	}
+	c.pending = pending
+}
+
+// waitTimer blocks until the loop under test creates a timer and returns its
+// duration.
+func (c *manualClock) waitTimer(t *testing.T) time.Duration {
+	t.Helper()
+
+	select {
+	case d := <-c.timers:
+		return d
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a timer")
+		return 0
+	}
+}
+
+type manualTimer struct {
+	clock   *manualClock
+	c       chan time.Time
+	at      time.Time
+	fired   bool
+	stopped bool
+}
+
+func (t *manualTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *manualTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	wasActive := !t.fired && !t.stopped
+	t.stopped = true
+	return wasActive
+}
+
+func TestWithClock(t *testing.T) {
+	t.Parallel()
+
+	b, err := backoff.NewExponential(1 * time.Hour)
+	if err != nil {
+		t.Fatalf("failed to create exponential backoff: %v", err)
+	}
+
+	c := newManualClock()
+	errc := make(chan error, 1)
+	var calls int
+	go func() {
+		errc <- DoWithOptions(context.Background(), b, func(_ context.Context) bool {
+			calls++
+			return calls < 3
+		}, WithClock(c))
+	}()
+
+	for _, want := range []time.Duration{1 * time.Hour, 2 * time.Hour} {
+		if got := c.waitTimer(t); got != want {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+		c.Advance(want)
+	}
+
+	if err := <-errc; err != ErrFunctionSignaledToStop {
+		t.Errorf("expected %q to be %q", err, ErrFunctionSignaledToStop)
+	}
+}
+
+func TestWithFixedRate(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		work   time.Duration
+		sleeps []time.Duration
+		starts []time.Duration
+	}{
+		{
+			name:   "subtracts_work",
+			work:   800 * time.Millisecond,
+			sleeps: []time.Duration{200 * time.Millisecond, 200 * time.Millisecond},
+			starts: []time.Duration{0, 1 * time.Second, 2 * time.Second},
+		},
+		{
+			name:   "overrun",
+			work:   1500 * time.Millisecond,
+			sleeps: []time.Duration{0, 0},
+			starts: []time.Duration{0, 1500 * time.Millisecond, 3 * time.Second},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			b, err := backoff.NewConstant(1 * time.Second)
+			if err != nil {
+				t.Fatalf("failed to create constant backoff: %v", err)
+			}
+
+			c := newManualClock()
+			start := c.Now()
+			var starts []time.Duration
+			errc := make(chan error, 1)
+			go func() {
+				errc <- DoWithOptions(context.Background(), b, func(_ context.Context) bool {
+					starts = append(starts, c.Now().Sub(start))
+					c.Advance(tc.work)
+					return len(starts) < len(tc.starts)
+				}, WithClock(c), WithFixedRate())
+			}()
+
+			for _, want := range tc.sleeps {
+				got := c.waitTimer(t)
+				if got != want {
+					t.Errorf("expected %v to be %v", got, want)
+				}
+				c.Advance(got)
+			}
+
+			if err := <-errc; err != ErrFunctionSignaledToStop {
+				t.Errorf("expected %q to be %q", err, ErrFunctionSignaledToStop)
+			}
+			for i := range tc.starts {
+				if starts[i] != tc.starts[i] {
+					t.Errorf("expected %v to be %v", starts, tc.starts)
+					break
+				}
+			}
+		})
+	}
+}
@@ -2,14 +2,23 @@ package repeat
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/swayne275/go-retry/backoff"
+	"github.com/swayne275/go-retry/internal/clock"
+	"github.com/swayne275/go-retry/internal/cost"
+	"github.com/swayne275/go-retry/internal/label"
 )
 
-var ErrFunctionSignaledToStop = fmt.Errorf("function signaled to stop")
-var ErrBackoffSignaledToStop = fmt.Errorf("backoff signaled to stop")
+// ErrFunctionSignaledToStop is returned when f signals the loop to stop. Its
+// label is "function_signaled_to_stop".
+var ErrFunctionSignaledToStop = label.New("function signaled to stop", "function_signaled_to_stop")
+
+// ErrBackoffSignaledToStop is returned when the backoff signals the loop to
+// stop. Its label is "backoff_signaled_to_stop".
+var ErrBackoffSignaledToStop = label.New("backoff signaled to stop", "backoff_signaled_to_stop")
 
 // RepeatFunc is a function passed to retry.
 // It returns true if the function should be repeated, false otherwise.
@@ -19,39 +28,67 @@ type RepeatFunc func(ctx context.Context) bool
 // the backoff signals to stop.
 // The provided context is passed to the RepeatFunc.
 func Do(ctx context.Context, b backoff.Backoff, f RepeatFunc) error {
-	for {
-		// Return immediately if ctx is canceled
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
+	return DoWithOptions(ctx, b, f)
+}
 
-		if !f(ctx) {
-			return ErrFunctionSignaledToStop
-		}
+// DoWithOptions is like Do, but its behavior can be customized with options.
+// With no options it behaves exactly like Do.
+func DoWithOptions(ctx context.Context, b backoff.Backoff, f RepeatFunc, opts ...Option) error {
+	return doWithCount(ctx, b, newOptions(opts), func(ctx context.Context, _ uint64) bool {
+		return f(ctx)
+	})
+}
 
-		next, stop := b.Next()
-		if stop {
-			return ErrBackoffSignaledToStop
-		}
+// DoUntilDone is like Do, but returns nil when f returns false, since that
+// means the work is done. It only returns an error when the backoff signals to
+// stop (ErrBackoffSignaledToStop) or ctx is done (ctx.Err()). It is a shorthand
+// for DoWithOptions with WithStopIsSuccess.
+func DoUntilDone(ctx context.Context, b backoff.Backoff, f RepeatFunc) error {
+	return DoWithOptions(ctx, b, f, WithStopIsSuccess())
+}
 
-		// ctx.Done() has priority, so we test it alone first
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
+// CountedRepeatFunc is like RepeatFunc, but is also passed the iteration
+// number, starting at 1 for the first call.
+type CountedRepeatFunc func(ctx context.Context, iteration uint64) bool
 
-		t := time.NewTimer(next)
-		select {
-		case <-ctx.Done():
-			t.Stop()
-			return ctx.Err()
-		case <-t.C:
-			continue
+// DoWithCount is like Do, but passes f the iteration number, which starts at 1
+// and increases by one with every call of f.
+func DoWithCount(ctx context.Context, b backoff.Backoff, f CountedRepeatFunc) error {
+	return doWithCount(ctx, b, newOptions(nil), f)
+}
+
+func doWithCount(ctx context.Context, b backoff.Backoff, o options, f CountedRepeatFunc) error {
+	var iteration uint64
+	return run(ctx, b, o, func(ctx context.Context) error {
+		iteration++
+		if !f(ctx, iteration) {
+			if o.stopIsSuccess {
+				return errFinished
+			}
+			return ErrFunctionSignaledToStop
 		}
-	}
+		return nil
+	})
+}
+
+// DoCounted is like Do, but also returns how many times f was called, however
+// the loop ended: f or the backoff signaling to stop, or ctx being done, in
+// which case the count is 0 if ctx was done before the first call.
+func DoCounted(ctx context.Context, b backoff.Backoff, f RepeatFunc) (uint64, error) {
+	var calls uint64
+	err := doWithCount(ctx, b, newOptions(nil), func(ctx context.Context, iteration uint64) bool {
+		calls = iteration
+		return f(ctx)
+	})
+	return calls, err
+}
+
+// DoAtRate is like Do, but repeats f at a fixed rate: the backoff value is the
+// time from the start of one call of f to the start of the next, rather than
+// the delay after f returns. It is a shorthand for DoWithOptions with
+// WithFixedRate.
+func DoAtRate(ctx context.Context, b backoff.Backoff, f RepeatFunc) error {
+	return DoWithOptions(ctx, b, f, WithFixedRate())
 }
 
 // RepeatUntilErrorFunc is a function passed to retry.
@@ -62,6 +99,105 @@ type RepeatUntilErrorFunc func(ctx context.Context) error
 // until the backoff signals to stop.
 // The provided context is passed to the RepeatFunc.
 func DoUntilError(ctx context.Context, b backoff.Backoff, f RepeatUntilErrorFunc) error {
+	return DoUntilErrorWithOptions(ctx, b, f)
+}
+
+// DoUntilErrorWithOptions is like DoUntilError, but its behavior can be
+// customized with options. With no options it behaves exactly like
+// DoUntilError.
+func DoUntilErrorWithOptions(ctx context.Context, b backoff.Backoff, f RepeatUntilErrorFunc, opts ...Option) error {
+	return doUntilErrorWithCount(ctx, b, newOptions(opts), func(ctx context.Context, _ uint64) error {
+		return f(ctx)
+	})
+}
+
+// CountedRepeatUntilErrorFunc is like RepeatUntilErrorFunc, but is also passed
+// the iteration number, starting at 1 for the first call.
+type CountedRepeatUntilErrorFunc func(ctx context.Context, iteration uint64) error
+
+// DoUntilErrorWithCount is like DoUntilError, but passes f the iteration
+// number, which starts at 1 and increases by one with every call of f.
+func DoUntilErrorWithCount(ctx context.Context, b backoff.Backoff, f CountedRepeatUntilErrorFunc) error {
+	return doUntilErrorWithCount(ctx, b, newOptions(nil), f)
+}
+
+func doUntilErrorWithCount(ctx context.Context, b backoff.Backoff, o options, f CountedRepeatUntilErrorFunc) error {
+	var iteration uint64
+	return run(ctx, b, o, func(ctx context.Context) error {
+		iteration++
+		if err := f(ctx, iteration); err != nil {
+			if o.stopIsSuccess && errors.Is(err, ErrFunctionSignaledToStop) {
+				return errFinished
+			}
+			return fmt.Errorf("%w: %w", ErrFunctionSignaledToStop, err)
+		}
+		return nil
+	})
+}
+
+// DoUntil is like DoUntilError, but treats errors from f that match any of
+// successErrs (according to errors.Is) as the loop finishing successfully, and
+// returns nil for them. Other errors stop the loop wrapped in
+// ErrFunctionSignaledToStop, as with DoUntilError. With no successErrs it
+// behaves exactly like DoUntilError.
+//
+// ctx is checked before each call of f, so if ctx is done the loop returns
+// ctx.Err() even if it matches one of successErrs. Only errors returned by f
+// itself are matched.
+func DoUntil(ctx context.Context, b backoff.Backoff, f RepeatUntilErrorFunc, successErrs ...error) error {
+	if len(successErrs) == 0 {
+		return DoUntilError(ctx, b, f)
+	}
+
+	return DoUntilFunc(ctx, b, f, func(err error) bool {
+		for _, target := range successErrs {
+			if errors.Is(err, target) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// DoUntilFunc is like DoUntil, but uses isSuccess to decide which errors
+// from f mean the loop finished successfully. A nil isSuccess behaves exactly
+// like DoUntilError.
+func DoUntilFunc(ctx context.Context, b backoff.Backoff, f RepeatUntilErrorFunc, isSuccess func(err error) bool) error {
+	return run(ctx, b, newOptions(nil), func(ctx context.Context) error {
+		if err := f(ctx); err != nil {
+			if isSuccess != nil && isSuccess(err) {
+				return errFinished
+			}
+			return fmt.Errorf("%w: %w", ErrFunctionSignaledToStop, err)
+		}
+		return nil
+	})
+}
+
+// errFinished is used internally to stop a loop that finished successfully.
+var errFinished = errors.New("finished")
+
+// run is the loop shared by Do and DoUntilError. It calls step until step
+// returns an error, which is returned as is, or the backoff or ctx stop it.
+// errFinished from step ends the loop with nil.
+func run(ctx context.Context, b backoff.Backoff, o options, step func(ctx context.Context) error) error {
+	m := cost.NewMeter(o.costAcc, o.costKey, o.delayHistogram, o.summary.Enabled(), o.clock)
+	err := loop(ctx, b, &o, m, step)
+	if err == errFinished {
+		err = nil
+	}
+	m.Done()
+	logSummary(ctx, &o, m, b, err)
+	return err
+}
+
+// loop is the loop of run, measured by m.
+func loop(ctx context.Context, b backoff.Backoff, o *options, m *cost.Meter, step func(ctx context.Context) error) error {
+	if err := backoff.Validate(b); err != nil {
+		return err
+	}
+
+	sleeper := clock.NewSleeper(o.clock)
 	for {
 		// Return immediately if ctx is canceled
 		select {
@@ -70,14 +206,33 @@ func DoUntilError(ctx context.Context, b backoff.Backoff, f RepeatUntilErrorFunc
 		default:
 		}
 
-		if err := f(ctx); err != nil {
-			return fmt.Errorf("%w: %w", ErrFunctionSignaledToStop, err)
+		var began time.Time
+		if o.fixedRate {
+			began = o.clock.Now()
+		}
+
+		m.Start()
+		err := step(ctx)
+		m.Attempted()
+		if err != nil {
+			return err
 		}
 
 		next, stop := b.Next()
 		if stop {
 			return ErrBackoffSignaledToStop
 		}
+		if next < 0 {
+			// A buggy backoff must not reach the timer.
+			next = 0
+		}
+		if o.fixedRate {
+			// Only sleep for what is left of the period after f ran
+			next -= o.clock.Now().Sub(began)
+			if next < 0 {
+				next = 0
+			}
+		}
 
 		// ctx.Done() has priority, so we test it alone first
 		select {
@@ -86,15 +241,34 @@ func DoUntilError(ctx context.Context, b backoff.Backoff, f RepeatUntilErrorFunc
 		default:
 		}
 
-		t := time.NewTimer(next)
-		select {
-		case <-ctx.Done():
-			t.Stop()
-			return ctx.Err()
-		case <-t.C:
-			continue
+		st := o.repeats.Load()
+		if st.Disabled {
+			return repeatsDisabled(st, nil)
+		}
+
+		m.Start()
+		err = sleeper.Sleep(ctx, next, st.Changed)
+		m.Slept()
+		if err == clock.ErrInterrupted {
+			return repeatsDisabled(o.repeats.Load(), nil)
 		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// build returns the backoff from factory, or an error wrapping
+// backoff.ErrNilBackoff if factory is nil or builds an invalid backoff.
+func build(factory func() backoff.Backoff) (backoff.Backoff, error) {
+	if factory == nil {
+		return nil, fmt.Errorf("%w: no backoff factory", backoff.ErrNilBackoff)
+	}
+	b := factory()
+	if err := backoff.Validate(b); err != nil {
+		return nil, err
 	}
+	return b, nil
 }
 
 // ConstantRepeat is a wrapper around repeat that uses a constant backoff. It will
@@ -129,3 +303,120 @@ func FibonacciRepeat(ctx context.Context, base time.Duration, f RepeatFunc) erro
 	}
 	return Do(ctx, b, f)
 }
+
+// ConstantRepeatUntilError is a wrapper around DoUntilError that uses a
+// constant backoff. It will repeat the function f until it returns an error, or
+// the context is canceled.
+func ConstantRepeatUntilError(ctx context.Context, t time.Duration, f RepeatUntilErrorFunc) error {
+	b, err := backoff.NewConstant(t)
+	if err != nil {
+		return fmt.Errorf("failed to create constant backoff: %w", err)
+	}
+
+	return DoUntilError(ctx, b, f)
+}
+
+// ExponentialRepeatUntilError is a wrapper around DoUntilError that uses an
+// exponential backoff. It will repeat the function f until it returns an error,
+// or the context is canceled.
+func ExponentialRepeatUntilError(ctx context.Context, base time.Duration, f RepeatUntilErrorFunc) error {
+	b, err := backoff.NewExponential(base)
+	if err != nil {
+		return fmt.Errorf("failed to create exponential backoff: %w", err)
+	}
+
+	return DoUntilError(ctx, b, f)
+}
+
+// FibonacciRepeatUntilError is a wrapper around DoUntilError that uses a
+// Fibonacci backoff. It will repeat the function f until it returns an error,
+// or the context is canceled.
+func FibonacciRepeatUntilError(ctx context.Context, base time.Duration, f RepeatUntilErrorFunc) error {
+	b, err := backoff.NewFibonacci(base)
+	if err != nil {
+		return fmt.Errorf("failed to create fibonacci backoff: %w", err)
+	}
+
+	return DoUntilError(ctx, b, f)
+}
+
+// ConstantRepeatN is like ConstantRepeat, but stops after n repeats, returning
+// ErrBackoffSignaledToStop. With n == 0, f is called once and never repeated.
+func ConstantRepeatN(ctx context.Context, t time.Duration, n uint64, f RepeatFunc) error {
+	b, err := backoff.NewConstant(t)
+	if err != nil {
+		return fmt.Errorf("failed to create constant backoff: %w", err)
+	}
+
+	return Do(ctx, backoff.WithMaxRetries(n, b), f)
+}
+
+// ExponentialRepeatN is like ExponentialRepeat, but stops after n repeats,
+// returning ErrBackoffSignaledToStop. With n == 0, f is called once and never
+// repeated.
+func ExponentialRepeatN(ctx context.Context, base time.Duration, n uint64, f RepeatFunc) error {
+	b, err := backoff.NewExponential(base)
+	if err != nil {
+		return fmt.Errorf("failed to create exponential backoff: %w", err)
+	}
+
+	return Do(ctx, backoff.WithMaxRetries(n, b), f)
+}
+
+// FibonacciRepeatN is like FibonacciRepeat, but stops after n repeats,
+// returning ErrBackoffSignaledToStop. With n == 0, f is called once and never
+// repeated.
+func FibonacciRepeatN(ctx context.Context, base time.Duration, n uint64, f RepeatFunc) error {
+	b, err := backoff.NewFibonacci(base)
+	if err != nil {
+		return fmt.Errorf("failed to create fibonacci backoff: %w", err)
+	}
+
+	return Do(ctx, backoff.WithMaxRetries(n, b), f)
+}
+
+// ConstantRepeatCapped is like ConstantRepeatN, but also checks t against cap,
+// like ExponentialRepeatCapped, so all three strategies can be configured the
+// same way. Since a constant backoff never grows, cap has no other effect.
+func ConstantRepeatCapped(ctx context.Context, t, cap time.Duration, maxRepeats uint64, f RepeatFunc) error {
+	b, err := backoff.NewConstant(t)
+	if err != nil {
+		return fmt.Errorf("failed to create constant backoff: %w", err)
+	}
+
+	return doCapped(ctx, b, t, cap, maxRepeats, f)
+}
+
+// ExponentialRepeatCapped is like ExponentialRepeatN, but never sleeps longer
+// than cap between calls: it composes NewExponential, WithCappedDuration and
+// WithMaxRetries. A base or cap that is not positive, or a cap less than base,
+// is returned as an error without calling f.
+func ExponentialRepeatCapped(ctx context.Context, base, cap time.Duration, maxRepeats uint64, f RepeatFunc) error {
+	b, err := backoff.NewExponential(base)
+	if err != nil {
+		return fmt.Errorf("failed to create exponential backoff: %w", err)
+	}
+
+	return doCapped(ctx, b, base, cap, maxRepeats, f)
+}
+
+// FibonacciRepeatCapped is like FibonacciRepeatN, but never sleeps longer than
+// cap between calls, validating its arguments as ExponentialRepeatCapped does.
+func FibonacciRepeatCapped(ctx context.Context, base, cap time.Duration, maxRepeats uint64, f RepeatFunc) error {
+	b, err := backoff.NewFibonacci(base)
+	if err != nil {
+		return fmt.Errorf("failed to create fibonacci backoff: %w", err)
+	}
+
+	return doCapped(ctx, b, base, cap, maxRepeats, f)
+}
+
+// doCapped implements the capped helpers: it repeats f with b, which starts at
+// base, capped at cap and limited to maxRepeats repeats.
+func doCapped(ctx context.Context, b backoff.Backoff, base, cap time.Duration, maxRepeats uint64, f RepeatFunc) error {
+	if cap < base {
+		return fmt.Errorf("invalid cap %v: must be at least the base %v", cap, base)
+	}
+
+	return Do(ctx, backoff.WithMaxRetries(maxRepeats, backoff.WithCappedDuration(cap, b)), f)
+}
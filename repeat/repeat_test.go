@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -135,6 +136,326 @@ func TestDoUntilError(t *testing.T) {
 	})
 }
 
+func TestDoUntilDone(t *testing.T) {
+	t.Parallel()
+
+	t.Run("function_stop_is_nil", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		cnt := 0
+		if err := DoUntilDone(context.Background(), b, func(_ context.Context) bool {
+			cnt++
+			return cnt < 3
+		}); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+		if cnt != 3 {
+			t.Errorf("expected %d to be %d", cnt, 3)
+		}
+	})
+
+	t.Run("backoff_stop", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		err = DoUntilDone(context.Background(), backoff.WithMaxRetries(2, b), func(_ context.Context) bool {
+			return true
+		})
+		if err != ErrBackoffSignaledToStop {
+			t.Errorf("expected %q to be %q", err, ErrBackoffSignaledToStop)
+		}
+	})
+
+	t.Run("context_cancelled", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := DoUntilDone(ctx, b, func(_ context.Context) bool { return false }); err != context.Canceled {
+			t.Errorf("expected %q to be %q", err, context.Canceled)
+		}
+	})
+}
+
+func TestDoUntilErrorWithOptions_stopIsSuccess(t *testing.T) {
+	t.Parallel()
+
+	errFoo := fmt.Errorf("foo")
+	cases := []struct {
+		name string
+		err  error
+		exp  error
+	}{
+		{name: "sentinel", err: ErrFunctionSignaledToStop, exp: nil},
+		{name: "wrapped_sentinel", err: fmt.Errorf("%w: all done", ErrFunctionSignaledToStop), exp: nil},
+		{name: "other_error", err: errFoo, exp: errFoo},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			b, err := backoff.NewConstant(1 * time.Nanosecond)
+			if err != nil {
+				t.Fatalf("failed to create constant backoff: %v", err)
+			}
+
+			err = DoUntilErrorWithOptions(context.Background(), b, func(_ context.Context) error {
+				return tc.err
+			}, WithStopIsSuccess())
+			if tc.exp == nil {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrFunctionSignaledToStop) || !errors.Is(err, tc.exp) {
+				t.Errorf("expected %q to be %q and %q", err, ErrFunctionSignaledToStop, tc.exp)
+			}
+		})
+	}
+}
+
+func TestDoUntil(t *testing.T) {
+	t.Parallel()
+
+	errQueueEmpty := errors.New("queue empty")
+	errBroken := errors.New("broken")
+
+	newBackoff := func(t *testing.T) backoff.Backoff {
+		t.Helper()
+
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		return b
+	}
+
+	// drain returns nil for the first n calls, then err.
+	drain := func(n int, err error) (RepeatUntilErrorFunc, *int) {
+		var cnt int
+		return func(_ context.Context) error {
+			cnt++
+			if cnt > n {
+				return err
+			}
+			return nil
+		}, &cnt
+	}
+
+	t.Run("sentinel_match", func(t *testing.T) {
+		t.Parallel()
+
+		f, cnt := drain(3, fmt.Errorf("wrapped: %w", errQueueEmpty))
+		if err := DoUntil(context.Background(), newBackoff(t), f, errBroken, errQueueEmpty); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+		if *cnt != 4 {
+			t.Errorf("expected %d to be %d", *cnt, 4)
+		}
+	})
+
+	t.Run("predicate_match", func(t *testing.T) {
+		t.Parallel()
+
+		f, _ := drain(2, errQueueEmpty)
+		err := DoUntilFunc(context.Background(), newBackoff(t), f, func(err error) bool {
+			return err.Error() == "queue empty"
+		})
+		if err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("non_matching_error", func(t *testing.T) {
+		t.Parallel()
+
+		f, _ := drain(2, errBroken)
+		err := DoUntil(context.Background(), newBackoff(t), f, errQueueEmpty)
+		if !errors.Is(err, ErrFunctionSignaledToStop) {
+			t.Errorf("expected %q to be %q", err, ErrFunctionSignaledToStop)
+		}
+		if !errors.Is(err, errBroken) {
+			t.Errorf("expected %q to be %q", err, errBroken)
+		}
+
+		f, _ = drain(2, errBroken)
+		err = DoUntilFunc(context.Background(), newBackoff(t), f, func(error) bool { return false })
+		if !errors.Is(err, ErrFunctionSignaledToStop) {
+			t.Errorf("expected %q to be %q", err, ErrFunctionSignaledToStop)
+		}
+	})
+
+	t.Run("empty_list_matches_DoUntilError", func(t *testing.T) {
+		t.Parallel()
+
+		f, _ := drain(2, errQueueEmpty)
+		got := DoUntil(context.Background(), newBackoff(t), f)
+		f, _ = drain(2, errQueueEmpty)
+		want := DoUntilError(context.Background(), newBackoff(t), f)
+		if got == nil || got.Error() != want.Error() {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+		if !errors.Is(got, errQueueEmpty) {
+			t.Errorf("expected %q to be %q", got, errQueueEmpty)
+		}
+
+		maxRetryBackoff := backoff.WithMaxRetries(3, newBackoff(t))
+		if err := DoUntil(context.Background(), maxRetryBackoff, func(_ context.Context) error { return nil }); err != ErrBackoffSignaledToStop {
+			t.Errorf("expected %q to be %q", err, ErrBackoffSignaledToStop)
+		}
+	})
+
+	t.Run("ctx_checked_first", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		var called bool
+		err := DoUntil(ctx, newBackoff(t), func(_ context.Context) error {
+			called = true
+			return nil
+		}, context.Canceled)
+		if err != context.Canceled {
+			t.Errorf("expected %q to be %q", err, context.Canceled)
+		}
+		if called {
+			t.Error("expected f not to be called")
+		}
+	})
+}
+
+func TestDo_cancelDuringSleep(t *testing.T) {
+	t.Parallel()
+
+	// Several short sleeps rearm the loop's timer before a long one, which
+	// canceling ctx must interrupt.
+	var n int
+	b := backoff.BackoffFunc(func() (time.Duration, bool) {
+		n++
+		if n <= 5 {
+			return 1 * time.Nanosecond, false
+		}
+		return time.Hour, false
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	var calls int
+	start := time.Now()
+	err := Do(ctx, b, func(_ context.Context) bool {
+		calls++
+		return true
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v to be %v", err, context.DeadlineExceeded)
+	}
+	if calls != 6 {
+		t.Errorf("expected %d to be %d", calls, 6)
+	}
+	if elapsed := time.Since(start); elapsed > time.Minute {
+		t.Errorf("expected to stop promptly, took %v", elapsed)
+	}
+}
+
+func TestDoAtRate(t *testing.T) {
+	t.Parallel()
+
+	period := 50 * time.Millisecond
+	work := 30 * time.Millisecond
+	b, err := backoff.NewConstant(period)
+	if err != nil {
+		t.Fatalf("failed to create constant backoff: %v", err)
+	}
+
+	var starts []time.Time
+	if err := DoAtRate(context.Background(), b, func(_ context.Context) bool {
+		starts = append(starts, time.Now())
+		time.Sleep(work)
+		return len(starts) < 6
+	}); err != ErrFunctionSignaledToStop {
+		t.Fatalf("expected %q to be %q", err, ErrFunctionSignaledToStop)
+	}
+
+	// With a fixed delay the last call would start 5*work = 150ms late; at a
+	// fixed rate only scheduling latency is left.
+	nominal := starts[0].Add(5 * period)
+	if late := starts[5].Sub(nominal); late > 5*work/2 {
+		t.Errorf("expected last call to start near %v, was %v late", nominal, late)
+	}
+}
+
+func TestDoWithCount(t *testing.T) {
+	t.Parallel()
+
+	const maxRetries = 4
+
+	newBackoff := func(t *testing.T) backoff.Backoff {
+		t.Helper()
+
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		return backoff.WithMaxRetries(maxRetries, b)
+	}
+
+	t.Run("do", func(t *testing.T) {
+		t.Parallel()
+
+		var seen []uint64
+		err := DoWithCount(context.Background(), newBackoff(t), func(_ context.Context, iteration uint64) bool {
+			seen = append(seen, iteration)
+			return true
+		})
+		if !errors.Is(err, ErrBackoffSignaledToStop) {
+			t.Errorf("expected %q to be %q", err, ErrBackoffSignaledToStop)
+		}
+		if got, want := fmt.Sprint(seen), "[1 2 3 4 5]"; got != want {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	})
+
+	t.Run("do_until_error", func(t *testing.T) {
+		t.Parallel()
+
+		var seen []uint64
+		errFoo := fmt.Errorf("foo")
+		err := DoUntilErrorWithCount(context.Background(), newBackoff(t), func(_ context.Context, iteration uint64) error {
+			seen = append(seen, iteration)
+			if iteration == 3 {
+				return errFoo
+			}
+			return nil
+		})
+		if !errors.Is(err, ErrFunctionSignaledToStop) || !errors.Is(err, errFoo) {
+			t.Errorf("expected %q to be %q and %q", err, ErrFunctionSignaledToStop, errFoo)
+		}
+		if got, want := fmt.Sprint(seen), "[1 2 3]"; got != want {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	})
+}
+
 func TestConstantRepeat(t *testing.T) {
 	t.Parallel()
 
@@ -248,3 +569,373 @@ func TestFibonacciRepeat(t *testing.T) {
 		}
 	})
 }
+
+func TestRepeatUntilError(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		repeat  func(context.Context, time.Duration, RepeatUntilErrorFunc) error
+		invalid string
+	}{
+		{name: "constant", repeat: ConstantRepeatUntilError, invalid: "failed to create constant backoff: "},
+		{name: "exponential", repeat: ExponentialRepeatUntilError, invalid: "failed to create exponential backoff: "},
+		{name: "fibonacci", repeat: FibonacciRepeatUntilError, invalid: "failed to create fibonacci backoff: "},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			t.Run("exit_on_context_cancelled", func(t *testing.T) {
+				t.Parallel()
+
+				f := func(_ context.Context) error { return nil }
+				ctx, cancel := context.WithCancel(context.Background())
+				go func() {
+					time.Sleep(10 * time.Nanosecond)
+					cancel()
+				}()
+
+				if err := tc.repeat(ctx, 1*time.Nanosecond, f); err != context.Canceled {
+					t.Errorf("expected %q to be %q", err, context.Canceled)
+				}
+			})
+
+			t.Run("exit_on_RepeatUntilErrorFunc_error", func(t *testing.T) {
+				t.Parallel()
+
+				cnt := 0
+				maxCnt := 3
+				errFoo := fmt.Errorf("foo")
+				f := func(_ context.Context) error {
+					cnt++
+					if cnt > maxCnt {
+						return errFoo
+					}
+					return nil
+				}
+
+				err := tc.repeat(context.Background(), 1*time.Nanosecond, f)
+				if !errors.Is(err, ErrFunctionSignaledToStop) || !errors.Is(err, errFoo) {
+					t.Errorf("expected %q to be %q and %q", err, ErrFunctionSignaledToStop, errFoo)
+				}
+				if cnt != maxCnt+1 {
+					t.Errorf("expected %d to be %d", cnt, maxCnt+1)
+				}
+			})
+
+			t.Run("invalid_backoff", func(t *testing.T) {
+				t.Parallel()
+
+				err := tc.repeat(context.Background(), 0, func(_ context.Context) error { return nil })
+				if err == nil || !strings.HasPrefix(err.Error(), tc.invalid) {
+					t.Errorf("expected %q to start with %q", err, tc.invalid)
+				}
+			})
+		})
+	}
+}
+
+func TestDoCounted(t *testing.T) {
+	t.Parallel()
+
+	newBackoff := func(t *testing.T) backoff.Backoff {
+		t.Helper()
+
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		return b
+	}
+
+	t.Run("function_stops", func(t *testing.T) {
+		t.Parallel()
+
+		calls := 0
+		n, err := DoCounted(context.Background(), newBackoff(t), func(_ context.Context) bool {
+			calls++
+			return calls < 3
+		})
+		if !errors.Is(err, ErrFunctionSignaledToStop) {
+			t.Errorf("expected %q to be %q", err, ErrFunctionSignaledToStop)
+		}
+		if n != 3 {
+			t.Errorf("expected %d to be 3", n)
+		}
+	})
+
+	t.Run("backoff_stops", func(t *testing.T) {
+		t.Parallel()
+
+		n, err := DoCounted(context.Background(), backoff.WithMaxRetries(4, newBackoff(t)), func(_ context.Context) bool {
+			return true
+		})
+		if !errors.Is(err, ErrBackoffSignaledToStop) {
+			t.Errorf("expected %q to be %q", err, ErrBackoffSignaledToStop)
+		}
+		if n != 5 {
+			t.Errorf("expected %d to be 5", n)
+		}
+	})
+
+	t.Run("context_canceled", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		calls := 0
+		n, err := DoCounted(ctx, newBackoff(t), func(_ context.Context) bool {
+			calls++
+			if calls == 2 {
+				cancel()
+			}
+			return true
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected %q to be %q", err, context.Canceled)
+		}
+		if n != 2 {
+			t.Errorf("expected %d to be 2", n)
+		}
+	})
+
+	t.Run("context_canceled_before_first", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		n, err := DoCounted(ctx, newBackoff(t), func(_ context.Context) bool {
+			t.Error("expected f not to be called")
+			return true
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected %q to be %q", err, context.Canceled)
+		}
+		if n != 0 {
+			t.Errorf("expected %d to be 0", n)
+		}
+	})
+}
+
+func TestDo_negativeBackoff(t *testing.T) {
+	t.Parallel()
+
+	c := newManualClock()
+	b := backoff.WithMaxRetries(3, backoff.BackoffFunc(func() (time.Duration, bool) {
+		return -1 * time.Second, false
+	}))
+
+	calls := 0
+	err := DoWithOptions(context.Background(), b, func(_ context.Context) bool {
+		calls++
+		return true
+	}, WithClock(c))
+	if !errors.Is(err, ErrBackoffSignaledToStop) {
+		t.Errorf("expected %q to be %q", err, ErrBackoffSignaledToStop)
+	}
+	if calls != 4 {
+		t.Errorf("expected %d to be 4", calls)
+	}
+	for i := 0; i < 3; i++ {
+		if d := c.waitTimer(t); d != 0 {
+			t.Errorf("expected timer %d of %v to be 0", i, d)
+		}
+	}
+}
+
+func TestRepeatN(t *testing.T) {
+	t.Parallel()
+
+	helpers := []struct {
+		name string
+		fn   func(ctx context.Context, base time.Duration, n uint64, f RepeatFunc) error
+	}{
+		{name: "constant", fn: ConstantRepeatN},
+		{name: "exponential", fn: ExponentialRepeatN},
+		{name: "fibonacci", fn: FibonacciRepeatN},
+	}
+
+	cases := []struct {
+		name      string
+		base      time.Duration
+		n         uint64
+		stopAfter int
+		expCalls  int
+		expErr    error
+		expConfig bool
+	}{
+		{
+			name:      "function_stops_first",
+			base:      1 * time.Nanosecond,
+			n:         5,
+			stopAfter: 3,
+			expCalls:  3,
+			expErr:    ErrFunctionSignaledToStop,
+		},
+		{
+			name:      "exhaustion",
+			base:      1 * time.Nanosecond,
+			n:         3,
+			stopAfter: 100,
+			expCalls:  4,
+			expErr:    ErrBackoffSignaledToStop,
+		},
+		{
+			name:      "zero_repeats",
+			base:      1 * time.Nanosecond,
+			n:         0,
+			stopAfter: 100,
+			expCalls:  1,
+			expErr:    ErrBackoffSignaledToStop,
+		},
+		{
+			name:      "bad_base",
+			base:      0,
+			n:         3,
+			expCalls:  0,
+			expConfig: true,
+		},
+	}
+
+	for _, h := range helpers {
+		for _, tc := range cases {
+			h, tc := h, tc
+
+			t.Run(h.name+"/"+tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				calls := 0
+				err := h.fn(context.Background(), tc.base, tc.n, func(_ context.Context) bool {
+					calls++
+					return calls < tc.stopAfter
+				})
+
+				if calls != tc.expCalls {
+					t.Errorf("expected %d to be %d", calls, tc.expCalls)
+				}
+				if tc.expConfig {
+					if err == nil || errors.Is(err, ErrBackoffSignaledToStop) || errors.Is(err, ErrFunctionSignaledToStop) {
+						t.Errorf("expected a construction error, got %v", err)
+					}
+					return
+				}
+				if !errors.Is(err, tc.expErr) {
+					t.Errorf("expected %q to be %q", err, tc.expErr)
+				}
+			})
+		}
+	}
+}
+
+func TestRepeatCapped(t *testing.T) {
+	t.Parallel()
+
+	helpers := []struct {
+		name string
+		fn   func(ctx context.Context, base, cap time.Duration, maxRepeats uint64, f RepeatFunc) error
+	}{
+		{name: "constant", fn: ConstantRepeatCapped},
+		{name: "exponential", fn: ExponentialRepeatCapped},
+		{name: "fibonacci", fn: FibonacciRepeatCapped},
+	}
+
+	cases := []struct {
+		name      string
+		base      time.Duration
+		cap       time.Duration
+		n         uint64
+		stopAfter int
+		expCalls  int
+		expErr    error
+		expConfig bool
+	}{
+		{
+			name:      "function_stops_first",
+			base:      1 * time.Nanosecond,
+			cap:       1 * time.Nanosecond,
+			n:         5,
+			stopAfter: 3,
+			expCalls:  3,
+			expErr:    ErrFunctionSignaledToStop,
+		},
+		{
+			name:      "exhaustion",
+			base:      1 * time.Nanosecond,
+			cap:       1 * time.Nanosecond,
+			n:         3,
+			stopAfter: 100,
+			expCalls:  4,
+			expErr:    ErrBackoffSignaledToStop,
+		},
+		{
+			name:      "bad_base",
+			base:      0,
+			cap:       1 * time.Second,
+			n:         3,
+			expConfig: true,
+		},
+		{
+			name:      "cap_below_base",
+			base:      2 * time.Second,
+			cap:       1 * time.Second,
+			n:         3,
+			expConfig: true,
+		},
+	}
+
+	for _, h := range helpers {
+		for _, tc := range cases {
+			h, tc := h, tc
+
+			t.Run(h.name+"/"+tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				calls := 0
+				err := h.fn(context.Background(), tc.base, tc.cap, tc.n, func(_ context.Context) bool {
+					calls++
+					return calls < tc.stopAfter
+				})
+
+				if calls != tc.expCalls {
+					t.Errorf("expected %d to be %d", calls, tc.expCalls)
+				}
+				if tc.expConfig {
+					if err == nil || errors.Is(err, ErrBackoffSignaledToStop) || errors.Is(err, ErrFunctionSignaledToStop) {
+						t.Errorf("expected a construction error, got %v", err)
+					}
+					return
+				}
+				if !errors.Is(err, tc.expErr) {
+					t.Errorf("expected %q to be %q", err, tc.expErr)
+				}
+			})
+		}
+	}
+
+	// Uncapped, 8 repeats from 1ms sleep 255ms in total with the exponential
+	// backoff and 54ms with the Fibonacci one; capped at 1ms, they sleep 8ms.
+	for _, h := range helpers {
+		h := h
+
+		t.Run(h.name+"/cap_observed", func(t *testing.T) {
+			t.Parallel()
+
+			start := time.Now()
+			err := h.fn(context.Background(), 1*time.Millisecond, 1*time.Millisecond, 8, func(_ context.Context) bool {
+				return true
+			})
+			if !errors.Is(err, ErrBackoffSignaledToStop) {
+				t.Errorf("expected %q to be %q", err, ErrBackoffSignaledToStop)
+			}
+			if elapsed := time.Since(start); elapsed > 40*time.Millisecond {
+				t.Errorf("expected %v to be at most 40ms", elapsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package repeat
+
+import (
+	"context"
+
+	"github.com/swayne275/go-retry/backoff"
+	"github.com/swayne275/go-retry/internal/label"
+)
+
+// sentinels lists every terminal classification of the errors returned by
+// the loops in this package. Add new terminal sentinels here.
+var sentinels = []error{
+	ErrFunctionSignaledToStop,
+	ErrBackoffSignaledToStop,
+	ErrRepeatsDisabled,
+	backoff.ErrNilBackoff,
+	context.Canceled,
+	context.DeadlineExceeded,
+}
+
+// AllSentinels returns every sentinel that classifies an error returned by the
+// loops in this package, including the context errors they return as is. Use
+// it with Classify to handle errors exhaustively: the list grows when a new
+// kind of terminal error is added.
+func AllSentinels() []error {
+	return append([]error(nil), sentinels...)
+}
+
+// Classify returns the sentinel from AllSentinels that classifies err, or nil
+// if there is none. An error wrapping several sentinels is classified by the
+// outermost one.
+func Classify(err error) error {
+	return label.Classify(err, sentinels)
+}
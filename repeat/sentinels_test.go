@@ -0,0 +1,59 @@
+package repeat
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestAllSentinels(t *testing.T) {
+	t.Parallel()
+
+	covered := make(map[error]bool)
+	for _, tc := range terminalPaths() {
+		err := tc.run(t)
+		got := Classify(err)
+		if got != tc.class {
+			t.Errorf("%s: expected %q to be classified as %q, got %v", tc.name, err, tc.class, got)
+		}
+		covered[got] = true
+	}
+
+	// Every listed sentinel must be reachable, so the list can't keep
+	// sentinels the loops no longer return.
+	for _, s := range AllSentinels() {
+		if !covered[s] {
+			t.Errorf("no terminal path is classified as %q", s)
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	t.Parallel()
+
+	errFoo := fmt.Errorf("foo")
+
+	cases := []struct {
+		name string
+		err  error
+		exp  error
+	}{
+		{name: "sentinel", err: ErrBackoffSignaledToStop, exp: ErrBackoffSignaledToStop},
+		{name: "wrapped", err: fmt.Errorf("%w: %w", ErrFunctionSignaledToStop, errFoo), exp: ErrFunctionSignaledToStop},
+		{name: "outermost_wins", err: fmt.Errorf("%w: %w", ErrFunctionSignaledToStop, context.Canceled), exp: ErrFunctionSignaledToStop},
+		{name: "unclassified", err: errFoo, exp: nil},
+		{name: "nil", err: nil, exp: nil},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := Classify(tc.err); got != tc.exp {
+				t.Errorf("expected %v to be %v", got, tc.exp)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package repeat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/swayne275/go-retry/backoff"
+	"github.com/swayne275/go-retry/internal/clock"
+	"github.com/swayne275/go-retry/internal/cost"
+)
+
+// DoOnSignal runs f every time signal fires, and at the latest every time the
+// fallback backoff's delay elapses, as a safety net for missed signals.
+//
+// A run where f returns nil resets the fallback backoff. A run where f returns
+// an error switches the loop to backing off: it ignores signals and waits out
+// the fallback's delays between runs until f succeeds again, after which it
+// goes back to waking on signals. Use WithSignalsWhileFailing to keep waking on
+// signals while backing off.
+//
+// Signals that arrive while f is running are coalesced into a single follow-up
+// run, as are any signals still pending when a run starts. A closed signal
+// channel is treated as one that never fires again.
+//
+// The loop stops and returns ctx.Err() when ctx is done, returns the error from
+// f as is if it wraps ErrFunctionSignaledToStop, and returns an error wrapping
+// ErrBackoffSignaledToStop, and the last error from f if the loop was backing
+// off, when the fallback backoff signals to stop.
+func DoOnSignal(ctx context.Context, signal <-chan struct{}, fallback backoff.Backoff, f RepeatUntilErrorFunc, opts ...Option) error {
+	o := newOptions(opts)
+	m := cost.NewMeter(o.costAcc, o.costKey, o.delayHistogram, o.summary.Enabled(), o.clock)
+	err := doOnSignal(ctx, signal, fallback, f, &o, m)
+	m.Done()
+	logSummary(ctx, &o, m, fallback, err)
+	return err
+}
+
+// doOnSignal is the loop of DoOnSignal, measured by m.
+func doOnSignal(ctx context.Context, signal <-chan struct{}, fallback backoff.Backoff, f RepeatUntilErrorFunc, o *options, m *cost.Meter) error {
+	if err := backoff.Validate(fallback); err != nil {
+		return err
+	}
+
+	var lastErr error
+	sleeper := clock.NewSleeper(o.clock)
+	for {
+		// Return immediately if ctx is canceled
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		signal = drain(signal)
+
+		m.Start()
+		err := f(ctx)
+		m.Attempted()
+		switch {
+		case err == nil:
+			lastErr = nil
+			fallback.Reset()
+		case errors.Is(err, ErrFunctionSignaledToStop):
+			return err
+		default:
+			lastErr = err
+		}
+
+		next, stop := fallback.Next()
+		if stop {
+			if lastErr != nil {
+				return fmt.Errorf("%w: %w", ErrBackoffSignaledToStop, lastErr)
+			}
+			return ErrBackoffSignaledToStop
+		}
+
+		// ctx.Done() has priority, so we test it alone first
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		st := o.repeats.Load()
+		if st.Disabled {
+			return repeatsDisabled(st, lastErr)
+		}
+
+		listen := signal
+		if lastErr != nil && !o.signalsWhileFailing {
+			listen = nil
+		}
+
+		m.Start()
+		t := sleeper.Timer(next)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			m.Slept()
+			return ctx.Err()
+		case <-st.Changed:
+			t.Stop()
+			m.Slept()
+			return repeatsDisabled(o.repeats.Load(), lastErr)
+		case <-t.C():
+		case _, ok := <-listen:
+			t.Stop()
+			if !ok {
+				signal = nil
+			}
+		}
+		m.Slept()
+	}
+}
+
+// drain discards any pending signals so they are all satisfied by the run
+// about to start. It returns nil if signal has been closed.
+func drain(signal <-chan struct{}) <-chan struct{} {
+	for signal != nil {
+		select {
+		case _, ok := <-signal:
+			if !ok {
+				return nil
+			}
+		default:
+			return signal
+		}
+	}
+	return nil
+}
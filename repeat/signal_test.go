@@ -0,0 +1,315 @@
+package repeat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+// resetCounter counts how many times the backoff it wraps is reset.
+type resetCounter struct {
+	backoff.Backoff
+	resets int64
+}
+
+func (b *resetCounter) Reset() {
+	atomic.AddInt64(&b.resets, 1)
+	b.Backoff.Reset()
+}
+
+func expectRun(t *testing.T, runs <-chan int, want int) {
+	t.Helper()
+
+	select {
+	case got := <-runs:
+		if got != want {
+			t.Fatalf("expected run %d, got run %d", want, got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for run %d", want)
+	}
+}
+
+func expectNoRun(t *testing.T, runs <-chan int) {
+	t.Helper()
+
+	select {
+	case got := <-runs:
+		t.Fatalf("unexpected run %d", got)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestDoOnSignal(t *testing.T) {
+	t.Parallel()
+
+	t.Run("signal_wakes_before_fallback", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewConstant(1 * time.Hour)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		c := newManualClock()
+		signal := make(chan struct{}, 1)
+		runs := make(chan int, 10)
+		errc := make(chan error, 1)
+		var n int
+		go func() {
+			errc <- DoOnSignal(ctx, signal, b, func(_ context.Context) error {
+				n++
+				runs <- n
+				return nil
+			}, WithClock(c))
+		}()
+
+		expectRun(t, runs, 1)
+		if got := c.waitTimer(t); got != 1*time.Hour {
+			t.Errorf("expected %v to be %v", got, 1*time.Hour)
+		}
+		signal <- struct{}{}
+		expectRun(t, runs, 2)
+		if got := c.Now(); !got.Equal(time.Unix(0, 0)) {
+			t.Errorf("expected no time to pass, got %v", got)
+		}
+
+		c.waitTimer(t)
+		cancel()
+		if err := <-errc; err != context.Canceled {
+			t.Errorf("expected %q to be %q", err, context.Canceled)
+		}
+	})
+
+	t.Run("fallback_fires_without_signal", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewConstant(1 * time.Minute)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		c := newManualClock()
+		runs := make(chan int, 10)
+		var n int
+		go func() {
+			_ = DoOnSignal(ctx, make(chan struct{}), b, func(_ context.Context) error {
+				n++
+				runs <- n
+				return nil
+			}, WithClock(c))
+		}()
+
+		expectRun(t, runs, 1)
+		c.waitTimer(t)
+		c.Advance(59 * time.Second)
+		expectNoRun(t, runs)
+		c.Advance(1 * time.Second)
+		expectRun(t, runs, 2)
+	})
+
+	t.Run("failure_backs_off_and_ignores_signals", func(t *testing.T) {
+		t.Parallel()
+
+		exp, err := backoff.NewExponential(1 * time.Second)
+		if err != nil {
+			t.Fatalf("failed to create exponential backoff: %v", err)
+		}
+		b := &resetCounter{Backoff: exp}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		c := newManualClock()
+		signal := make(chan struct{}, 1)
+		runs := make(chan int, 10)
+		var n int
+		go func() {
+			_ = DoOnSignal(ctx, signal, b, func(_ context.Context) error {
+				n++
+				runs <- n
+				if n == 1 || n == 2 {
+					return fmt.Errorf("failure %d", n)
+				}
+				return nil
+			}, WithClock(c))
+		}()
+
+		expectRun(t, runs, 1)
+		if got := c.waitTimer(t); got != 1*time.Second {
+			t.Errorf("expected %v to be %v", got, 1*time.Second)
+		}
+		signal <- struct{}{}
+		expectNoRun(t, runs)
+
+		c.Advance(1 * time.Second)
+		expectRun(t, runs, 2)
+		if got := c.waitTimer(t); got != 2*time.Second {
+			t.Errorf("expected %v to be %v", got, 2*time.Second)
+		}
+
+		c.Advance(2 * time.Second)
+		expectRun(t, runs, 3)
+
+		// Success resets the fallback and resumes signal-driven runs.
+		if got := c.waitTimer(t); got != 1*time.Second {
+			t.Errorf("expected %v to be %v", got, 1*time.Second)
+		}
+		if got := atomic.LoadInt64(&b.resets); got != 1 {
+			t.Errorf("expected %d to be %d", got, 1)
+		}
+		signal <- struct{}{}
+		expectRun(t, runs, 4)
+	})
+
+	t.Run("signals_while_failing", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewConstant(1 * time.Hour)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		c := newManualClock()
+		signal := make(chan struct{}, 1)
+		runs := make(chan int, 10)
+		var n int
+		go func() {
+			_ = DoOnSignal(ctx, signal, b, func(_ context.Context) error {
+				n++
+				runs <- n
+				return fmt.Errorf("failure %d", n)
+			}, WithClock(c), WithSignalsWhileFailing())
+		}()
+
+		expectRun(t, runs, 1)
+		c.waitTimer(t)
+		signal <- struct{}{}
+		expectRun(t, runs, 2)
+	})
+
+	t.Run("coalesces_signal_burst", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewConstant(1 * time.Hour)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		c := newManualClock()
+		signal := make(chan struct{}, 10)
+		runs := make(chan int, 10)
+		proceed := make(chan struct{})
+		var n int
+		go func() {
+			_ = DoOnSignal(ctx, signal, b, func(_ context.Context) error {
+				n++
+				runs <- n
+				if n == 2 {
+					<-proceed
+				}
+				return nil
+			}, WithClock(c))
+		}()
+
+		expectRun(t, runs, 1)
+		c.waitTimer(t)
+		signal <- struct{}{}
+		expectRun(t, runs, 2)
+
+		// A burst while run 2 is in progress.
+		for i := 0; i < 5; i++ {
+			signal <- struct{}{}
+		}
+		close(proceed)
+
+		c.waitTimer(t)
+		expectRun(t, runs, 3)
+		c.waitTimer(t)
+		expectNoRun(t, runs)
+	})
+
+	t.Run("closed_signal_falls_back_to_backoff", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewConstant(1 * time.Hour)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		c := newManualClock()
+		signal := make(chan struct{})
+		runs := make(chan int, 10)
+		var n int
+		go func() {
+			_ = DoOnSignal(ctx, signal, b, func(_ context.Context) error {
+				n++
+				runs <- n
+				return nil
+			}, WithClock(c))
+		}()
+
+		expectRun(t, runs, 1)
+		c.waitTimer(t)
+		close(signal)
+		expectRun(t, runs, 2)
+		c.waitTimer(t)
+		expectNoRun(t, runs)
+		c.Advance(1 * time.Hour)
+		expectRun(t, runs, 3)
+	})
+
+	t.Run("terminal_paths", func(t *testing.T) {
+		t.Parallel()
+
+		newBackoff := func(max uint64) backoff.Backoff {
+			return backoff.WithMaxRetries(max, backoff.BackoffFunc(func() (time.Duration, bool) {
+				return 1 * time.Nanosecond, false
+			}))
+		}
+
+		errStop := fmt.Errorf("%w: done", ErrFunctionSignaledToStop)
+		err := DoOnSignal(context.Background(), nil, newBackoff(10), func(_ context.Context) error {
+			return errStop
+		})
+		if err != errStop {
+			t.Errorf("expected %q to be %q", err, errStop)
+		}
+
+		// Success resets the fallback, so only a backoff that stops outright
+		// ends a healthy loop.
+		stopped := backoff.BackoffFunc(func() (time.Duration, bool) {
+			return 0, true
+		})
+		err = DoOnSignal(context.Background(), nil, stopped, func(_ context.Context) error {
+			return nil
+		})
+		if err != ErrBackoffSignaledToStop {
+			t.Errorf("expected %q to be %q", err, ErrBackoffSignaledToStop)
+		}
+
+		errFlaky := fmt.Errorf("flaky")
+		err = DoOnSignal(context.Background(), nil, newBackoff(2), func(_ context.Context) error {
+			return errFlaky
+		})
+		if !errors.Is(err, ErrBackoffSignaledToStop) {
+			t.Errorf("expected %q to be %q", err, ErrBackoffSignaledToStop)
+		}
+		if !errors.Is(err, errFlaky) {
+			t.Errorf("expected %q to be %q", err, errFlaky)
+		}
+	})
+}
@@ -0,0 +1,48 @@
+package repeat
+
+import (
+	"context"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+// Stream repeats f like Do, in a new goroutine, sending the value of every
+// call on the first channel as it is produced. The loop stops when f returns
+// false, whose value is still sent, when the backoff signals to stop, or when
+// ctx is done. Both channels are then closed, exactly once.
+//
+// The value channel is unbuffered, so a slow consumer applies backpressure:
+// the next call of f is not made, nor its sleep started, until the value of
+// the previous one has been received. A consumer that stops reading must
+// cancel ctx to end the loop; the value being sent is then dropped.
+//
+// The error channel receives at most one error before it is closed: nothing
+// when f returns false, ErrBackoffSignaledToStop when the backoff stops, and
+// ctx.Err() when ctx is done. It is buffered, so it can be read after the
+// value channel is drained.
+func Stream[T any](ctx context.Context, b backoff.Backoff, f func(ctx context.Context) (T, bool), opts ...Option) (<-chan T, <-chan error) {
+	values := make(chan T)
+	errs := make(chan error, 1)
+
+	go func() {
+		err := run(ctx, b, newOptions(opts), func(ctx context.Context) error {
+			v, more := f(ctx)
+			select {
+			case values <- v:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			if !more {
+				return errFinished
+			}
+			return nil
+		})
+		close(values)
+		if err != nil {
+			errs <- err
+		}
+		close(errs)
+	}()
+
+	return values, errs
+}
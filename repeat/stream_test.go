@@ -0,0 +1,152 @@
+package repeat
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+func TestStream(t *testing.T) {
+	t.Parallel()
+
+	newBackoff := func(t *testing.T) backoff.Backoff {
+		t.Helper()
+
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		return b
+	}
+
+	// counter produces 1, 2, 3, ... forever.
+	counter := func() func(context.Context) (int, bool) {
+		var n int
+		return func(_ context.Context) (int, bool) {
+			n++
+			return n, true
+		}
+	}
+
+	t.Run("read_then_cancel", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		values, errs := Stream(ctx, newBackoff(t), counter())
+
+		var got []int
+		for v := range values {
+			got = append(got, v)
+			if len(got) == 5 {
+				cancel()
+				break
+			}
+		}
+		if exp := []int{1, 2, 3, 4, 5}; !reflect.DeepEqual(got, exp) {
+			t.Errorf("expected %v to be %v", got, exp)
+		}
+
+		// The loop ends without anyone reading the value it was sending.
+		if err := <-errs; !errors.Is(err, context.Canceled) {
+			t.Errorf("expected %v to be %v", err, context.Canceled)
+		}
+		if _, ok := <-errs; ok {
+			t.Error("expected the error channel to be closed")
+		}
+		for range values {
+		}
+	})
+
+	t.Run("stops_when_f_returns_false", func(t *testing.T) {
+		t.Parallel()
+
+		var n int
+		values, errs := Stream(context.Background(), newBackoff(t), func(_ context.Context) (string, bool) {
+			n++
+			return string(rune('a' + n - 1)), n < 3
+		})
+
+		var got []string
+		for v := range values {
+			got = append(got, v)
+		}
+		if exp := []string{"a", "b", "c"}; !reflect.DeepEqual(got, exp) {
+			t.Errorf("expected %v to be %v", got, exp)
+		}
+		if err, ok := <-errs; ok {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("backoff_stops", func(t *testing.T) {
+		t.Parallel()
+
+		values, errs := Stream(context.Background(), backoff.WithMaxRetries(2, newBackoff(t)), counter())
+
+		var got []int
+		for v := range values {
+			got = append(got, v)
+		}
+		if exp := []int{1, 2, 3}; !reflect.DeepEqual(got, exp) {
+			t.Errorf("expected %v to be %v", got, exp)
+		}
+		if err := <-errs; !errors.Is(err, ErrBackoffSignaledToStop) {
+			t.Errorf("expected %v to be %v", err, ErrBackoffSignaledToStop)
+		}
+	})
+
+	t.Run("backpressure", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		calls := make(chan int, 10)
+		var n int
+		values, errs := Stream(ctx, newBackoff(t), func(_ context.Context) (int, bool) {
+			n++
+			calls <- n
+			return n, true
+		})
+
+		// Until the first value is received, f is not called again.
+		<-calls
+		select {
+		case c := <-calls:
+			t.Fatalf("expected no call before the first value was received, got call %d", c)
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		if v := <-values; v != 1 {
+			t.Errorf("expected %d to be %d", v, 1)
+		}
+		if c := <-calls; c != 2 {
+			t.Errorf("expected %d to be %d", c, 2)
+		}
+
+		cancel()
+		for range values {
+		}
+		if err := <-errs; !errors.Is(err, context.Canceled) {
+			t.Errorf("expected %v to be %v", err, context.Canceled)
+		}
+	})
+
+	t.Run("nil_backoff", func(t *testing.T) {
+		t.Parallel()
+
+		values, errs := Stream(context.Background(), nil, counter())
+		for v := range values {
+			t.Errorf("expected no values, got %d", v)
+		}
+		if err := <-errs; !errors.Is(err, backoff.ErrNilBackoff) {
+			t.Errorf("expected %v to be %v", err, backoff.ErrNilBackoff)
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package repeat
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/swayne275/go-retry/backoff"
+	"github.com/swayne275/go-retry/internal/cost"
+)
+
+// WithSummaryLog makes the loop log exactly one record to logger at level when
+// it ends, however it ends. The record, with the message "repeat summary", has
+// these attributes:
+//
+//   - operation: operationName
+//   - outcome: "finished" if the loop returned nil, or the LabelOf the error
+//     returned, such as "function_signaled_to_stop"
+//   - attempts: the number of times f was called
+//   - total_sleep_ms: the time slept between runs, in milliseconds
+//   - elapsed_ms: the time the whole loop took, in milliseconds
+//   - error: the message of the error returned, or ""
+//   - policy: the backoff's String method if it has one, or its type
+//
+// Loops canceled before their first run are not logged, unless
+// WithSummaryLogOnEarlyCancel is also given. A nil logger logs nothing.
+func WithSummaryLog(logger *slog.Logger, level slog.Level, operationName string) Option {
+	return func(o *options) {
+		o.summary.Logger = logger
+		o.summary.Level = level
+		o.summary.Operation = operationName
+	}
+}
+
+// WithSummaryLogOnEarlyCancel makes WithSummaryLog also log loops canceled
+// before their first run.
+func WithSummaryLogOnEarlyCancel() Option {
+	return func(o *options) {
+		o.summary.EarlyCancel = true
+	}
+}
+
+// logSummary logs the summary of a loop that returned err, if enabled.
+func logSummary(ctx context.Context, o *options, m *cost.Meter, b backoff.Backoff, err error) {
+	if !o.summary.Enabled() {
+		return
+	}
+
+	outcome := "finished"
+	if err != nil {
+		outcome = LabelOf(err)
+	}
+	o.summary.Log(ctx, "repeat summary", m, b, outcome, err)
+}
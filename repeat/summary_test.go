@@ -0,0 +1,178 @@
+package repeat
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+// captureHandler is a slog.Handler that keeps every record it handles.
+type captureHandler struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *captureHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *captureHandler) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h *captureHandler) WithGroup(string) slog.Handler            { return h }
+
+func (h *captureHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.records = append(h.records, r.Clone())
+	return nil
+}
+
+// attrs returns the attributes of every record handled so far.
+func (h *captureHandler) attrs() []map[string]any {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	out := make([]map[string]any, 0, len(h.records))
+	for _, r := range h.records {
+		m := make(map[string]any)
+		r.Attrs(func(a slog.Attr) bool {
+			m[a.Key] = a.Value.Any()
+			return true
+		})
+		out = append(out, m)
+	}
+	return out
+}
+
+func TestWithSummaryLog(t *testing.T) {
+	t.Parallel()
+
+	newBackoff := func(t *testing.T, maxRetries uint64) backoff.Backoff {
+		t.Helper()
+
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		return backoff.WithMaxRetries(maxRetries, b)
+	}
+
+	cases := []struct {
+		name     string
+		run      func(t *testing.T, opts ...Option) error
+		outcome  string
+		attempts uint64
+	}{
+		{
+			name: "function_signaled_to_stop",
+			run: func(t *testing.T, opts ...Option) error {
+				var calls int
+				return DoWithOptions(context.Background(), newBackoff(t, 5), func(_ context.Context) bool {
+					calls++
+					return calls < 3
+				}, opts...)
+			},
+			outcome:  "function_signaled_to_stop",
+			attempts: 3,
+		},
+		{
+			name: "until_error",
+			run: func(t *testing.T, opts ...Option) error {
+				return DoUntilErrorWithOptions(context.Background(), newBackoff(t, 5), func(_ context.Context) error {
+					return fmt.Errorf("oops")
+				}, opts...)
+			},
+			outcome:  "function_signaled_to_stop",
+			attempts: 1,
+		},
+		{
+			name: "backoff_signaled_to_stop",
+			run: func(t *testing.T, opts ...Option) error {
+				return DoWithOptions(context.Background(), newBackoff(t, 2), func(_ context.Context) bool {
+					return true
+				}, opts...)
+			},
+			outcome:  "backoff_signaled_to_stop",
+			attempts: 3,
+		},
+		{
+			name: "canceled_between_runs",
+			run: func(t *testing.T, opts ...Option) error {
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				return DoWithOptions(ctx, newBackoff(t, 5), func(_ context.Context) bool {
+					cancel()
+					return true
+				}, opts...)
+			},
+			outcome:  "context_canceled",
+			attempts: 1,
+		},
+		{
+			name: "on_signal_exhausted",
+			run: func(t *testing.T, opts ...Option) error {
+				stop := backoff.BackoffFunc(func() (time.Duration, bool) { return 0, true })
+				return DoOnSignal(context.Background(), nil, stop, func(_ context.Context) error {
+					return nil
+				}, opts...)
+			},
+			outcome:  "backoff_signaled_to_stop",
+			attempts: 1,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			h := &captureHandler{}
+			err := tc.run(t, WithSummaryLog(slog.New(h), slog.LevelInfo, "poll"))
+
+			got := h.attrs()
+			if len(got) != 1 {
+				t.Fatalf("expected %d to be %d", len(got), 1)
+			}
+			for k, want := range map[string]any{
+				"operation": "poll",
+				"outcome":   tc.outcome,
+				"attempts":  tc.attempts,
+				"error":     err.Error(),
+			} {
+				if got[0][k] != want {
+					t.Errorf("expected %s %v to be %v", k, got[0][k], want)
+				}
+			}
+			if _, ok := got[0]["policy"].(string); !ok {
+				t.Errorf("expected policy to be a string, got %v", got[0]["policy"])
+			}
+			for _, k := range []string{"total_sleep_ms", "elapsed_ms"} {
+				if _, ok := got[0][k].(int64); !ok {
+					t.Errorf("expected %s to be an int64, got %v", k, got[0][k])
+				}
+			}
+		})
+	}
+
+	t.Run("early_cancel", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		h := &captureHandler{}
+		f := func(_ context.Context) bool { return true }
+		_ = DoWithOptions(ctx, newBackoff(t, 3), f, WithSummaryLog(slog.New(h), slog.LevelInfo, "poll"))
+		if got := len(h.attrs()); got != 0 {
+			t.Errorf("expected %d to be %d", got, 0)
+		}
+
+		_ = DoWithOptions(ctx, newBackoff(t, 3), f, WithSummaryLog(slog.New(h), slog.LevelInfo, "poll"), WithSummaryLogOnEarlyCancel())
+		if got := len(h.attrs()); got != 1 {
+			t.Errorf("expected %d to be %d", got, 1)
+		}
+	})
+}
@@ -0,0 +1,161 @@
+package repeat
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+	"github.com/swayne275/go-retry/internal/apitest"
+)
+
+func TestZeroValues(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	newBackoff := func(t *testing.T) backoff.Backoff {
+		t.Helper()
+
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		return backoff.WithMaxRetries(2, b)
+	}
+	again := func(_ context.Context) bool { return true }
+
+	apitest.CheckZeroValues(t, map[string]func(t *testing.T){
+		"Cost": func(t *testing.T) {
+			var c Cost
+			if c.Attempts != 0 {
+				t.Errorf("expected %d to be %d", c.Attempts, 0)
+			}
+		},
+		"CostAccumulator": func(t *testing.T) {
+			var acc CostAccumulator
+			_ = DoWithOptions(ctx, newBackoff(t), again, WithCostKey(&acc, "k"))
+			if got := acc.Snapshot()["k"].Attempts; got != 3 {
+				t.Errorf("expected %d to be %d", got, 3)
+			}
+		},
+		"DelayHistogram": func(t *testing.T) {
+			var h DelayHistogram
+			_ = DoWithOptions(ctx, newBackoff(t), again, WithDelayHistogram(&h))
+			if s := h.Snapshot(); len(s.Counts) != 0 || s.Sum <= 0 {
+				t.Errorf("expected only a sum, got %+v", s)
+			}
+		},
+		"DelayHistogramSnapshot": func(t *testing.T) {
+			var s DelayHistogramSnapshot
+			if len(s.Counts) != 0 {
+				t.Errorf("expected no counts, got %v", s.Counts)
+			}
+		},
+		"Handle": func(t *testing.T) {
+			var h Handle
+			h.Stop()
+			h.Reset()
+			if err := h.Wait(); err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+			<-h.Done()
+			if _, err := ExportState(&h); !errors.Is(err, backoff.ErrNilBackoff) {
+				t.Errorf("expected %v to be %v", err, backoff.ErrNilBackoff)
+			}
+		},
+		"DisabledError": func(t *testing.T) {
+			var err DisabledError
+			if err.Error() == "" {
+				t.Error("expected an error message")
+			}
+			if errors.Is(&err, ErrRepeatsDisabled) {
+				t.Error("expected zero error to wrap nothing")
+			}
+		},
+	})
+}
+
+func TestNilBackoff(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var zero backoff.ResettableBackoff
+
+	cases := []struct {
+		name string
+		run  func() error
+	}{
+		{name: "do", run: func() error {
+			return Do(ctx, &zero, func(context.Context) bool { return true })
+		}},
+		{name: "do_until_error", run: func() error {
+			return DoUntilError(ctx, nil, func(context.Context) error { return nil })
+		}},
+		{name: "do_on_signal", run: func() error {
+			return DoOnSignal(ctx, nil, &zero, func(context.Context) error { return nil })
+		}},
+		{name: "start", run: func() error {
+			return Start(ctx, "k", nil, func(context.Context) bool { return true }).Wait()
+		}},
+		{name: "start_from_state", run: func() error {
+			_, err := StartFromState(ctx, []byte(`{"version":1}`), func() backoff.Backoff { return &zero }, func(context.Context) bool { return true })
+			return err
+		}},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := tc.run(); !errors.Is(err, backoff.ErrNilBackoff) {
+				t.Errorf("expected %v to be %v", err, backoff.ErrNilBackoff)
+			}
+		})
+	}
+}
+
+// TestNilOptions checks that options given nil, where they take a pointer or
+// interface, leave the loop running as if they weren't passed.
+func TestNilOptions(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	cases := []struct {
+		name string
+		opt  Option
+	}{
+		{name: "clock", opt: WithClock(nil)},
+		{name: "cost_key", opt: WithCostKey(nil, "k")},
+		{name: "delay_histogram", opt: WithDelayHistogram(nil)},
+		{name: "summary_log", opt: WithSummaryLog(nil, 0, "op")},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			b, err := backoff.NewConstant(1 * time.Nanosecond)
+			if err != nil {
+				t.Fatalf("failed to create constant backoff: %v", err)
+			}
+
+			var calls int
+			err = DoWithOptions(ctx, backoff.WithMaxRetries(1, b), func(context.Context) bool {
+				calls++
+				return true
+			}, tc.opt)
+			if !errors.Is(err, ErrBackoffSignaledToStop) {
+				t.Errorf("expected %v to be %v", err, ErrBackoffSignaledToStop)
+			}
+			if calls != 2 {
+				t.Errorf("expected %d to be %d", calls, 2)
+			}
+		})
+	}
+}
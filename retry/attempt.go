@@ -0,0 +1,80 @@
+package retry
+
+import (
+	"context"
+	"time"
+)
+
+type attemptKey struct{}
+
+// attemptInfo is what a loop tells the function it calls about the call.
+type attemptInfo struct {
+	// n is the number of the attempt, starting at 1.
+	n uint64
+	// start is when the loop started, by clock.
+	start time.Time
+	clock Clock
+	// lastErr is the error of the previous attempt, if any.
+	lastErr error
+}
+
+// attemptContext carries an attemptInfo. It answers for attemptKey itself rather
+// than wrapping ctx with context.WithValue, so each attempt costs a single
+// allocation.
+type attemptContext struct {
+	context.Context
+	attemptInfo
+}
+
+// Value implements context.Context.
+func (c *attemptContext) Value(key any) any {
+	if key == (attemptKey{}) {
+		return &c.attemptInfo
+	}
+	return c.Context.Value(key)
+}
+
+// withAttempt returns a copy of ctx carrying a.
+func withAttempt(ctx context.Context, a attemptInfo) context.Context {
+	return &attemptContext{Context: ctx, attemptInfo: a}
+}
+
+// attemptFrom returns the attemptInfo carried by ctx, or nil if there is none.
+func attemptFrom(ctx context.Context) *attemptInfo {
+	a, _ := ctx.Value(attemptKey{}).(*attemptInfo)
+	return a
+}
+
+// AttemptFromContext returns the number of the attempt a function is being
+// called for, from the context the loops of this package pass it. The first
+// call is attempt 1, and every call after it, retried or not, counts one more.
+// It returns 0 for a context that doesn't come from a loop.
+func AttemptFromContext(ctx context.Context) uint64 {
+	if a := attemptFrom(ctx); a != nil {
+		return a.n
+	}
+	return 0
+}
+
+// ElapsedFromContext returns how long ago the loop calling a function started,
+// by the loop's clock, from the context the loops of this package pass it. A
+// function can use it to change its behavior after retrying for a while, such
+// as switching to a fallback endpoint. It returns 0 for a context that
+// doesn't come from a loop.
+func ElapsedFromContext(ctx context.Context) time.Duration {
+	if a := attemptFrom(ctx); a != nil && a.clock != nil {
+		return a.clock.Now().Sub(a.start)
+	}
+	return 0
+}
+
+// LastErrorFromContext returns the error of the previous attempt of the loop
+// calling a function, without its RetryableError mark, from the context the
+// loops of this package pass it. It returns nil on the first attempt, and for
+// a context that doesn't come from a loop.
+func LastErrorFromContext(ctx context.Context) error {
+	if a := attemptFrom(ctx); a != nil {
+		return a.lastErr
+	}
+	return nil
+}
@@ -0,0 +1,247 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+func TestAttemptFromContext(t *testing.T) {
+	t.Parallel()
+
+	newBackoff := func(t *testing.T, maxRetries uint64) backoff.Backoff {
+		t.Helper()
+
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		return backoff.WithMaxRetries(maxRetries, b)
+	}
+
+	t.Run("outside_loop", func(t *testing.T) {
+		t.Parallel()
+
+		if got := AttemptFromContext(context.Background()); got != 0 {
+			t.Errorf("expected %v to be %v", got, 0)
+		}
+	})
+
+	t.Run("counts_every_call", func(t *testing.T) {
+		t.Parallel()
+
+		var seen []uint64
+		err := Do(context.Background(), newBackoff(t, 3), func(ctx context.Context) error {
+			seen = append(seen, AttemptFromContext(ctx))
+			return RetryableError(fmt.Errorf("oops"))
+		})
+		if !errors.Is(err, ErrBackoffSignaledToStop) {
+			t.Errorf("expected %q to be %q", err, ErrBackoffSignaledToStop)
+		}
+		if want := []uint64{1, 2, 3, 4}; !reflect.DeepEqual(seen, want) {
+			t.Errorf("expected %v to be %v", seen, want)
+		}
+	})
+
+	t.Run("includes_non_retryable", func(t *testing.T) {
+		t.Parallel()
+
+		var seen []uint64
+		err := Do(context.Background(), newBackoff(t, 5), func(ctx context.Context) error {
+			n := AttemptFromContext(ctx)
+			seen = append(seen, n)
+			if n == 3 {
+				return fmt.Errorf("oops") // not retryable
+			}
+			return RetryableError(fmt.Errorf("oops"))
+		})
+		if !errors.Is(err, ErrNonRetryable) {
+			t.Errorf("expected %q to be %q", err, ErrNonRetryable)
+		}
+		if want := []uint64{1, 2, 3}; !reflect.DeepEqual(seen, want) {
+			t.Errorf("expected %v to be %v", seen, want)
+		}
+	})
+
+	t.Run("with_attempt_timeout", func(t *testing.T) {
+		t.Parallel()
+
+		var seen []uint64
+		_ = DoWithOptions(context.Background(), newBackoff(t, 2), func(ctx context.Context) error {
+			seen = append(seen, AttemptFromContext(ctx))
+			return RetryableError(fmt.Errorf("oops"))
+		}, WithAdaptiveAttemptTimeout(NewLatencyTracker(10), 0.9, 2, time.Second, time.Minute))
+		if want := []uint64{1, 2, 3}; !reflect.DeepEqual(seen, want) {
+			t.Errorf("expected %v to be %v", seen, want)
+		}
+	})
+
+	t.Run("two_phase", func(t *testing.T) {
+		t.Parallel()
+
+		policy := func() backoff.Backoff { return newBackoff(t, 1) }
+		var seen []uint64
+		_ = DoTwoPhase(context.Background(), policy, policy, func(ctx context.Context) (bool, error) {
+			seen = append(seen, AttemptFromContext(ctx))
+			return len(seen) > 1, RetryableError(fmt.Errorf("oops"))
+		})
+		if want := []uint64{1, 2, 3}; !reflect.DeepEqual(seen, want) {
+			t.Errorf("expected %v to be %v", seen, want)
+		}
+	})
+
+	t.Run("pipelined", func(t *testing.T) {
+		t.Parallel()
+
+		var seen []uint64
+		_ = DoPipelined(context.Background(), newBackoff(t, 2), 1, func(ctx context.Context) error {
+			seen = append(seen, AttemptFromContext(ctx))
+			return RetryableError(fmt.Errorf("oops"))
+		})
+		if want := []uint64{1, 2, 3}; !reflect.DeepEqual(seen, want) {
+			t.Errorf("expected %v to be %v", seen, want)
+		}
+	})
+}
+
+func TestElapsedAndLastErrorFromContext(t *testing.T) {
+	t.Parallel()
+
+	t.Run("outside_loop", func(t *testing.T) {
+		t.Parallel()
+
+		if got := ElapsedFromContext(context.Background()); got != 0 {
+			t.Errorf("expected %v to be %v", got, 0)
+		}
+		if got := LastErrorFromContext(context.Background()); got != nil {
+			t.Errorf("expected %v to be nil", got)
+		}
+	})
+
+	t.Run("across_attempts", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewConstant(1 * time.Second)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		c := newFakeClock()
+		errs := []error{fmt.Errorf("first"), fmt.Errorf("second"), fmt.Errorf("third")}
+
+		var elapsed []time.Duration
+		var last []error
+		err = DoWithOptions(context.Background(), b, func(ctx context.Context) error {
+			elapsed = append(elapsed, ElapsedFromContext(ctx))
+			last = append(last, LastErrorFromContext(ctx))
+
+			// Time spent in f counts too.
+			c.Advance(100 * time.Millisecond)
+
+			if n := AttemptFromContext(ctx); n <= uint64(len(errs)) {
+				return RetryableError(errs[n-1])
+			}
+			return nil
+		}, WithClock(c))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		wantElapsed := []time.Duration{0, 1100 * time.Millisecond, 2200 * time.Millisecond, 3300 * time.Millisecond}
+		if !reflect.DeepEqual(elapsed, wantElapsed) {
+			t.Errorf("expected %v to be %v", elapsed, wantElapsed)
+		}
+		wantLast := []error{nil, errs[0], errs[1], errs[2]}
+		if !reflect.DeepEqual(last, wantLast) {
+			t.Errorf("expected %v to be %v", last, wantLast)
+		}
+	})
+
+	t.Run("fallback_after_elapsed", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewConstant(4 * time.Second)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		var endpoints []string
+		err = DoWithOptions(context.Background(), b, func(ctx context.Context) error {
+			endpoint := "primary"
+			if ElapsedFromContext(ctx) >= 10*time.Second {
+				endpoint = "fallback"
+			}
+			endpoints = append(endpoints, endpoint)
+			if endpoint == "primary" {
+				return RetryableError(fmt.Errorf("primary down"))
+			}
+			return nil
+		}, WithClock(newFakeClock()))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if want := []string{"primary", "primary", "primary", "fallback"}; !reflect.DeepEqual(endpoints, want) {
+			t.Errorf("expected %v to be %v", endpoints, want)
+		}
+	})
+
+	t.Run("attempt_timeout_and_caller_values", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		type key struct{}
+		ctx := context.WithValue(context.Background(), key{}, "caller")
+		errFirst := fmt.Errorf("first")
+
+		var last error
+		var value any
+		err = DoWithOptions(ctx, b, func(ctx context.Context) error {
+			if AttemptFromContext(ctx) == 1 {
+				return RetryableError(errFirst)
+			}
+			last, value = LastErrorFromContext(ctx), ctx.Value(key{})
+			return nil
+		}, WithAttemptTimeout(time.Hour))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if last != errFirst {
+			t.Errorf("expected %v to be %v", last, errFirst)
+		}
+		if value != "caller" {
+			t.Errorf("expected %v to be %v", value, "caller")
+		}
+	})
+
+	t.Run("do_constant", func(t *testing.T) {
+		t.Parallel()
+
+		errFirst := fmt.Errorf("first")
+		var last []error
+		err := DoConstant(context.Background(), 1*time.Nanosecond, func(ctx context.Context) error {
+			last = append(last, LastErrorFromContext(ctx))
+			if len(last) == 1 {
+				return RetryableError(errFirst)
+			}
+			if ElapsedFromContext(ctx) <= 0 {
+				t.Error("expected time to have elapsed")
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if want := []error{nil, errFirst}; !reflect.DeepEqual(last, want) {
+			t.Errorf("expected %v to be %v", last, want)
+		}
+	})
+}
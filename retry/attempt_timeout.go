@@ -0,0 +1,39 @@
+package retry
+
+import "time"
+
+// WithAttemptTimeout bounds each attempt made by DoWithOptions to d, so an
+// attempt stuck on, say, a hung connection can't stall the loop. f sees the
+// timeout as the deadline of its ctx and must honor it. A d of zero or less
+// disables the bound.
+//
+// An attempt that fails because its own timeout expired, while ctx is still
+// live, is retried even if f did not mark the error as retryable; see
+// WithAttemptTimeoutNotRetried to change that. Canceling ctx still stops the
+// whole loop. Combined with WithAdaptiveAttemptTimeout, the shorter of the
+// two timeouts applies.
+func WithAttemptTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.attemptTimeout = d
+	}
+}
+
+// WithAttemptTimeoutNotRetried makes an attempt that fails because the timeout
+// set with WithAttemptTimeout or WithAdaptiveAttemptTimeout expired be treated
+// like any other error from f: it is retried only if f marked it retryable.
+func WithAttemptTimeoutNotRetried() Option {
+	return func(o *options) {
+		o.attemptTimeoutNotRetried = true
+	}
+}
+
+// timeout returns the bound on the next attempt, and whether there is one.
+func (o *options) timeout() (time.Duration, bool) {
+	d, ok := o.attemptTimeout, o.attemptTimeout > 0
+	if at := o.adaptiveTimeout; at != nil {
+		if adaptive := at.tracker.Timeout(at.quantile, at.multiplier, at.floor, at.ceil); !ok || adaptive < d {
+			d, ok = adaptive, true
+		}
+	}
+	return d, ok
+}
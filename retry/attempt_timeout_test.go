@@ -0,0 +1,132 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+func TestWithAttemptTimeout(t *testing.T) {
+	t.Parallel()
+
+	newBackoff := func(t *testing.T, maxRetries uint64) backoff.Backoff {
+		t.Helper()
+
+		b, err := backoff.NewConstant(1 * time.Millisecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		return backoff.WithMaxRetries(maxRetries, b)
+	}
+
+	// hangOnce hangs on its first call until its ctx is done, like an attempt
+	// stuck on a dead connection, and succeeds after that.
+	hangOnce := func(calls *int64) RetryFunc {
+		return func(ctx context.Context) error {
+			if atomic.AddInt64(calls, 1) == 1 {
+				<-ctx.Done()
+				return ctx.Err()
+			}
+			return nil
+		}
+	}
+
+	t.Run("rescues_hanging_attempt", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int64
+		if err := DoWithOptions(context.Background(), newBackoff(t, 3), hangOnce(&calls), WithAttemptTimeout(10*time.Millisecond)); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if got, want := atomic.LoadInt64(&calls), int64(2); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("sets_deadline", func(t *testing.T) {
+		t.Parallel()
+
+		var remaining time.Duration
+		if err := DoWithOptions(context.Background(), newBackoff(t, 0), func(ctx context.Context) error {
+			deadline, ok := ctx.Deadline()
+			if !ok {
+				return errors.New("expected a deadline")
+			}
+			remaining = time.Until(deadline)
+			return nil
+		}, WithAttemptTimeout(time.Hour)); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if remaining <= 0 || remaining > time.Hour {
+			t.Errorf("expected deadline within an hour, got %v", remaining)
+		}
+	})
+
+	t.Run("not_retried", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int64
+		err := DoWithOptions(context.Background(), newBackoff(t, 3), hangOnce(&calls),
+			WithAttemptTimeout(10*time.Millisecond), WithAttemptTimeoutNotRetried())
+		if !errors.Is(err, ErrNonRetryable) || !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected %v to be %v and %v", err, ErrNonRetryable, context.DeadlineExceeded)
+		}
+		if got, want := atomic.LoadInt64(&calls), int64(1); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("parent_cancellation_stops_loop", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var calls int64
+		err := DoWithOptions(ctx, newBackoff(t, 3), func(ctx context.Context) error {
+			atomic.AddInt64(&calls, 1)
+			cancel()
+			<-ctx.Done()
+			return ctx.Err()
+		}, WithAttemptTimeout(time.Hour))
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected %v to be %v", err, context.Canceled)
+		}
+		if got, want := atomic.LoadInt64(&calls), int64(1); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("shorter_of_adaptive", func(t *testing.T) {
+		t.Parallel()
+
+		var remaining time.Duration
+		if err := DoWithOptions(context.Background(), newBackoff(t, 0), func(ctx context.Context) error {
+			deadline, _ := ctx.Deadline()
+			remaining = time.Until(deadline)
+			return nil
+		}, WithAttemptTimeout(time.Hour), WithAdaptiveAttemptTimeout(NewLatencyTracker(10), 0.9, 2, time.Millisecond, 20*time.Millisecond)); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if remaining <= 0 || remaining > 20*time.Millisecond {
+			t.Errorf("expected deadline within 20ms, got %v", remaining)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Parallel()
+
+		if err := DoWithOptions(context.Background(), newBackoff(t, 0), func(ctx context.Context) error {
+			if _, ok := ctx.Deadline(); ok {
+				return errors.New("expected no deadline")
+			}
+			return nil
+		}, WithAttemptTimeout(0)); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,135 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+	"github.com/swayne275/go-retry/internal/clock"
+	"github.com/swayne275/go-retry/internal/label"
+)
+
+// ErrBudgetExhausted is returned when a Budget disallows the next retry. Its
+// label is "budget_exhausted".
+var ErrBudgetExhausted = label.New("retry budget exhausted", "budget_exhausted")
+
+// budgetWindow is the number of one-second buckets a Budget remembers.
+const budgetWindow = 10
+
+// Budget limits retries across many loops, so that when a dependency browns
+// out the loops sharing the budget don't multiply the load on it. Modeled on
+// the retry budgets of Finagle and gRPC, it allows retries in proportion to
+// successful attempts: over the last 10 seconds, the retries made may not
+// exceed ratio times the successful attempts, plus minPerSecond retries for
+// every second of the window, so a quiet caller can still retry.
+//
+// A Budget is safe for concurrent use. The zero Budget allows no retries.
+type Budget struct {
+	ratio        float64
+	minPerSecond int
+	clock        Clock
+
+	mu      sync.Mutex
+	start   time.Time
+	last    int64
+	buckets [budgetWindow]budgetBucket
+}
+
+type budgetBucket struct {
+	successes int64
+	retries   int64
+}
+
+// NewBudget creates a budget that allows ratio retries per successful attempt,
+// plus minPerSecond retries per second regardless of successes. For example,
+// a ratio of 0.1 allows one retry for every ten successful attempts.
+func NewBudget(ratio float64, minPerSecond int) *Budget {
+	return NewBudgetWithClock(ratio, minPerSecond, nil)
+}
+
+// NewBudgetWithClock is like NewBudget, but measures time with c. A nil c
+// selects the real clock.
+func NewBudgetWithClock(ratio float64, minPerSecond int, c Clock) *Budget {
+	c = clock.Or(c)
+	return &Budget{
+		ratio:        ratio,
+		minPerSecond: minPerSecond,
+		clock:        c,
+		start:        c.Now(),
+	}
+}
+
+// bucket returns the bucket for the current second, clearing the buckets of
+// the seconds that passed since the last call. The caller must hold b.mu.
+func (b *Budget) bucket() *budgetBucket {
+	c := clock.Or(b.clock)
+	if b.start.IsZero() {
+		b.start = c.Now()
+	}
+
+	now := int64(c.Now().Sub(b.start) / time.Second)
+	if now > b.last {
+		for i := b.last + 1; i <= now && i <= b.last+budgetWindow; i++ {
+			b.buckets[i%budgetWindow] = budgetBucket{}
+		}
+		b.last = now
+	}
+	return &b.buckets[b.last%budgetWindow]
+}
+
+// Succeeded records a successful attempt, which replenishes the budget.
+func (b *Budget) Succeeded() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.bucket().successes++
+}
+
+// Withdraw reserves one retry. It returns false, and reserves nothing, if the
+// budget disallows it.
+func (b *Budget) Withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	cur := b.bucket()
+	var successes, retries int64
+	for _, bk := range b.buckets {
+		successes += bk.successes
+		retries += bk.retries
+	}
+
+	allowed := int64(b.ratio*float64(successes)) + int64(b.minPerSecond)*budgetWindow
+	if retries >= allowed {
+		return false
+	}
+	cur.retries++
+	return true
+}
+
+// WithBudget makes DoWithOptions withdraw a retry from budget before each
+// retry, and record every successful attempt in it. The first attempt is
+// never checked. If the budget disallows a retry, the loop stops with an error
+// wrapping ErrBudgetExhausted and the last error from f.
+//
+// A retry is withdrawn once the loop decides to make it, so it counts against
+// the budget even if ctx is canceled while sleeping before it.
+func WithBudget(budget *Budget) Option {
+	return func(o *options) {
+		o.budget = budget
+	}
+}
+
+// DoWithBudget is like Do, but shares budget with the other loops using it to
+// limit their combined retries. It is a shorthand for DoWithOptions with
+// WithBudget.
+func DoWithBudget(ctx context.Context, budget *Budget, b backoff.Backoff, f RetryFunc) error {
+	return DoWithOptions(ctx, b, f, WithBudget(budget))
+}
+
+// budgetExhausted returns the error of a loop whose budget disallowed the
+// retry after err.
+func budgetExhausted(err error) error {
+	return fmt.Errorf("%w: %w", ErrBudgetExhausted, err)
+}
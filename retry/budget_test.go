@@ -0,0 +1,185 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+func TestBudget(t *testing.T) {
+	t.Parallel()
+
+	// withdraw withdraws from b until it refuses, up to limit times, and returns
+	// how many withdrawals it allowed.
+	withdraw := func(b *Budget, limit int) int {
+		for i := 0; i < limit; i++ {
+			if !b.Withdraw() {
+				return i
+			}
+		}
+		return limit
+	}
+
+	t.Run("replenished_by_successes", func(t *testing.T) {
+		t.Parallel()
+
+		b := NewBudgetWithClock(0.5, 0, newFakeClock())
+		if got := withdraw(b, 100); got != 0 {
+			t.Errorf("expected %d to be %d", got, 0)
+		}
+		for i := 0; i < 4; i++ {
+			b.Succeeded()
+		}
+		if got := withdraw(b, 100); got != 2 {
+			t.Errorf("expected %d to be %d", got, 2)
+		}
+	})
+
+	t.Run("min_per_second", func(t *testing.T) {
+		t.Parallel()
+
+		b := NewBudgetWithClock(0, 1, newFakeClock())
+		if got := withdraw(b, 100); got != budgetWindow {
+			t.Errorf("expected %d to be %d", got, budgetWindow)
+		}
+	})
+
+	t.Run("window_expires", func(t *testing.T) {
+		t.Parallel()
+
+		c := newFakeClock()
+		b := NewBudgetWithClock(1, 0, c)
+		for i := 0; i < 5; i++ {
+			b.Succeeded()
+		}
+		if got := withdraw(b, 3); got != 3 {
+			t.Errorf("expected %d to be %d", got, 3)
+		}
+
+		// Still within the window: 2 of the 5 left.
+		c.Advance(9 * time.Second)
+		if got := withdraw(b, 100); got != 2 {
+			t.Errorf("expected %d to be %d", got, 2)
+		}
+
+		// The first second has expired, but the 2 retries made since still
+		// count against new successes.
+		c.Advance(1 * time.Second)
+		if got := withdraw(b, 100); got != 0 {
+			t.Errorf("expected %d to be %d", got, 0)
+		}
+		for i := 0; i < 3; i++ {
+			b.Succeeded()
+		}
+		if got := withdraw(b, 100); got != 1 {
+			t.Errorf("expected %d to be %d", got, 1)
+		}
+	})
+
+	t.Run("long_idle", func(t *testing.T) {
+		t.Parallel()
+
+		c := newFakeClock()
+		b := NewBudgetWithClock(0, 1, c)
+		withdraw(b, 100)
+
+		c.Advance(time.Hour)
+		if got := withdraw(b, 100); got != budgetWindow {
+			t.Errorf("expected %d to be %d", got, budgetWindow)
+		}
+	})
+}
+
+func TestDoWithBudget(t *testing.T) {
+	t.Parallel()
+
+	newBackoff := func(t *testing.T) backoff.Backoff {
+		t.Helper()
+
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		return backoff.WithMaxRetries(5, b)
+	}
+
+	t.Run("first_attempt_not_checked", func(t *testing.T) {
+		t.Parallel()
+
+		// Without successes the budget allows no retries.
+		budget := NewBudgetWithClock(1, 0, newFakeClock())
+		var calls int
+		if err := DoWithBudget(context.Background(), budget, newBackoff(t), func(_ context.Context) error {
+			calls++
+			return nil
+		}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if calls != 1 {
+			t.Errorf("expected %d to be %d", calls, 1)
+		}
+
+		// The success replenished the budget.
+		if !budget.Withdraw() {
+			t.Error("expected the success to allow a retry")
+		}
+	})
+
+	t.Run("exhausted", func(t *testing.T) {
+		t.Parallel()
+
+		errFoo := fmt.Errorf("foo")
+		err := DoWithBudget(context.Background(), NewBudget(0, 0), newBackoff(t), func(_ context.Context) error {
+			return RetryableError(errFoo)
+		})
+		if !errors.Is(err, ErrBudgetExhausted) || !errors.Is(err, errFoo) {
+			t.Errorf("expected %v to be %v and %v", err, ErrBudgetExhausted, errFoo)
+		}
+	})
+
+	t.Run("throttles_sustained_failure", func(t *testing.T) {
+		t.Parallel()
+
+		const callers = 100
+		budget := NewBudget(0.1, 1)
+
+		var calls atomic.Int64
+		var throttled atomic.Int64
+		var wg sync.WaitGroup
+		for i := 0; i < callers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				err := DoWithBudget(context.Background(), budget, newBackoff(t), func(_ context.Context) error {
+					calls.Add(1)
+					return RetryableError(fmt.Errorf("unavailable"))
+				})
+				switch {
+				case errors.Is(err, ErrBudgetExhausted):
+					throttled.Add(1)
+				case errors.Is(err, ErrBackoffSignaledToStop):
+				default:
+					t.Errorf("unexpected error %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		// Without the budget there would be 6 calls per caller. With no
+		// successes, only the minimum of 1 retry per second of the window is
+		// allowed on top of the first attempts.
+		if got, want := calls.Load(), int64(callers+budgetWindow); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if got := throttled.Load(); got < callers-budgetWindow {
+			t.Errorf("expected at least %d throttled callers, got %d", callers-budgetWindow, got)
+		}
+	})
+}
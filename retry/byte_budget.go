@@ -0,0 +1,128 @@
+package retry
+
+import (
+	"sync"
+	"time"
+
+	"github.com/swayne275/go-retry/internal/clock"
+	"github.com/swayne275/go-retry/internal/label"
+)
+
+// ErrByteBudgetExceeded is returned when a ByteBudget cannot cover the
+// estimated cost of the next retry. Its label is "byte_budget_exceeded".
+var ErrByteBudgetExceeded = label.New("byte budget exceeded", "byte_budget_exceeded")
+
+// ByteBudget limits how many bytes may be spent within a fixed window of time.
+// The full limit becomes available again at the start of each window.
+//
+// A ByteBudget is safe for concurrent use, so one budget can be shared by many
+// loops to enforce a global limit.
+type ByteBudget struct {
+	limit  int64
+	window time.Duration
+	clock  Clock
+
+	mu          sync.Mutex
+	windowStart time.Time
+	spent       int64
+}
+
+// NewByteBudget creates a budget that allows limit bytes per window.
+func NewByteBudget(limit int64, window time.Duration) *ByteBudget {
+	return NewByteBudgetWithClock(limit, window, nil)
+}
+
+// NewByteBudgetWithClock is like NewByteBudget, but measures windows with c.
+// A nil c selects the real clock.
+func NewByteBudgetWithClock(limit int64, window time.Duration, c Clock) *ByteBudget {
+	c = clock.Or(c)
+	return &ByteBudget{
+		limit:       limit,
+		window:      window,
+		clock:       c,
+		windowStart: c.Now(),
+	}
+}
+
+// refill starts a new window if the current one has elapsed. The caller must
+// hold b.mu.
+func (b *ByteBudget) refill() {
+	if b.window <= 0 {
+		return
+	}
+	now := b.clock.Now()
+	if elapsed := now.Sub(b.windowStart); elapsed >= b.window {
+		b.windowStart = b.windowStart.Add(elapsed - elapsed%b.window)
+		b.spent = 0
+	}
+}
+
+// Charge reserves n bytes from the budget. It returns false, and reserves
+// nothing, if fewer than n bytes remain in the current window.
+func (b *ByteBudget) Charge(n int64) bool {
+	_, ok := b.ChargeWindow(n)
+	return ok
+}
+
+// ChargeWindow is like Charge, but also returns the start of the window the
+// bytes were charged to, for Refund.
+func (b *ByteBudget) ChargeWindow(n int64) (time.Time, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+	if n <= 0 {
+		return b.windowStart, true
+	}
+	if b.spent+n > b.limit {
+		return b.windowStart, false
+	}
+	b.spent += n
+	return b.windowStart, true
+}
+
+// Refund returns n bytes, charged to the window starting at window, to the
+// budget. A refund for a window that has already ended is dropped: the full
+// limit is available again, so returning the bytes would overdraw the new
+// window.
+func (b *ByteBudget) Refund(n int64, window time.Time) {
+	if n <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+	if !window.Equal(b.windowStart) {
+		return
+	}
+	b.spent -= n
+	if b.spent < 0 {
+		b.spent = 0
+	}
+}
+
+// Remaining returns the number of bytes left in the current window.
+func (b *ByteBudget) Remaining() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+	return b.limit - b.spent
+}
+
+// WithByteBudget makes DoWithOptions reserve costPerAttempt(attempt) bytes
+// from b before each retry, where attempt is the number of the attempt about
+// to be made (2 for the first retry). The first attempt is never charged.
+//
+// If the budget cannot cover the cost, the loop stops with an error wrapping
+// ErrByteBudgetExceeded and the last error from f. If the reserved attempt
+// does not run, for example because ctx is canceled while sleeping, the
+// reservation is refunded.
+func WithByteBudget(b *ByteBudget, costPerAttempt func(attempt uint64) int64) Option {
+	return func(o *options) {
+		o.byteBudget = b
+		o.byteCost = costPerAttempt
+	}
+}
@@ -0,0 +1,230 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+func TestByteBudget(t *testing.T) {
+	t.Parallel()
+
+	t.Run("charge_and_refund", func(t *testing.T) {
+		t.Parallel()
+
+		b := NewByteBudgetWithClock(100, time.Hour, newFakeClock())
+		window, _ := b.ChargeWindow(0)
+
+		steps := []struct {
+			charge int64
+			refund int64
+			ok     bool
+			left   int64
+		}{
+			{charge: 40, ok: true, left: 60},
+			{charge: 50, ok: true, left: 10},
+			{charge: 20, ok: false, left: 10},
+			{refund: 50, left: 60},
+			{charge: 60, ok: true, left: 0},
+			{charge: 1, ok: false, left: 0},
+			{charge: 0, ok: true, left: 0},
+			{refund: 500, left: 100},
+		}
+
+		for i, step := range steps {
+			if step.refund > 0 {
+				b.Refund(step.refund, window)
+			} else if got := b.Charge(step.charge); got != step.ok {
+				t.Errorf("step %d: expected %v to be %v", i, got, step.ok)
+			}
+			if got := b.Remaining(); got != step.left {
+				t.Errorf("step %d: expected %d to be %d", i, got, step.left)
+			}
+		}
+	})
+
+	t.Run("refund_after_window_ends", func(t *testing.T) {
+		t.Parallel()
+
+		c := newFakeClock()
+		b := NewByteBudgetWithClock(10, time.Minute, c)
+
+		window, ok := b.ChargeWindow(6)
+		if !ok {
+			t.Fatal("expected charge to succeed")
+		}
+		c.Advance(1 * time.Minute)
+		if !b.Charge(8) {
+			t.Fatal("expected charge to succeed in the new window")
+		}
+
+		// The bytes came from a window that has ended, so the new one is not
+		// credited with them.
+		b.Refund(6, window)
+		if got, want := b.Remaining(), int64(2); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("window_refills", func(t *testing.T) {
+		t.Parallel()
+
+		c := newFakeClock()
+		b := NewByteBudgetWithClock(10, time.Minute, c)
+
+		if !b.Charge(10) {
+			t.Fatal("expected charge to succeed")
+		}
+		c.Advance(59 * time.Second)
+		if b.Charge(1) {
+			t.Fatal("expected charge to fail before the window ends")
+		}
+		c.Advance(1 * time.Second)
+		if !b.Charge(10) {
+			t.Fatal("expected charge to succeed in the new window")
+		}
+
+		// Several idle windows later, the budget is full and aligned to the
+		// window boundary.
+		c.Advance(150 * time.Second)
+		if got, want := b.Remaining(), int64(10); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if !b.Charge(10) {
+			t.Fatal("expected charge to succeed")
+		}
+		c.Advance(30 * time.Second)
+		if got, want := b.Remaining(), int64(10); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("shared_contention", func(t *testing.T) {
+		t.Parallel()
+
+		b := NewByteBudgetWithClock(1000, time.Hour, newFakeClock())
+
+		var granted int64
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					if b.Charge(1) {
+						atomic.AddInt64(&granted, 1)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		if got, want := atomic.LoadInt64(&granted), int64(1000); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+}
+
+func TestWithByteBudget(t *testing.T) {
+	t.Parallel()
+
+	t.Run("exceeded", func(t *testing.T) {
+		t.Parallel()
+
+		c := newFakeClock()
+		budget := NewByteBudgetWithClock(250, time.Hour, c)
+		b, err := backoff.NewConstant(1 * time.Second)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		var costs []uint64
+		errUpload := fmt.Errorf("upload failed")
+		var calls int
+		err = DoWithOptions(context.Background(), b, func(_ context.Context) error {
+			calls++
+			return RetryableError(errUpload)
+		}, WithClock(c), WithByteBudget(budget, func(attempt uint64) int64 {
+			costs = append(costs, attempt)
+			return 100
+		}))
+		if !errors.Is(err, ErrByteBudgetExceeded) {
+			t.Errorf("expected %q to be %q", err, ErrByteBudgetExceeded)
+		}
+		if !errors.Is(err, errUpload) {
+			t.Errorf("expected %q to be %q", err, errUpload)
+		}
+
+		// The first attempt is free, the next two are covered, the fourth is not.
+		if got, want := calls, 3; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if got, want := costs, []uint64{2, 3, 4}; fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+		if got, want := budget.Remaining(), int64(50); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("refund_on_cancel", func(t *testing.T) {
+		t.Parallel()
+
+		budget := NewByteBudget(1000, time.Hour)
+		b, err := backoff.NewConstant(1 * time.Hour)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			cancel()
+		}()
+
+		err = DoWithOptions(ctx, b, func(_ context.Context) error {
+			return RetryableError(fmt.Errorf("oops"))
+		}, WithByteBudget(budget, func(_ uint64) int64 { return 300 }))
+		if err != context.Canceled {
+			t.Errorf("expected %q to be %q", err, context.Canceled)
+		}
+		if got, want := budget.Remaining(), int64(1000); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("success_keeps_charges", func(t *testing.T) {
+		t.Parallel()
+
+		c := newFakeClock()
+		budget := NewByteBudgetWithClock(1000, time.Hour, c)
+		b, err := backoff.NewConstant(1 * time.Second)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		var calls int
+		if err := DoWithOptions(context.Background(), b, func(_ context.Context) error {
+			calls++
+			if calls < 3 {
+				return RetryableError(fmt.Errorf("oops"))
+			}
+			return nil
+		}, WithClock(c), WithByteBudget(budget, func(attempt uint64) int64 {
+			return int64(attempt) * 10
+		})); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		// Attempts 2 and 3 were charged 20 and 30.
+		if got, want := budget.Remaining(), int64(950); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+}
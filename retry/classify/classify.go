@@ -0,0 +1,110 @@
+// Package classify provides predicates that decide whether an error is worth
+// retrying, for retry.WithShouldRetry and retry.DoWithRetryCheck, and
+// combinators to build them up. Every predicate looks through wrapped errors.
+//
+// The predicate for gRPC status codes is retrygrpc.Codes, in the separate
+// retrygrpc module, so this one stays free of dependencies.
+package classify
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+)
+
+// Temporary reports whether err is a failure that usually goes away on its
+// own: a network timeout reported through net.Error, such as one from a
+// net.OpError or url.Error, a connection reset or refused by the peer, or
+// io.ErrUnexpectedEOF from a connection closed mid-response.
+//
+// Context cancellation and deadline errors are never temporary, though
+// context.DeadlineExceeded reports a timeout, so a caller that gave up is not
+// retried.
+func Temporary(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var nerr net.Error
+	return errors.As(err, &nerr) && nerr.Timeout()
+}
+
+// HTTPStatusCoder is implemented by errors that carry the status code of the
+// HTTP response that caused them, such as those made by HTTPError.
+type HTTPStatusCoder interface {
+	HTTPStatusCode() int
+}
+
+// HTTPError returns an error wrapping err that carries code as the status code
+// of the HTTP response it came from, for HTTPStatus to match.
+func HTTPError(code int, err error) error {
+	return &httpError{code: code, err: err}
+}
+
+type httpError struct {
+	code int
+	err  error
+}
+
+func (e *httpError) HTTPStatusCode() int {
+	return e.code
+}
+
+func (e *httpError) Unwrap() error {
+	return e.err
+}
+
+func (e *httpError) Error() string {
+	return e.err.Error()
+}
+
+// HTTPStatus returns a predicate reporting whether err carries one of codes as
+// its HTTP status code, through the first HTTPStatusCoder in its chain.
+func HTTPStatus(codes ...int) func(err error) bool {
+	set := make(map[int]bool, len(codes))
+	for _, c := range codes {
+		set[c] = true
+	}
+	return func(err error) bool {
+		var serr HTTPStatusCoder
+		return errors.As(err, &serr) && set[serr.HTTPStatusCode()]
+	}
+}
+
+// Any returns a predicate reporting whether any of preds does. With no
+// predicates it reports false. Nil predicates are ignored.
+func Any(preds ...func(err error) bool) func(err error) bool {
+	return func(err error) bool {
+		for _, p := range preds {
+			if p != nil && p(err) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// All returns a predicate reporting whether all of preds do. With no
+// predicates it reports true. Nil predicates are ignored.
+func All(preds ...func(err error) bool) func(err error) bool {
+	return func(err error) bool {
+		for _, p := range preds {
+			if p != nil && !p(err) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Not returns a predicate reporting the opposite of pred. A nil pred is
+// treated as reporting false, so Not(nil) reports true.
+func Not(pred func(err error) bool) func(err error) bool {
+	return func(err error) bool {
+		return pred == nil || !pred(err)
+	}
+}
@@ -0,0 +1,197 @@
+package classify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+	"github.com/swayne275/go-retry/retry"
+)
+
+// timeoutError is a net.Error reporting a timeout, like the one net returns
+// when a deadline passes.
+type timeoutError struct{ timeout bool }
+
+func (e timeoutError) Error() string   { return "i/o timeout" }
+func (e timeoutError) Timeout() bool   { return e.timeout }
+func (e timeoutError) Temporary() bool { return e.timeout }
+
+func opError(err error) error {
+	return &net.OpError{Op: "dial", Net: "tcp", Err: err}
+}
+
+func urlError(err error) error {
+	return &url.Error{Op: "Get", URL: "http://example.com", Err: err}
+}
+
+func TestTemporary(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		err  error
+		exp  bool
+	}{
+		{name: "nil", err: nil, exp: false},
+		{name: "plain", err: fmt.Errorf("oops"), exp: false},
+		{name: "op_timeout", err: opError(timeoutError{timeout: true}), exp: true},
+		{name: "op_not_timeout", err: opError(timeoutError{timeout: false}), exp: false},
+		{name: "url_timeout", err: urlError(opError(timeoutError{timeout: true})), exp: true},
+		{name: "connection_reset", err: opError(os.NewSyscallError("read", syscall.ECONNRESET)), exp: true},
+		{name: "connection_refused", err: urlError(opError(os.NewSyscallError("connect", syscall.ECONNREFUSED))), exp: true},
+		{name: "other_errno", err: opError(os.NewSyscallError("connect", syscall.EACCES)), exp: false},
+		{name: "unexpected_eof", err: fmt.Errorf("read body: %w", io.ErrUnexpectedEOF), exp: true},
+		{name: "eof", err: io.EOF, exp: false},
+		{name: "deadline_exceeded", err: urlError(context.DeadlineExceeded), exp: false},
+		{name: "canceled", err: fmt.Errorf("dial: %w", context.Canceled), exp: false},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := Temporary(tc.err); got != tc.exp {
+				t.Errorf("expected %t to be %t", got, tc.exp)
+			}
+		})
+	}
+}
+
+func TestHTTPStatus(t *testing.T) {
+	t.Parallel()
+
+	pred := HTTPStatus(http.StatusTooManyRequests, http.StatusServiceUnavailable)
+	errBase := fmt.Errorf("request failed")
+
+	cases := []struct {
+		name string
+		err  error
+		exp  bool
+	}{
+		{name: "nil", err: nil, exp: false},
+		{name: "no_status", err: errBase, exp: false},
+		{name: "matching", err: HTTPError(http.StatusServiceUnavailable, errBase), exp: true},
+		{name: "wrapped", err: fmt.Errorf("get: %w", HTTPError(http.StatusTooManyRequests, errBase)), exp: true},
+		{name: "in_url_error", err: urlError(HTTPError(http.StatusTooManyRequests, errBase)), exp: true},
+		{name: "other_status", err: HTTPError(http.StatusNotFound, errBase), exp: false},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := pred(tc.err); got != tc.exp {
+				t.Errorf("expected %t to be %t", got, tc.exp)
+			}
+		})
+	}
+
+	t.Run("preserves_error", func(t *testing.T) {
+		t.Parallel()
+
+		err := HTTPError(http.StatusBadGateway, errBase)
+		if !errors.Is(err, errBase) {
+			t.Errorf("expected %q to be %q", err, errBase)
+		}
+		if err.Error() != errBase.Error() {
+			t.Errorf("expected %q to be %q", err.Error(), errBase.Error())
+		}
+	})
+}
+
+func TestCombinators(t *testing.T) {
+	t.Parallel()
+
+	yes := func(error) bool { return true }
+	no := func(error) bool { return false }
+
+	cases := []struct {
+		name string
+		pred func(err error) bool
+		exp  bool
+	}{
+		{name: "any_empty", pred: Any(), exp: false},
+		{name: "any_none", pred: Any(no, no), exp: false},
+		{name: "any_one", pred: Any(no, yes), exp: true},
+		{name: "any_nil", pred: Any(nil, no), exp: false},
+		{name: "all_empty", pred: All(), exp: true},
+		{name: "all_every", pred: All(yes, yes), exp: true},
+		{name: "all_one_fails", pred: All(yes, no), exp: false},
+		{name: "all_nil", pred: All(nil, yes), exp: true},
+		{name: "not_yes", pred: Not(yes), exp: false},
+		{name: "not_no", pred: Not(no), exp: true},
+		{name: "not_nil", pred: Not(nil), exp: true},
+		{name: "nested", pred: All(Any(no, yes), Not(no)), exp: true},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tc.pred(fmt.Errorf("oops")); got != tc.exp {
+				t.Errorf("expected %t to be %t", got, tc.exp)
+			}
+		})
+	}
+}
+
+func TestWithShouldRetry(t *testing.T) {
+	t.Parallel()
+
+	// Temporary failures are retried, except 501 Not Implemented.
+	pred := All(
+		Any(Temporary, HTTPStatus(http.StatusServiceUnavailable, http.StatusNotImplemented)),
+		Not(HTTPStatus(http.StatusNotImplemented)),
+	)
+
+	cases := []struct {
+		name  string
+		err   error
+		calls int
+	}{
+		{name: "temporary", err: opError(timeoutError{timeout: true}), calls: 4},
+		{name: "retried_status", err: HTTPError(http.StatusServiceUnavailable, fmt.Errorf("unavailable")), calls: 4},
+		{name: "excluded_status", err: HTTPError(http.StatusNotImplemented, fmt.Errorf("not implemented")), calls: 1},
+		{name: "permanent", err: fmt.Errorf("bad request"), calls: 1},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			b, err := backoff.NewConstant(1 * time.Nanosecond)
+			if err != nil {
+				t.Fatalf("failed to create constant backoff: %v", err)
+			}
+
+			var calls int
+			err = retry.DoWithRetryCheck(context.Background(), backoff.WithMaxRetries(3, b), func(_ context.Context) error {
+				calls++
+				return tc.err
+			}, pred)
+			if !errors.Is(err, tc.err) {
+				t.Errorf("expected %q to be %q", err, tc.err)
+			}
+			if calls != tc.calls {
+				t.Errorf("expected %d to be %d", calls, tc.calls)
+			}
+		})
+	}
+}
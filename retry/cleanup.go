@@ -0,0 +1,71 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/swayne275/go-retry/internal/label"
+)
+
+// ErrAttemptSetupFailed is returned, wrapping the hook's error, when the
+// register hook of WithAttemptCleanup fails with an error that is not marked
+// as retryable. Its label is "attempt_setup_failed".
+var ErrAttemptSetupFailed = label.New("attempt setup failed", "attempt_setup_failed")
+
+// ErrCleanupPanicked is returned when a cleanup registered with
+// WithAttemptCleanup panics. The error includes the panic value and wraps the
+// attempt's error, if it had one. Its label is "cleanup_panicked".
+var ErrCleanupPanicked = label.New("attempt cleanup panicked", "cleanup_panicked")
+
+// WithAttemptCleanup makes DoWithOptions call register before each attempt,
+// and the cleanup it returns after that attempt, so resources acquired for an
+// attempt are released before the next one starts, however the attempt ends.
+//
+// The cleanup runs after the attempt returns or panics, and strictly before
+// the next call of register. A nil cleanup is skipped. If register fails, the
+// attempt is not made: an error marked with RetryableError is retried like a
+// failed attempt, any other error stops the loop wrapped in
+// ErrAttemptSetupFailed. If a cleanup panics, the panic is recovered and the
+// loop stops with an error wrapping ErrCleanupPanicked, since the resources of
+// the attempt may not have been released.
+func WithAttemptCleanup(register func(ctx context.Context) (cleanup func(), err error)) Option {
+	return func(o *options) {
+		o.attemptSetup = register
+	}
+}
+
+// runAttempt calls f between the hooks of WithAttemptCleanup, if register is
+// set. err is the attempt's error. fatal is set if the loop must stop because
+// register failed with a non-retryable error or the cleanup panicked.
+func runAttempt(ctx context.Context, register func(ctx context.Context) (func(), error), f RetryFunc) (err, fatal error) {
+	if register == nil {
+		return f(ctx), nil
+	}
+
+	cleanup, err := register(ctx)
+	if err != nil {
+		if _, ok := asRetryable(err, nil); ok {
+			return err, nil
+		}
+		return nil, fmt.Errorf("%w: %w", ErrAttemptSetupFailed, err)
+	}
+
+	if cleanup != nil {
+		defer func() {
+			fatal = runCleanup(cleanup)
+		}()
+	}
+	return f(ctx), nil
+}
+
+// runCleanup calls cleanup, turning a panic into an error.
+func runCleanup(cleanup func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrCleanupPanicked, r)
+		}
+	}()
+
+	cleanup()
+	return nil
+}
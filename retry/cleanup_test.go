@@ -0,0 +1,195 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+// hookRecorder records the interleaving of register, attempt and cleanup
+// calls.
+type hookRecorder struct {
+	events []string
+	n      int
+}
+
+func (r *hookRecorder) register(ctx context.Context) (func(), error) {
+	r.n++
+	n := r.n
+	r.events = append(r.events, fmt.Sprintf("register%d", n))
+	return func() {
+		r.events = append(r.events, fmt.Sprintf("cleanup%d", n))
+	}, nil
+}
+
+func (r *hookRecorder) attempt() {
+	r.events = append(r.events, fmt.Sprintf("attempt%d", r.n))
+}
+
+func (r *hookRecorder) String() string {
+	return strings.Join(r.events, " ")
+}
+
+func TestWithAttemptCleanup(t *testing.T) {
+	t.Parallel()
+
+	newBackoff := func(t *testing.T, maxRetries uint64) backoff.Backoff {
+		t.Helper()
+
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		return backoff.WithMaxRetries(maxRetries, b)
+	}
+
+	t.Run("ordering_until_success", func(t *testing.T) {
+		t.Parallel()
+
+		r := &hookRecorder{}
+		var calls int
+		if err := DoWithOptions(context.Background(), newBackoff(t, 5), func(_ context.Context) error {
+			r.attempt()
+			calls++
+			if calls < 3 {
+				return RetryableError(fmt.Errorf("oops"))
+			}
+			return nil
+		}, WithAttemptCleanup(r.register)); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		want := "register1 attempt1 cleanup1 register2 attempt2 cleanup2 register3 attempt3 cleanup3"
+		if got := r.String(); got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+	})
+
+	t.Run("ordering_until_exhausted", func(t *testing.T) {
+		t.Parallel()
+
+		r := &hookRecorder{}
+		err := DoWithOptions(context.Background(), newBackoff(t, 1), func(_ context.Context) error {
+			r.attempt()
+			return RetryableError(fmt.Errorf("oops"))
+		}, WithAttemptCleanup(r.register))
+		if !errors.Is(err, ErrBackoffSignaledToStop) {
+			t.Errorf("expected %q to be %q", err, ErrBackoffSignaledToStop)
+		}
+
+		want := "register1 attempt1 cleanup1 register2 attempt2 cleanup2"
+		if got := r.String(); got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+	})
+
+	t.Run("cleanup_runs_when_attempt_panics", func(t *testing.T) {
+		t.Parallel()
+
+		r := &hookRecorder{}
+		var calls int
+		func() {
+			defer func() {
+				if got := recover(); got != "boom" {
+					t.Errorf("expected panic %q, got %v", "boom", got)
+				}
+			}()
+			_ = DoWithOptions(context.Background(), newBackoff(t, 5), func(_ context.Context) error {
+				r.attempt()
+				calls++
+				if calls == 2 {
+					panic("boom")
+				}
+				return RetryableError(fmt.Errorf("oops"))
+			}, WithAttemptCleanup(r.register))
+		}()
+
+		want := "register1 attempt1 cleanup1 register2 attempt2 cleanup2"
+		if got := r.String(); got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+	})
+
+	t.Run("setup_failure_is_not_retried", func(t *testing.T) {
+		t.Parallel()
+
+		errSetup := fmt.Errorf("no temp dir")
+		err := DoWithOptions(context.Background(), newBackoff(t, 5), func(_ context.Context) error {
+			t.Error("expected f not to be called")
+			return nil
+		}, WithAttemptCleanup(func(context.Context) (func(), error) {
+			return nil, errSetup
+		}))
+		if !errors.Is(err, ErrAttemptSetupFailed) {
+			t.Errorf("expected %q to be %q", err, ErrAttemptSetupFailed)
+		}
+		if !errors.Is(err, errSetup) {
+			t.Errorf("expected %q to be %q", err, errSetup)
+		}
+	})
+
+	t.Run("retryable_setup_failure_is_retried", func(t *testing.T) {
+		t.Parallel()
+
+		var registers, calls int
+		if err := DoWithOptions(context.Background(), newBackoff(t, 5), func(_ context.Context) error {
+			calls++
+			return nil
+		}, WithAttemptCleanup(func(context.Context) (func(), error) {
+			registers++
+			if registers < 3 {
+				return nil, RetryableError(fmt.Errorf("busy"))
+			}
+			return nil, nil
+		})); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if registers != 3 || calls != 1 {
+			t.Errorf("expected 3 registers and 1 call, got %d and %d", registers, calls)
+		}
+	})
+
+	t.Run("cleanup_panic_stops_loop", func(t *testing.T) {
+		t.Parallel()
+
+		errFoo := fmt.Errorf("foo")
+		var calls int
+		err := DoWithOptions(context.Background(), newBackoff(t, 5), func(_ context.Context) error {
+			calls++
+			return RetryableError(errFoo)
+		}, WithAttemptCleanup(func(context.Context) (func(), error) {
+			return func() { panic("leaked") }, nil
+		}))
+		if !errors.Is(err, ErrCleanupPanicked) {
+			t.Errorf("expected %q to be %q", err, ErrCleanupPanicked)
+		}
+		if !errors.Is(err, errFoo) {
+			t.Errorf("expected %q to be %q", err, errFoo)
+		}
+		if !strings.Contains(err.Error(), "leaked") {
+			t.Errorf("expected %q to contain the panic value", err)
+		}
+		if calls != 1 {
+			t.Errorf("expected %d to be %d", calls, 1)
+		}
+	})
+
+	t.Run("cleanup_panic_after_success", func(t *testing.T) {
+		t.Parallel()
+
+		err := DoWithOptions(context.Background(), newBackoff(t, 5), func(_ context.Context) error {
+			return nil
+		}, WithAttemptCleanup(func(context.Context) (func(), error) {
+			return func() { panic("leaked") }, nil
+		}))
+		if !errors.Is(err, ErrCleanupPanicked) {
+			t.Errorf("expected %q to be %q", err, ErrCleanupPanicked)
+		}
+	})
+}
@@ -0,0 +1,68 @@
+package retry
+
+import (
+	"context"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+	"github.com/swayne275/go-retry/internal/clock"
+)
+
+// DoConstant behaves like ConstantRetry, retrying f every d until it succeeds,
+// returns an error that is not retryable, or ctx is done, but skips the
+// backoff and options machinery of Do. It is meant for hot paths: past the
+// first attempt, the only allocation per attempt is the context carrying the
+// attempt number, and the sleeps reuse a single timer.
+//
+// Retryable errors, RetryableAfterError hints, context priority, the
+// attempt values of the context passed to f, such as AttemptFromContext, and
+// GlobalControl all behave as they do in Do. An invalid
+// d returns the error ConstantRetry returns for it.
+func DoConstant(ctx context.Context, d time.Duration, f RetryFunc) error {
+	if d <= 0 {
+		return ValidateHelperInputs(backoff.StrategyConstant, d)
+	}
+
+	sleeper := clock.NewSleeper(clock.Real)
+	begun := time.Now()
+	var lastErr error
+	for attempt := uint64(1); ; attempt++ {
+		// Return immediately if ctx is canceled
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		err := f(withAttempt(ctx, attemptInfo{n: attempt, start: begun, clock: clock.Real, lastErr: lastErr}))
+		if err == nil {
+			return nil
+		}
+
+		rerr, ok := asRetryable(err, nil)
+		if !ok {
+			return nonRetryable(ctx, err)
+		}
+		lastErr = rerr.Unwrap()
+
+		// ctx.Done() has priority, so we test it alone first
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		st := global.ctl().Retries.Load()
+		if st.Disabled {
+			return retriesDisabled(st, lastErr)
+		}
+
+		err = sleeper.Sleep(ctx, rerr.sleep(d), st.Changed)
+		if err == clock.ErrInterrupted {
+			return retriesDisabled(global.ctl().Retries.Load(), lastErr)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestDoConstant(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	errFoo := fmt.Errorf("foo")
+
+	t.Run("retries_until_success", func(t *testing.T) {
+		t.Parallel()
+
+		var attempts []uint64
+		err := DoConstant(ctx, 1*time.Nanosecond, func(ctx context.Context) error {
+			attempts = append(attempts, AttemptFromContext(ctx))
+			if len(attempts) < 3 {
+				return RetryableError(errFoo)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if exp := []uint64{1, 2, 3}; fmt.Sprint(attempts) != fmt.Sprint(exp) {
+			t.Errorf("expected %v to be %v", attempts, exp)
+		}
+	})
+
+	t.Run("non_retryable", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int
+		err := DoConstant(ctx, 1*time.Nanosecond, func(_ context.Context) error {
+			calls++
+			return errFoo
+		})
+		if !errors.Is(err, ErrNonRetryable) || !errors.Is(err, errFoo) {
+			t.Errorf("expected %v to be %v and %v", err, ErrNonRetryable, errFoo)
+		}
+		if calls != 1 {
+			t.Errorf("expected %d to be %d", calls, 1)
+		}
+	})
+
+	t.Run("non_retryable_wins", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int
+		err := DoConstant(ctx, 1*time.Nanosecond, func(_ context.Context) error {
+			calls++
+			return errors.Join(RetryableError(errFoo), NonRetryableError(errFoo))
+		})
+		if !errors.Is(err, ErrNonRetryable) {
+			t.Errorf("expected %v to be %v", err, ErrNonRetryable)
+		}
+		if calls != 1 {
+			t.Errorf("expected %d to be %d", calls, 1)
+		}
+	})
+
+	t.Run("canceled_before_start", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		err := DoConstant(ctx, 1*time.Nanosecond, func(_ context.Context) error {
+			t.Error("expected f not to be called")
+			return nil
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected %v to be %v", err, context.Canceled)
+		}
+	})
+
+	t.Run("canceled_by_f", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		// ctx is checked before sleeping, even for a zero hint.
+		var calls int
+		err := DoConstant(ctx, 1*time.Nanosecond, func(_ context.Context) error {
+			calls++
+			cancel()
+			return RetryableAfterError(errFoo, 0)
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected %v to be %v", err, context.Canceled)
+		}
+		if calls != 1 {
+			t.Errorf("expected %d to be %d", calls, 1)
+		}
+	})
+
+	t.Run("canceled_during_sleep", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+
+		err := DoConstant(ctx, 1*time.Hour, func(_ context.Context) error {
+			return RetryableError(errFoo)
+		})
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected %v to be %v", err, context.DeadlineExceeded)
+		}
+	})
+
+	t.Run("hint_replaces_delay", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int
+		err := DoConstant(ctx, 1*time.Hour, func(_ context.Context) error {
+			calls++
+			if calls == 1 {
+				return RetryableAfterError(errFoo, 1*time.Nanosecond)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("invalid_delay", func(t *testing.T) {
+		t.Parallel()
+
+		err := DoConstant(ctx, 0, func(_ context.Context) error {
+			t.Error("expected f not to be called")
+			return nil
+		})
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+		if exp := ConstantRetry(ctx, 0, nil); err != exp {
+			t.Errorf("expected %v to be %v", err, exp)
+		}
+	})
+}
+
+// asError implements As, which only errors.As can honor.
+type asError struct {
+	target error
+}
+
+func (e *asError) Error() string {
+	return "as"
+}
+
+func (e *asError) As(target any) bool {
+	if t, ok := target.(**retryableError); ok {
+		if rerr, ok := e.target.(*retryableError); ok {
+			*t = rerr
+			return true
+		}
+	}
+	return false
+}
+
+func TestAsRetryable_matchesErrorsAs(t *testing.T) {
+	t.Parallel()
+
+	errFoo := fmt.Errorf("foo")
+	marked := RetryableError(errFoo)
+
+	cases := []struct {
+		name string
+		err  error
+	}{
+		{name: "unmarked", err: errFoo},
+		{name: "marked", err: marked},
+		{name: "wrapped", err: fmt.Errorf("a: %w", fmt.Errorf("b: %w", marked))},
+		{name: "outer_mark_first", err: RetryableAfterError(marked, time.Second)},
+		{name: "joined", err: errors.Join(errFoo, marked)},
+		{name: "joined_non_retryable", err: errors.Join(marked, NonRetryableError(errFoo))},
+		{name: "multi_wrapped", err: fmt.Errorf("%w and %w", errFoo, marked)},
+		{name: "as_method", err: &asError{target: marked}},
+		{name: "as_method_wrapped", err: fmt.Errorf("a: %w", &asError{target: marked})},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var expRerr *retryableError
+			var nerr *nonRetryableError
+			expOK := !errors.As(tc.err, &nerr) && errors.As(tc.err, &expRerr)
+
+			rerr, ok := asRetryable(tc.err, nil)
+			if ok != expOK {
+				t.Fatalf("expected %v to be %v", ok, expOK)
+			}
+			if ok && rerr != expRerr {
+				t.Errorf("expected %v to be %v", rerr, expRerr)
+			}
+		})
+	}
+}
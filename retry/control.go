@@ -0,0 +1,116 @@
+package retry
+
+import (
+	"sync"
+
+	"github.com/swayne275/go-retry/internal/control"
+	"github.com/swayne275/go-retry/internal/label"
+)
+
+// ErrRetriesDisabled is wrapped, with the reason and the last error from f, in
+// the DisabledError returned by loops that stopped retrying because retries
+// were disabled with Control.DisableRetries. Its label is "retries_disabled".
+var ErrRetriesDisabled = label.New("retries disabled", "retries_disabled")
+
+// DisabledError is returned by a loop that stopped because its Control
+// disabled retries. Reason is the reason given to the Control; the error wraps
+// ErrRetriesDisabled and the last error from f.
+type DisabledError = control.DisabledError
+
+// Control is a switch that makes loops stop retrying, for example while an
+// incident makes retry storms worse than failures. While retries are disabled,
+// first attempts still run, but a loop whose attempt fails returns at once
+// instead of retrying, and loops sleeping between attempts wake up and return.
+// Either way they return a DisabledError wrapping the last error from f.
+//
+// Control also has a separate switch for the loops of the repeat package.
+//
+// A Control is safe for concurrent use, and toggling it is cheap for the
+// loops consulting it. The zero Control is ready to use, with retries and
+// repeats enabled, and is independent of the GlobalControl.
+type Control struct {
+	once sync.Once
+	c    *control.Control
+}
+
+// ControlStatus describes the switches of a Control.
+type ControlStatus struct {
+	RetriesDisabled bool
+	RetriesReason   string
+	RepeatsDisabled bool
+	RepeatsReason   string
+}
+
+var global = &Control{c: control.Global}
+
+// GlobalControl returns the process-wide Control, which every loop consults
+// unless WithControl says otherwise.
+func GlobalControl() *Control {
+	return global
+}
+
+// NewControl creates a Control with retries and repeats enabled, for loops
+// given it with WithControl.
+func NewControl() *Control {
+	return &Control{c: control.New()}
+}
+
+// ctl returns the switches of c, creating them for the zero Control.
+func (c *Control) ctl() *control.Control {
+	c.once.Do(func() {
+		if c.c == nil {
+			c.c = control.New()
+		}
+	})
+	return c.c
+}
+
+// DisableRetries makes loops stop retrying, reporting reason.
+func (c *Control) DisableRetries(reason string) {
+	c.ctl().Retries.Disable(reason)
+}
+
+// EnableRetries restores normal retries. Loops that already stopped are not
+// restarted.
+func (c *Control) EnableRetries() {
+	c.ctl().Retries.Enable()
+}
+
+// DisableRepeats makes the loops of the repeat package stop repeating,
+// reporting reason. Only the process-wide Control affects them.
+func (c *Control) DisableRepeats(reason string) {
+	c.ctl().Repeats.Disable(reason)
+}
+
+// EnableRepeats restores normal repeats.
+func (c *Control) EnableRepeats() {
+	c.ctl().Repeats.Enable()
+}
+
+// Status reports the current state of the switches.
+func (c *Control) Status() ControlStatus {
+	retries, repeats := c.ctl().Retries.Load(), c.ctl().Repeats.Load()
+	return ControlStatus{
+		RetriesDisabled: retries.Disabled,
+		RetriesReason:   retries.Reason,
+		RepeatsDisabled: repeats.Disabled,
+		RepeatsReason:   repeats.Reason,
+	}
+}
+
+// WithControl makes DoWithOptions consult c instead of the GlobalControl. A
+// nil c selects the GlobalControl.
+func WithControl(c *Control) Option {
+	return func(o *options) {
+		if c == nil {
+			c = global
+		}
+		o.control = c
+	}
+}
+
+// retriesDisabled returns the error for a loop stopped by st, which disabled
+// retries, after f returned lastErr.
+func retriesDisabled(st *control.State, lastErr error) error {
+	return &DisabledError{Sentinel: ErrRetriesDisabled, Reason: st.Reason, Err: lastErr}
+}
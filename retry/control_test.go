@@ -0,0 +1,220 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+func TestControl(t *testing.T) {
+	t.Parallel()
+
+	errOops := fmt.Errorf("oops")
+
+	expectDisabled := func(t *testing.T, err error, reason string) {
+		t.Helper()
+
+		var derr *DisabledError
+		if !errors.As(err, &derr) {
+			t.Fatalf("expected %q to be a DisabledError", err)
+		}
+		if derr.Reason != reason {
+			t.Errorf("expected %q to be %q", derr.Reason, reason)
+		}
+		if !errors.Is(err, ErrRetriesDisabled) {
+			t.Errorf("expected %q to be %q", err, ErrRetriesDisabled)
+		}
+	}
+
+	t.Run("wakes_sleeping_loop", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewConstant(1 * time.Hour)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		c := NewControl()
+		attempted := make(chan struct{}, 1)
+		errc := make(chan error, 1)
+		go func() {
+			errc <- DoWithOptions(context.Background(), b, func(_ context.Context) error {
+				attempted <- struct{}{}
+				return RetryableError(errOops)
+			}, WithControl(c))
+		}()
+
+		<-attempted
+		c.DisableRetries("incident 42")
+
+		select {
+		case err := <-errc:
+			expectDisabled(t, err, "incident 42")
+			if !errors.Is(err, errOops) {
+				t.Errorf("expected %q to be %q", err, errOops)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("sleeping loop did not wake")
+		}
+	})
+
+	t.Run("first_attempts_still_run", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		c := NewControl()
+		c.DisableRetries("incident")
+
+		var attempts int
+		if err := DoWithOptions(context.Background(), b, func(_ context.Context) error {
+			attempts++
+			return nil
+		}, WithControl(c)); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		err = DoWithOptions(context.Background(), b, func(_ context.Context) error {
+			attempts++
+			return RetryableError(errOops)
+		}, WithControl(c))
+		expectDisabled(t, err, "incident")
+		if !errors.Is(err, errOops) {
+			t.Errorf("expected %q to be %q", err, errOops)
+		}
+		if attempts != 2 {
+			t.Errorf("expected %v to be %v", attempts, 2)
+		}
+	})
+
+	t.Run("enable_restores", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		c := NewControl()
+		c.DisableRetries("incident")
+		if got, want := c.Status(), (ControlStatus{RetriesDisabled: true, RetriesReason: "incident"}); got != want {
+			t.Errorf("expected %+v to be %+v", got, want)
+		}
+		c.EnableRetries()
+		if got, want := c.Status(), (ControlStatus{}); got != want {
+			t.Errorf("expected %+v to be %+v", got, want)
+		}
+
+		var attempts int
+		err = DoWithOptions(context.Background(), backoff.WithMaxRetries(3, b), func(_ context.Context) error {
+			attempts++
+			return RetryableError(errOops)
+		}, WithControl(c))
+		if !errors.Is(err, ErrBackoffSignaledToStop) {
+			t.Errorf("expected %q to be %q", err, ErrBackoffSignaledToStop)
+		}
+		if attempts != 4 {
+			t.Errorf("expected %v to be %v", attempts, 4)
+		}
+	})
+
+	t.Run("concurrent_toggles", func(t *testing.T) {
+		t.Parallel()
+
+		c := NewControl()
+
+		const loops = 200
+		errc := make(chan error, loops)
+		for i := 0; i < loops; i++ {
+			go func() {
+				b, err := backoff.NewConstant(1 * time.Millisecond)
+				if err != nil {
+					errc <- err
+					return
+				}
+				errc <- DoWithOptions(context.Background(), b, func(_ context.Context) error {
+					return RetryableError(errOops)
+				}, WithControl(c))
+			}()
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+
+				for j := 0; j < 200; j++ {
+					if (i+j)%2 == 0 {
+						c.DisableRetries(fmt.Sprintf("toggle %d", i))
+					} else {
+						c.EnableRetries()
+					}
+					_ = c.Status()
+				}
+			}(i)
+		}
+		wg.Wait()
+		c.DisableRetries("final")
+
+		deadline := time.After(10 * time.Second)
+		for i := 0; i < loops; i++ {
+			select {
+			case err := <-errc:
+				if !errors.Is(err, ErrRetriesDisabled) {
+					t.Errorf("expected %q to be %q", err, ErrRetriesDisabled)
+				}
+			case <-deadline:
+				t.Fatalf("only %d of %d loops returned", i, loops)
+			}
+		}
+	})
+}
+
+// TestGlobalControl is not parallel, so disabling the global control can't
+// affect other tests.
+func TestGlobalControl(t *testing.T) {
+	c := GlobalControl()
+	c.DisableRetries("incident")
+	defer c.EnableRetries()
+
+	b, err := backoff.NewConstant(1 * time.Nanosecond)
+	if err != nil {
+		t.Fatalf("failed to create constant backoff: %v", err)
+	}
+	retryable := func(_ context.Context) error {
+		return RetryableError(fmt.Errorf("oops"))
+	}
+	policy := func() backoff.Backoff { return b }
+
+	for name, run := range map[string]func() error{
+		"do": func() error {
+			return Do(context.Background(), b, retryable)
+		},
+		"two_phase": func() error {
+			return DoTwoPhase(context.Background(), policy, policy, func(ctx context.Context) (bool, error) {
+				return false, retryable(ctx)
+			})
+		},
+		"pipelined": func() error {
+			return DoPipelined(context.Background(), b, 3, retryable)
+		},
+	} {
+		if err := run(); !errors.Is(err, ErrRetriesDisabled) {
+			t.Errorf("%s: expected %q to be %q", name, err, ErrRetriesDisabled)
+		}
+	}
+
+	c.EnableRetries()
+	if err := Do(context.Background(), backoff.WithMaxRetries(1, b), retryable); !errors.Is(err, ErrBackoffSignaledToStop) {
+		t.Errorf("expected %q to be %q", err, ErrBackoffSignaledToStop)
+	}
+}
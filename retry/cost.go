@@ -0,0 +1,28 @@
+package retry
+
+import "github.com/swayne275/go-retry/internal/cost"
+
+// Cost is the time spent by one or more loops: the number of attempts, the
+// time slept between them, and the time spent executing them.
+type Cost = cost.Cost
+
+// CostAccumulator sums the Cost of loops per key, for example per tenant, so
+// the time spent retrying can be attributed. It is safe for concurrent use and
+// can be shared with the loops of the repeat package.
+type CostAccumulator = cost.Accumulator
+
+// NewCostAccumulator creates an empty CostAccumulator. Read it with Snapshot,
+// or with Drain to read and clear it for periodic export.
+func NewCostAccumulator() *CostAccumulator {
+	return cost.New()
+}
+
+// WithCostKey makes DoWithOptions add the Cost of the loop to acc under key
+// when the loop ends, however it ends. Keys are arbitrary; keeping their
+// number bounded is up to the caller.
+func WithCostKey(acc *CostAccumulator, key string) Option {
+	return func(o *options) {
+		o.costAcc = acc
+		o.costKey = key
+	}
+}
@@ -0,0 +1,193 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+// countingClock counts how often the time is read.
+type countingClock struct {
+	*fakeClock
+	nows int64
+}
+
+func (c *countingClock) Now() time.Time {
+	atomic.AddInt64(&c.nows, 1)
+	return c.fakeClock.Now()
+}
+
+func TestWithCostKey(t *testing.T) {
+	t.Parallel()
+
+	// run makes a loop of 5 attempts taking 3ms each, with 4 sleeps of 10ms.
+	run := func(t *testing.T, opts ...Option) {
+		t.Helper()
+
+		b, err := backoff.NewConstant(10 * time.Millisecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		c := newFakeClock()
+		opts = append(opts, WithClock(c))
+		_ = DoWithOptions(context.Background(), backoff.WithMaxRetries(4, b), func(_ context.Context) error {
+			c.Advance(3 * time.Millisecond)
+			return RetryableError(fmt.Errorf("oops"))
+		}, opts...)
+	}
+	perLoop := Cost{Attempts: 5, Sleep: 40 * time.Millisecond, Execution: 15 * time.Millisecond}
+	times := func(c Cost, n int) Cost {
+		return Cost{
+			Attempts:  c.Attempts * uint64(n),
+			Sleep:     c.Sleep * time.Duration(n),
+			Execution: c.Execution * time.Duration(n),
+		}
+	}
+
+	t.Run("exact_sums_across_concurrent_loops", func(t *testing.T) {
+		t.Parallel()
+
+		acc := NewCostAccumulator()
+		keys := []string{"tenant-a", "tenant-b", "tenant-c"}
+		loops := 20
+
+		var wg sync.WaitGroup
+		for _, key := range keys {
+			for i := 0; i < loops; i++ {
+				wg.Add(1)
+				go func(key string) {
+					defer wg.Done()
+					run(t, WithCostKey(acc, key))
+				}(key)
+			}
+		}
+		wg.Wait()
+
+		got := acc.Snapshot()
+		if len(got) != len(keys) {
+			t.Fatalf("expected %v to have %d keys", got, len(keys))
+		}
+		for _, key := range keys {
+			if want := times(perLoop, loops); got[key] != want {
+				t.Errorf("%s: expected %+v to be %+v", key, got[key], want)
+			}
+		}
+	})
+
+	t.Run("counts_successful_and_canceled_loops", func(t *testing.T) {
+		t.Parallel()
+
+		acc := NewCostAccumulator()
+		c := newFakeClock()
+		b, err := backoff.NewConstant(10 * time.Millisecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		var calls int
+		if err := DoWithOptions(context.Background(), b, func(_ context.Context) error {
+			calls++
+			c.Advance(1 * time.Millisecond)
+			if calls < 3 {
+				return RetryableError(fmt.Errorf("oops"))
+			}
+			return nil
+		}, WithClock(c), WithCostKey(acc, "ok")); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_ = DoWithOptions(ctx, b, func(_ context.Context) error {
+			return nil
+		}, WithClock(c), WithCostKey(acc, "canceled"))
+
+		got := acc.Snapshot()
+		if want := (Cost{Attempts: 3, Sleep: 20 * time.Millisecond, Execution: 3 * time.Millisecond}); got["ok"] != want {
+			t.Errorf("expected %+v to be %+v", got["ok"], want)
+		}
+		if want := (Cost{}); got["canceled"] != want {
+			t.Errorf("expected %+v to be %+v", got["canceled"], want)
+		}
+	})
+
+	t.Run("drain_under_concurrent_writers", func(t *testing.T) {
+		t.Parallel()
+
+		acc := NewCostAccumulator()
+		loops := 200
+
+		var mu sync.Mutex
+		drained := make(map[string]Cost)
+		collect := func(m map[string]Cost) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			for key, c := range m {
+				// A loop's cost must never be split across drains.
+				if n := int(c.Attempts / perLoop.Attempts); c != times(perLoop, n) {
+					t.Errorf("%s: drained a partial loop: %+v", key, c)
+				}
+				d := drained[key]
+				d.Attempts += c.Attempts
+				d.Sleep += c.Sleep
+				d.Execution += c.Execution
+				drained[key] = d
+			}
+		}
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 100; i++ {
+				collect(acc.Drain())
+			}
+		}()
+
+		var wg sync.WaitGroup
+		for i := 0; i < loops; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				run(t, WithCostKey(acc, fmt.Sprintf("tenant-%d", i%4)))
+			}(i)
+		}
+		wg.Wait()
+		<-done
+		collect(acc.Drain())
+
+		if got := acc.Snapshot(); len(got) != 0 {
+			t.Errorf("expected %v to be empty", got)
+		}
+		for i := 0; i < 4; i++ {
+			key := fmt.Sprintf("tenant-%d", i)
+			if want := times(perLoop, loops/4); drained[key] != want {
+				t.Errorf("%s: expected %+v to be %+v", key, drained[key], want)
+			}
+		}
+	})
+
+	t.Run("no_clock_reads_without_option", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewConstant(10 * time.Millisecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		c := &countingClock{fakeClock: newFakeClock()}
+		_ = DoWithOptions(context.Background(), backoff.WithMaxRetries(4, b), func(_ context.Context) error {
+			return RetryableError(fmt.Errorf("oops"))
+		}, WithClock(c))
+		// The loop only reads its start, for ElapsedFromContext.
+		if got := atomic.LoadInt64(&c.nows); got != 1 {
+			t.Errorf("expected %d to be %d", got, 1)
+		}
+	})
+}
@@ -0,0 +1,175 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+func TestNewDelayHistogram(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		buckets []time.Duration
+		err     error
+	}{
+		{name: "valid", buckets: []time.Duration{0, time.Millisecond, time.Second}},
+		{name: "empty", buckets: nil, err: ErrInvalidDelayHistogram},
+		{name: "unsorted", buckets: []time.Duration{time.Second, time.Millisecond}, err: ErrInvalidDelayHistogram},
+		{name: "duplicate", buckets: []time.Duration{time.Second, time.Second}, err: ErrInvalidDelayHistogram},
+		{name: "negative", buckets: []time.Duration{-time.Second, time.Second}, err: ErrInvalidDelayHistogram},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewDelayHistogram(tc.buckets)
+			if !errors.Is(err, tc.err) {
+				t.Errorf("expected %v to be %v", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestDelayHistogram(t *testing.T) {
+	t.Parallel()
+
+	buckets := []time.Duration{10 * time.Millisecond, 100 * time.Millisecond, time.Second}
+
+	t.Run("bucket_placement", func(t *testing.T) {
+		t.Parallel()
+
+		h, err := NewDelayHistogram(buckets)
+		if err != nil {
+			t.Fatalf("failed to create histogram: %v", err)
+		}
+		for _, d := range []time.Duration{
+			0,
+			10 * time.Millisecond,   // boundary, first bucket
+			10*time.Millisecond + 1, // just above, second bucket
+			100 * time.Millisecond,  // boundary, second bucket
+			time.Second,             // boundary, third bucket
+			time.Second + 1,         // overflow
+			time.Hour,               // overflow
+		} {
+			h.Observe(d)
+		}
+
+		got := h.Snapshot()
+		if exp := []uint64{2, 2, 1, 2}; !reflect.DeepEqual(got.Counts, exp) {
+			t.Errorf("expected %v to be %v", got.Counts, exp)
+		}
+		if !reflect.DeepEqual(got.Buckets, buckets) {
+			t.Errorf("expected %v to be %v", got.Buckets, buckets)
+		}
+		if exp := 20*time.Millisecond + 1 + 100*time.Millisecond + 2*time.Second + 1 + time.Hour; got.Sum != exp {
+			t.Errorf("expected %v to be %v", got.Sum, exp)
+		}
+	})
+
+	t.Run("records_slept_delays", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewExponential(10 * time.Millisecond)
+		if err != nil {
+			t.Fatalf("failed to create exponential backoff: %v", err)
+		}
+		h, err := NewDelayHistogram(buckets)
+		if err != nil {
+			t.Fatalf("failed to create histogram: %v", err)
+		}
+
+		// Sleeps of 10ms, 20ms, 40ms, 80ms, 160ms, 320ms, 640ms and 1.28s.
+		_ = DoWithOptions(context.Background(), backoff.WithMaxRetries(8, b), func(_ context.Context) error {
+			return RetryableError(fmt.Errorf("oops"))
+		}, WithClock(newFakeClock()), WithDelayHistogram(h))
+
+		if got, exp := h.Snapshot().Counts, []uint64{1, 3, 3, 1}; !reflect.DeepEqual(got, exp) {
+			t.Errorf("expected %v to be %v", got, exp)
+		}
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		t.Parallel()
+
+		a, err := NewDelayHistogram(buckets)
+		if err != nil {
+			t.Fatalf("failed to create histogram: %v", err)
+		}
+		b, err := NewDelayHistogram(buckets)
+		if err != nil {
+			t.Fatalf("failed to create histogram: %v", err)
+		}
+		a.Observe(5 * time.Millisecond)
+		a.Observe(time.Minute)
+		b.Observe(50 * time.Millisecond)
+		b.Observe(time.Minute)
+
+		if err := a.Merge(b); err != nil {
+			t.Fatalf("failed to merge: %v", err)
+		}
+		got := a.Snapshot()
+		if exp := []uint64{1, 1, 0, 2}; !reflect.DeepEqual(got.Counts, exp) {
+			t.Errorf("expected %v to be %v", got.Counts, exp)
+		}
+		if exp := 55*time.Millisecond + 2*time.Minute; got.Sum != exp {
+			t.Errorf("expected %v to be %v", got.Sum, exp)
+		}
+		if got, exp := b.Snapshot().Counts, []uint64{0, 1, 0, 1}; !reflect.DeepEqual(got, exp) {
+			t.Errorf("expected %v to be %v", got, exp)
+		}
+
+		c, err := NewDelayHistogram(buckets[:2])
+		if err != nil {
+			t.Fatalf("failed to create histogram: %v", err)
+		}
+		if err := a.Merge(c); !errors.Is(err, ErrInvalidDelayHistogram) {
+			t.Errorf("expected %v to be %v", err, ErrInvalidDelayHistogram)
+		}
+		if err := a.Merge(nil); !errors.Is(err, ErrInvalidDelayHistogram) {
+			t.Errorf("expected %v to be %v", err, ErrInvalidDelayHistogram)
+		}
+	})
+
+	t.Run("concurrent_loops", func(t *testing.T) {
+		t.Parallel()
+
+		h, err := NewDelayHistogram(buckets)
+		if err != nil {
+			t.Fatalf("failed to create histogram: %v", err)
+		}
+
+		const loops = 50
+		var wg sync.WaitGroup
+		for i := 0; i < loops; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				b, err := backoff.NewConstant(50 * time.Millisecond)
+				if err != nil {
+					t.Errorf("failed to create constant backoff: %v", err)
+					return
+				}
+				_ = DoWithOptions(context.Background(), backoff.WithMaxRetries(4, b), func(_ context.Context) error {
+					return RetryableError(fmt.Errorf("oops"))
+				}, WithClock(newFakeClock()), WithDelayHistogram(h))
+			}()
+		}
+		wg.Wait()
+
+		if got, exp := h.Snapshot().Counts, []uint64{0, 4 * loops, 0, 0}; !reflect.DeepEqual(got, exp) {
+			t.Errorf("expected %v to be %v", got, exp)
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package retry
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/swayne275/go-retry/internal/label"
+)
+
+// ErrRetryExpectationExceeded is returned, wrapping the last retryable error,
+// when a loop would retry more times than a test expectation set with
+// WithTestExpectation allows. Its label is "retry_expectation_exceeded".
+var ErrRetryExpectationExceeded = label.New("retried more than expected", "retry_expectation_exceeded")
+
+// TB is the subset of testing.TB used by WithTestExpectation.
+type TB interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// testExpectation is the retry bound set with WithTestExpectation.
+type testExpectation struct {
+	t          TB
+	maxRetries uint64
+}
+
+// WithTestExpectation makes DoWithOptions fail t as soon as the loop is about
+// to make more than maxRetries retries, for tests asserting that a code path
+// retries at most so many times. The failure lists the error of every attempt
+// so far, to explain why the loop retried, and the loop stops at once with an
+// error wrapping ErrRetryExpectationExceeded and the last error from f.
+//
+// The failure is reported with t.Errorf, not t.Fatalf, so it is safe for the
+// loop to run on a goroutine other than the test's. A nil t makes the option
+// inert.
+func WithTestExpectation(t TB, maxRetries uint64) Option {
+	return func(o *options) {
+		if t == nil {
+			o.expectation = nil
+			return
+		}
+		o.expectation = &testExpectation{t: t, maxRetries: maxRetries}
+	}
+}
+
+// check reports whether the loop may make retry number retry, given the
+// errors of the attempts so far, failing the test if not.
+func (e *testExpectation) check(retry uint64, errs []error) error {
+	if retry <= e.maxRetries {
+		return nil
+	}
+
+	var b strings.Builder
+	for i, err := range errs {
+		fmt.Fprintf(&b, "\n\tattempt %d: %v", i+1, err)
+	}
+	e.t.Helper()
+	e.t.Errorf("retry: expected at most %d retries, but the loop was about to make retry %d after:%s", e.maxRetries, retry, b.String())
+
+	return fmt.Errorf("%w: %w", ErrRetryExpectationExceeded, errs[len(errs)-1])
+}
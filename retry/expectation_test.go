@@ -0,0 +1,152 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+// recordingTB is a TB that records failures instead of failing the test.
+type recordingTB struct {
+	mu     sync.Mutex
+	errors []string
+}
+
+func (t *recordingTB) Helper() {}
+
+func (t *recordingTB) Errorf(format string, args ...any) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func (t *recordingTB) Errors() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]string(nil), t.errors...)
+}
+
+func TestWithTestExpectation(t *testing.T) {
+	t.Parallel()
+
+	// failUntil returns f that fails with a numbered retryable error until
+	// attempt n, and succeeds from then on.
+	failUntil := func(n uint64) RetryFunc {
+		return func(ctx context.Context) error {
+			if attempt := AttemptFromContext(ctx); attempt < n {
+				return RetryableError(fmt.Errorf("oops %d", attempt))
+			}
+			return nil
+		}
+	}
+
+	cases := []struct {
+		name       string
+		maxRetries uint64
+		f          RetryFunc
+		calls      uint64
+		fail       bool
+	}{
+		{name: "no_retries", maxRetries: 0, f: failUntil(1), calls: 1},
+		{name: "within_bound", maxRetries: 2, f: failUntil(3), calls: 3},
+		{name: "exceeds_zero", maxRetries: 0, f: failUntil(5), calls: 1, fail: true},
+		{name: "exceeds_bound", maxRetries: 2, f: failUntil(5), calls: 3, fail: true},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			b, err := backoff.NewConstant(1 * time.Nanosecond)
+			if err != nil {
+				t.Fatalf("failed to create constant backoff: %v", err)
+			}
+
+			var calls uint64
+			var tb recordingTB
+			err = DoWithOptions(context.Background(), b, func(ctx context.Context) error {
+				calls++
+				return tc.f(ctx)
+			}, WithTestExpectation(&tb, tc.maxRetries))
+
+			if calls != tc.calls {
+				t.Errorf("expected %d to be %d", calls, tc.calls)
+			}
+
+			failures := tb.Errors()
+			if !tc.fail {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				if len(failures) != 0 {
+					t.Errorf("expected no failures, got %v", failures)
+				}
+				return
+			}
+
+			if !errors.Is(err, ErrRetryExpectationExceeded) {
+				t.Errorf("expected %v to be %v", err, ErrRetryExpectationExceeded)
+			}
+			if exp := fmt.Sprintf("oops %d", tc.calls); !strings.HasSuffix(err.Error(), exp) {
+				t.Errorf("expected %q to end with the last error %q", err, exp)
+			}
+			if len(failures) != 1 {
+				t.Fatalf("expected exactly one failure, got %v", failures)
+			}
+			msg := failures[0]
+			if exp := fmt.Sprintf("expected at most %d retries, but the loop was about to make retry %d", tc.maxRetries, tc.maxRetries+1); !strings.Contains(msg, exp) {
+				t.Errorf("expected %q to contain %q", msg, exp)
+			}
+			for i := uint64(1); i <= tc.calls; i++ {
+				if exp := fmt.Sprintf("attempt %d: oops %d", i, i); !strings.Contains(msg, exp) {
+					t.Errorf("expected %q to contain %q", msg, exp)
+				}
+			}
+		})
+	}
+
+	t.Run("nil_tb_is_inert", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		if err := DoWithOptions(context.Background(), b, failUntil(4), WithTestExpectation(nil, 0)); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("other_goroutine", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		var tb recordingTB
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- DoWithOptions(context.Background(), b, failUntil(4), WithTestExpectation(&tb, 1))
+		}()
+
+		if err := <-errCh; !errors.Is(err, ErrRetryExpectationExceeded) {
+			t.Errorf("expected %v to be %v", err, ErrRetryExpectationExceeded)
+		}
+		if got := len(tb.Errors()); got != 1 {
+			t.Errorf("expected %d to be %d", got, 1)
+		}
+	})
+}
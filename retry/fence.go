@@ -0,0 +1,50 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/swayne275/go-retry/internal/label"
+)
+
+// ErrFenced is returned, wrapping the fence check's error and the last
+// retryable error if there was one, when a fence set with WithFence or
+// WithFenceOnRetry reports that the loop must not make another attempt. Its
+// label is "fenced".
+var ErrFenced = label.New("fenced", "fenced")
+
+// WithFence makes DoWithOptions call check before every attempt, including the
+// first, with the loop's context. check guards attempts made on behalf of
+// something that can be superseded, such as a lease whose fencing token may
+// have been taken over by another holder while the loop slept. If check returns
+// an error, the loop stops without calling f again and returns an error
+// wrapping ErrFenced, check's error and the last error from f.
+func WithFence(check func(ctx context.Context) error) Option {
+	return func(o *options) {
+		o.fence = check
+		o.fenceOnRetry = false
+	}
+}
+
+// WithFenceOnRetry is like WithFence, but lets the first attempt proceed
+// without calling check, for callers that have just acquired what check
+// verifies.
+func WithFenceOnRetry(check func(ctx context.Context) error) Option {
+	return func(o *options) {
+		o.fence = check
+		o.fenceOnRetry = true
+	}
+}
+
+// checkFence calls check, returning the ErrFenced error to end the loop with
+// if it fails. lastErr is the last error from f, if there was an attempt.
+func checkFence(ctx context.Context, check func(ctx context.Context) error, lastErr error) error {
+	err := check(ctx)
+	if err == nil {
+		return nil
+	}
+	if lastErr != nil {
+		return fmt.Errorf("%w: %w: %w", ErrFenced, err, lastErr)
+	}
+	return fmt.Errorf("%w: %w", ErrFenced, err)
+}
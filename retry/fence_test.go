@@ -0,0 +1,125 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+func TestWithFence(t *testing.T) {
+	t.Parallel()
+
+	newBackoff := func(t *testing.T, maxRetries uint64) backoff.Backoff {
+		t.Helper()
+
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		return backoff.WithMaxRetries(maxRetries, b)
+	}
+
+	errLeaseLost := fmt.Errorf("lease lost")
+	errWrite := fmt.Errorf("write failed")
+
+	t.Run("trips_between_attempts", func(t *testing.T) {
+		t.Parallel()
+
+		var attempts, checks int
+		err := DoWithOptions(context.Background(), newBackoff(t, 5), func(_ context.Context) error {
+			attempts++
+			return RetryableError(errWrite)
+		}, WithFence(func(_ context.Context) error {
+			checks++
+			if checks == 3 {
+				return errLeaseLost
+			}
+			return nil
+		}))
+		if !errors.Is(err, ErrFenced) {
+			t.Errorf("expected %q to be %q", err, ErrFenced)
+		}
+		if !errors.Is(err, errLeaseLost) {
+			t.Errorf("expected %q to be %q", err, errLeaseLost)
+		}
+		if !errors.Is(err, errWrite) {
+			t.Errorf("expected %q to be %q", err, errWrite)
+		}
+		if got, want := attempts, 2; got != want {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+		if got := Classify(err); got != ErrFenced {
+			t.Errorf("expected %v to be %v", got, ErrFenced)
+		}
+	})
+
+	t.Run("trips_before_first_attempt", func(t *testing.T) {
+		t.Parallel()
+
+		var attempts int
+		err := DoWithOptions(context.Background(), newBackoff(t, 5), func(_ context.Context) error {
+			attempts++
+			return nil
+		}, WithFence(func(_ context.Context) error {
+			return errLeaseLost
+		}))
+		if !errors.Is(err, ErrFenced) || !errors.Is(err, errLeaseLost) {
+			t.Errorf("expected %q to wrap %q and %q", err, ErrFenced, errLeaseLost)
+		}
+		if attempts != 0 {
+			t.Errorf("expected %v to be %v", attempts, 0)
+		}
+	})
+
+	t.Run("on_retry_skips_first_check", func(t *testing.T) {
+		t.Parallel()
+
+		var attempts, checks int
+		err := DoWithOptions(context.Background(), newBackoff(t, 5), func(_ context.Context) error {
+			attempts++
+			return RetryableError(errWrite)
+		}, WithFenceOnRetry(func(_ context.Context) error {
+			checks++
+			return errLeaseLost
+		}))
+		if !errors.Is(err, ErrFenced) || !errors.Is(err, errWrite) {
+			t.Errorf("expected %q to wrap %q and %q", err, ErrFenced, errWrite)
+		}
+		if attempts != 1 || checks != 1 {
+			t.Errorf("expected 1 attempt and 1 check, got %d and %d", attempts, checks)
+		}
+	})
+
+	t.Run("never_trips", func(t *testing.T) {
+		t.Parallel()
+
+		run := func(opts ...Option) (int, error) {
+			var attempts int
+			err := DoWithOptions(context.Background(), newBackoff(t, 3), func(_ context.Context) error {
+				attempts++
+				return RetryableError(errWrite)
+			}, opts...)
+			return attempts, err
+		}
+
+		var checks int
+		fenced, fencedErr := run(WithFence(func(_ context.Context) error {
+			checks++
+			return nil
+		}))
+		plain, plainErr := run()
+		if fenced != plain {
+			t.Errorf("expected %v to be %v", fenced, plain)
+		}
+		if fencedErr.Error() != plainErr.Error() {
+			t.Errorf("expected %q to be %q", fencedErr, plainErr)
+		}
+		if checks != fenced {
+			t.Errorf("expected a check before each of the %d attempts, got %d", fenced, checks)
+		}
+	})
+}
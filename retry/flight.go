@@ -0,0 +1,86 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// flightGroup collapses concurrent calls that share a key into one.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[any]*flightCall
+}
+
+type flightCall struct {
+	done chan struct{}
+	err  error
+	// dups counts the calls that found this one in flight.
+	dups int
+}
+
+// begin registers a call for key. If one is already in flight it returns that
+// call and false.
+func (g *flightGroup) begin(key any) (*flightCall, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if c, ok := g.calls[key]; ok {
+		c.dups++
+		return c, false
+	}
+	if g.calls == nil {
+		g.calls = make(map[any]*flightCall)
+	}
+	c := &flightCall{done: make(chan struct{})}
+	g.calls[key] = c
+	return c, true
+}
+
+func (g *flightGroup) end(key any, c *flightCall, err error) {
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+
+	c.err = err
+	close(c.done)
+}
+
+// do calls fn and returns its error, unless a call for key is already in
+// flight, in which case it waits for that call, or for ctx to be done, and
+// returns its error instead; shared reports which happened. If fn panics, the
+// waiters get an error wrapping ErrPanicked and the panic continues.
+func (g *flightGroup) do(ctx context.Context, key any, fn func() error) (err error, shared bool) {
+	c, first := g.begin(key)
+	if !first {
+		select {
+		case <-c.done:
+			return c.err, true
+		case <-ctx.Done():
+			return ctx.Err(), true
+		}
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			g.end(key, c, fmt.Errorf("%w: %v", ErrPanicked, r))
+			panic(r)
+		}
+		g.end(key, c, err)
+	}()
+	return fn(), false
+}
+
+// start calls fn in a new goroutine, unless a call for key is already in
+// flight.
+func (g *flightGroup) start(key any, fn func()) {
+	c, first := g.begin(key)
+	if !first {
+		return
+	}
+
+	go func() {
+		defer g.end(key, c, nil)
+		fn()
+	}()
+}
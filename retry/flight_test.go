@@ -0,0 +1,170 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// waitForDups waits until n later calls have found the call for key in flight.
+func waitForDups(g *flightGroup, key any, n int) {
+	for {
+		g.mu.Lock()
+		var dups int
+		if c, ok := g.calls[key]; ok {
+			dups = c.dups
+		}
+		g.mu.Unlock()
+		if dups == n {
+			return
+		}
+		runtime.Gosched()
+	}
+}
+
+func TestFlightGroup(t *testing.T) {
+	t.Parallel()
+
+	t.Run("do_shares_result", func(t *testing.T) {
+		t.Parallel()
+
+		var g flightGroup
+		errFoo := fmt.Errorf("foo")
+		entered := make(chan struct{})
+		release := make(chan struct{})
+		var calls int64
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err, _ := g.do(context.Background(), "k", func() error {
+				atomic.AddInt64(&calls, 1)
+				close(entered)
+				<-release
+				return errFoo
+			}); err != errFoo {
+				t.Errorf("expected %q to be %q", err, errFoo)
+			}
+		}()
+		<-entered
+
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err, shared := g.do(context.Background(), "k", func() error {
+					atomic.AddInt64(&calls, 1)
+					return nil
+				}); err != errFoo || !shared {
+					t.Errorf("expected %q to be %q", err, errFoo)
+				}
+			}()
+		}
+
+		// Wait for every later caller to find the call in flight.
+		waitForDups(&g, "k", 5)
+
+		// A different key is not collapsed.
+		if err, _ := g.do(context.Background(), "other", func() error { return nil }); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+
+		close(release)
+		wg.Wait()
+
+		if got := atomic.LoadInt64(&calls); got != 1 {
+			t.Errorf("expected %d to be %d", got, 1)
+		}
+	})
+
+	t.Run("do_waiter_gives_up", func(t *testing.T) {
+		t.Parallel()
+
+		var g flightGroup
+		entered := make(chan struct{})
+		release := make(chan struct{})
+		defer close(release)
+
+		go g.do(context.Background(), "k", func() error {
+			close(entered)
+			<-release
+			return nil
+		})
+		<-entered
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err, shared := g.do(ctx, "k", func() error {
+			t.Error("expected fn not to be called")
+			return nil
+		})
+		if !errors.Is(err, context.Canceled) || !shared {
+			t.Errorf("expected %q to be %q", err, context.Canceled)
+		}
+	})
+
+	t.Run("do_leader_panics", func(t *testing.T) {
+		t.Parallel()
+
+		var g flightGroup
+		entered := make(chan struct{})
+		release := make(chan struct{})
+		panicked := make(chan any, 1)
+
+		go func() {
+			defer func() { panicked <- recover() }()
+			g.do(context.Background(), "k", func() error {
+				close(entered)
+				<-release
+				panic("boom")
+			})
+		}()
+		<-entered
+
+		waited := make(chan error, 1)
+		go func() {
+			err, _ := g.do(context.Background(), "k", func() error { return nil })
+			waited <- err
+		}()
+		waitForDups(&g, "k", 1)
+		close(release)
+
+		if r := <-panicked; r != "boom" {
+			t.Errorf("expected %v to be %v", r, "boom")
+		}
+		if err := <-waited; !errors.Is(err, ErrPanicked) {
+			t.Errorf("expected %q to be %q", err, ErrPanicked)
+		}
+	})
+
+	t.Run("start_skips_while_in_flight", func(t *testing.T) {
+		t.Parallel()
+
+		var g flightGroup
+		release := make(chan struct{})
+		done := make(chan struct{})
+		var calls int64
+
+		g.start("k", func() {
+			atomic.AddInt64(&calls, 1)
+			<-release
+			close(done)
+		})
+		for i := 0; i < 5; i++ {
+			g.start("k", func() {
+				atomic.AddInt64(&calls, 1)
+			})
+		}
+		close(release)
+		<-done
+
+		if got := atomic.LoadInt64(&calls); got != 1 {
+			t.Errorf("expected %d to be %d", got, 1)
+		}
+	})
+}
@@ -0,0 +1,99 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+// Group runs several functions concurrently, each in its own retry loop with
+// a fresh backoff, and waits for all of them. It is modeled on errgroup, but a
+// function that fails does not cancel the others: each is retried on its own,
+// and Wait reports every function that ultimately failed.
+//
+// The zero value is usable, with a background context and no backoff, so
+// every function fails with backoff.ErrNilBackoff; use NewGroup.
+type Group struct {
+	ctx        context.Context
+	newBackoff func() backoff.Backoff
+	opts       []Option
+
+	// sem, if set, holds a token for every function running.
+	sem chan struct{}
+
+	wg sync.WaitGroup
+	mu sync.Mutex
+	// errs holds the error of every function, in the order Go was called.
+	errs []error
+}
+
+// NewGroup returns a Group that retries every function given to Go under ctx,
+// with a backoff from newBackoff and opts, as DoWithOptions does. A Group is
+// not tied to a single use: functions can be added after Wait returns, and
+// Wait then waits for them.
+func NewGroup(ctx context.Context, newBackoff func() backoff.Backoff, opts ...Option) *Group {
+	g := &Group{ctx: ctx, newBackoff: newBackoff, opts: opts}
+	if o := newOptions(opts); o.groupLimit > 0 {
+		g.sem = make(chan struct{}, o.groupLimit)
+	}
+	return g
+}
+
+// WithConcurrencyLimit bounds how many functions of a Group run at once, sleeps
+// between their attempts included. Go blocks until a function finishes while
+// n are running. Values of n below 1 remove the bound, which is the default.
+// Loops other than Group ignore it.
+func WithConcurrencyLimit(n int) Option {
+	return func(o *options) {
+		o.groupLimit = n
+	}
+}
+
+// Go calls f in a new goroutine, retrying it with a backoff of its own until
+// it succeeds or its loop gives up.
+func (g *Group) Go(f RetryFunc) {
+	if g.sem != nil {
+		g.sem <- struct{}{}
+	}
+
+	g.mu.Lock()
+	i := len(g.errs)
+	g.errs = append(g.errs, nil)
+	g.mu.Unlock()
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if g.sem != nil {
+			defer func() { <-g.sem }()
+		}
+
+		ctx := g.ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		var b backoff.Backoff
+		if g.newBackoff != nil {
+			b = g.newBackoff()
+		}
+
+		if err := DoWithOptions(ctx, b, f, g.opts...); err != nil {
+			g.mu.Lock()
+			g.errs[i] = err
+			g.mu.Unlock()
+		}
+	}()
+}
+
+// Wait waits for every function given to Go to finish. It returns nil if all
+// of them succeeded, or the errors of those that failed joined with
+// errors.Join, in the order the functions were given to Go.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return errors.Join(g.errs...)
+}
@@ -0,0 +1,195 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+func TestGroup(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("mixed", func(t *testing.T) {
+		t.Parallel()
+
+		var made atomic.Int32
+		g := NewGroup(ctx, func() backoff.Backoff {
+			made.Add(1)
+			b, err := backoff.NewConstant(1 * time.Nanosecond)
+			if err != nil {
+				t.Errorf("failed to create constant backoff: %v", err)
+			}
+			return backoff.WithMaxRetries(2, b)
+		})
+
+		errFlaky := fmt.Errorf("flaky")
+		errDown := fmt.Errorf("down")
+
+		var succeedCalls, eventualCalls, failCalls atomic.Int32
+		g.Go(func(_ context.Context) error {
+			succeedCalls.Add(1)
+			return nil
+		})
+		g.Go(func(_ context.Context) error {
+			// Fails as often as one backoff allows: a shared backoff would run
+			// out before this succeeds.
+			if eventualCalls.Add(1) <= 2 {
+				return RetryableError(errFlaky)
+			}
+			return nil
+		})
+		g.Go(func(_ context.Context) error {
+			failCalls.Add(1)
+			return RetryableError(errDown)
+		})
+
+		err := g.Wait()
+		if !errors.Is(err, ErrBackoffSignaledToStop) || !errors.Is(err, errDown) {
+			t.Errorf("expected %v to be %v and %v", err, ErrBackoffSignaledToStop, errDown)
+		}
+		if errors.Is(err, errFlaky) {
+			t.Errorf("expected %v not to be %v", err, errFlaky)
+		}
+
+		for _, c := range []struct {
+			name string
+			got  int32
+			exp  int32
+		}{
+			{"backoffs", made.Load(), 3},
+			{"succeed", succeedCalls.Load(), 1},
+			{"eventual", eventualCalls.Load(), 3},
+			{"fail", failCalls.Load(), 3},
+		} {
+			if c.got != c.exp {
+				t.Errorf("%s: expected %d to be %d", c.name, c.got, c.exp)
+			}
+		}
+	})
+
+	t.Run("joins_in_go_order", func(t *testing.T) {
+		t.Parallel()
+
+		g := NewGroup(ctx, func() backoff.Backoff {
+			b, err := backoff.NewConstant(1 * time.Nanosecond)
+			if err != nil {
+				t.Errorf("failed to create constant backoff: %v", err)
+			}
+			return b
+		})
+
+		errA, errB := fmt.Errorf("a"), fmt.Errorf("b")
+		release := make(chan struct{})
+		g.Go(func(_ context.Context) error {
+			<-release
+			return errA
+		})
+		g.Go(func(_ context.Context) error {
+			defer close(release)
+			return errB
+		})
+
+		err := g.Wait()
+		var joined interface{ Unwrap() []error }
+		if !errors.As(err, &joined) || len(joined.Unwrap()) != 2 {
+			t.Fatalf("expected %v to join 2 errors", err)
+		}
+		errs := joined.Unwrap()
+		if !errors.Is(errs[0], errA) || !errors.Is(errs[1], errB) {
+			t.Errorf("expected %v to be %v then %v", errs, errA, errB)
+		}
+		for _, err := range errs {
+			if !errors.Is(err, ErrNonRetryable) {
+				t.Errorf("expected %v to be %v", err, ErrNonRetryable)
+			}
+		}
+	})
+
+	t.Run("all_succeed", func(t *testing.T) {
+		t.Parallel()
+
+		g := NewGroup(ctx, func() backoff.Backoff {
+			b, err := backoff.NewConstant(1 * time.Nanosecond)
+			if err != nil {
+				t.Errorf("failed to create constant backoff: %v", err)
+			}
+			return b
+		})
+		for i := 0; i < 3; i++ {
+			g.Go(func(_ context.Context) error { return nil })
+		}
+		if err := g.Wait(); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		g := NewGroup(ctx, func() backoff.Backoff {
+			b, err := backoff.NewConstant(1 * time.Hour)
+			if err != nil {
+				t.Errorf("failed to create constant backoff: %v", err)
+			}
+			return b
+		})
+		g.Go(func(_ context.Context) error { return RetryableError(fmt.Errorf("oops")) })
+		if err := g.Wait(); !errors.Is(err, context.Canceled) {
+			t.Errorf("expected %v to be %v", err, context.Canceled)
+		}
+	})
+}
+
+func TestWithConcurrencyLimit(t *testing.T) {
+	t.Parallel()
+
+	const limit = 2
+
+	g := NewGroup(context.Background(), func() backoff.Backoff {
+		b, err := backoff.NewConstant(1 * time.Millisecond)
+		if err != nil {
+			t.Errorf("failed to create constant backoff: %v", err)
+		}
+		return backoff.WithMaxRetries(1, b)
+	}, WithConcurrencyLimit(limit))
+
+	var mu sync.Mutex
+	var running, peak int
+	for i := 0; i < 6; i++ {
+		g.Go(func(_ context.Context) error {
+			mu.Lock()
+			running++
+			if running > peak {
+				peak = running
+			}
+			mu.Unlock()
+
+			time.Sleep(time.Millisecond)
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+			return RetryableError(fmt.Errorf("oops"))
+		})
+	}
+
+	err := g.Wait()
+	var joined interface{ Unwrap() []error }
+	if !errors.As(err, &joined) || len(joined.Unwrap()) != 6 {
+		t.Errorf("expected %v to join 6 errors", err)
+	}
+	if peak > limit {
+		t.Errorf("expected %d to be at most %d", peak, limit)
+	}
+}
@@ -0,0 +1,33 @@
+package retry
+
+import (
+	"context"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+// DoHedged calls f and, if it hasn't returned after the first delay from b,
+// calls it again alongside, hedging against a slow attempt. Each further
+// delay from b paces the next hedge, and an attempt failing with a retryable
+// error starts the next one at once. At most maxInFlight attempts run at once;
+// values below 1 are treated as 1.
+//
+// The first attempt to succeed wins, and the attempts still running are then
+// canceled through their context; DoHedged does not wait for them to return.
+// A non-retryable error stops new attempts from starting, but the ones in
+// flight may still succeed; if none does, the error wraps ErrNonRetryable and
+// the first non-retryable error. f must therefore be idempotent and safe to
+// call concurrently.
+//
+// As with Do, f must mark errors as retryable. b is consulted once per
+// attempt started. When it signals to stop and every attempt has failed with
+// a retryable error, the error wraps ErrBackoffSignaledToStop and the most
+// recent failure. A failure marked with RetryableAfterError holds off the next
+// attempt for the delay it asks for instead of starting it at once.
+//
+// Of opts, the loop honors WithClock, WithControl and WithShouldRetry; the
+// others only apply to DoWithOptions.
+func DoHedged(ctx context.Context, b backoff.Backoff, maxInFlight int, f RetryFunc, opts ...Option) error {
+	o := newOptions(opts)
+	return doConcurrent(ctx, b, maxInFlight, f, true, &o)
+}
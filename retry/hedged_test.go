@@ -0,0 +1,148 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+func TestDoHedged(t *testing.T) {
+	t.Parallel()
+
+	newBackoff := func(t *testing.T, delay time.Duration, retries uint64) backoff.Backoff {
+		t.Helper()
+
+		b, err := backoff.NewConstant(delay)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		return backoff.WithMaxRetries(retries, b)
+	}
+
+	t.Run("slow_then_fast", func(t *testing.T) {
+		t.Parallel()
+
+		var attempts int64
+		canceled := make(chan struct{})
+		start := time.Now()
+		err := DoHedged(context.Background(), newBackoff(t, 10*time.Millisecond, 3), 2, func(ctx context.Context) error {
+			if atomic.AddInt64(&attempts, 1) == 1 {
+				// The first attempt is slow, and is abandoned.
+				select {
+				case <-ctx.Done():
+					close(canceled)
+					return RetryableError(ctx.Err())
+				case <-time.After(5 * time.Second):
+					return nil
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("expected the hedge to win, took %v", elapsed)
+		}
+		if got, want := atomic.LoadInt64(&attempts), int64(2); got != want {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+
+		select {
+		case <-canceled:
+		case <-time.After(5 * time.Second):
+			t.Fatal("abandoned attempt was never canceled")
+		}
+	})
+
+	t.Run("failure_hedges_at_once", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		var attempts int64
+		err := DoHedged(ctx, newBackoff(t, time.Hour, 3), 1, func(_ context.Context) error {
+			if atomic.AddInt64(&attempts, 1) == 1 {
+				return RetryableError(fmt.Errorf("oops"))
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got, want := atomic.LoadInt64(&attempts), int64(2); got != want {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	})
+
+	t.Run("success_after_non_retryable", func(t *testing.T) {
+		t.Parallel()
+
+		var attempts int64
+		firstDone := make(chan struct{})
+		secondStarted := make(chan struct{})
+		err := DoHedged(context.Background(), newBackoff(t, 1*time.Second, 5), 2, func(_ context.Context) error {
+			if atomic.AddInt64(&attempts, 1) == 1 {
+				<-secondStarted
+				close(firstDone)
+				return io.EOF // not retryable
+			}
+			close(secondStarted)
+			<-firstDone
+			return nil
+		}, WithClock(newFakeClock()))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("first_non_retryable_wins", func(t *testing.T) {
+		t.Parallel()
+
+		errBar := fmt.Errorf("bar")
+		var attempts int64
+		firstDone := make(chan struct{})
+		secondStarted := make(chan struct{})
+		err := DoHedged(context.Background(), newBackoff(t, 1*time.Second, 5), 2, func(_ context.Context) error {
+			if atomic.AddInt64(&attempts, 1) == 1 {
+				<-secondStarted
+				close(firstDone)
+				return io.EOF
+			}
+			close(secondStarted)
+			<-firstDone
+			return errBar
+		}, WithClock(newFakeClock()))
+		if !errors.Is(err, ErrNonRetryable) || !errors.Is(err, io.EOF) {
+			t.Errorf("expected %q to wrap %q and %q", err, ErrNonRetryable, io.EOF)
+		}
+		if errors.Is(err, errBar) {
+			t.Errorf("expected %q not to wrap %q", err, errBar)
+		}
+		if got, want := atomic.LoadInt64(&attempts), int64(2); got != want {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	})
+
+	t.Run("exhausted", func(t *testing.T) {
+		t.Parallel()
+
+		var attempts int64
+		err := DoHedged(context.Background(), newBackoff(t, 1*time.Second, 2), 2, func(_ context.Context) error {
+			return RetryableError(fmt.Errorf("attempt %d", atomic.AddInt64(&attempts, 1)))
+		}, WithClock(newFakeClock()))
+		if !errors.Is(err, ErrBackoffSignaledToStop) || !errors.Is(err, ErrRetriesExhausted) {
+			t.Errorf("expected %q to wrap %q and %q", err, ErrBackoffSignaledToStop, ErrRetriesExhausted)
+		}
+		if got, want := atomic.LoadInt64(&attempts), int64(3); got != want {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	})
+}
@@ -0,0 +1,106 @@
+package retry
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+// maxHelperErrors bounds the number of construction errors the convenience
+// helpers remember.
+const maxHelperErrors = 64
+
+type helperKey struct {
+	strategy string
+	base     time.Duration
+}
+
+// helperErrors remembers the errors returned for invalid helper inputs, so the
+// same input always gets the same error value.
+var helperErrors = struct {
+	mu     sync.Mutex
+	errors map[helperKey]error
+}{errors: make(map[helperKey]error)}
+
+// ValidateHelperInputs reports whether the convenience helpers, such as
+// ConstantRetry and ExponentialRetryN, accept base for strategy, one of the
+// backoff.Strategy constants. Services taking these from configuration can
+// call it at startup to fail once instead of on every request.
+//
+// The error is the one the helpers return for the same input. Repeated invalid
+// inputs return the identical error value, so callers can deduplicate them
+// with errors.Is.
+func ValidateHelperInputs(strategy string, base time.Duration) error {
+	_, err := helperBackoff(strategy, base)
+	return err
+}
+
+// helperBackoff creates the backoff the convenience helpers use for strategy
+// and base.
+func helperBackoff(strategy string, base time.Duration) (backoff.Backoff, error) {
+	var b backoff.Backoff
+	var err error
+	switch strategy {
+	case backoff.StrategyConstant:
+		b, err = backoff.NewConstant(base)
+	case backoff.StrategyExponential:
+		b, err = backoff.NewExponential(base)
+	case backoff.StrategyFibonacci:
+		b, err = backoff.NewFibonacci(base)
+	case backoff.StrategyLinear:
+		// There is no increment to configure, so it steps by base: base,
+		// 2*base, 3*base...
+		b, err = backoff.NewLinear(base, base)
+	default:
+		return nil, helperError(strategy, base, func() error {
+			return fmt.Errorf("invalid strategy: %q", strategy)
+		})
+	}
+	if err != nil {
+		return nil, helperError(strategy, base, func() error {
+			return fmt.Errorf("failed to create %s backoff: %w", strategy, err)
+		})
+	}
+	return b, nil
+}
+
+// cappedHelperBackoff creates the backoff the capped convenience helpers use:
+// the backoff for strategy and base, capped at cap and limited to maxRetries
+// retries.
+func cappedHelperBackoff(strategy string, base, cap time.Duration, maxRetries uint64) (backoff.Backoff, error) {
+	b, err := helperBackoff(strategy, base)
+	if err != nil {
+		return nil, err
+	}
+	if cap < base {
+		return nil, fmt.Errorf("invalid cap %v: must be at least the base %v", cap, base)
+	}
+
+	return backoff.WithMaxRetries(maxRetries, backoff.WithCappedDuration(cap, b)), nil
+}
+
+// helperError returns the error remembered for strategy and base, remembering
+// the one newErr makes if there is none yet. When the cache is full an
+// arbitrary entry is forgotten to make room.
+func helperError(strategy string, base time.Duration, newErr func() error) error {
+	key := helperKey{strategy: strategy, base: base}
+
+	helperErrors.mu.Lock()
+	defer helperErrors.mu.Unlock()
+
+	if err, ok := helperErrors.errors[key]; ok {
+		return err
+	}
+
+	if len(helperErrors.errors) >= maxHelperErrors {
+		for k := range helperErrors.errors {
+			delete(helperErrors.errors, k)
+			break
+		}
+	}
+	err := newErr()
+	helperErrors.errors[key] = err
+	return err
+}
@@ -0,0 +1,95 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+// TestValidateHelperInputs is not parallel, because it fills the shared cache
+// of helper errors.
+func TestValidateHelperInputs(t *testing.T) {
+	ctx := context.Background()
+	succeed := func(_ context.Context) error { return nil }
+
+	t.Run("identical_error", func(t *testing.T) {
+		err := ConstantRetry(ctx, 0, succeed)
+		if err == nil {
+			t.Fatal("expected err")
+		}
+		if again := ConstantRetryN(ctx, 0, 3, succeed); again != err {
+			t.Errorf("expected the identical error, got %p and %p", again, err)
+		}
+		if again := ValidateHelperInputs(backoff.StrategyConstant, 0); again != err {
+			t.Errorf("expected the identical error, got %p and %p", again, err)
+		}
+	})
+
+	t.Run("distinct_inputs", func(t *testing.T) {
+		errs := []error{
+			ValidateHelperInputs(backoff.StrategyConstant, 0),
+			ValidateHelperInputs(backoff.StrategyConstant, -1),
+			ValidateHelperInputs(backoff.StrategyExponential, 0),
+			ExponentialRetryN(ctx, -1, 3, succeed),
+			FibonacciRetry(ctx, 0, succeed),
+			ValidateHelperInputs("quadratic", time.Second),
+		}
+		for i := range errs {
+			if errs[i] == nil {
+				t.Fatalf("expected err %d", i)
+			}
+			for j := range errs[:i] {
+				if errs[i] == errs[j] {
+					t.Errorf("expected errors %d and %d to differ", j, i)
+				}
+			}
+		}
+	})
+
+	t.Run("bounded", func(t *testing.T) {
+		for i := 0; i < 2*maxHelperErrors; i++ {
+			_ = ValidateHelperInputs(backoff.StrategyFibonacci, -time.Duration(i))
+		}
+
+		helperErrors.mu.Lock()
+		got := len(helperErrors.errors)
+		helperErrors.mu.Unlock()
+		if got > maxHelperErrors {
+			t.Errorf("expected %d to be at most %d", got, maxHelperErrors)
+		}
+
+		// Forgotten inputs still get an error.
+		if err := ValidateHelperInputs(backoff.StrategyFibonacci, 0); err == nil {
+			t.Error("expected err")
+		}
+	})
+
+	t.Run("valid_inputs", func(t *testing.T) {
+		for _, strategy := range []string{backoff.StrategyConstant, backoff.StrategyExponential, backoff.StrategyFibonacci, backoff.StrategyLinear} {
+			if err := ValidateHelperInputs(strategy, time.Nanosecond); err != nil {
+				t.Errorf("%s: unexpected error: %v", strategy, err)
+			}
+		}
+		if err := ExponentialRetry(ctx, time.Nanosecond, succeed); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		helperErrors.mu.Lock()
+		defer helperErrors.mu.Unlock()
+		for k := range helperErrors.errors {
+			if k.base > 0 && k.strategy != "quadratic" {
+				t.Errorf("expected valid input %v not to be cached", k)
+			}
+		}
+	})
+
+	t.Run("wraps_constructor_error", func(t *testing.T) {
+		err := ValidateHelperInputs(backoff.StrategyExponential, 0)
+		if errors.Unwrap(err) == nil {
+			t.Errorf("expected %q to wrap the constructor's error", err)
+		}
+	})
+}
@@ -0,0 +1,117 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+// DoWithHook is like Do, but calls hook after each failed attempt that will be
+// retried, as WithOnRetry does.
+func DoWithHook(ctx context.Context, b backoff.Backoff, f RetryFunc, hook func(attempt uint64, err error, next time.Duration)) error {
+	return DoWithOptions(ctx, b, f, WithOnRetry(hook))
+}
+
+// WithOnRetry makes DoWithOptions call hook after each failed attempt that
+// will be retried, before sleeping, for logging and metrics. hook receives the
+// number of the attempt that failed, starting at 1, the error f returned with
+// its RetryableError wrapper removed, and how long the loop is about to sleep.
+// It is not called when an attempt fails for good or succeeds.
+//
+// The loop waits for hook to return before sleeping, but not past ctx: if ctx
+// is done while hook runs, the loop returns ctx.Err() at once and leaves hook
+// to finish on its own, so a hook that ignores ctx and never returns keeps its
+// goroutine. A panic in hook continues in the goroutine that called
+// DoWithOptions, where it can be recovered as an error wrapping ErrPanicked
+// that holds the panic value and hook's stack, or, with WithRecover or
+// WithRecoverRetryable, stops the loop with an error wrapping ErrNonRetryable
+// and ErrPanicked.
+func WithOnRetry(hook func(attempt uint64, err error, next time.Duration)) Option {
+	return func(o *options) {
+		o.onRetry = hook
+	}
+}
+
+// WithOnRetryChained is like WithOnRetry, but keeps the hook set by an earlier
+// option, if any, and calls hook after it. It lets a wrapper around
+// DoWithOptions observe retries without replacing a hook its caller passed in.
+func WithOnRetryChained(hook func(attempt uint64, err error, next time.Duration)) Option {
+	return func(o *options) {
+		if hook == nil {
+			return
+		}
+		prev := o.onRetry
+		if prev == nil {
+			o.onRetry = hook
+			return
+		}
+		o.onRetry = func(attempt uint64, err error, next time.Duration) {
+			prev(attempt, err, next)
+			hook(attempt, err, next)
+		}
+	}
+}
+
+// callHook runs fn, returning when it does or when ctx is done, whichever
+// comes first. It returns ctx.Err() in the latter case, and a *hookPanic if fn
+// panicked, since fn runs on a goroutine of its own where a panic could not be
+// recovered by the caller.
+func callHook(ctx context.Context, fn func()) error {
+	done := make(chan struct{})
+	var p *hookPanic
+	go func() {
+		defer close(done)
+		defer func() {
+			if r := recover(); r != nil {
+				p = &hookPanic{value: r, stack: debug.Stack()}
+			}
+		}()
+		fn()
+	}()
+
+	select {
+	case <-done:
+		if p != nil {
+			return p
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// hookPanic is the error of callHook when the hook panicked.
+type hookPanic struct {
+	value any
+	stack []byte
+}
+
+func (p *hookPanic) Error() string {
+	return fmt.Sprintf("%v: %v\n\n%s", ErrPanicked, p.value, p.stack)
+}
+
+// Unwrap returns ErrPanicked, and the panic value if it is an error.
+func (p *hookPanic) Unwrap() []error {
+	if err, ok := p.value.(error); ok {
+		return []error{ErrPanicked, err}
+	}
+	return []error{ErrPanicked}
+}
+
+// hookFailed returns the error that ends a loop whose hook call failed with
+// err. If the hook panicked, the panic continues with the hookPanic, so the
+// hook's stack isn't lost, unless recover is set, in which case it stops the
+// loop as a panic from f would under WithRecover.
+func hookFailed(err error, recover bool) error {
+	p, ok := err.(*hookPanic)
+	if !ok {
+		return err
+	}
+	if !recover {
+		panic(p)
+	}
+	return fmt.Errorf("%w: %w", ErrNonRetryable, p)
+}
@@ -0,0 +1,208 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+func TestWithOnRetry(t *testing.T) {
+	t.Parallel()
+
+	errOops := fmt.Errorf("oops")
+
+	type call struct {
+		attempt uint64
+		err     error
+		next    time.Duration
+	}
+
+	t.Run("called_after_each_retryable_failure", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewExponential(1 * time.Second)
+		if err != nil {
+			t.Fatalf("failed to create exponential backoff: %v", err)
+		}
+
+		var attempts int
+		var calls []call
+		if err := DoWithOptions(context.Background(), b, func(_ context.Context) error {
+			attempts++
+			if attempts < 4 {
+				return RetryableError(errOops)
+			}
+			return nil
+		}, WithClock(newFakeClock()), WithOnRetry(func(attempt uint64, err error, next time.Duration) {
+			calls = append(calls, call{attempt, err, next})
+		})); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		want := []call{
+			{1, errOops, 1 * time.Second},
+			{2, errOops, 2 * time.Second},
+			{3, errOops, 4 * time.Second},
+		}
+		if !reflect.DeepEqual(calls, want) {
+			t.Errorf("expected %v to be %v", calls, want)
+		}
+	})
+
+	t.Run("not_called_for_final_failure", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		var calls int
+		err = DoWithHook(context.Background(), backoff.WithMaxRetries(2, b), func(_ context.Context) error {
+			return RetryableError(errOops)
+		}, func(uint64, error, time.Duration) {
+			calls++
+		})
+		if !errors.Is(err, ErrBackoffSignaledToStop) {
+			t.Errorf("expected %q to be %q", err, ErrBackoffSignaledToStop)
+		}
+		if calls != 2 {
+			t.Errorf("expected %v to be %v", calls, 2)
+		}
+
+		calls = 0
+		_ = DoWithHook(context.Background(), b, func(_ context.Context) error {
+			return errOops // not retryable
+		}, func(uint64, error, time.Duration) {
+			calls++
+		})
+		if calls != 0 {
+			t.Errorf("expected %v to be %v", calls, 0)
+		}
+	})
+
+	t.Run("fast_first_retry", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewConstant(1 * time.Second)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		var calls []call
+		_ = DoWithOptions(context.Background(), backoff.WithMaxRetries(2, b), func(_ context.Context) error {
+			return RetryableError(errOops)
+		}, WithClock(newFakeClock()), WithFastFirstRetryThreshold(time.Second), WithOnRetry(func(attempt uint64, err error, next time.Duration) {
+			calls = append(calls, call{attempt, err, next})
+		}))
+
+		want := []call{
+			{1, errOops, 0},
+			{2, errOops, 1 * time.Second},
+		}
+		if !reflect.DeepEqual(calls, want) {
+			t.Errorf("expected %v to be %v", calls, want)
+		}
+	})
+
+	t.Run("slow_hook_does_not_block_cancel", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		release := make(chan struct{})
+		defer close(release)
+
+		errc := make(chan error, 1)
+		go func() {
+			errc <- DoWithHook(ctx, b, func(_ context.Context) error {
+				return RetryableError(errOops)
+			}, func(uint64, error, time.Duration) {
+				cancel()
+				<-release
+			})
+		}()
+
+		select {
+		case err := <-errc:
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("expected %q to be %q", err, context.Canceled)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("loop blocked on a slow hook")
+		}
+	})
+
+	t.Run("panicking_hook", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		f := func(_ context.Context) error {
+			return RetryableError(errOops)
+		}
+		hook := func(uint64, error, time.Duration) {
+			panic("boom")
+		}
+
+		// Without WithRecover, the panic reaches the caller's goroutine, with
+		// the hook's stack.
+		func() {
+			defer func() {
+				r, _ := recover().(error)
+				if !errors.Is(r, ErrPanicked) {
+					t.Errorf("expected %v to be %v", r, ErrPanicked)
+				}
+				if r == nil || !strings.Contains(r.Error(), "boom") || !strings.Contains(r.Error(), "goroutine") {
+					t.Errorf("expected %v to hold the panic value and stack", r)
+				}
+			}()
+			_ = DoWithHook(context.Background(), b, f, hook)
+			t.Error("expected a panic")
+		}()
+
+		err = DoWithOptions(context.Background(), b, f, WithOnRetry(hook), WithRecover())
+		if !errors.Is(err, ErrNonRetryable) {
+			t.Errorf("expected %q to be %q", err, ErrNonRetryable)
+		}
+		if !errors.Is(err, ErrPanicked) {
+			t.Errorf("expected %q to be %q", err, ErrPanicked)
+		}
+	})
+	t.Run("chained", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		var calls []string
+		hook := func(name string) func(uint64, error, time.Duration) {
+			return func(uint64, error, time.Duration) {
+				calls = append(calls, name)
+			}
+		}
+		_ = DoWithOptions(context.Background(), backoff.WithMaxRetries(1, b), func(_ context.Context) error {
+			return RetryableError(errOops)
+		}, WithOnRetryChained(hook("first")), WithOnRetry(hook("caller")), WithOnRetryChained(hook("wrapper")), WithOnRetryChained(nil))
+
+		if want := []string{"caller", "wrapper"}; !reflect.DeepEqual(calls, want) {
+			t.Errorf("expected %v to be %v", calls, want)
+		}
+	})
+}
@@ -0,0 +1,17 @@
+package retry
+
+import "github.com/swayne275/go-retry/internal/label"
+
+// Labeled is implemented by the errors this package returns from its loops.
+// RetryLabel returns a stable, low-cardinality snake_case label, such as
+// "non_retryable" or "retries_exhausted", for grouping errors in logs and
+// metrics without parsing their messages. Labels are part of the public API
+// and do not change.
+type Labeled = label.Labeled
+
+// LabelOf returns the label of the first Labeled error in err's chain, in the
+// order errors.As walks it. Context errors are labeled "context_canceled" and
+// "context_deadline_exceeded". It returns "unknown" if err carries no label.
+func LabelOf(err error) string {
+	return label.Of(err)
+}
@@ -0,0 +1,280 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+func TestLabelOf(t *testing.T) {
+	t.Parallel()
+
+	errFoo := fmt.Errorf("foo")
+
+	// Labels are a public contract: changing any of these is a breaking change.
+	cases := []struct {
+		name string
+		err  error
+		exp  string
+	}{
+		{name: "non_retryable", err: ErrNonRetryable, exp: "non_retryable"},
+		{name: "retries_exhausted", err: ErrBackoffSignaledToStop, exp: "retries_exhausted"},
+		{name: "dependency_never_ready", err: ErrDependencyNeverReady, exp: "dependency_never_ready"},
+		{name: "byte_budget_exceeded", err: ErrByteBudgetExceeded, exp: "byte_budget_exceeded"},
+		{name: "budget_exhausted", err: ErrBudgetExhausted, exp: "budget_exhausted"},
+		{name: "unknown_policy", err: ErrUnknownPolicy, exp: "unknown_policy"},
+		{name: "invalid_histogram", err: ErrInvalidDelayHistogram, exp: "invalid_histogram"},
+		{name: "mark_failed", err: ErrMarkFailed, exp: "mark_failed"},
+		{name: "attempt_setup_failed", err: ErrAttemptSetupFailed, exp: "attempt_setup_failed"},
+		{name: "cleanup_panicked", err: ErrCleanupPanicked, exp: "cleanup_panicked"},
+		{name: "fenced", err: ErrFenced, exp: "fenced"},
+		{name: "retries_disabled", err: ErrRetriesDisabled, exp: "retries_disabled"},
+		{name: "ladder_exhausted", err: ErrLadderExhausted, exp: "ladder_exhausted"},
+		{name: "retry_expectation_exceeded", err: ErrRetryExpectationExceeded, exp: "retry_expectation_exceeded"},
+		{name: "random_source_not_supported", err: backoff.ErrRandomSourceNotSupported, exp: "random_source_not_supported"},
+		{name: "nil_backoff", err: backoff.ErrNilBackoff, exp: "nil_backoff"},
+		{name: "context_canceled", err: context.Canceled, exp: "context_canceled"},
+		{name: "context_deadline_exceeded", err: context.DeadlineExceeded, exp: "context_deadline_exceeded"},
+		{name: "wrapped", err: fmt.Errorf("%w: %w", ErrNonRetryable, errFoo), exp: "non_retryable"},
+		{name: "nested", err: fmt.Errorf("outer: %w", fmt.Errorf("%w: %w", ErrBackoffSignaledToStop, errFoo)), exp: "retries_exhausted"},
+		{name: "first_label_wins", err: fmt.Errorf("%w: %w", ErrBackoffSignaledToStop, context.Canceled), exp: "retries_exhausted"},
+		{name: "wrapped_context", err: fmt.Errorf("oops: %w", context.Canceled), exp: "context_canceled"},
+		{name: "unlabeled", err: errFoo, exp: "unknown"},
+		{name: "nil", err: nil, exp: "unknown"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := LabelOf(tc.err); got != tc.exp {
+				t.Errorf("expected %q to be %q", got, tc.exp)
+			}
+		})
+	}
+}
+
+func TestLabelOf_terminalPaths(t *testing.T) {
+	t.Parallel()
+
+	// Every way DoWithOptions can return an error must carry a label, so a
+	// new exit path can't ship unlabeled.
+	for _, tc := range terminalPaths() {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.run(t)
+			if err == nil {
+				t.Fatal("expected err")
+			}
+			if got := LabelOf(err); got == "unknown" {
+				t.Errorf("expected a label for %q", err)
+			}
+		})
+	}
+}
+
+// terminalPath is one way a loop can return an error, and the sentinel that
+// classifies it.
+type terminalPath struct {
+	name  string
+	class error
+	run   func(t *testing.T) error
+}
+
+// terminalPaths lists every way the loops can return an error. Add new exit
+// paths here so they are checked for labels and classification.
+func terminalPaths() []terminalPath {
+	newBackoff := func(t *testing.T, maxRetries uint64) backoff.Backoff {
+		t.Helper()
+
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		return backoff.WithMaxRetries(maxRetries, b)
+	}
+	retryable := func(_ context.Context) error {
+		return RetryableError(fmt.Errorf("oops"))
+	}
+
+	return []terminalPath{
+		{
+			name:  "canceled_before_first_attempt",
+			class: context.Canceled,
+			run: func(t *testing.T) error {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return Do(ctx, newBackoff(t, 3), retryable)
+			},
+		},
+		{
+			name:  "canceled_between_attempts",
+			class: context.Canceled,
+			run: func(t *testing.T) error {
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				return Do(ctx, newBackoff(t, 3), func(_ context.Context) error {
+					cancel()
+					return RetryableError(fmt.Errorf("oops"))
+				})
+			},
+		},
+		{
+			name:  "deadline_while_sleeping",
+			class: context.DeadlineExceeded,
+			run: func(t *testing.T) error {
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+				defer cancel()
+				b, err := backoff.NewConstant(1 * time.Hour)
+				if err != nil {
+					t.Fatalf("failed to create constant backoff: %v", err)
+				}
+				return Do(ctx, b, retryable)
+			},
+		},
+		{
+			name:  "non_retryable",
+			class: ErrNonRetryable,
+			run: func(t *testing.T) error {
+				return Do(context.Background(), newBackoff(t, 3), func(_ context.Context) error {
+					return fmt.Errorf("oops")
+				})
+			},
+		},
+		{
+			name:  "retries_exhausted",
+			class: ErrBackoffSignaledToStop,
+			run: func(t *testing.T) error {
+				return Do(context.Background(), newBackoff(t, 3), retryable)
+			},
+		},
+		{
+			name:  "dependency_never_ready",
+			class: ErrDependencyNeverReady,
+			run: func(t *testing.T) error {
+				g := NewReadinessGate("db", retryable, constantPolicy(t, 1))
+				return DoWithOptions(context.Background(), newBackoff(t, 3), retryable, WithReadinessGate(g))
+			},
+		},
+		{
+			name:  "byte_budget_exceeded",
+			class: ErrByteBudgetExceeded,
+			run: func(t *testing.T) error {
+				budget := NewByteBudget(0, time.Hour)
+				return DoWithOptions(context.Background(), newBackoff(t, 3), retryable, WithByteBudget(budget, func(uint64) int64 {
+					return 1
+				}))
+			},
+		},
+		{
+			name:  "budget_exhausted",
+			class: ErrBudgetExhausted,
+			run: func(t *testing.T) error {
+				return DoWithBudget(context.Background(), NewBudget(0, 0), newBackoff(t, 3), retryable)
+			},
+		},
+		{
+			name:  "unseedable_backoff",
+			class: backoff.ErrRandomSourceNotSupported,
+			run: func(t *testing.T) error {
+				return DoWithOptions(context.Background(), opaqueJitter{newBackoff(t, 3)}, retryable, WithDeterministicSeed(1))
+			},
+		},
+		{
+			name:  "nil_backoff",
+			class: backoff.ErrNilBackoff,
+			run: func(t *testing.T) error {
+				return Do(context.Background(), &backoff.ResettableBackoff{}, retryable)
+			},
+		},
+		{
+			name:  "mark_failed",
+			class: ErrMarkFailed,
+			run: func(t *testing.T) error {
+				return DoOnce(context.Background(), failingMarker{}, "k", newBackoff(t, 3), func(_ context.Context) error {
+					return nil
+				})
+			},
+		},
+		{
+			name:  "attempt_setup_failed",
+			class: ErrAttemptSetupFailed,
+			run: func(t *testing.T) error {
+				return DoWithOptions(context.Background(), newBackoff(t, 3), retryable, WithAttemptCleanup(func(context.Context) (func(), error) {
+					return nil, fmt.Errorf("no temp dir")
+				}))
+			},
+		},
+		{
+			name:  "cleanup_panicked",
+			class: ErrCleanupPanicked,
+			run: func(t *testing.T) error {
+				return DoWithOptions(context.Background(), newBackoff(t, 3), retryable, WithAttemptCleanup(func(context.Context) (func(), error) {
+					return func() { panic("oops") }, nil
+				}))
+			},
+		},
+		{
+			name:  "fenced",
+			class: ErrFenced,
+			run: func(t *testing.T) error {
+				return DoWithOptions(context.Background(), newBackoff(t, 3), retryable, WithFenceOnRetry(func(context.Context) error {
+					return fmt.Errorf("lease lost")
+				}))
+			},
+		},
+		{
+			name:  "retries_disabled",
+			class: ErrRetriesDisabled,
+			run: func(t *testing.T) error {
+				c := NewControl()
+				c.DisableRetries("incident")
+				return DoWithOptions(context.Background(), newBackoff(t, 3), retryable, WithControl(c))
+			},
+		},
+		{
+			name:  "ladder_exhausted",
+			class: ErrLadderExhausted,
+			run: func(t *testing.T) error {
+				l := Ladder[int]{Rungs: []Rung[int]{{
+					Backoff: func() backoff.Backoff { return newBackoff(t, 1) },
+					Attempt: func(ctx context.Context) (int, error) { return 0, retryable(ctx) },
+				}}}
+				_, _, err := l.Do(context.Background())
+				return err
+			},
+		},
+		{
+			name:  "retry_expectation_exceeded",
+			class: ErrRetryExpectationExceeded,
+			run: func(t *testing.T) error {
+				return DoWithOptions(context.Background(), newBackoff(t, 3), retryable, WithTestExpectation(&recordingTB{}, 1))
+			},
+		},
+		{
+			name:  "two_phase_exhausted",
+			class: ErrBackoffSignaledToStop,
+			run: func(t *testing.T) error {
+				policy := func() backoff.Backoff { return newBackoff(t, 1) }
+				return DoTwoPhase(context.Background(), policy, policy, func(ctx context.Context) (bool, error) {
+					return true, retryable(ctx)
+				})
+			},
+		},
+		{
+			name:  "pipelined_exhausted",
+			class: ErrBackoffSignaledToStop,
+			run: func(t *testing.T) error {
+				return DoPipelined(context.Background(), newBackoff(t, 1), 2, retryable)
+			},
+		},
+	}
+}
@@ -0,0 +1,114 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+	"github.com/swayne275/go-retry/internal/label"
+)
+
+// ErrLadderExhausted is returned by Ladder.Do, wrapping the error of every
+// rung, when no rung succeeded. Its label is "ladder_exhausted".
+var ErrLadderExhausted = label.New("every rung of the ladder failed", "ladder_exhausted")
+
+// errNoAttempt is the error of a rung with no Attempt.
+var errNoAttempt = fmt.Errorf("rung has no attempt function")
+
+// RungIndex is the position of a rung in a Ladder, starting at 0 for the
+// highest quality rung.
+type RungIndex int
+
+// Rung is one quality level of a Ladder. A Rung missing its Backoff or
+// Attempt, such as the zero Rung, fails at once, so the ladder moves on.
+type Rung[T any] struct {
+	// Backoff creates the schedule that paces retries of Attempt.
+	Backoff func() backoff.Backoff
+	// Attempt produces the result at this quality level. As with Do, it must
+	// mark errors as retryable.
+	Attempt func(ctx context.Context) (T, error)
+	// MaxAttempts, if positive, limits how many times Attempt is called.
+	MaxAttempts uint64
+	// MaxDuration, if positive, limits how long the rung schedules retries
+	// for, as backoff.WithMaxDuration does. It does not interrupt an attempt
+	// in progress.
+	MaxDuration time.Duration
+}
+
+// Ladder works down a list of rungs, from the best result to the most
+// degraded one, such as a full result, then a cached one, then a partial one.
+// Each rung is retried as Do would retry it, within the rung's budget.
+//
+// The zero Ladder has no rungs. A Ladder may be used concurrently as long as
+// its fields are not modified.
+//
+// Unlike a single retry loop, a Ladder treats a non-retryable error from a
+// rung like the rung's retries running out: both move on to the next rung,
+// since a lower rung may not depend on whatever failed.
+type Ladder[T any] struct {
+	Rungs []Rung[T]
+	// OnTransition, if set, is called when rung from fails with err and the
+	// ladder moves on to rung to, for example to count degraded responses.
+	// It is called as WithOnRetry calls its hook; a panic in it continues in
+	// the goroutine that called Do.
+	OnTransition func(from, to RungIndex, err error)
+}
+
+// Do tries each rung in turn, returning the result of the first that
+// succeeds along with its index, so callers know the quality of the result.
+//
+// If ctx is done, Do stops at once and returns ctx.Err() with the index of the
+// rung in progress. If every rung fails, Do returns the index of the last rung
+// and an error wrapping ErrLadderExhausted and the errors of all rungs, joined
+// in rung order; the error of each rung is the one Do would have returned for
+// it. A ladder with no rungs returns an index of -1 and ErrLadderExhausted.
+func (l *Ladder[T]) Do(ctx context.Context) (T, RungIndex, error) {
+	var zero T
+	errs := make([]error, 0, len(l.Rungs))
+
+	for i, rung := range l.Rungs {
+		idx := RungIndex(i)
+		if i > 0 && l.OnTransition != nil {
+			if err := callHook(ctx, func() { l.OnTransition(idx-1, idx, errs[i-1]) }); err != nil {
+				return zero, idx - 1, hookFailed(err, false)
+			}
+		}
+
+		v, err := rung.do(ctx)
+		if err == nil {
+			return v, idx, nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return zero, idx, ctxErr
+		}
+		errs = append(errs, fmt.Errorf("rung %d: %w", i, err))
+	}
+
+	if len(errs) == 0 {
+		return zero, -1, ErrLadderExhausted
+	}
+	return zero, RungIndex(len(l.Rungs) - 1), fmt.Errorf("%w: %w", ErrLadderExhausted, errors.Join(errs...))
+}
+
+// do makes one pass through the rung, within its budget. A rung missing its
+// Backoff or Attempt fails without being attempted.
+func (r Rung[T]) do(ctx context.Context) (T, error) {
+	var zero T
+	b, err := build(r.Backoff)
+	if err != nil {
+		return zero, err
+	}
+	if r.Attempt == nil {
+		return zero, errNoAttempt
+	}
+
+	if r.MaxDuration > 0 {
+		b = backoff.WithMaxDuration(r.MaxDuration, b)
+	}
+	if r.MaxAttempts > 0 {
+		b = backoff.WithMaxRetries(r.MaxAttempts-1, b)
+	}
+	return DoValue(ctx, b, r.Attempt)
+}
@@ -0,0 +1,244 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLadder_Do(t *testing.T) {
+	t.Parallel()
+
+	policy := constantPolicy(t, 1<<30)
+	retryable := func(_ context.Context) (string, error) {
+		return "", RetryableError(fmt.Errorf("oops"))
+	}
+	nonRetryable := func(_ context.Context) (string, error) {
+		return "", fmt.Errorf("broken")
+	}
+	succeed := func(v string) func(context.Context) (string, error) {
+		return func(_ context.Context) (string, error) {
+			return v, nil
+		}
+	}
+
+	t.Run("success_at_each_rung", func(t *testing.T) {
+		t.Parallel()
+
+		for want := 0; want < 3; want++ {
+			want := want
+
+			t.Run(fmt.Sprintf("rung_%d", want), func(t *testing.T) {
+				t.Parallel()
+
+				var l Ladder[string]
+				for i := 0; i < 3; i++ {
+					attempt := retryable
+					if i == want {
+						attempt = succeed(fmt.Sprintf("level %d", i))
+					}
+					l.Rungs = append(l.Rungs, Rung[string]{Backoff: policy, Attempt: attempt, MaxAttempts: 2})
+				}
+
+				v, idx, err := l.Do(context.Background())
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				if idx != RungIndex(want) {
+					t.Errorf("expected %d to be %d", idx, want)
+				}
+				if exp := fmt.Sprintf("level %d", want); v != exp {
+					t.Errorf("expected %q to be %q", v, exp)
+				}
+			})
+		}
+	})
+
+	t.Run("transitions", func(t *testing.T) {
+		t.Parallel()
+
+		var calls [2]int64
+		count := func(i int, attempt func(context.Context) (string, error)) func(context.Context) (string, error) {
+			return func(ctx context.Context) (string, error) {
+				atomic.AddInt64(&calls[i], 1)
+				return attempt(ctx)
+			}
+		}
+
+		type transition struct {
+			from, to RungIndex
+			err      error
+		}
+		var transitions []transition
+		l := Ladder[string]{
+			Rungs: []Rung[string]{
+				{Backoff: policy, Attempt: count(0, retryable), MaxAttempts: 3},
+				{Backoff: policy, Attempt: count(1, nonRetryable)},
+				{Backoff: policy, Attempt: succeed("partial")},
+			},
+			OnTransition: func(from, to RungIndex, err error) {
+				transitions = append(transitions, transition{from, to, err})
+			},
+		}
+
+		v, idx, err := l.Do(context.Background())
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if v != "partial" || idx != 2 {
+			t.Errorf("expected %q at %d to be %q at %d", v, idx, "partial", 2)
+		}
+
+		// Exhaustion moves on after the rung's attempt budget, a
+		// non-retryable error after one attempt.
+		if got, want := atomic.LoadInt64(&calls[0]), int64(3); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if got, want := atomic.LoadInt64(&calls[1]), int64(1); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+
+		if len(transitions) != 2 {
+			t.Fatalf("expected 2 transitions, got %d", len(transitions))
+		}
+		if tr := transitions[0]; tr.from != 0 || tr.to != 1 || !errors.Is(tr.err, ErrBackoffSignaledToStop) {
+			t.Errorf("unexpected transition %+v", tr)
+		}
+		if tr := transitions[1]; tr.from != 1 || tr.to != 2 || !errors.Is(tr.err, ErrNonRetryable) {
+			t.Errorf("unexpected transition %+v", tr)
+		}
+	})
+
+	t.Run("max_duration", func(t *testing.T) {
+		t.Parallel()
+
+		l := Ladder[string]{
+			Rungs: []Rung[string]{
+				{Backoff: policy, Attempt: retryable, MaxDuration: 10 * time.Millisecond},
+				{Backoff: policy, Attempt: succeed("cached")},
+			},
+		}
+
+		v, idx, err := l.Do(context.Background())
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if v != "cached" || idx != 1 {
+			t.Errorf("expected %q at %d to be %q at %d", v, idx, "cached", 1)
+		}
+	})
+
+	t.Run("canceled_mid_ladder", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var reached int64
+		l := Ladder[string]{
+			Rungs: []Rung[string]{
+				{Backoff: policy, Attempt: nonRetryable},
+				{Backoff: policy, Attempt: func(_ context.Context) (string, error) {
+					cancel()
+					return "", RetryableError(fmt.Errorf("oops"))
+				}},
+				{Backoff: policy, Attempt: func(_ context.Context) (string, error) {
+					atomic.AddInt64(&reached, 1)
+					return "partial", nil
+				}},
+			},
+		}
+
+		_, idx, err := l.Do(ctx)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected %v to be %v", err, context.Canceled)
+		}
+		if errors.Is(err, ErrLadderExhausted) {
+			t.Errorf("expected %v not to be %v", err, ErrLadderExhausted)
+		}
+		if idx != 1 {
+			t.Errorf("expected %d to be %d", idx, 1)
+		}
+		if got := atomic.LoadInt64(&reached); got != 0 {
+			t.Errorf("expected the last rung not to run, ran %d times", got)
+		}
+	})
+
+	t.Run("exhausted", func(t *testing.T) {
+		t.Parallel()
+
+		errFoo := fmt.Errorf("foo")
+		l := Ladder[string]{
+			Rungs: []Rung[string]{
+				{Backoff: policy, Attempt: retryable, MaxAttempts: 2},
+				{Backoff: policy, Attempt: func(_ context.Context) (string, error) {
+					return "", errFoo
+				}},
+			},
+		}
+
+		v, idx, err := l.Do(context.Background())
+		if v != "" {
+			t.Errorf("expected %q to be empty", v)
+		}
+		if idx != 1 {
+			t.Errorf("expected %d to be %d", idx, 1)
+		}
+		for _, want := range []error{ErrLadderExhausted, ErrBackoffSignaledToStop, ErrNonRetryable, errFoo} {
+			if !errors.Is(err, want) {
+				t.Errorf("expected %v to wrap %v", err, want)
+			}
+		}
+		if got := Classify(err); got != ErrLadderExhausted {
+			t.Errorf("expected %v to be %v", got, ErrLadderExhausted)
+		}
+
+		// The rung errors are joined in order under the sentinel.
+		rungErrs := rungErrors(t, err)
+		if len(rungErrs) != 2 {
+			t.Fatalf("expected 2 rung errors, got %d", len(rungErrs))
+		}
+		if !strings.HasPrefix(rungErrs[0].Error(), "rung 0: ") || !errors.Is(rungErrs[0], ErrBackoffSignaledToStop) {
+			t.Errorf("unexpected first rung error %q", rungErrs[0])
+		}
+		if !strings.HasPrefix(rungErrs[1].Error(), "rung 1: ") || !errors.Is(rungErrs[1], errFoo) {
+			t.Errorf("unexpected second rung error %q", rungErrs[1])
+		}
+	})
+
+	t.Run("no_rungs", func(t *testing.T) {
+		t.Parallel()
+
+		var l Ladder[string]
+		_, idx, err := l.Do(context.Background())
+		if !errors.Is(err, ErrLadderExhausted) {
+			t.Errorf("expected %v to be %v", err, ErrLadderExhausted)
+		}
+		if idx != -1 {
+			t.Errorf("expected %d to be %d", idx, -1)
+		}
+	})
+}
+
+// rungErrors returns the per-rung errors joined in an error from Ladder.Do.
+func rungErrors(t *testing.T, err error) []error {
+	t.Helper()
+
+	multi, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		t.Fatalf("expected %v to wrap several errors", err)
+	}
+	errs := multi.Unwrap()
+	if len(errs) != 2 || errs[0] != ErrLadderExhausted {
+		t.Fatalf("expected %v to wrap %v and the joined rung errors", err, ErrLadderExhausted)
+	}
+	joined, ok := errs[1].(interface{ Unwrap() []error })
+	if !ok {
+		t.Fatalf("expected %v to be joined", errs[1])
+	}
+	return joined.Unwrap()
+}
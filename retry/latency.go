@@ -0,0 +1,150 @@
+package retry
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// minLatencySamples is the number of samples a LatencyTracker needs before it
+// reports quantiles, or its window if that is smaller.
+const minLatencySamples = 5
+
+// LatencyTracker keeps the latencies of the most recent successful attempts
+// and estimates quantiles over them. Because it works on quantiles rather than
+// means, a few very slow attempts do not skew its estimates.
+//
+// A LatencyTracker is safe for concurrent use, so one tracker can be shared by
+// every loop calling the same dependency. The zero LatencyTracker remembers
+// only the last latency, like one from NewLatencyTracker(1).
+type LatencyTracker struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+	full    bool
+}
+
+// NewLatencyTracker creates a tracker that remembers the last window
+// latencies. A window smaller than 1 is treated as 1.
+func NewLatencyTracker(window int) *LatencyTracker {
+	if window < 1 {
+		window = 1
+	}
+	return &LatencyTracker{
+		samples: make([]time.Duration, window),
+	}
+}
+
+// Observe records the latency of a successful attempt.
+func (t *LatencyTracker) Observe(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.samples) == 0 {
+		t.samples = make([]time.Duration, 1)
+	}
+	t.samples[t.next] = d
+	t.next++
+	if t.next == len(t.samples) {
+		t.next = 0
+		t.full = true
+	}
+}
+
+// Len returns the number of latencies currently held.
+func (t *LatencyTracker) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.len()
+}
+
+func (t *LatencyTracker) len() int {
+	if t.full {
+		return len(t.samples)
+	}
+	return t.next
+}
+
+// Quantile returns the q-quantile (0 <= q <= 1) of the held latencies using
+// the nearest-rank method. It returns false if the tracker does not yet have
+// enough samples for a meaningful estimate.
+func (t *LatencyTracker) Quantile(q float64) (time.Duration, bool) {
+	t.mu.Lock()
+	n := t.len()
+	if n == 0 || (n < minLatencySamples && n < len(t.samples)) {
+		t.mu.Unlock()
+		return 0, false
+	}
+	sorted := make([]time.Duration, n)
+	copy(sorted, t.samples[:n])
+	t.mu.Unlock()
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	switch {
+	case q <= 0:
+		return sorted[0], true
+	case q >= 1:
+		return sorted[n-1], true
+	}
+	rank := int(math.Ceil(q*float64(n))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank], true
+}
+
+// Timeout suggests an attempt timeout of the q-quantile latency scaled by
+// multiplier and clamped to [floor, ceil]. Until the tracker has enough
+// samples it returns ceil.
+func (t *LatencyTracker) Timeout(q, multiplier float64, floor, ceil time.Duration) time.Duration {
+	latency, ok := t.Quantile(q)
+	if !ok {
+		return ceil
+	}
+
+	d := time.Duration(float64(latency) * multiplier)
+	if d < floor {
+		d = floor
+	}
+	if d > ceil {
+		d = ceil
+	}
+	return d
+}
+
+type adaptiveTimeout struct {
+	tracker    *LatencyTracker
+	quantile   float64
+	multiplier float64
+	floor      time.Duration
+	ceil       time.Duration
+}
+
+// WithAdaptiveAttemptTimeout bounds each attempt made by DoWithOptions with a
+// timeout derived from tracker: the quantile latency times multiplier, clamped
+// to [floor, ceil], or ceil until tracker has enough samples. The latency of
+// every successful attempt is recorded in tracker. A floor above ceil is
+// lowered to ceil. A nil tracker or a ceil of zero or less disables the bound,
+// as a d of zero or less does for WithAttemptTimeout.
+//
+// An attempt that fails because its own timeout expired, while ctx is still
+// live, is retried even if f did not mark the error as retryable; see
+// WithAttemptTimeoutNotRetried to change that.
+func WithAdaptiveAttemptTimeout(tracker *LatencyTracker, quantile, multiplier float64, floor, ceil time.Duration) Option {
+	return func(o *options) {
+		if tracker == nil || ceil <= 0 {
+			o.adaptiveTimeout = nil
+			return
+		}
+		o.adaptiveTimeout = &adaptiveTimeout{
+			tracker:    tracker,
+			quantile:   quantile,
+			multiplier: multiplier,
+			floor:      min(floor, ceil),
+			ceil:       ceil,
+		}
+	}
+}
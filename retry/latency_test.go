@@ -0,0 +1,301 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+func TestLatencyTracker(t *testing.T) {
+	t.Parallel()
+
+	t.Run("quantiles_uniform", func(t *testing.T) {
+		t.Parallel()
+
+		tr := NewLatencyTracker(100)
+		// Insert out of order; the estimate must not depend on arrival order.
+		for i := 100; i >= 1; i-- {
+			tr.Observe(time.Duration(i) * time.Millisecond)
+		}
+
+		cases := []struct {
+			q   float64
+			exp time.Duration
+		}{
+			{q: 0, exp: 1 * time.Millisecond},
+			{q: 0.5, exp: 50 * time.Millisecond},
+			{q: 0.9, exp: 90 * time.Millisecond},
+			{q: 0.99, exp: 99 * time.Millisecond},
+			{q: 1, exp: 100 * time.Millisecond},
+		}
+		for _, tc := range cases {
+			got, ok := tr.Quantile(tc.q)
+			if !ok {
+				t.Fatalf("expected enough samples for q=%v", tc.q)
+			}
+			if got != tc.exp {
+				t.Errorf("q=%v: expected %v to be %v", tc.q, got, tc.exp)
+			}
+		}
+	})
+
+	t.Run("outliers_do_not_skew_median", func(t *testing.T) {
+		t.Parallel()
+
+		tr := NewLatencyTracker(20)
+		for i := 0; i < 18; i++ {
+			tr.Observe(10 * time.Millisecond)
+		}
+		tr.Observe(10 * time.Second)
+		tr.Observe(30 * time.Second)
+
+		if got, _ := tr.Quantile(0.5); got != 10*time.Millisecond {
+			t.Errorf("expected %v to be %v", got, 10*time.Millisecond)
+		}
+	})
+
+	t.Run("window_evicts_oldest", func(t *testing.T) {
+		t.Parallel()
+
+		tr := NewLatencyTracker(10)
+		for i := 1; i <= 20; i++ {
+			tr.Observe(time.Duration(i) * time.Millisecond)
+		}
+
+		if got, want := tr.Len(), 10; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if got, _ := tr.Quantile(0); got != 11*time.Millisecond {
+			t.Errorf("expected %v to be %v", got, 11*time.Millisecond)
+		}
+	})
+
+	t.Run("timeout_for_scripted_history", func(t *testing.T) {
+		t.Parallel()
+
+		tr := NewLatencyTracker(50)
+		floor, ceil := 50*time.Millisecond, 2*time.Second
+
+		// Too few samples: fall back to ceil.
+		for i := 0; i < minLatencySamples-1; i++ {
+			tr.Observe(100 * time.Millisecond)
+			if got := tr.Timeout(0.9, 2, floor, ceil); got != ceil {
+				t.Errorf("expected %v to be %v", got, ceil)
+			}
+		}
+
+		tr.Observe(100 * time.Millisecond)
+		if got, want := tr.Timeout(0.9, 2, floor, ceil), 200*time.Millisecond; got != want {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+
+		// Clamped to the floor.
+		if got := tr.Timeout(0.9, 0.1, floor, ceil); got != floor {
+			t.Errorf("expected %v to be %v", got, floor)
+		}
+
+		// Clamped to the ceiling.
+		if got := tr.Timeout(0.9, 100, floor, ceil); got != ceil {
+			t.Errorf("expected %v to be %v", got, ceil)
+		}
+	})
+
+	t.Run("small_window_is_enough_when_full", func(t *testing.T) {
+		t.Parallel()
+
+		tr := NewLatencyTracker(2)
+		tr.Observe(1 * time.Millisecond)
+		if _, ok := tr.Quantile(0.5); ok {
+			t.Error("expected too few samples")
+		}
+		tr.Observe(3 * time.Millisecond)
+		if got, ok := tr.Quantile(1); !ok || got != 3*time.Millisecond {
+			t.Errorf("expected %v to be %v", got, 3*time.Millisecond)
+		}
+	})
+
+	t.Run("concurrent_use", func(t *testing.T) {
+		t.Parallel()
+
+		tr := NewLatencyTracker(64)
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(2)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 500; j++ {
+					tr.Observe(time.Duration(i*j) * time.Microsecond)
+				}
+			}(i)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 500; j++ {
+					tr.Timeout(0.95, 1.5, time.Millisecond, time.Second)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+func TestWithAdaptiveAttemptTimeout(t *testing.T) {
+	t.Parallel()
+
+	t.Run("rescues_hanging_attempt", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewConstant(1 * time.Millisecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		tr := NewLatencyTracker(10)
+		var calls int
+		var deadlines []time.Duration
+		err = DoWithOptions(context.Background(), backoff.WithMaxRetries(3, b), func(ctx context.Context) error {
+			calls++
+			deadline, ok := ctx.Deadline()
+			if !ok {
+				return fmt.Errorf("expected a deadline")
+			}
+			deadlines = append(deadlines, time.Until(deadline))
+
+			if calls == 1 {
+				// Hang until the attempt timeout rescues us.
+				<-ctx.Done()
+				return ctx.Err()
+			}
+			return nil
+		}, WithAdaptiveAttemptTimeout(tr, 0.9, 2, 1*time.Millisecond, 20*time.Millisecond))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if got, want := calls, 2; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		for _, d := range deadlines {
+			if d > 20*time.Millisecond {
+				t.Errorf("expected deadline %v to be at most the ceiling", d)
+			}
+		}
+		if got, want := tr.Len(), 1; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("uses_tracked_latency", func(t *testing.T) {
+		t.Parallel()
+
+		tr := NewLatencyTracker(10)
+		for i := 0; i < 10; i++ {
+			tr.Observe(10 * time.Millisecond)
+		}
+
+		b, err := backoff.NewConstant(1 * time.Millisecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		var remaining time.Duration
+		if err := DoWithOptions(context.Background(), b, func(ctx context.Context) error {
+			deadline, _ := ctx.Deadline()
+			remaining = time.Until(deadline)
+			return nil
+		}, WithAdaptiveAttemptTimeout(tr, 0.5, 3, 1*time.Millisecond, 1*time.Hour)); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if remaining <= 0 || remaining > 30*time.Millisecond {
+			t.Errorf("expected deadline within 30ms, got %v", remaining)
+		}
+	})
+
+	t.Run("parent_cancellation_is_not_retried", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewConstant(1 * time.Millisecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+		defer cancel()
+
+		var calls int
+		err = DoWithOptions(ctx, b, func(ctx context.Context) error {
+			calls++
+			<-ctx.Done()
+			return ctx.Err()
+		}, WithAdaptiveAttemptTimeout(NewLatencyTracker(10), 0.9, 2, time.Millisecond, time.Hour))
+		if err == nil {
+			t.Fatal("expected err")
+		}
+		if calls != 1 {
+			t.Errorf("expected %d to be %d", calls, 1)
+		}
+	})
+
+	t.Run("floor_above_ceil", func(t *testing.T) {
+		t.Parallel()
+
+		tr := NewLatencyTracker(10)
+		for i := 0; i < 10; i++ {
+			tr.Observe(1 * time.Hour)
+		}
+
+		b, err := backoff.NewConstant(1 * time.Millisecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		var remaining time.Duration
+		if err := DoWithOptions(context.Background(), b, func(ctx context.Context) error {
+			deadline, _ := ctx.Deadline()
+			remaining = time.Until(deadline)
+			return nil
+		}, WithAdaptiveAttemptTimeout(tr, 0.5, 1, 1*time.Hour, 1*time.Second)); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if remaining <= 0 || remaining > 1*time.Second {
+			t.Errorf("expected deadline within 1s, got %v", remaining)
+		}
+	})
+
+	disabled := []struct {
+		name    string
+		tracker *LatencyTracker
+		ceil    time.Duration
+	}{
+		{name: "nil_tracker", ceil: 1 * time.Hour},
+		{name: "zero_ceil", tracker: NewLatencyTracker(10)},
+		{name: "negative_ceil", tracker: NewLatencyTracker(10), ceil: -1 * time.Second},
+	}
+
+	for _, tc := range disabled {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			b, err := backoff.NewConstant(1 * time.Millisecond)
+			if err != nil {
+				t.Fatalf("failed to create constant backoff: %v", err)
+			}
+
+			if err := DoWithOptions(context.Background(), b, func(ctx context.Context) error {
+				if deadline, ok := ctx.Deadline(); ok {
+					return fmt.Errorf("expected no deadline, got %v", deadline)
+				}
+				return nil
+			}, WithAdaptiveAttemptTimeout(tc.tracker, 0.5, 2, 0, tc.ceil)); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		})
+	}
+}
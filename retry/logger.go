@@ -0,0 +1,94 @@
+package retry
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+	"github.com/swayne275/go-retry/internal/cost"
+)
+
+// WithLogger makes DoWithOptions log the progress of the loop to logger. A nil
+// logger logs nothing, and a loop whose first attempt succeeds logs nothing.
+//
+// Each failed attempt that will be retried is logged at slog.LevelDebug, with
+// the message "retry attempt failed" and these attributes:
+//
+//   - attempt: the number of the attempt that failed, starting at 1
+//   - error: the message of the error f returned
+//   - next_ms: how long the loop is about to sleep, in milliseconds
+//   - elapsed_ms: the time since the loop started, in milliseconds
+//
+// A loop that ends with an error is logged at slog.LevelWarn, with the
+// message "retry gave up" and these attributes:
+//
+//   - attempts: the number of times f was called
+//   - outcome: the LabelOf the error returned
+//   - error: the message of the error returned
+//   - elapsed_ms: the time the whole loop took, in milliseconds
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// DoLogged is like Do, but logs its progress to logger. It is a shorthand for
+// DoWithOptions with WithLogger.
+func DoLogged(ctx context.Context, b backoff.Backoff, f RetryFunc, logger *slog.Logger) error {
+	return DoWithOptions(ctx, b, f, WithLogger(logger))
+}
+
+// logRetry logs a failed attempt that will be retried after next, if a logger
+// is set.
+func logRetry(ctx context.Context, o *options, m *cost.Meter, attempt uint64, err error, next time.Duration) {
+	if o.logger == nil {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.Uint64("attempt", attempt),
+		slog.String("error", err.Error()),
+		slog.Int64("next_ms", next.Milliseconds()),
+		slog.Int64("elapsed_ms", m.Running().Milliseconds()),
+	}
+	attrs, ok := suppressLog(o, err, attrs)
+	if !ok {
+		return
+	}
+	o.logger.LogAttrs(ctx, slog.LevelDebug, "retry attempt failed", attrs...)
+}
+
+// logGaveUp logs a loop that ended with err, if a logger is set and err is not
+// nil.
+func logGaveUp(ctx context.Context, o *options, m *cost.Meter, err error) {
+	if o.logger == nil || err == nil {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.Uint64("attempts", m.Cost().Attempts),
+		slog.String("outcome", LabelOf(err)),
+		slog.String("error", err.Error()),
+		slog.Int64("elapsed_ms", m.Elapsed().Milliseconds()),
+	}
+	attrs, ok := suppressLog(o, err, attrs)
+	if !ok {
+		return
+	}
+	o.logger.LogAttrs(ctx, slog.LevelWarn, "retry gave up", attrs...)
+}
+
+// suppressLog consults the suppressor set with WithLoggerSuppressor, if any,
+// about a record for err. It reports whether to log the record, and adds the
+// "suppressed" attribute to attrs if so.
+func suppressLog(o *options, err error, attrs []slog.Attr) ([]slog.Attr, bool) {
+	if o.logSuppress == nil {
+		return attrs, true
+	}
+	ok, suppressed := o.logSuppress(err)
+	if !ok {
+		return nil, false
+	}
+	return append(attrs, slog.Int("suppressed", suppressed)), true
+}
@@ -0,0 +1,112 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+func TestWithLogger(t *testing.T) {
+	t.Parallel()
+
+	newBackoff := func(t *testing.T) backoff.Backoff {
+		t.Helper()
+
+		b, err := backoff.NewExponential(1 * time.Second)
+		if err != nil {
+			t.Fatalf("failed to create exponential backoff: %v", err)
+		}
+		return backoff.WithMaxRetries(2, b)
+	}
+
+	// failing returns a function that fails n times before it succeeds.
+	failing := func(n int) RetryFunc {
+		var calls int
+		return func(_ context.Context) error {
+			calls++
+			if calls <= n {
+				return RetryableError(fmt.Errorf("oops %d", calls))
+			}
+			return nil
+		}
+	}
+
+	retried := func(attempt uint64, next, elapsed int64) map[string]any {
+		return map[string]any{
+			"attempt":    attempt,
+			"error":      fmt.Sprintf("oops %d", attempt),
+			"next_ms":    next,
+			"elapsed_ms": elapsed,
+		}
+	}
+
+	cases := []struct {
+		name   string
+		f      RetryFunc
+		levels []slog.Level
+		attrs  []map[string]any
+	}{
+		{
+			name: "first_attempt_succeeds",
+			f:    failing(0),
+		},
+		{
+			name:   "retried",
+			f:      failing(2),
+			levels: []slog.Level{slog.LevelDebug, slog.LevelDebug},
+			attrs: []map[string]any{
+				retried(1, 1000, 0),
+				retried(2, 2000, 1000),
+			},
+		},
+		{
+			name:   "gave_up",
+			f:      failing(3),
+			levels: []slog.Level{slog.LevelDebug, slog.LevelDebug, slog.LevelWarn},
+			attrs: []map[string]any{
+				retried(1, 1000, 0),
+				retried(2, 2000, 1000),
+				{
+					"attempts":   uint64(3),
+					"outcome":    "retries_exhausted",
+					"error":      "backoff signaled to stop: max retries exhausted: oops 3",
+					"elapsed_ms": int64(3000),
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			h := &captureHandler{}
+			_ = DoWithOptions(context.Background(), newBackoff(t), tc.f, WithClock(newFakeClock()), WithLogger(slog.New(h)))
+
+			if got := h.attrs(); len(got) != len(tc.attrs) || (len(got) > 0 && !reflect.DeepEqual(got, tc.attrs)) {
+				t.Errorf("expected %v to be %v", got, tc.attrs)
+			}
+			for i, r := range h.records {
+				if i < len(tc.levels) && r.Level != tc.levels[i] {
+					t.Errorf("record %d: expected %v to be %v", i, r.Level, tc.levels[i])
+				}
+			}
+		})
+	}
+
+	t.Run("nil_logger", func(t *testing.T) {
+		t.Parallel()
+
+		err := DoLogged(context.Background(), newBackoff(t), failing(1), nil)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,73 @@
+package retry
+
+import (
+	"context"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+	"github.com/swayne275/go-retry/internal/cost"
+)
+
+// Metrics observes retry loops in aggregate, such as by updating Prometheus
+// counters and histograms. The package calls it but does not depend on any
+// metrics library. name is the name given to the loop with DoNamed or
+// WithName, which should be low-cardinality. Its methods are called on the
+// goroutine running the loop and should return quickly.
+type Metrics interface {
+	// ObserveAttempt is called after each call of f, with the number of the
+	// attempt, starting at 1, and the error f returned, which is nil if it
+	// succeeded.
+	ObserveAttempt(name string, attempt uint64, err error)
+	// ObserveSleep is called before each sleep between attempts, with its
+	// duration.
+	ObserveSleep(name string, d time.Duration)
+	// ObserveOutcome is called once when the loop ends, however it ends, with
+	// "success" or the LabelOf the error returned, and the time the whole loop
+	// took.
+	ObserveOutcome(name string, outcome string, total time.Duration)
+}
+
+// WithMetrics makes DoWithOptions report to m. A nil m reports nothing and
+// costs nothing.
+func WithMetrics(m Metrics) Option {
+	return func(o *options) {
+		o.metrics = m
+	}
+}
+
+// WithName names the loop for WithMetrics.
+func WithName(name string) Option {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+// DoNamed is like DoWithOptions, but names the loop for WithMetrics.
+func DoNamed(ctx context.Context, name string, b backoff.Backoff, f RetryFunc, opts ...Option) error {
+	return DoWithOptions(ctx, b, f, append([]Option{WithName(name)}, opts...)...)
+}
+
+// observeAttempt reports an attempt that returned err, or stopped the loop
+// with fatal, if metrics are set.
+func observeAttempt(o *options, attempt uint64, err, fatal error) {
+	if o.metrics == nil {
+		return
+	}
+	if err == nil {
+		err = fatal
+	}
+	o.metrics.ObserveAttempt(o.name, attempt, err)
+}
+
+// observeOutcome reports a loop that ended with err, if metrics are set.
+func observeOutcome(o *options, m *cost.Meter, err error) {
+	if o.metrics == nil {
+		return
+	}
+
+	outcome := "success"
+	if err != nil {
+		outcome = LabelOf(err)
+	}
+	o.metrics.ObserveOutcome(o.name, outcome, m.Elapsed())
+}
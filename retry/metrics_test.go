@@ -0,0 +1,131 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+// fakeMetrics records every observation as a string.
+type fakeMetrics struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (m *fakeMetrics) record(format string, args ...any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.events = append(m.events, fmt.Sprintf(format, args...))
+}
+
+func (m *fakeMetrics) ObserveAttempt(name string, attempt uint64, err error) {
+	m.record("%s attempt %d: %v", name, attempt, err)
+}
+
+func (m *fakeMetrics) ObserveSleep(name string, d time.Duration) {
+	m.record("%s sleep %v", name, d)
+}
+
+func (m *fakeMetrics) ObserveOutcome(name string, outcome string, total time.Duration) {
+	m.record("%s outcome %s after %v", name, outcome, total)
+}
+
+func TestDoNamed_metrics(t *testing.T) {
+	t.Parallel()
+
+	newBackoff := func(t *testing.T) backoff.Backoff {
+		t.Helper()
+
+		b, err := backoff.NewExponential(1 * time.Second)
+		if err != nil {
+			t.Fatalf("failed to create exponential backoff: %v", err)
+		}
+		return backoff.WithMaxRetries(1, b)
+	}
+
+	cases := []struct {
+		name   string
+		f      func(cancel context.CancelFunc) RetryFunc
+		events []string
+	}{
+		{
+			name: "success",
+			f: func(_ context.CancelFunc) RetryFunc {
+				var calls int
+				return func(_ context.Context) error {
+					calls++
+					if calls == 1 {
+						return RetryableError(fmt.Errorf("oops"))
+					}
+					return nil
+				}
+			},
+			events: []string{
+				"fetch attempt 1: retryable: oops",
+				"fetch sleep 1s",
+				"fetch attempt 2: <nil>",
+				"fetch outcome success after 1s",
+			},
+		},
+		{
+			name: "exhausted",
+			f: func(_ context.CancelFunc) RetryFunc {
+				return func(_ context.Context) error {
+					return RetryableError(fmt.Errorf("oops"))
+				}
+			},
+			events: []string{
+				"fetch attempt 1: retryable: oops",
+				"fetch sleep 1s",
+				"fetch attempt 2: retryable: oops",
+				"fetch outcome retries_exhausted after 1s",
+			},
+		},
+		{
+			name: "canceled",
+			f: func(cancel context.CancelFunc) RetryFunc {
+				return func(_ context.Context) error {
+					cancel()
+					return RetryableError(fmt.Errorf("oops"))
+				}
+			},
+			events: []string{
+				"fetch attempt 1: retryable: oops",
+				"fetch outcome context_canceled after 0s",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			m := &fakeMetrics{}
+			_ = DoNamed(ctx, "fetch", newBackoff(t), tc.f(cancel), WithClock(newFakeClock()), WithMetrics(m))
+			if !reflect.DeepEqual(m.events, tc.events) {
+				t.Errorf("expected %q to be %q", m.events, tc.events)
+			}
+		})
+	}
+
+	t.Run("nil_metrics", func(t *testing.T) {
+		t.Parallel()
+
+		if err := DoNamed(context.Background(), "fetch", newBackoff(t), func(_ context.Context) error {
+			return nil
+		}, WithMetrics(nil)); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,209 @@
+package retry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/swayne275/go-retry/backoff"
+	"github.com/swayne275/go-retry/internal/label"
+)
+
+// ErrMarkFailed is returned by DoOnce, wrapping the MarkerStore's error, when
+// f succeeded but the marker recording that could not be written. f may run
+// again the next time DoOnce is called for the key. Its label is "mark_failed".
+var ErrMarkFailed = label.New("failed to mark as done", "mark_failed")
+
+// MarkerStore records which keys DoOnce has completed. Implementations that
+// persist their markers make DoOnce hold across process restarts.
+type MarkerStore interface {
+	// IsDone reports whether key has been marked as done.
+	IsDone(ctx context.Context, key string) (bool, error)
+	// MarkDone marks key as done.
+	MarkDone(ctx context.Context, key string) error
+}
+
+// onces holds the DoOnce calls in flight, per marker store and key.
+var onces flightGroup
+
+// onceKey identifies a DoOnce call. marker is the identity of the marker
+// store, from cacheIdentity, so it is always comparable.
+type onceKey struct {
+	marker any
+	key    string
+}
+
+// DoOnce runs f with the backoff until it succeeds, as Do does, unless marker
+// says key is already done, in which case it returns nil without calling f.
+// After f succeeds, key is marked as done before DoOnce returns; if that fails
+// the error wraps ErrMarkFailed.
+//
+// Concurrent calls for the same marker store and key within one process
+// collapse into one: the later calls wait for the first, or for their own ctx
+// to be done. Once the first finishes they check marker again, returning nil
+// if key was marked as done and otherwise running f themselves. Marker stores
+// are told apart by address if they are pointers, maps or channels, and by
+// value otherwise; calls on a store that is none of these and not comparable
+// either are not collapsed.
+func DoOnce(ctx context.Context, marker MarkerStore, key string, b backoff.Backoff, f RetryFunc) error {
+	once := func() error {
+		done, err := marker.IsDone(ctx, key)
+		if err != nil {
+			return fmt.Errorf("failed to check marker: %w", err)
+		}
+		if done {
+			return nil
+		}
+
+		if err := Do(ctx, b, f); err != nil {
+			return err
+		}
+
+		if err := marker.MarkDone(ctx, key); err != nil {
+			return fmt.Errorf("%w: %w", ErrMarkFailed, err)
+		}
+		return nil
+	}
+
+	id, ok := cacheIdentity(marker)
+	if !ok {
+		return once()
+	}
+
+	for {
+		err, shared := onces.do(ctx, onceKey{marker: id, key: key}, once)
+		if !shared {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// The first call's result is its own: look at the marker instead, and
+		// if it failed, try again, leading unless another call got there first.
+		done, err := marker.IsDone(ctx, key)
+		if err != nil {
+			return fmt.Errorf("failed to check marker: %w", err)
+		}
+		if done {
+			return nil
+		}
+	}
+}
+
+// MemoryMarkerStore is a MarkerStore that keeps its markers in memory, so they
+// last only as long as the process. The zero MemoryMarkerStore is empty and
+// ready to use.
+type MemoryMarkerStore struct {
+	mu   sync.Mutex
+	done map[string]bool
+}
+
+// NewMemoryMarkerStore creates an empty MemoryMarkerStore.
+func NewMemoryMarkerStore() *MemoryMarkerStore {
+	return &MemoryMarkerStore{
+		done: make(map[string]bool),
+	}
+}
+
+// IsDone implements MarkerStore.
+func (s *MemoryMarkerStore) IsDone(_ context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.done[key], nil
+}
+
+// MarkDone implements MarkerStore.
+func (s *MemoryMarkerStore) MarkDone(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.done == nil {
+		s.done = make(map[string]bool)
+	}
+	s.done[key] = true
+	return nil
+}
+
+// FileMarkerStore is a MarkerStore that keeps one file per marker in a
+// directory, so its markers survive process restarts. A FileMarkerStore with
+// no directory, such as the zero value, fails every call rather than writing
+// markers to the working directory.
+type FileMarkerStore struct {
+	dir string
+}
+
+// NewFileMarkerStore creates a FileMarkerStore keeping its markers in dir. The
+// directory is created when the first marker is written.
+func NewFileMarkerStore(dir string) *FileMarkerStore {
+	return &FileMarkerStore{dir: dir}
+}
+
+// errNoMarkerDir is returned by a FileMarkerStore with no directory.
+var errNoMarkerDir = fmt.Errorf("file marker store has no directory")
+
+// path returns the marker file for key. It is named by the SHA-256 of key, so
+// any key, however long, makes a valid file name; the file holds key itself.
+func (s *FileMarkerStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+".done")
+}
+
+// IsDone implements MarkerStore.
+func (s *FileMarkerStore) IsDone(ctx context.Context, key string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	if s.dir == "" {
+		return false, errNoMarkerDir
+	}
+
+	_, err := os.Stat(s.path(key))
+	switch {
+	case err == nil:
+		return true, nil
+	case os.IsNotExist(err):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// MarkDone implements MarkerStore. The marker is written to a temporary file
+// and renamed into place, so a crash never leaves a partial marker behind.
+func (s *FileMarkerStore) MarkDone(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if s.dir == "" {
+		return errNoMarkerDir
+	}
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(s.dir, ".marker-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.WriteString(key); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), s.path(key))
+}
@@ -0,0 +1,318 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+// failingMarker is a MarkerStore whose reads fail with checkErr, if set, and
+// whose writes always fail.
+type failingMarker struct {
+	checkErr error
+}
+
+var errMarkerDown = fmt.Errorf("marker store down")
+
+func (m failingMarker) IsDone(context.Context, string) (bool, error) {
+	return false, m.checkErr
+}
+
+func (m failingMarker) MarkDone(context.Context, string) error {
+	return errMarkerDown
+}
+
+func TestDoOnce(t *testing.T) {
+	t.Parallel()
+
+	newBackoff := func(t *testing.T) backoff.Backoff {
+		t.Helper()
+
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		return backoff.WithMaxRetries(3, b)
+	}
+
+	stores := []struct {
+		name  string
+		store func(t *testing.T) MarkerStore
+	}{
+		{
+			name:  "memory",
+			store: func(t *testing.T) MarkerStore { return NewMemoryMarkerStore() },
+		},
+		{
+			name:  "file",
+			store: func(t *testing.T) MarkerStore { return NewFileMarkerStore(t.TempDir()) },
+		},
+	}
+
+	for _, sc := range stores {
+		sc := sc
+
+		t.Run(sc.name, func(t *testing.T) {
+			t.Parallel()
+
+			t.Run("runs_and_marks", func(t *testing.T) {
+				t.Parallel()
+
+				store := sc.store(t)
+				var calls int
+				f := func(_ context.Context) error {
+					calls++
+					if calls < 3 {
+						return RetryableError(fmt.Errorf("oops"))
+					}
+					return nil
+				}
+
+				if err := DoOnce(context.Background(), store, "migrate/v2", newBackoff(t), f); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				if calls != 3 {
+					t.Errorf("expected %d to be %d", calls, 3)
+				}
+				if done, err := store.IsDone(context.Background(), "migrate/v2"); err != nil || !done {
+					t.Errorf("expected key to be marked done, got %v, %v", done, err)
+				}
+
+				// Once done, f never runs again.
+				if err := DoOnce(context.Background(), store, "migrate/v2", newBackoff(t), f); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				if calls != 3 {
+					t.Errorf("expected %d to be %d", calls, 3)
+				}
+			})
+
+			t.Run("skips_when_done", func(t *testing.T) {
+				t.Parallel()
+
+				store := sc.store(t)
+				if err := store.MarkDone(context.Background(), "../k"); err != nil {
+					t.Fatalf("failed to mark: %v", err)
+				}
+
+				if err := DoOnce(context.Background(), store, "../k", newBackoff(t), func(_ context.Context) error {
+					t.Error("expected f not to be called")
+					return nil
+				}); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+			})
+
+			t.Run("failure_is_not_marked", func(t *testing.T) {
+				t.Parallel()
+
+				store := sc.store(t)
+				errFoo := fmt.Errorf("foo")
+				err := DoOnce(context.Background(), store, "k", newBackoff(t), func(_ context.Context) error {
+					return errFoo
+				})
+				if !errors.Is(err, ErrNonRetryable) || !errors.Is(err, errFoo) {
+					t.Errorf("expected %q to be %q", err, ErrNonRetryable)
+				}
+				if done, _ := store.IsDone(context.Background(), "k"); done {
+					t.Error("expected key not to be marked done")
+				}
+			})
+		})
+	}
+
+	t.Run("file_markers_survive_restarts", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		if err := DoOnce(context.Background(), NewFileMarkerStore(dir), "k", newBackoff(t), func(_ context.Context) error {
+			return nil
+		}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		done, err := NewFileMarkerStore(dir).IsDone(context.Background(), "k")
+		if err != nil || !done {
+			t.Errorf("expected key to be marked done, got %v, %v", done, err)
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatalf("failed to read dir: %v", err)
+		}
+		if len(entries) != 1 {
+			t.Errorf("expected only the marker file, got %v", entries)
+		}
+	})
+
+	t.Run("long_key", func(t *testing.T) {
+		t.Parallel()
+
+		store := NewFileMarkerStore(t.TempDir())
+		key := strings.Repeat("k", 1000)
+		var calls int
+		for i := 0; i < 2; i++ {
+			if err := DoOnce(context.Background(), store, key, newBackoff(t), func(_ context.Context) error {
+				calls++
+				return nil
+			}); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		}
+		if calls != 1 {
+			t.Errorf("expected %d to be %d", calls, 1)
+		}
+	})
+
+	t.Run("mark_failure", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int
+		err := DoOnce(context.Background(), failingMarker{}, "k", newBackoff(t), func(_ context.Context) error {
+			calls++
+			return nil
+		})
+		if !errors.Is(err, ErrMarkFailed) {
+			t.Errorf("expected %q to be %q", err, ErrMarkFailed)
+		}
+		if !errors.Is(err, errMarkerDown) {
+			t.Errorf("expected %q to be %q", err, errMarkerDown)
+		}
+		if calls != 1 {
+			t.Errorf("expected %d to be %d", calls, 1)
+		}
+	})
+
+	t.Run("store_error", func(t *testing.T) {
+		t.Parallel()
+
+		errFoo := fmt.Errorf("foo")
+		err := DoOnce(context.Background(), failingMarker{checkErr: errFoo}, "k", newBackoff(t), func(_ context.Context) error {
+			t.Error("expected f not to be called")
+			return nil
+		})
+		if !errors.Is(err, errFoo) {
+			t.Errorf("expected %q to be %q", err, errFoo)
+		}
+		if errors.Is(err, ErrMarkFailed) {
+			t.Errorf("expected %q not to be %q", err, ErrMarkFailed)
+		}
+	})
+
+	t.Run("concurrent_calls_collapse", func(t *testing.T) {
+		t.Parallel()
+
+		store := NewMemoryMarkerStore()
+		started := make(chan struct{})
+		release := make(chan struct{})
+		var calls int64
+		f := func(_ context.Context) error {
+			if atomic.AddInt64(&calls, 1) == 1 {
+				close(started)
+			}
+			<-release
+			return nil
+		}
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 10)
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- DoOnce(context.Background(), store, "k", newBackoff(t), f)
+			}()
+		}
+		<-started
+		close(release)
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			if err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		}
+		if got := atomic.LoadInt64(&calls); got != 1 {
+			t.Errorf("expected %d to be %d", got, 1)
+		}
+	})
+
+	t.Run("waiter_runs_after_leader_panics", func(t *testing.T) {
+		t.Parallel()
+
+		store := NewMemoryMarkerStore()
+		entered := make(chan struct{})
+		release := make(chan struct{})
+		panicked := make(chan any, 1)
+
+		go func() {
+			defer func() { panicked <- recover() }()
+			DoOnce(context.Background(), store, "k", newBackoff(t), func(_ context.Context) error {
+				close(entered)
+				<-release
+				panic("boom")
+			})
+		}()
+		<-entered
+
+		var calls int64
+		waited := make(chan error, 1)
+		go func() {
+			waited <- DoOnce(context.Background(), store, "k", newBackoff(t), func(_ context.Context) error {
+				atomic.AddInt64(&calls, 1)
+				return nil
+			})
+		}()
+		id, _ := cacheIdentity(store)
+		waitForDups(&onces, onceKey{marker: id, key: "k"}, 1)
+		close(release)
+
+		if r := <-panicked; r != "boom" {
+			t.Errorf("expected %v to be %v", r, "boom")
+		}
+		if err := <-waited; err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+		if got := atomic.LoadInt64(&calls); got != 1 {
+			t.Errorf("expected %d to be %d", got, 1)
+		}
+		if done, err := store.IsDone(context.Background(), "k"); err != nil || !done {
+			t.Errorf("expected key to be marked done, got %v, %v", done, err)
+		}
+	})
+
+	t.Run("waiter_ctx_canceled", func(t *testing.T) {
+		t.Parallel()
+
+		store := NewMemoryMarkerStore()
+		entered := make(chan struct{})
+		release := make(chan struct{})
+		defer close(release)
+
+		go DoOnce(context.Background(), store, "k", newBackoff(t), func(_ context.Context) error {
+			close(entered)
+			<-release
+			return nil
+		})
+		<-entered
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		err := DoOnce(ctx, store, "k", newBackoff(t), func(_ context.Context) error {
+			t.Error("expected f not to be called")
+			return nil
+		})
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected %q to be %q", err, context.DeadlineExceeded)
+		}
+	})
+}
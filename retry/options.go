@@ -0,0 +1,172 @@
+package retry
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/swayne275/go-retry/internal/clock"
+	"github.com/swayne275/go-retry/internal/summary"
+)
+
+// Clock tells the time and creates timers for DoWithOptions. It exists so
+// tests can substitute a fake; see the retrytest package.
+type Clock = clock.Clock
+
+// Timer is a single-shot timer created by a Clock.
+type Timer = clock.Timer
+
+// Option configures the behavior of DoWithOptions.
+type Option func(*options)
+
+type options struct {
+	clock Clock
+
+	// gate, if set, must report ready before attempts are made.
+	gate *ReadinessGate
+	// gateMode says before which attempts gate is consulted.
+	gateMode gateMode
+
+	// fence, if set, is checked before attempts; fenceOnRetry skips the check
+	// before the first one.
+	fence        func(ctx context.Context) error
+	fenceOnRetry bool
+
+	// priorityGate, if set, bounds how many attempts run at once.
+	priorityGate *PriorityGate
+
+	// budget, if set, must allow each retry and is told of every success.
+	budget *Budget
+
+	// byteBudget, if set, is charged byteCost before each retry.
+	byteBudget *ByteBudget
+	byteCost   func(attempt uint64) int64
+
+	// shouldRetry, if set, decides whether errors from f that aren't marked
+	// either way are retried.
+	shouldRetry func(err error) bool
+
+	// bareErrors returns errors from f without wrapping them in the
+	// package's sentinels.
+	bareErrors bool
+
+	// fastFirstRetry skips the first sleep if the first attempt failed in
+	// less than this long.
+	fastFirstRetry time.Duration
+
+	// adaptiveTimeout, if set, bounds each attempt.
+	adaptiveTimeout *adaptiveTimeout
+	// attemptTimeout, if positive, bounds each attempt.
+	attemptTimeout time.Duration
+	// attemptTimeoutNotRetried stops an attempt that timed out from being
+	// retried unless f marked its error retryable.
+	attemptTimeoutNotRetried bool
+
+	// seed, if seeded is set, seeds every random layer of the backoff.
+	seed   int64
+	seeded bool
+
+	// costAcc, if set, is charged the cost of the loop under costKey.
+	costAcc *CostAccumulator
+	costKey string
+
+	// delayHistogram, if set, records every sleep between attempts.
+	delayHistogram *DelayHistogram
+
+	// control can disable retries.
+	control *Control
+
+	// onRetry, if set, is called after each failed attempt that will be
+	// retried.
+	onRetry func(attempt uint64, err error, next time.Duration)
+
+	// metrics, if set, observes every attempt, sleep and outcome under name.
+	metrics Metrics
+	name    string
+
+	// logger, if set, is told of every failed attempt that will be retried
+	// and of the loop giving up.
+	logger *slog.Logger
+	// logSuppress, if set, decides whether the records of logger are logged,
+	// and how many were suppressed before each.
+	logSuppress func(err error) (bool, int)
+
+	// expectation, if set, fails a test when the loop retries too often.
+	expectation *testExpectation
+
+	// summary, if enabled, logs one record when the loop ends.
+	summary summary.Config
+
+	// recoverPanics recovers panics from f; retryPanics retries them.
+	recoverPanics bool
+	retryPanics   bool
+
+	// resetOnSuccess resets the backoff after f succeeds.
+	resetOnSuccess bool
+
+	// attemptSetup, if set, runs before each attempt and returns the
+	// cleanup to run after it.
+	attemptSetup func(ctx context.Context) (cleanup func(), err error)
+
+	// revalidateTimeout bounds background refreshes started by
+	// DoStaleWhileRevalidate.
+	revalidateTimeout time.Duration
+
+	// groupLimit, if positive, bounds how many functions of a Group run at
+	// once.
+	groupLimit int
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		clock:             clock.Real,
+		revalidateTimeout: defaultRevalidateTimeout,
+		control:           global,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+	return o
+}
+
+// WithClock makes DoWithOptions use c for all of its sleeps instead of the
+// real clock. A nil c selects the real clock.
+func WithClock(c Clock) Option {
+	return func(o *options) {
+		o.clock = clock.Or(c)
+	}
+}
+
+// WithFastFirstRetryThreshold makes DoWithOptions retry immediately after the
+// first attempt if that attempt failed in less than d, which usually means the
+// dependency refused the connection outright rather than timing out. The
+// backoff is still consulted for that retry, so decorators such as
+// WithMaxRetries count it, but its value is not slept. Only the first retry is
+// affected; later retries always follow the schedule. A delay requested with
+// RetryableAfterError is honored even then.
+func WithFastFirstRetryThreshold(d time.Duration) Option {
+	return func(o *options) {
+		o.fastFirstRetry = d
+	}
+}
+
+// WithDeterministicSeed makes every layer of the backoff that draws random
+// numbers, such as the jitter decorators, draw them from one private stream
+// seeded with seed. Combined with a fake Clock, this makes a run reproducible.
+//
+// The source is swapped on the backoff itself and is not put back when the
+// loop returns, so the backoff must not be shared with other loops, and keeps
+// drawing from the seeded stream if it is used again. Build a new backoff for
+// each seeded run.
+//
+// DoWithOptions returns an error wrapping backoff.ErrRandomSourceNotSupported,
+// without calling f, if the backoff contains a layer that draws random numbers
+// from a source that can't be replaced. See backoff.UseRandomSource.
+func WithDeterministicSeed(seed int64) Option {
+	return func(o *options) {
+		o.seed = seed
+		o.seeded = true
+	}
+}
@@ -0,0 +1,237 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+// fakeClock is a manually advanced Clock whose timers fire immediately,
+// advancing the clock by their duration.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(0, 0)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+func (c *fakeClock) NewTimer(d time.Duration) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	c.sleeps = append(c.sleeps, d)
+
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return firedTimer(ch)
+}
+
+func (c *fakeClock) Sleeps() []time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]time.Duration(nil), c.sleeps...)
+}
+
+type firedTimer chan time.Time
+
+func (t firedTimer) C() <-chan time.Time { return t }
+func (t firedTimer) Stop() bool          { return false }
+
+func TestWithClock(t *testing.T) {
+	t.Parallel()
+
+	b, err := backoff.NewExponential(1 * time.Hour)
+	if err != nil {
+		t.Fatalf("failed to create exponential backoff: %v", err)
+	}
+
+	c := newFakeClock()
+	var calls int
+	if err := DoWithOptions(context.Background(), b, func(_ context.Context) error {
+		calls++
+		if calls < 4 {
+			return RetryableError(fmt.Errorf("oops"))
+		}
+		return nil
+	}, WithClock(c)); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	want := []time.Duration{1 * time.Hour, 2 * time.Hour, 4 * time.Hour}
+	got := c.Sleeps()
+	if len(got) != len(want) {
+		t.Fatalf("expected %v to be %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	}
+}
+
+func TestWithFastFirstRetryThreshold(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		durs     []time.Duration // how long each attempt takes
+		expSleep []time.Duration
+	}{
+		{
+			name:     "instant_first_failure_skips_first_sleep",
+			durs:     []time.Duration{0, 0, 0, 0},
+			expSleep: []time.Duration{2 * time.Second, 3 * time.Second},
+		},
+		{
+			name:     "slow_first_failure_sleeps",
+			durs:     []time.Duration{5 * time.Second, 0, 0, 0},
+			expSleep: []time.Duration{1 * time.Second, 2 * time.Second, 3 * time.Second},
+		},
+		{
+			name:     "later_fast_failures_sleep",
+			durs:     []time.Duration{5 * time.Second, 0, 5 * time.Second, 0},
+			expSleep: []time.Duration{1 * time.Second, 2 * time.Second, 3 * time.Second},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var nexts int
+			delays := []time.Duration{1 * time.Second, 2 * time.Second, 3 * time.Second}
+			b := backoff.BackoffFunc(func() (time.Duration, bool) {
+				if nexts >= len(delays) {
+					return 0, true
+				}
+				nexts++
+				return delays[nexts-1], false
+			})
+
+			c := newFakeClock()
+			var calls int
+			err := DoWithOptions(context.Background(), b, func(_ context.Context) error {
+				c.Advance(tc.durs[calls])
+				calls++
+				return RetryableError(fmt.Errorf("oops"))
+			}, WithClock(c), WithFastFirstRetryThreshold(1*time.Millisecond))
+			if err == nil {
+				t.Fatal("expected err")
+			}
+
+			if got, want := calls, len(delays)+1; got != want {
+				t.Errorf("expected %d to be %d", got, want)
+			}
+			if got, want := nexts, len(delays); got != want {
+				t.Errorf("expected %d to be %d", got, want)
+			}
+			if got := c.Sleeps(); fmt.Sprint(got) != fmt.Sprint(tc.expSleep) {
+				t.Errorf("expected %v to be %v", got, tc.expSleep)
+			}
+		})
+	}
+}
+
+func TestWithDeterministicSeed(t *testing.T) {
+	t.Parallel()
+
+	run := func(t *testing.T, seed int64, wrap func(backoff.Backoff) backoff.Backoff) ([]time.Duration, int, error) {
+		t.Helper()
+
+		b, err := backoff.NewExponential(1 * time.Second)
+		if err != nil {
+			t.Fatalf("failed to create exponential backoff: %v", err)
+		}
+		b, err = backoff.WithJitter(500*time.Millisecond, b)
+		if err != nil {
+			t.Fatalf("failed to add jitter: %v", err)
+		}
+		b, err = backoff.WithJitterPercent(20, b)
+		if err != nil {
+			t.Fatalf("failed to add jitter: %v", err)
+		}
+		b = backoff.WithMaxRetries(10, b)
+		if wrap != nil {
+			b = wrap(b)
+		}
+
+		c := newFakeClock()
+		var calls int
+		err = DoWithOptions(context.Background(), b, func(_ context.Context) error {
+			calls++
+			return RetryableError(fmt.Errorf("oops"))
+		}, WithClock(c), WithDeterministicSeed(seed))
+		return c.Sleeps(), calls, err
+	}
+
+	t.Run("same_seed_same_trace", func(t *testing.T) {
+		t.Parallel()
+
+		a, callsA, _ := run(t, 42, nil)
+		b, callsB, _ := run(t, 42, nil)
+		if callsA != callsB {
+			t.Errorf("expected %d to be %d", callsA, callsB)
+		}
+		if fmt.Sprint(a) != fmt.Sprint(b) {
+			t.Errorf("expected %v to be %v", a, b)
+		}
+	})
+
+	t.Run("different_seed_different_trace", func(t *testing.T) {
+		t.Parallel()
+
+		a, _, _ := run(t, 42, nil)
+		b, _, _ := run(t, 43, nil)
+		if fmt.Sprint(a) == fmt.Sprint(b) {
+			t.Errorf("expected %v to differ from %v", a, b)
+		}
+	})
+
+	t.Run("rejects_unseedable_layer", func(t *testing.T) {
+		t.Parallel()
+
+		sleeps, calls, err := run(t, 42, func(b backoff.Backoff) backoff.Backoff {
+			return opaqueJitter{b}
+		})
+		if !errors.Is(err, backoff.ErrRandomSourceNotSupported) {
+			t.Errorf("expected %q to be %q", err, backoff.ErrRandomSourceNotSupported)
+		}
+		if calls != 0 || len(sleeps) != 0 {
+			t.Errorf("expected no attempts, got %d calls and sleeps %v", calls, sleeps)
+		}
+	})
+}
+
+// opaqueJitter claims to draw random numbers without allowing its source to
+// be replaced.
+type opaqueJitter struct {
+	backoff.Backoff
+}
+
+func (opaqueJitter) Randomized() bool { return true }
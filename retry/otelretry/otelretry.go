@@ -0,0 +1,149 @@
+// Package otelretry records the attempts of retry loops with OpenTelemetry.
+// It is a separate module, so the core module stays free of dependencies.
+package otelretry
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/swayne275/go-retry/backoff"
+	"github.com/swayne275/go-retry/retry"
+)
+
+// The attributes recorded for each attempt.
+const (
+	// AttemptKey is the number of the attempt, starting at 1.
+	AttemptKey = attribute.Key("retry.attempt")
+	// ErrorKey is the message of the error the attempt returned, if any.
+	ErrorKey = attribute.Key("retry.error")
+	// BackoffKey is how long the loop slept after the attempt, in
+	// milliseconds. It is only set for attempts that were retried.
+	BackoffKey = attribute.Key("retry.backoff_ms")
+)
+
+// EventName is the name of the span event recorded for each attempt, and of
+// the span created for it with WithChildSpans.
+const EventName = "retry.attempt"
+
+// Option configures the behavior of Do.
+type Option func(*options)
+
+type options struct {
+	tracer    trace.Tracer
+	retryOpts []retry.Option
+}
+
+// WithChildSpans makes Do start a child span with tracer for each attempt,
+// instead of recording an event on the span in ctx. The span is passed to f
+// in its context, and marked as failed if the attempt returned an error.
+func WithChildSpans(tracer trace.Tracer) Option {
+	return func(o *options) {
+		o.tracer = tracer
+	}
+}
+
+// WithRetryOptions passes opts to retry.DoWithOptions. A hook set with
+// retry.WithOnRetry among them is still called, before the one Do uses to
+// record the backoff.
+func WithRetryOptions(opts ...retry.Option) Option {
+	return func(o *options) {
+		o.retryOpts = append(o.retryOpts, opts...)
+	}
+}
+
+// Do is like retry.Do, but records each attempt on the span found in ctx as an
+// event named EventName, with the attributes AttemptKey, ErrorKey if the
+// attempt failed, and BackoffKey if it was retried. If ctx has no span, the
+// events are dropped.
+func Do(ctx context.Context, b backoff.Backoff, f retry.RetryFunc, opts ...Option) error {
+	var o options
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+
+	r := &recorder{parent: trace.SpanFromContext(ctx)}
+	attempt := func(ctx context.Context) error {
+		r.finish(0, false)
+
+		a := &pendingAttempt{}
+		if o.tracer != nil {
+			ctx, a.span = o.tracer.Start(ctx, EventName)
+		}
+		err := f(ctx)
+		a.err = err
+		r.start(a)
+		return err
+	}
+
+	hook := retry.WithOnRetryChained(func(_ uint64, _ error, next time.Duration) {
+		r.finish(next, true)
+	})
+	err := retry.DoWithOptions(ctx, b, attempt, append(o.retryOpts, hook)...)
+	r.finish(0, false)
+	return err
+}
+
+// pendingAttempt is an attempt that ran, but whose backoff isn't known yet.
+type pendingAttempt struct {
+	n    uint64
+	err  error
+	span trace.Span
+}
+
+// recorder records the attempts of one loop. The hook that learns the backoff
+// may run on another goroutine, so it is guarded by mu.
+type recorder struct {
+	parent trace.Span
+
+	mu       sync.Mutex
+	attempts uint64
+	pending  *pendingAttempt
+}
+
+// start numbers a and holds it until its backoff is known.
+func (r *recorder) start(a *pendingAttempt) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.attempts++
+	a.n = r.attempts
+	r.pending = a
+}
+
+// finish records the pending attempt, if any, with the backoff that followed
+// it if it was retried.
+func (r *recorder) finish(next time.Duration, retried bool) {
+	r.mu.Lock()
+	a := r.pending
+	r.pending = nil
+	r.mu.Unlock()
+	if a == nil {
+		return
+	}
+
+	attrs := []attribute.KeyValue{AttemptKey.Int64(int64(a.n))}
+	if a.err != nil {
+		attrs = append(attrs, ErrorKey.String(a.err.Error()))
+	}
+	if retried {
+		attrs = append(attrs, BackoffKey.Int64(next.Milliseconds()))
+	}
+
+	if a.span == nil {
+		r.parent.AddEvent(EventName, trace.WithAttributes(attrs...))
+		return
+	}
+	a.span.SetAttributes(attrs...)
+	if a.err != nil {
+		a.span.RecordError(a.err)
+		a.span.SetStatus(codes.Error, a.err.Error())
+	}
+	a.span.End()
+}
@@ -0,0 +1,172 @@
+package otelretry
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/swayne275/go-retry/backoff"
+	"github.com/swayne275/go-retry/retry"
+)
+
+func TestDo(t *testing.T) {
+	t.Parallel()
+
+	newBackoff := func(t *testing.T) backoff.Backoff {
+		t.Helper()
+
+		b, err := backoff.NewConstant(1 * time.Millisecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		return backoff.WithMaxRetries(2, b)
+	}
+
+	// failing returns a function that fails n times before it succeeds.
+	failing := func(n int) retry.RetryFunc {
+		var calls int
+		return func(_ context.Context) error {
+			calls++
+			if calls <= n {
+				return retry.RetryableError(fmt.Errorf("oops %d", calls))
+			}
+			return nil
+		}
+	}
+
+	retried := func(n int64) []attribute.KeyValue {
+		return []attribute.KeyValue{
+			AttemptKey.Int64(n),
+			ErrorKey.String(fmt.Sprintf("retryable: oops %d", n)),
+			BackoffKey.Int64(1),
+		}
+	}
+
+	cases := []struct {
+		name  string
+		fails int
+		attrs [][]attribute.KeyValue
+	}{
+		{
+			name:  "first_attempt_succeeds",
+			fails: 0,
+			attrs: [][]attribute.KeyValue{{AttemptKey.Int64(1)}},
+		},
+		{
+			name:  "retried",
+			fails: 2,
+			attrs: [][]attribute.KeyValue{
+				retried(1),
+				retried(2),
+				{AttemptKey.Int64(3)},
+			},
+		},
+		{
+			name:  "exhausted",
+			fails: 3,
+			attrs: [][]attribute.KeyValue{
+				retried(1),
+				retried(2),
+				{AttemptKey.Int64(3), ErrorKey.String("retryable: oops 3")},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name+"/events", func(t *testing.T) {
+			t.Parallel()
+
+			sr := tracetest.NewSpanRecorder()
+			tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)).Tracer("test")
+
+			ctx, parent := tracer.Start(context.Background(), "parent")
+			_ = Do(ctx, newBackoff(t), failing(tc.fails))
+			parent.End()
+
+			spans := sr.Ended()
+			if len(spans) != 1 {
+				t.Fatalf("expected %d spans to be %d", len(spans), 1)
+			}
+			events := spans[0].Events()
+			if len(events) != len(tc.attrs) {
+				t.Fatalf("expected %d events to be %d", len(events), len(tc.attrs))
+			}
+			for i, e := range events {
+				if e.Name != EventName {
+					t.Errorf("event %d: expected %q to be %q", i, e.Name, EventName)
+				}
+				if !reflect.DeepEqual(e.Attributes, tc.attrs[i]) {
+					t.Errorf("event %d: expected %v to be %v", i, e.Attributes, tc.attrs[i])
+				}
+			}
+		})
+
+		t.Run(tc.name+"/child_spans", func(t *testing.T) {
+			t.Parallel()
+
+			sr := tracetest.NewSpanRecorder()
+			tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)).Tracer("test")
+
+			ctx, parent := tracer.Start(context.Background(), "parent")
+			_ = Do(ctx, newBackoff(t), failing(tc.fails), WithChildSpans(tracer))
+			parent.End()
+
+			spans := sr.Ended()
+			if len(spans) != len(tc.attrs)+1 {
+				t.Fatalf("expected %d spans to be %d", len(spans), len(tc.attrs)+1)
+			}
+			for i, s := range spans[:len(tc.attrs)] {
+				if s.Name() != EventName {
+					t.Errorf("span %d: expected %q to be %q", i, s.Name(), EventName)
+				}
+				if s.Parent().SpanID() != parent.SpanContext().SpanID() {
+					t.Errorf("span %d: expected to be a child of the parent span", i)
+				}
+				if !reflect.DeepEqual(s.Attributes(), tc.attrs[i]) {
+					t.Errorf("span %d: expected %v to be %v", i, s.Attributes(), tc.attrs[i])
+				}
+				failed := len(tc.attrs[i]) > 1
+				if got := s.Status().Code == codes.Error; got != failed {
+					t.Errorf("span %d: expected failed %t to be %t", i, got, failed)
+				}
+			}
+		})
+	}
+
+	t.Run("no_span", func(t *testing.T) {
+		t.Parallel()
+
+		if err := Do(context.Background(), newBackoff(t), failing(1)); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+	t.Run("caller_hook", func(t *testing.T) {
+		t.Parallel()
+
+		sr := tracetest.NewSpanRecorder()
+		tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)).Tracer("test")
+
+		var calls []uint64
+		ctx, parent := tracer.Start(context.Background(), "parent")
+		_ = Do(ctx, newBackoff(t), failing(2), WithRetryOptions(retry.WithOnRetry(func(attempt uint64, _ error, _ time.Duration) {
+			calls = append(calls, attempt)
+		})))
+		parent.End()
+
+		if want := []uint64{1, 2}; !reflect.DeepEqual(calls, want) {
+			t.Errorf("expected %v to be %v", calls, want)
+		}
+		if got := len(sr.Ended()[0].Events()); got != 3 {
+			t.Errorf("expected %d to be %d", got, 3)
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/swayne275/go-retry/backoff"
+	"github.com/swayne275/go-retry/internal/clock"
+)
+
+// DoPaginated fetches every page of a paginated source, starting at start,
+// and passes the items of each page to sink in order.
+//
+// Each page is fetched as Do would, with a fresh backoff from perPage, so fetch
+// must mark errors as retryable. When a page's backoff signals to stop, the
+// page is not given up on: one step of the global backoff is taken, and after
+// its delay the page is tried again with a fresh perPage backoff. This bounds
+// the whole pagination however badly individual pages behave.
+//
+// Pagination ends successfully when fetch reports done, after sink has
+// processed the last page. It fails when the global backoff signals to stop,
+// fetch returns a non-retryable error, sink returns an error, or ctx is done.
+//
+// Either way, lastCursor is the cursor of the first page that was not
+// processed, so a failed pagination can be resumed by calling DoPaginated with
+// it as start. After success it is the next cursor fetch returned for the last
+// page.
+func DoPaginated[C, T any](ctx context.Context, perPage func() backoff.Backoff, global backoff.Backoff, start C, fetch func(ctx context.Context, cursor C) (items []T, next C, done bool, err error), sink func(items []T) error) (lastCursor C, err error) {
+	return doPaginated(ctx, clock.Real, perPage, global, start, fetch, sink)
+}
+
+func doPaginated[C, T any](ctx context.Context, c Clock, perPage func() backoff.Backoff, global backoff.Backoff, start C, fetch func(ctx context.Context, cursor C) ([]T, C, bool, error), sink func([]T) error) (C, error) {
+	cursor := start
+	if err := backoff.Validate(global); err != nil {
+		return cursor, err
+	}
+
+	sleeper := clock.NewSleeper(c)
+	for {
+		b, err := build(perPage)
+		if err != nil {
+			return cursor, err
+		}
+
+		var items []T
+		var next C
+		var done bool
+		err = DoWithOptions(ctx, b, func(ctx context.Context) error {
+			var err error
+			items, next, done, err = fetch(ctx, cursor)
+			return err
+		}, WithClock(c))
+
+		switch {
+		case err == nil:
+			if err := sink(items); err != nil {
+				return cursor, fmt.Errorf("failed to process page: %w", err)
+			}
+			cursor = next
+			if done {
+				return cursor, nil
+			}
+			continue
+		case Classify(err) != ErrBackoffSignaledToStop:
+			return cursor, err
+		}
+
+		// The page's own backoff gave up, so escalate to the global one.
+		delay, stop := global.Next()
+		if stop {
+			return cursor, err
+		}
+
+		// ctx.Done() has priority, so we test it alone first
+		select {
+		case <-ctx.Done():
+			return cursor, ctx.Err()
+		default:
+		}
+
+		if err := sleeper.Sleep(ctx, delay, nil); err != nil {
+			return cursor, err
+		}
+	}
+}
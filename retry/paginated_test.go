@@ -0,0 +1,220 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+// pagedSource serves pages 0 to pages-1, each holding its own number, and
+// fails the fetch of a page with the errors scripted for it, in order, before
+// serving it.
+type pagedSource struct {
+	pages    int
+	failures map[int][]error
+	fetches  map[int]int
+}
+
+func (s *pagedSource) fetch(_ context.Context, cursor int) ([]int, int, bool, error) {
+	if s.fetches == nil {
+		s.fetches = make(map[int]int)
+	}
+	s.fetches[cursor]++
+	if errs := s.failures[cursor]; len(errs) > 0 {
+		s.failures[cursor] = errs[1:]
+		return nil, 0, false, errs[0]
+	}
+	return []int{cursor}, cursor + 1, cursor+1 == s.pages, nil
+}
+
+func TestDoPaginated(t *testing.T) {
+	t.Parallel()
+
+	errFlaky := RetryableError(fmt.Errorf("flaky"))
+
+	perPage := func(t *testing.T, maxRetries uint64) func() backoff.Backoff {
+		t.Helper()
+
+		return func() backoff.Backoff {
+			b, err := backoff.NewConstant(1 * time.Second)
+			if err != nil {
+				t.Fatalf("failed to create constant backoff: %v", err)
+			}
+			return backoff.WithMaxRetries(maxRetries, b)
+		}
+	}
+	global := func(t *testing.T, maxRetries uint64) backoff.Backoff {
+		t.Helper()
+
+		b, err := backoff.NewConstant(1 * time.Hour)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		return backoff.WithMaxRetries(maxRetries, b)
+	}
+	collect := func(got *[]int) func([]int) error {
+		return func(items []int) error {
+			*got = append(*got, items...)
+			return nil
+		}
+	}
+
+	t.Run("flaky_page_recovered", func(t *testing.T) {
+		t.Parallel()
+
+		src := &pagedSource{pages: 5, failures: map[int][]error{2: {errFlaky, errFlaky}}}
+		c := newFakeClock()
+		var got []int
+		cursor, err := doPaginated(context.Background(), c, perPage(t, 3), global(t, 3), 0, src.fetch, collect(&got))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cursor != 5 {
+			t.Errorf("expected %v to be %v", cursor, 5)
+		}
+		if want := []int{0, 1, 2, 3, 4}; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+		if want := []time.Duration{1 * time.Second, 1 * time.Second}; !reflect.DeepEqual(c.Sleeps(), want) {
+			t.Errorf("expected %v to be %v", c.Sleeps(), want)
+		}
+	})
+
+	t.Run("escalates_to_global", func(t *testing.T) {
+		t.Parallel()
+
+		src := &pagedSource{pages: 4, failures: map[int][]error{
+			2: {errFlaky, errFlaky, errFlaky, errFlaky, errFlaky},
+		}}
+		c := newFakeClock()
+		var got []int
+		cursor, err := doPaginated(context.Background(), c, perPage(t, 1), global(t, 5), 0, src.fetch, collect(&got))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cursor != 4 {
+			t.Errorf("expected %v to be %v", cursor, 4)
+		}
+		if want := []int{0, 1, 2, 3}; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+		// Each round of the page's budget makes two attempts, with one page
+		// delay between them, and every exhausted round takes a global delay.
+		want := []time.Duration{
+			1 * time.Second, 1 * time.Hour,
+			1 * time.Second, 1 * time.Hour,
+			1 * time.Second,
+		}
+		if !reflect.DeepEqual(c.Sleeps(), want) {
+			t.Errorf("expected %v to be %v", c.Sleeps(), want)
+		}
+		if got := src.fetches[2]; got != 6 {
+			t.Errorf("expected %v to be %v", got, 6)
+		}
+	})
+
+	t.Run("global_exhausted_returns_resume_cursor", func(t *testing.T) {
+		t.Parallel()
+
+		errDown := fmt.Errorf("down")
+		src := &pagedSource{pages: 5, failures: map[int][]error{
+			3: {RetryableError(errDown), RetryableError(errDown), RetryableError(errDown), RetryableError(errDown)},
+		}}
+		var got []int
+		cursor, err := doPaginated(context.Background(), newFakeClock(), perPage(t, 1), global(t, 1), 0, src.fetch, collect(&got))
+		if !errors.Is(err, ErrBackoffSignaledToStop) || !errors.Is(err, errDown) {
+			t.Errorf("expected %q to wrap %q and %q", err, ErrBackoffSignaledToStop, errDown)
+		}
+		if cursor != 3 {
+			t.Errorf("expected %v to be %v", cursor, 3)
+		}
+		if want := []int{0, 1, 2}; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+
+		// Resuming from the cursor finishes the pagination.
+		cursor, err = doPaginated(context.Background(), newFakeClock(), perPage(t, 1), global(t, 1), cursor, src.fetch, collect(&got))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cursor != 5 {
+			t.Errorf("expected %v to be %v", cursor, 5)
+		}
+		if want := []int{0, 1, 2, 3, 4}; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	})
+
+	t.Run("non_retryable", func(t *testing.T) {
+		t.Parallel()
+
+		src := &pagedSource{pages: 5, failures: map[int][]error{2: {fmt.Errorf("forbidden")}}}
+		var got []int
+		cursor, err := doPaginated(context.Background(), newFakeClock(), perPage(t, 3), global(t, 3), 0, src.fetch, collect(&got))
+		if !errors.Is(err, ErrNonRetryable) {
+			t.Errorf("expected %q to be %q", err, ErrNonRetryable)
+		}
+		if cursor != 2 {
+			t.Errorf("expected %v to be %v", cursor, 2)
+		}
+	})
+
+	t.Run("sink_error", func(t *testing.T) {
+		t.Parallel()
+
+		errFull := fmt.Errorf("disk full")
+		src := &pagedSource{pages: 5}
+		cursor, err := doPaginated(context.Background(), newFakeClock(), perPage(t, 3), global(t, 3), 0, src.fetch, func(items []int) error {
+			if items[0] == 1 {
+				return errFull
+			}
+			return nil
+		})
+		if !errors.Is(err, errFull) {
+			t.Errorf("expected %q to be %q", err, errFull)
+		}
+		if cursor != 1 {
+			t.Errorf("expected %v to be %v", cursor, 1)
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		src := &pagedSource{pages: 5}
+		cursor, err := doPaginated(ctx, newFakeClock(), perPage(t, 3), global(t, 3), 0, src.fetch, func(items []int) error {
+			if items[0] == 2 {
+				cancel()
+			}
+			return nil
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected %q to be %q", err, context.Canceled)
+		}
+		if cursor != 3 {
+			t.Errorf("expected %v to be %v", cursor, 3)
+		}
+	})
+
+	t.Run("done_on_first_page", func(t *testing.T) {
+		t.Parallel()
+
+		src := &pagedSource{pages: 1}
+		var got []int
+		cursor, err := DoPaginated(context.Background(), perPage(t, 3), global(t, 3), 0, src.fetch, collect(&got))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cursor != 1 || !reflect.DeepEqual(got, []int{0}) {
+			t.Errorf("expected cursor 1 and items [0], got %v and %v", cursor, got)
+		}
+	})
+}
@@ -0,0 +1,157 @@
+package retry
+
+import (
+	"context"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+// DoPipelined is like Do, but does not wait for an attempt to finish before
+// starting the next one. Each delay from b paces the launch of the next
+// attempt from the launch of the previous one, so a hung attempt doesn't delay
+// discovering that a fresh one would succeed. At most maxOutstanding attempts
+// run at once; when that many are in flight, the next launch waits for one of
+// them to finish. Values of maxOutstanding below 1 are treated as 1.
+//
+// The loop settles on the first success or non-retryable error. The attempts
+// still running are then canceled through their context and their results are
+// discarded; DoPipelined does not wait for them to return. f must therefore be
+// idempotent and safe to call concurrently.
+//
+// b is consulted once per launch, so its budget counts attempts started. When
+// it signals to stop and every launched attempt has failed with a retryable
+// error, the error wraps ErrBackoffSignaledToStop and the most recent failure.
+// A failure marked with RetryableAfterError holds off the next launch for the
+// delay it asks for, in place of the delay from b.
+//
+// Of opts, the loop honors WithClock, WithControl and WithShouldRetry; the
+// others only apply to DoWithOptions.
+func DoPipelined(ctx context.Context, b backoff.Backoff, maxOutstanding int, f RetryFunc, opts ...Option) error {
+	o := newOptions(opts)
+	return doConcurrent(ctx, b, maxOutstanding, f, false, &o)
+}
+
+// doConcurrent is the loop of DoPipelined and DoHedged. When hedged, a
+// retryable failure makes the next launch due at once, and a non-retryable
+// error stops further launches but lets the attempts in flight finish, so one
+// of them can still succeed.
+func doConcurrent(ctx context.Context, b backoff.Backoff, maxOutstanding int, f RetryFunc, hedged bool, o *options) error {
+	if err := backoff.Validate(b); err != nil {
+		return err
+	}
+	if maxOutstanding < 1 {
+		maxOutstanding = 1
+	}
+
+	c := o.clock
+	attemptCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// results is buffered for every attempt that can be in flight, so
+	// attempts finishing after the loop returned never block.
+	results := make(chan error, maxOutstanding)
+
+	begun := c.Now()
+	var launched uint64
+	var outstanding int
+	var lastErr error
+	// fatal is the first non-retryable error of a hedged loop.
+	var fatal error
+	// due is set when the next launch's delay has elapsed.
+	due := true
+	// paced is the timer pacing the next launch, if any.
+	var paced Timer
+	exhausted := false
+	defer func() {
+		if paced != nil {
+			paced.Stop()
+		}
+	}()
+
+	for {
+		// ctx.Done() has priority, so we test it alone first
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		// While retries are disabled, only the first attempt is launched, and
+		// the loop stops at the first failure.
+		st := o.control.ctl().Retries.Load()
+		if st.Disabled && lastErr != nil {
+			return retriesDisabled(st, lastErr)
+		}
+
+		if due && !exhausted && fatal == nil && outstanding < maxOutstanding && (launched == 0 || !st.Disabled) {
+			due = false
+			if paced != nil {
+				paced.Stop()
+				paced = nil
+			}
+			launched++
+			outstanding++
+			go func(ctx context.Context) {
+				results <- f(ctx)
+			}(withAttempt(attemptCtx, attemptInfo{n: launched, start: begun, clock: c, lastErr: lastErr}))
+
+			next, stop := b.Next()
+			if stop {
+				exhausted = true
+			} else {
+				paced = c.NewTimer(next)
+			}
+		}
+
+		if fatal != nil && outstanding == 0 {
+			return nonRetryable(ctx, fatal)
+		}
+		if exhausted && outstanding == 0 {
+			return stopped(b, lastErr)
+		}
+
+		// tick is nil, and so never ready, unless a launch is being paced.
+		var tick <-chan time.Time
+		if paced != nil {
+			tick = paced.C()
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-st.Changed:
+		case <-tick:
+			paced = nil
+			due = true
+		case err := <-results:
+			outstanding--
+			if err == nil {
+				return nil
+			}
+
+			// Not retryable
+			rerr, ok := asRetryable(err, o.shouldRetry)
+			if !ok {
+				if !hedged {
+					return nonRetryable(ctx, err)
+				}
+				if fatal == nil {
+					fatal = err
+				}
+				continue
+			}
+			lastErr = rerr.Unwrap()
+			switch {
+			case rerr.hinted:
+				// The server's hint replaces the pacing of the next launch.
+				if paced != nil {
+					paced.Stop()
+				}
+				due, paced = false, c.NewTimer(rerr.after)
+			case hedged:
+				due = true
+			}
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+// waitFor spins until cond holds, failing the test if it doesn't within a few
+// seconds.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestDoPipelined(t *testing.T) {
+	t.Parallel()
+
+	newBackoff := func(t *testing.T, retries uint64) backoff.Backoff {
+		t.Helper()
+
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		return backoff.WithMaxRetries(retries, b)
+	}
+
+	t.Run("recovery_while_hung", func(t *testing.T) {
+		t.Parallel()
+
+		var attempts int64
+		canceled := make(chan struct{})
+		err := DoPipelined(context.Background(), newBackoff(t, 10), 2, func(ctx context.Context) error {
+			if atomic.AddInt64(&attempts, 1) == 1 {
+				// The first probe hangs until it is canceled.
+				<-ctx.Done()
+				close(canceled)
+				return RetryableError(ctx.Err())
+			}
+			return nil
+		}, WithClock(newFakeClock()))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got, want := atomic.LoadInt64(&attempts), int64(2); got != want {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+
+		select {
+		case <-canceled:
+		case <-time.After(5 * time.Second):
+			t.Fatal("hung attempt was never canceled")
+		}
+	})
+
+	t.Run("outstanding_bound", func(t *testing.T) {
+		t.Parallel()
+
+		var started, running, peak int64
+		release := make(chan struct{})
+		errc := make(chan error, 1)
+		go func() {
+			errc <- DoPipelined(context.Background(), newBackoff(t, 5), 3, func(_ context.Context) error {
+				atomic.AddInt64(&started, 1)
+				n := atomic.AddInt64(&running, 1)
+				for {
+					p := atomic.LoadInt64(&peak)
+					if n <= p || atomic.CompareAndSwapInt64(&peak, p, n) {
+						break
+					}
+				}
+				<-release
+				atomic.AddInt64(&running, -1)
+				return RetryableError(fmt.Errorf("oops"))
+			}, WithClock(newFakeClock()))
+		}()
+
+		waitFor(t, func() bool { return atomic.LoadInt64(&started) == 3 })
+		time.Sleep(20 * time.Millisecond)
+		if got, want := atomic.LoadInt64(&started), int64(3); got != want {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+
+		close(release)
+		if err := <-errc; !errors.Is(err, ErrBackoffSignaledToStop) {
+			t.Errorf("expected %q to be %q", err, ErrBackoffSignaledToStop)
+		}
+		if got, want := atomic.LoadInt64(&started), int64(6); got != want {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+		if got, want := atomic.LoadInt64(&peak), int64(3); got != want {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	})
+
+	t.Run("straggler_cancellation", func(t *testing.T) {
+		t.Parallel()
+
+		var attempts int64
+		canceled := make(chan struct{}, 2)
+		err := DoPipelined(context.Background(), newBackoff(t, 10), 3, func(ctx context.Context) error {
+			if atomic.AddInt64(&attempts, 1) < 3 {
+				<-ctx.Done()
+				canceled <- struct{}{}
+				return RetryableError(ctx.Err())
+			}
+			return io.EOF // not retryable
+		}, WithClock(newFakeClock()))
+		if !errors.Is(err, ErrNonRetryable) || !errors.Is(err, io.EOF) {
+			t.Errorf("expected %q to wrap %q and %q", err, ErrNonRetryable, io.EOF)
+		}
+
+		for i := 0; i < 2; i++ {
+			select {
+			case <-canceled:
+			case <-time.After(5 * time.Second):
+				t.Fatal("straggler was never canceled")
+			}
+		}
+	})
+
+	t.Run("exhausted_wraps_most_recent", func(t *testing.T) {
+		t.Parallel()
+
+		errs := []error{fmt.Errorf("first"), fmt.Errorf("second"), fmt.Errorf("third")}
+		var attempts int64
+		err := DoPipelined(context.Background(), newBackoff(t, 2), 1, func(_ context.Context) error {
+			return RetryableError(errs[atomic.AddInt64(&attempts, 1)-1])
+		}, WithClock(newFakeClock()))
+		if !errors.Is(err, ErrBackoffSignaledToStop) {
+			t.Errorf("expected %q to be %q", err, ErrBackoffSignaledToStop)
+		}
+		if !errors.Is(err, errs[2]) {
+			t.Errorf("expected %q to be %q", err, errs[2])
+		}
+	})
+
+	t.Run("paces_launches", func(t *testing.T) {
+		t.Parallel()
+
+		c := newFakeClock()
+		b, err := backoff.NewExponential(1 * time.Second)
+		if err != nil {
+			t.Fatalf("failed to create exponential backoff: %v", err)
+		}
+
+		var attempts int64
+		if err := DoPipelined(context.Background(), b, 1, func(_ context.Context) error {
+			if atomic.AddInt64(&attempts, 1) < 4 {
+				return RetryableError(fmt.Errorf("oops"))
+			}
+			return nil
+		}, WithClock(c)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// One delay is drawn per launch, including the last.
+		want := []time.Duration{1 * time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second}
+		if got := c.Sleeps(); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		var started int64
+		errc := make(chan error, 1)
+		go func() {
+			errc <- DoPipelined(ctx, newBackoff(t, 10), 2, func(ctx context.Context) error {
+				atomic.AddInt64(&started, 1)
+				<-ctx.Done()
+				return RetryableError(ctx.Err())
+			}, WithClock(newFakeClock()))
+		}()
+
+		waitFor(t, func() bool { return atomic.LoadInt64(&started) == 2 })
+		cancel()
+		if err := <-errc; !errors.Is(err, context.Canceled) {
+			t.Errorf("expected %q to be %q", err, context.Canceled)
+		}
+	})
+
+	t.Run("honors_retry_after", func(t *testing.T) {
+		t.Parallel()
+
+		c := newFakeClock()
+		b, err := backoff.NewExponential(1 * time.Second)
+		if err != nil {
+			t.Fatalf("failed to create exponential backoff: %v", err)
+		}
+
+		var attempts int64
+		if err := DoPipelined(context.Background(), b, 1, func(_ context.Context) error {
+			if atomic.AddInt64(&attempts, 1) == 1 {
+				return RetryableAfterError(fmt.Errorf("throttled"), 30*time.Second)
+			}
+			return nil
+		}, WithClock(c)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// The hint replaces the pacing of the second launch.
+		want := []time.Duration{1 * time.Second, 30 * time.Second, 2 * time.Second}
+		if got := c.Sleeps(); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	})
+
+	t.Run("control", func(t *testing.T) {
+		t.Parallel()
+
+		var ctl Control
+		ctl.DisableRetries("maintenance")
+		var attempts int64
+		err := DoPipelined(context.Background(), newBackoff(t, 10), 2, func(_ context.Context) error {
+			atomic.AddInt64(&attempts, 1)
+			return RetryableError(fmt.Errorf("oops"))
+		}, WithClock(newFakeClock()), WithControl(&ctl))
+		if !errors.Is(err, ErrRetriesDisabled) {
+			t.Errorf("expected %q to be %q", err, ErrRetriesDisabled)
+		}
+		if got := atomic.LoadInt64(&attempts); got != 1 {
+			t.Errorf("expected %d to be %d", got, 1)
+		}
+	})
+
+	t.Run("canceled_during_attempt", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		err := DoPipelined(ctx, newBackoff(t, 10), 1, func(ctx context.Context) error {
+			cancel()
+			return ctx.Err()
+		}, WithClock(newFakeClock()))
+		if err != context.Canceled {
+			t.Errorf("expected %v to be %v", err, context.Canceled)
+		}
+	})
+}
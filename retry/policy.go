@@ -0,0 +1,177 @@
+package retry
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+	"github.com/swayne275/go-retry/internal/label"
+)
+
+// ErrUnknownPolicy is returned when a PolicySet has no policy with the
+// requested name. Its label is "unknown_policy".
+var ErrUnknownPolicy = label.New("unknown policy", "unknown_policy")
+
+// PolicySet holds named backoff policies that can be reloaded at runtime.
+//
+// Updates replace the whole set at once: readers always see either the set
+// before an Update or the set after it, never a mix. Factories returned by
+// Policy read the current set each time they are called, so loops that build a
+// fresh backoff per operation pick up new configuration automatically.
+//
+// A PolicySet is safe for concurrent use. The zero PolicySet is empty and
+// ready to use.
+type PolicySet struct {
+	current atomic.Pointer[map[string]backoff.Config]
+
+	mu     sync.Mutex
+	nextID uint64
+	subs   map[uint64]func(changed []string)
+}
+
+// NewPolicySet creates an empty PolicySet.
+func NewPolicySet() *PolicySet {
+	s := &PolicySet{
+		subs: make(map[uint64]func(changed []string)),
+	}
+	empty := make(map[string]backoff.Config)
+	s.current.Store(&empty)
+	return s
+}
+
+// policies returns the current set, which is empty until the first Update of
+// the zero PolicySet.
+func (s *PolicySet) policies() map[string]backoff.Config {
+	if current := s.current.Load(); current != nil {
+		return *current
+	}
+	return nil
+}
+
+// Update validates every config in policies and, only if all of them are
+// valid, atomically replaces the current set with them. On a validation
+// failure the current set is left untouched.
+//
+// After a successful swap, subscribers are called synchronously with the sorted
+// names of the policies that were added, removed, or changed. They are not
+// called if nothing changed.
+func (s *PolicySet) Update(policies map[string]backoff.Config) error {
+	next := make(map[string]backoff.Config, len(policies))
+	for name, cfg := range policies {
+		if _, err := backoff.FromConfig(cfg); err != nil {
+			return fmt.Errorf("invalid policy %q: %w", name, err)
+		}
+		next[name] = cfg
+	}
+
+	s.mu.Lock()
+	prev := s.policies()
+	s.current.Store(&next)
+
+	var changed []string
+	for name, cfg := range next {
+		if old, ok := prev[name]; !ok || old != cfg {
+			changed = append(changed, name)
+		}
+	}
+	for name := range prev {
+		if _, ok := next[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+
+	subs := make([]func(changed []string), 0, len(s.subs))
+	for _, sub := range s.subs {
+		subs = append(subs, sub)
+	}
+	s.mu.Unlock()
+
+	if len(changed) == 0 {
+		return nil
+	}
+	for _, sub := range subs {
+		sub(changed)
+	}
+	return nil
+}
+
+// Snapshot returns a copy of the current set of policies.
+func (s *PolicySet) Snapshot() map[string]backoff.Config {
+	current := s.policies()
+	snapshot := make(map[string]backoff.Config, len(current))
+	for name, cfg := range current {
+		snapshot[name] = cfg
+	}
+	return snapshot
+}
+
+// NewPolicy builds a backoff from the current config of the named policy. It
+// returns an error wrapping ErrUnknownPolicy if there is no such policy.
+func (s *PolicySet) NewPolicy(name string) (backoff.Backoff, error) {
+	cfg, ok := s.policies()[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownPolicy, name)
+	}
+	return backoff.FromConfig(cfg)
+}
+
+// Policy returns a factory that builds a backoff from the named policy's
+// config as of the time the factory is called.
+//
+// If the policy does not exist when the factory is called, the factory returns
+// a backoff that always signals to stop and that backoff.Validate rejects with
+// an error wrapping ErrUnknownPolicy, so Do and the other loops return that
+// error without making an attempt rather than retrying on an unintended
+// schedule. Use NewPolicy to get the error directly.
+func (s *PolicySet) Policy(name string) func() backoff.Backoff {
+	return func() backoff.Backoff {
+		b, err := s.NewPolicy(name)
+		if err != nil {
+			return unknownPolicy{err: err}
+		}
+		return b
+	}
+}
+
+// unknownPolicy is the backoff Policy builds for a policy that doesn't exist.
+type unknownPolicy struct {
+	err error
+}
+
+// Next implements backoff.Backoff. It always signals to stop.
+func (p unknownPolicy) Next() (time.Duration, bool) {
+	return 0, true
+}
+
+// Reset implements backoff.Backoff.
+func (p unknownPolicy) Reset() {}
+
+// Validate implements backoff.Validator, returning the error wrapping
+// ErrUnknownPolicy.
+func (p unknownPolicy) Validate() error {
+	return p.err
+}
+
+// Subscribe registers fn to be called with the names of changed policies
+// after each Update. It returns a function that removes the subscription.
+func (s *PolicySet) Subscribe(fn func(changed []string)) (unsubscribe func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := s.nextID
+	s.nextID++
+	if s.subs == nil {
+		s.subs = make(map[uint64]func(changed []string))
+	}
+	s.subs[id] = fn
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.subs, id)
+	}
+}
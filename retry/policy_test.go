@@ -0,0 +1,190 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+func TestPolicySet(t *testing.T) {
+	t.Parallel()
+
+	t.Run("factory_uses_current_snapshot", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewPolicySet()
+		if err := s.Update(map[string]backoff.Config{
+			"db": {Strategy: backoff.StrategyConstant, Base: 1 * time.Second},
+		}); err != nil {
+			t.Fatalf("failed to update: %v", err)
+		}
+
+		factory := s.Policy("db")
+		if val, _ := factory().Next(); val != 1*time.Second {
+			t.Errorf("expected %v to be %v", val, 1*time.Second)
+		}
+
+		if err := s.Update(map[string]backoff.Config{
+			"db": {Strategy: backoff.StrategyConstant, Base: 2 * time.Second},
+		}); err != nil {
+			t.Fatalf("failed to update: %v", err)
+		}
+		if val, _ := factory().Next(); val != 2*time.Second {
+			t.Errorf("expected %v to be %v", val, 2*time.Second)
+		}
+	})
+
+	t.Run("missing_policy", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewPolicySet()
+		if _, err := s.NewPolicy("nope"); !errors.Is(err, ErrUnknownPolicy) {
+			t.Errorf("expected %q to be %q", err, ErrUnknownPolicy)
+		}
+		if _, stop := s.Policy("nope")().Next(); !stop {
+			t.Error("expected missing policy factory to stop")
+		}
+		if err := backoff.Validate(s.Policy("nope")()); !errors.Is(err, ErrUnknownPolicy) {
+			t.Errorf("expected %q to be %q", err, ErrUnknownPolicy)
+		}
+
+		var calls int
+		err := Do(context.Background(), s.Policy("nope")(), func(_ context.Context) error {
+			calls++
+			return nil
+		})
+		if !errors.Is(err, ErrUnknownPolicy) {
+			t.Errorf("expected %q to be %q", err, ErrUnknownPolicy)
+		}
+		if calls != 0 {
+			t.Errorf("expected %d to be %d", calls, 0)
+		}
+	})
+
+	t.Run("validation_failure_keeps_old_snapshot", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewPolicySet()
+		good := map[string]backoff.Config{
+			"db":    {Strategy: backoff.StrategyConstant, Base: 1 * time.Second},
+			"cache": {Strategy: backoff.StrategyFibonacci, Base: 1 * time.Second},
+		}
+		if err := s.Update(good); err != nil {
+			t.Fatalf("failed to update: %v", err)
+		}
+
+		var notified bool
+		s.Subscribe(func(_ []string) { notified = true })
+
+		if err := s.Update(map[string]backoff.Config{
+			"db":    {Strategy: backoff.StrategyConstant, Base: 5 * time.Second},
+			"cache": {Strategy: "bogus", Base: 1 * time.Second},
+		}); err == nil {
+			t.Fatal("expected an error")
+		}
+
+		if got := s.Snapshot(); !reflect.DeepEqual(got, good) {
+			t.Errorf("expected %v to be %v", got, good)
+		}
+		if notified {
+			t.Error("expected no notification for a failed update")
+		}
+	})
+
+	t.Run("subscription_reports_changed_names", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewPolicySet()
+		if err := s.Update(map[string]backoff.Config{
+			"db":      {Strategy: backoff.StrategyConstant, Base: 1 * time.Second},
+			"cache":   {Strategy: backoff.StrategyConstant, Base: 1 * time.Second},
+			"partner": {Strategy: backoff.StrategyConstant, Base: 1 * time.Second},
+		}); err != nil {
+			t.Fatalf("failed to update: %v", err)
+		}
+
+		var got [][]string
+		unsubscribe := s.Subscribe(func(changed []string) {
+			got = append(got, changed)
+		})
+
+		if err := s.Update(map[string]backoff.Config{
+			"db":      {Strategy: backoff.StrategyConstant, Base: 1 * time.Second},
+			"cache":   {Strategy: backoff.StrategyConstant, Base: 2 * time.Second},
+			"billing": {Strategy: backoff.StrategyConstant, Base: 1 * time.Second},
+		}); err != nil {
+			t.Fatalf("failed to update: %v", err)
+		}
+
+		// No changes, no notification.
+		if err := s.Update(s.Snapshot()); err != nil {
+			t.Fatalf("failed to update: %v", err)
+		}
+
+		unsubscribe()
+		if err := s.Update(nil); err != nil {
+			t.Fatalf("failed to update: %v", err)
+		}
+
+		want := [][]string{{"billing", "cache", "partner"}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	})
+
+	t.Run("concurrent_reads_never_see_partial_update", func(t *testing.T) {
+		t.Parallel()
+
+		gen := func(d time.Duration) map[string]backoff.Config {
+			return map[string]backoff.Config{
+				"db":    {Strategy: backoff.StrategyConstant, Base: d, Cap: d},
+				"cache": {Strategy: backoff.StrategyConstant, Base: d, Cap: d},
+			}
+		}
+
+		s := NewPolicySet()
+		if err := s.Update(gen(1)); err != nil {
+			t.Fatalf("failed to update: %v", err)
+		}
+
+		stop := make(chan struct{})
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				factory := s.Policy("db")
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+					}
+
+					snap := s.Snapshot()
+					if snap["db"] != snap["cache"] {
+						t.Errorf("observed partial update: %v", snap)
+						return
+					}
+					if _, halt := factory().Next(); halt {
+						t.Error("expected policy to exist throughout")
+						return
+					}
+				}
+			}()
+		}
+
+		for d := time.Duration(2); d < 500; d++ {
+			if err := s.Update(gen(d)); err != nil {
+				t.Fatalf("failed to update: %v", err)
+			}
+		}
+		close(stop)
+		wg.Wait()
+	})
+}
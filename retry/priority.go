@@ -0,0 +1,168 @@
+package retry
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/swayne275/go-retry/internal/clock"
+)
+
+// defaultMaxRetryWait is how long a retry waits on a PriorityGate created by
+// NewPriorityGate before it is admitted ahead of fresh requests.
+const defaultMaxRetryWait = 1 * time.Second
+
+// PriorityGate limits how many attempts may run at once, admitting waiting
+// first attempts ("fresh" requests) before waiting retries, so a burst of
+// retries can't crowd out new work. Within a class, waiters are admitted in
+// the order they arrived.
+//
+// To keep retries from starving under sustained load, a retry that has waited
+// at least the gate's maximum retry wait is admitted ahead of every fresh
+// request, taking the next slot to free up. The gate never admits more than
+// its capacity.
+//
+// A PriorityGate is safe for concurrent use, so one gate can be shared by many
+// loops. The zero PriorityGate behaves like one from NewPriorityGate(1).
+type PriorityGate struct {
+	capacity     int
+	maxRetryWait time.Duration
+	clock        Clock
+
+	mu      sync.Mutex
+	inUse   int
+	fresh   *list.List
+	retries *list.List
+}
+
+// priorityWaiter is an attempt waiting on a PriorityGate.
+type priorityWaiter struct {
+	since time.Time
+	// ready is closed once the waiter holds a slot.
+	ready   chan struct{}
+	granted bool
+}
+
+// NewPriorityGate creates a gate that lets capacity attempts run at once. A
+// capacity smaller than 1 is treated as 1. Retries that have waited one second
+// are admitted ahead of fresh requests.
+func NewPriorityGate(capacity int) *PriorityGate {
+	return NewPriorityGateWithClock(capacity, defaultMaxRetryWait, nil)
+}
+
+// NewPriorityGateWithClock is like NewPriorityGate, but admits retries ahead
+// of fresh requests once they have waited maxRetryWait, as measured by c. A
+// maxRetryWait of zero or less lets every waiting retry go first, and a nil c
+// selects the real clock.
+func NewPriorityGateWithClock(capacity int, maxRetryWait time.Duration, c Clock) *PriorityGate {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &PriorityGate{
+		capacity:     capacity,
+		maxRetryWait: maxRetryWait,
+		clock:        clock.Or(c),
+		fresh:        list.New(),
+		retries:      list.New(),
+	}
+}
+
+// acquire blocks until the caller holds a slot or ctx is done. retry selects
+// the class the caller waits in. Every successful acquire must be paired with
+// a release.
+func (g *PriorityGate) acquire(ctx context.Context, retry bool) error {
+	g.mu.Lock()
+	g.init()
+	// Waiters only queue while the gate is full, so a free slot is never
+	// taken ahead of them.
+	if g.inUse < g.capacity {
+		g.inUse++
+		g.mu.Unlock()
+		return nil
+	}
+
+	queue := g.fresh
+	if retry {
+		queue = g.retries
+	}
+	w := &priorityWaiter{since: g.clock.Now(), ready: make(chan struct{})}
+	e := queue.PushBack(w)
+	g.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+	}
+
+	g.mu.Lock()
+	if w.granted {
+		// The slot was handed over as ctx finished; pass it on.
+		g.releaseLocked()
+	} else {
+		queue.Remove(e)
+	}
+	g.mu.Unlock()
+	return ctx.Err()
+}
+
+// release frees a slot taken by acquire, handing it to the next waiter.
+func (g *PriorityGate) release() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.releaseLocked()
+}
+
+// init sets up the zero PriorityGate. The caller must hold g.mu.
+func (g *PriorityGate) init() {
+	if g.fresh != nil {
+		return
+	}
+	g.capacity = 1
+	g.maxRetryWait = defaultMaxRetryWait
+	g.clock = clock.Real
+	g.fresh = list.New()
+	g.retries = list.New()
+}
+
+// releaseLocked frees a slot. The caller must hold g.mu.
+func (g *PriorityGate) releaseLocked() {
+	g.init()
+	next := g.next()
+	if next == nil {
+		g.inUse--
+		return
+	}
+	w := next.Value.(*priorityWaiter)
+	w.granted = true
+	close(w.ready)
+}
+
+// next removes and returns the waiter to admit next, or nil if none is
+// waiting. The caller must hold g.mu.
+func (g *PriorityGate) next() *list.Element {
+	if e := g.retries.Front(); e != nil && g.clock.Now().Sub(e.Value.(*priorityWaiter).since) >= g.maxRetryWait {
+		g.retries.Remove(e)
+		return e
+	}
+	for _, queue := range []*list.List{g.fresh, g.retries} {
+		if e := queue.Front(); e != nil {
+			queue.Remove(e)
+			return e
+		}
+	}
+	return nil
+}
+
+// WithPriorityGate makes DoWithOptions hold a slot of g while each attempt
+// runs, waiting as a fresh request before the first attempt and as a retry
+// before the rest. The slot is released when the attempt returns, even if it
+// panics. If ctx is done while waiting, the loop returns ctx.Err() without
+// making the attempt.
+func WithPriorityGate(g *PriorityGate) Option {
+	return func(o *options) {
+		o.priorityGate = g
+	}
+}
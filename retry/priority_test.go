@@ -0,0 +1,381 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+// queued returns how many fresh requests and retries are waiting on g.
+func (g *PriorityGate) queued() (fresh, retries int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.fresh.Len(), g.retries.Len()
+}
+
+// held returns how many slots of g are in use.
+func (g *PriorityGate) held() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.inUse
+}
+
+// expectIdle fails t unless g has no slots in use and nothing waiting.
+func expectIdle(t *testing.T, g *PriorityGate) {
+	t.Helper()
+
+	fresh, retries := g.queued()
+	if got := g.held(); got != 0 || fresh != 0 || retries != 0 {
+		t.Errorf("expected idle gate, got %d held, %d fresh and %d retries waiting", got, fresh, retries)
+	}
+}
+
+func TestPriorityGate(t *testing.T) {
+	t.Parallel()
+
+	// admitOrder fills a gate of capacity 1, queues one waiter per class in
+	// the order given, then frees slots one at a time and returns the order
+	// the waiters were admitted in.
+	admitOrder := func(t *testing.T, g *PriorityGate, c *fakeClock, wait time.Duration, classes ...bool) []bool {
+		t.Helper()
+
+		if err := g.acquire(context.Background(), false); err != nil {
+			t.Fatalf("failed to acquire: %v", err)
+		}
+
+		admitted := make(chan bool, len(classes))
+		for i, retry := range classes {
+			retry := retry
+			go func() {
+				if err := g.acquire(context.Background(), retry); err != nil {
+					t.Errorf("failed to acquire: %v", err)
+				}
+				admitted <- retry
+			}()
+			waitFor(t, func() bool {
+				fresh, retries := g.queued()
+				return fresh+retries == i+1
+			})
+		}
+		c.Advance(wait)
+
+		var order []bool
+		for range classes {
+			g.release()
+			order = append(order, <-admitted)
+		}
+		g.release()
+		expectIdle(t, g)
+		return order
+	}
+
+	t.Run("fresh_before_retry", func(t *testing.T) {
+		t.Parallel()
+
+		c := newFakeClock()
+		g := NewPriorityGateWithClock(1, time.Second, c)
+
+		got := admitOrder(t, g, c, 0, true, true, false, false)
+		if want := []bool{false, false, true, true}; fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	})
+
+	t.Run("starved_retry_first", func(t *testing.T) {
+		t.Parallel()
+
+		c := newFakeClock()
+		g := NewPriorityGateWithClock(1, time.Second, c)
+
+		got := admitOrder(t, g, c, time.Second, true, false)
+		if want := []bool{true, false}; fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	})
+
+	t.Run("starvation_bound_not_reached", func(t *testing.T) {
+		t.Parallel()
+
+		c := newFakeClock()
+		g := NewPriorityGateWithClock(1, time.Second, c)
+
+		got := admitOrder(t, g, c, time.Second-time.Nanosecond, true, false)
+		if want := []bool{false, true}; fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	})
+
+	t.Run("capacity", func(t *testing.T) {
+		t.Parallel()
+
+		g := NewPriorityGate(2)
+		for i := 0; i < 2; i++ {
+			if err := g.acquire(context.Background(), i > 0); err != nil {
+				t.Fatalf("failed to acquire: %v", err)
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if err := g.acquire(ctx, false); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected %v to be %v", err, context.DeadlineExceeded)
+		}
+
+		g.release()
+		g.release()
+		expectIdle(t, g)
+	})
+
+	t.Run("zero_capacity", func(t *testing.T) {
+		t.Parallel()
+
+		g := NewPriorityGate(0)
+		if err := g.acquire(context.Background(), false); err != nil {
+			t.Fatalf("failed to acquire: %v", err)
+		}
+		g.release()
+		expectIdle(t, g)
+	})
+
+	t.Run("canceled_waiter_leaves_queue", func(t *testing.T) {
+		t.Parallel()
+
+		g := NewPriorityGate(1)
+		if err := g.acquire(context.Background(), false); err != nil {
+			t.Fatalf("failed to acquire: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- g.acquire(ctx, true)
+		}()
+		waitFor(t, func() bool {
+			_, retries := g.queued()
+			return retries == 1
+		})
+
+		cancel()
+		if err := <-errCh; !errors.Is(err, context.Canceled) {
+			t.Errorf("expected %v to be %v", err, context.Canceled)
+		}
+
+		g.release()
+		expectIdle(t, g)
+	})
+}
+
+func TestWithPriorityGate(t *testing.T) {
+	t.Parallel()
+
+	newBackoff := func(t *testing.T, maxRetries uint64) backoff.Backoff {
+		t.Helper()
+
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		return backoff.WithMaxRetries(maxRetries, b)
+	}
+
+	t.Run("class_per_attempt", func(t *testing.T) {
+		t.Parallel()
+
+		g := NewPriorityGate(1)
+		if err := g.acquire(context.Background(), false); err != nil {
+			t.Fatalf("failed to acquire: %v", err)
+		}
+
+		inFirst := make(chan struct{})
+		proceed := make(chan struct{})
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- DoWithOptions(context.Background(), newBackoff(t, 3), func(ctx context.Context) error {
+				if AttemptFromContext(ctx) == 1 {
+					close(inFirst)
+					<-proceed
+					return RetryableError(fmt.Errorf("oops"))
+				}
+				return nil
+			}, WithPriorityGate(g))
+		}()
+
+		// The first attempt waits as a fresh request.
+		waitFor(t, func() bool {
+			fresh, retries := g.queued()
+			return fresh == 1 && retries == 0
+		})
+		g.release()
+		<-inFirst
+
+		// Hold the slot the first attempt frees so the retry has to wait.
+		acquired := make(chan struct{})
+		go func() {
+			if err := g.acquire(context.Background(), false); err != nil {
+				t.Errorf("failed to acquire: %v", err)
+			}
+			close(acquired)
+		}()
+		waitFor(t, func() bool {
+			fresh, _ := g.queued()
+			return fresh == 1
+		})
+		close(proceed)
+		<-acquired
+
+		// The second attempt waits as a retry.
+		waitFor(t, func() bool {
+			fresh, retries := g.queued()
+			return fresh == 0 && retries == 1
+		})
+		g.release()
+
+		if err := <-errCh; err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+		expectIdle(t, g)
+	})
+
+	t.Run("releases_on_every_path", func(t *testing.T) {
+		t.Parallel()
+
+		cases := []struct {
+			name string
+			opts []Option
+			f    RetryFunc
+		}{
+			{
+				name: "success",
+				f:    func(_ context.Context) error { return nil },
+			},
+			{
+				name: "retries_exhausted",
+				f:    func(_ context.Context) error { return RetryableError(fmt.Errorf("oops")) },
+			},
+			{
+				name: "non_retryable",
+				f:    func(_ context.Context) error { return fmt.Errorf("oops") },
+			},
+			{
+				name: "cleanup_panicked",
+				opts: []Option{WithAttemptCleanup(func(context.Context) (func(), error) {
+					return func() { panic("oops") }, nil
+				})},
+				f: func(_ context.Context) error { return nil },
+			},
+			{
+				name: "attempt_setup_failed",
+				opts: []Option{WithAttemptCleanup(func(context.Context) (func(), error) {
+					return nil, fmt.Errorf("no temp dir")
+				})},
+				f: func(_ context.Context) error { return nil },
+			},
+			{
+				name: "f_panics",
+				f:    func(_ context.Context) error { panic("oops") },
+			},
+		}
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				g := NewPriorityGate(1)
+				func() {
+					defer func() {
+						_ = recover()
+					}()
+					_ = DoWithOptions(context.Background(), newBackoff(t, 2), tc.f, append(tc.opts, WithPriorityGate(g))...)
+				}()
+				expectIdle(t, g)
+			})
+		}
+	})
+
+	t.Run("canceled_while_waiting", func(t *testing.T) {
+		t.Parallel()
+
+		g := NewPriorityGate(1)
+		if err := g.acquire(context.Background(), false); err != nil {
+			t.Fatalf("failed to acquire: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errCh := make(chan error, 1)
+		var calls int64
+		go func() {
+			errCh <- DoWithOptions(ctx, newBackoff(t, 3), func(_ context.Context) error {
+				atomic.AddInt64(&calls, 1)
+				return nil
+			}, WithPriorityGate(g))
+		}()
+		waitFor(t, func() bool {
+			fresh, _ := g.queued()
+			return fresh == 1
+		})
+
+		cancel()
+		if err := <-errCh; !errors.Is(err, context.Canceled) {
+			t.Errorf("expected %v to be %v", err, context.Canceled)
+		}
+		if got := atomic.LoadInt64(&calls); got != 0 {
+			t.Errorf("expected no attempts, got %d", got)
+		}
+
+		g.release()
+		expectIdle(t, g)
+	})
+
+	t.Run("mixed_traffic", func(t *testing.T) {
+		t.Parallel()
+
+		const capacity = 3
+		g := NewPriorityGateWithClock(capacity, time.Millisecond, nil)
+
+		var running, peak int64
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			i := i
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				err := DoWithOptions(context.Background(), newBackoff(t, 5), func(ctx context.Context) error {
+					n := atomic.AddInt64(&running, 1)
+					defer atomic.AddInt64(&running, -1)
+					for {
+						p := atomic.LoadInt64(&peak)
+						if n <= p || atomic.CompareAndSwapInt64(&peak, p, n) {
+							break
+						}
+					}
+					time.Sleep(100 * time.Microsecond)
+
+					if AttemptFromContext(ctx) <= uint64(i%3) {
+						return RetryableError(fmt.Errorf("oops"))
+					}
+					return nil
+				}, WithPriorityGate(g))
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if got := atomic.LoadInt64(&peak); got > capacity {
+			t.Errorf("expected at most %d concurrent attempts, got %d", capacity, got)
+		}
+		expectIdle(t, g)
+	})
+}
@@ -0,0 +1,38 @@
+package retry
+
+import (
+	"context"
+	"io"
+
+	"github.com/swayne275/go-retry/backoff"
+	"github.com/swayne275/go-retry/internal/clock"
+)
+
+// DoReader copies the stream returned by open to w, retrying with b when the
+// copy fails, as Do would. Rather than restarting, each retry resumes where
+// the previous attempt stopped: open is passed the offset of the first byte w
+// has not received yet, which is 0 on the first attempt, and must return the
+// stream from that offset on. w thus receives the content exactly once.
+//
+// Errors from open and from reading the stream are retried only if marked with
+// RetryableError, as in Do, so open should mark both the errors of its own and
+// those of the reader it returns. Errors from w are returned as they would be
+// from f. Each stream is closed once its copy ends.
+func DoReader(ctx context.Context, b backoff.Backoff, open func(ctx context.Context, offset int64) (io.ReadCloser, error), w io.Writer) error {
+	return doReader(ctx, clock.Real, b, open, w)
+}
+
+func doReader(ctx context.Context, c Clock, b backoff.Backoff, open func(ctx context.Context, offset int64) (io.ReadCloser, error), w io.Writer) error {
+	var offset int64
+	return DoWithOptions(ctx, b, func(ctx context.Context) error {
+		r, err := open(ctx, offset)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+
+		n, err := io.Copy(w, r)
+		offset += n
+		return err
+	}, WithClock(c))
+}
@@ -0,0 +1,202 @@
+package retry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+// flakySource serves content from any offset. Its first failures streams fail
+// with err after failAfter bytes.
+type flakySource struct {
+	content   string
+	failAfter int
+	failures  int
+	err       error
+	offsets   []int64
+	closed    int
+}
+
+func (s *flakySource) open(_ context.Context, offset int64) (io.ReadCloser, error) {
+	s.offsets = append(s.offsets, offset)
+	var r io.Reader = strings.NewReader(s.content[offset:])
+	if s.failures > 0 {
+		s.failures--
+		r = io.MultiReader(io.LimitReader(r, int64(s.failAfter)), &errReader{err: s.err})
+	}
+	return &closeCounter{Reader: r, closed: &s.closed}, nil
+}
+
+type errReader struct {
+	err error
+}
+
+func (r *errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+type closeCounter struct {
+	io.Reader
+	closed *int
+}
+
+func (c *closeCounter) Close() error {
+	*c.closed++
+	return nil
+}
+
+// failingWriter fails every write.
+type failingWriter struct {
+	err error
+}
+
+func (w failingWriter) Write([]byte) (int, error) {
+	return 0, w.err
+}
+
+func TestDoReader(t *testing.T) {
+	t.Parallel()
+
+	const content = "the quick brown fox jumps over the lazy dog"
+	errFlaky := RetryableError(fmt.Errorf("connection reset"))
+
+	newBackoff := func(t *testing.T, maxRetries uint64) backoff.Backoff {
+		t.Helper()
+
+		b, err := backoff.NewConstant(1 * time.Second)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		return backoff.WithMaxRetries(maxRetries, b)
+	}
+
+	t.Run("resumes_after_failures", func(t *testing.T) {
+		t.Parallel()
+
+		src := &flakySource{content: content, failAfter: 10, failures: 3, err: errFlaky}
+		var buf bytes.Buffer
+		if err := doReader(context.Background(), newFakeClock(), newBackoff(t, 5), src.open, &buf); err != nil {
+			t.Fatalf("expected %v to be nil", err)
+		}
+		if got := buf.String(); got != content {
+			t.Errorf("expected %q to be %q", got, content)
+		}
+		if got, want := src.offsets, []int64{0, 10, 20, 30}; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+		if got, want := src.closed, 4; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("no_failures", func(t *testing.T) {
+		t.Parallel()
+
+		src := &flakySource{content: content}
+		var buf bytes.Buffer
+		if err := doReader(context.Background(), newFakeClock(), newBackoff(t, 5), src.open, &buf); err != nil {
+			t.Fatalf("expected %v to be nil", err)
+		}
+		if got := buf.String(); got != content {
+			t.Errorf("expected %q to be %q", got, content)
+		}
+		if got, want := src.offsets, []int64{0}; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	})
+
+	t.Run("backoff_stops", func(t *testing.T) {
+		t.Parallel()
+
+		src := &flakySource{content: content, failAfter: 5, failures: 10, err: errFlaky}
+		var buf bytes.Buffer
+		err := doReader(context.Background(), newFakeClock(), newBackoff(t, 2), src.open, &buf)
+		if !errors.Is(err, ErrBackoffSignaledToStop) {
+			t.Fatalf("expected %v to be %v", err, ErrBackoffSignaledToStop)
+		}
+		if got, want := buf.String(), content[:15]; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+	})
+
+	t.Run("non_retryable_read", func(t *testing.T) {
+		t.Parallel()
+
+		errCorrupt := fmt.Errorf("corrupt")
+		src := &flakySource{content: content, failAfter: 5, failures: 1, err: errCorrupt}
+		var buf bytes.Buffer
+		err := doReader(context.Background(), newFakeClock(), newBackoff(t, 5), src.open, &buf)
+		if !errors.Is(err, ErrNonRetryable) || !errors.Is(err, errCorrupt) {
+			t.Fatalf("expected %q to wrap %q and %q", err, ErrNonRetryable, errCorrupt)
+		}
+		if got, want := len(src.offsets), 1; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("open_fails", func(t *testing.T) {
+		t.Parallel()
+
+		var offsets []int64
+		var calls int
+		open := func(ctx context.Context, offset int64) (io.ReadCloser, error) {
+			offsets = append(offsets, offset)
+			calls++
+			if calls < 3 {
+				return nil, errFlaky
+			}
+			return io.NopCloser(strings.NewReader(content[offset:])), nil
+		}
+
+		var buf bytes.Buffer
+		if err := doReader(context.Background(), newFakeClock(), newBackoff(t, 5), open, &buf); err != nil {
+			t.Fatalf("expected %v to be nil", err)
+		}
+		if got := buf.String(); got != content {
+			t.Errorf("expected %q to be %q", got, content)
+		}
+		if got, want := offsets, []int64{0, 0, 0}; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	})
+
+	t.Run("writer_fails", func(t *testing.T) {
+		t.Parallel()
+
+		errDisk := fmt.Errorf("disk full")
+		src := &flakySource{content: content}
+		err := doReader(context.Background(), newFakeClock(), newBackoff(t, 5), src.open, failingWriter{err: errDisk})
+		if !errors.Is(err, ErrNonRetryable) || !errors.Is(err, errDisk) {
+			t.Fatalf("expected %q to wrap %q and %q", err, ErrNonRetryable, errDisk)
+		}
+		if got, want := src.closed, 1; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("real_clock", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewConstant(1 * time.Millisecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		src := &flakySource{content: content, failAfter: 7, failures: 2, err: errFlaky}
+		var buf bytes.Buffer
+		if err := DoReader(context.Background(), b, src.open, &buf); err != nil {
+			t.Fatalf("expected %v to be nil", err)
+		}
+		if got := buf.String(); got != content {
+			t.Errorf("expected %q to be %q", got, content)
+		}
+	})
+}
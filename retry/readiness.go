@@ -0,0 +1,170 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/swayne275/go-retry/backoff"
+	"github.com/swayne275/go-retry/internal/label"
+)
+
+// ErrDependencyNeverReady is returned by loops waiting on a ReadinessGate
+// whose probe gave up before the dependency became ready. Its label is
+// "dependency_never_ready".
+var ErrDependencyNeverReady = label.New("dependency never became ready", "dependency_never_ready")
+
+// ReadinessGate delays retry loops until a named dependency reports healthy.
+//
+// The gate runs its probe, itself a retry loop paced by the probe policy,
+// exactly once no matter how many loops wait on it. The probe is started the
+// first time anything waits on or inspects the gate, and runs independently of
+// any waiter's context so one caller giving up does not abort it for the rest.
+//
+// A ReadinessGate is safe for concurrent use and must be created with
+// NewReadinessGate. The zero ReadinessGate has no probe, so it fails at once
+// with ErrDependencyNeverReady.
+type ReadinessGate struct {
+	name   string
+	probe  RetryFunc
+	policy func() backoff.Backoff
+
+	once  sync.Once
+	ready chan struct{}
+	done  chan struct{}
+	err   error
+}
+
+// NewReadinessGate creates a gate for the dependency called name. probe is
+// retried with a backoff from probePolicy until it succeeds, which opens the
+// gate, or the policy gives up, which fails it permanently.
+func NewReadinessGate(name string, probe RetryFunc, probePolicy func() backoff.Backoff) *ReadinessGate {
+	return &ReadinessGate{
+		name:   name,
+		probe:  probe,
+		policy: probePolicy,
+	}
+}
+
+// Ready returns a channel that is closed once the dependency is ready. It is
+// never closed if the probe gives up; use Err to detect that case.
+func (g *ReadinessGate) Ready() <-chan struct{} {
+	g.start()
+	return g.ready
+}
+
+// Err returns an error wrapping ErrDependencyNeverReady and the probe's
+// terminal error if the probe gave up, or nil while probing is still in
+// progress or after it succeeded.
+func (g *ReadinessGate) Err() error {
+	g.start()
+	select {
+	case <-g.done:
+		return g.err
+	default:
+		return nil
+	}
+}
+
+func (g *ReadinessGate) start() {
+	g.once.Do(func() {
+		g.ready = make(chan struct{})
+		g.done = make(chan struct{})
+		go g.run()
+	})
+}
+
+func (g *ReadinessGate) run() {
+	defer close(g.done)
+
+	if g.probe == nil {
+		g.err = fmt.Errorf("%w: %s: no probe", ErrDependencyNeverReady, g.name)
+		return
+	}
+	b, err := build(g.policy)
+	if err != nil {
+		g.err = fmt.Errorf("%w: %s: %w", ErrDependencyNeverReady, g.name, err)
+		return
+	}
+	if err := Do(context.Background(), b, g.probe); err != nil {
+		g.err = fmt.Errorf("%w: %s: %w", ErrDependencyNeverReady, g.name, err)
+		return
+	}
+	close(g.ready)
+}
+
+// wait blocks until the gate is settled or ctx is done. It reports whether it
+// had to block, so the caller knows its backoff state may be stale.
+func (g *ReadinessGate) wait(ctx context.Context) (bool, error) {
+	g.start()
+
+	waited := false
+	select {
+	case <-g.done:
+	default:
+		waited = true
+		select {
+		case <-ctx.Done():
+			return waited, ctx.Err()
+		case <-g.done:
+		}
+	}
+
+	return waited, g.err
+}
+
+// WithReadinessGate makes DoWithOptions wait for g to report ready before
+// the first attempt. If the wait blocked, the backoff is reset so the loop
+// starts with a fresh schedule. If the gate's probe gives up, the loop returns
+// the gate's ErrDependencyNeverReady error without calling f.
+func WithReadinessGate(g *ReadinessGate) Option {
+	return func(o *options) {
+		o.gate = g
+		o.gateMode = gateFirst
+	}
+}
+
+// WithReadinessGateOnRetry is like WithReadinessGate, but lets the first
+// attempt proceed immediately and only waits on g before retries. This suits
+// callers that expect the dependency to usually be up and only want to stop
+// hammering it once it has failed.
+func WithReadinessGateOnRetry(g *ReadinessGate) Option {
+	return func(o *options) {
+		o.gate = g
+		o.gateMode = gateRetries
+	}
+}
+
+// WithReadinessGateAlways is like WithReadinessGate, but waits on g before
+// every retry as well as before the first attempt. Each wait that blocks
+// resets the backoff.
+func WithReadinessGateAlways(g *ReadinessGate) Option {
+	return func(o *options) {
+		o.gate = g
+		o.gateMode = gateAlways
+	}
+}
+
+// gateMode says before which attempts a loop waits on its ReadinessGate.
+type gateMode uint8
+
+const (
+	// gateFirst waits before the first attempt only.
+	gateFirst gateMode = iota
+	// gateRetries waits before retries only.
+	gateRetries
+	// gateAlways waits before every attempt.
+	gateAlways
+)
+
+// applies reports whether the loop waits on its gate before attempt.
+func (m gateMode) applies(attempt uint64) bool {
+	switch m {
+	case gateRetries:
+		return attempt > 1
+	case gateAlways:
+		return true
+	default:
+		return attempt == 1
+	}
+}
@@ -0,0 +1,321 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+func constantPolicy(t *testing.T, maxRetries uint64) func() backoff.Backoff {
+	t.Helper()
+
+	return func() backoff.Backoff {
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		return backoff.WithMaxRetries(maxRetries, b)
+	}
+}
+
+func TestReadinessGate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("releases_waiters_together", func(t *testing.T) {
+		t.Parallel()
+
+		release := make(chan struct{})
+		g := NewReadinessGate("db", func(_ context.Context) error {
+			select {
+			case <-release:
+				return nil
+			default:
+				return RetryableError(fmt.Errorf("not yet"))
+			}
+		}, constantPolicy(t, 1<<20))
+
+		const loops = 20
+		var calls int64
+		var wg sync.WaitGroup
+		errs := make(chan error, loops)
+		for i := 0; i < loops; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- DoWithOptions(context.Background(), constantPolicy(t, 3)(), func(_ context.Context) error {
+					atomic.AddInt64(&calls, 1)
+					return nil
+				}, WithReadinessGate(g))
+			}()
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		if got := atomic.LoadInt64(&calls); got != 0 {
+			t.Fatalf("expected no attempts before ready, got %d", got)
+		}
+
+		close(release)
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			if err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		}
+		if got, want := atomic.LoadInt64(&calls), int64(loops); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		select {
+		case <-g.Ready():
+		default:
+			t.Error("expected gate to be ready")
+		}
+		if err := g.Err(); err != nil {
+			t.Errorf("expected no gate error, got %v", err)
+		}
+	})
+
+	t.Run("probe_exhaustion_fails_waiters", func(t *testing.T) {
+		t.Parallel()
+
+		errProbe := fmt.Errorf("connection refused")
+		g := NewReadinessGate("db", func(_ context.Context) error {
+			return RetryableError(errProbe)
+		}, constantPolicy(t, 3))
+
+		const loops = 10
+		var calls int64
+		var wg sync.WaitGroup
+		errs := make(chan error, loops)
+		for i := 0; i < loops; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- DoWithOptions(context.Background(), constantPolicy(t, 3)(), func(_ context.Context) error {
+					atomic.AddInt64(&calls, 1)
+					return nil
+				}, WithReadinessGate(g))
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			if !errors.Is(err, ErrDependencyNeverReady) {
+				t.Errorf("expected %q to be %q", err, ErrDependencyNeverReady)
+			}
+			if !errors.Is(err, errProbe) {
+				t.Errorf("expected %q to be %q", err, errProbe)
+			}
+		}
+		if got := atomic.LoadInt64(&calls); got != 0 {
+			t.Errorf("expected f to never be called, got %d calls", got)
+		}
+		if err := g.Err(); !errors.Is(err, ErrDependencyNeverReady) {
+			t.Errorf("expected %q to be %q", err, ErrDependencyNeverReady)
+		}
+		select {
+		case <-g.Ready():
+			t.Error("expected gate to never be ready")
+		default:
+		}
+	})
+
+	t.Run("caller_cancellation_while_waiting", func(t *testing.T) {
+		t.Parallel()
+
+		release := make(chan struct{})
+		defer close(release)
+		g := NewReadinessGate("db", func(ctx context.Context) error {
+			<-release
+			return nil
+		}, constantPolicy(t, 0))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			cancel()
+		}()
+
+		var calls int
+		err := DoWithOptions(ctx, constantPolicy(t, 3)(), func(_ context.Context) error {
+			calls++
+			return nil
+		}, WithReadinessGate(g))
+		if err != context.Canceled {
+			t.Errorf("expected %q to be %q", err, context.Canceled)
+		}
+		if calls != 0 {
+			t.Errorf("expected f to never be called, got %d calls", calls)
+		}
+		if err := g.Err(); err != nil {
+			t.Errorf("expected probe to keep running, got %v", err)
+		}
+	})
+
+	t.Run("single_prober", func(t *testing.T) {
+		t.Parallel()
+
+		const probeFailures = 3
+		var probes int64
+		g := NewReadinessGate("db", func(_ context.Context) error {
+			if atomic.AddInt64(&probes, 1) <= probeFailures {
+				return RetryableError(fmt.Errorf("not yet"))
+			}
+			return nil
+		}, constantPolicy(t, 1<<20))
+
+		var wg sync.WaitGroup
+		for i := 0; i < 100; i++ {
+			wg.Add(3)
+			go func() {
+				defer wg.Done()
+				_ = DoWithOptions(context.Background(), constantPolicy(t, 0)(), func(_ context.Context) error {
+					return nil
+				}, WithReadinessGate(g))
+			}()
+			go func() {
+				defer wg.Done()
+				<-g.Ready()
+			}()
+			go func() {
+				defer wg.Done()
+				_ = g.Err()
+			}()
+		}
+		wg.Wait()
+
+		if got, want := atomic.LoadInt64(&probes), int64(probeFailures+1); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("gate_on_retry_skips_first_attempt", func(t *testing.T) {
+		t.Parallel()
+
+		release := make(chan struct{})
+		g := NewReadinessGate("db", func(_ context.Context) error {
+			<-release
+			return nil
+		}, constantPolicy(t, 0))
+
+		firstAttempt := make(chan struct{})
+		var calls int64
+		errc := make(chan error, 1)
+		go func() {
+			errc <- DoWithOptions(context.Background(), constantPolicy(t, 3)(), func(_ context.Context) error {
+				if atomic.AddInt64(&calls, 1) == 1 {
+					close(firstAttempt)
+					return RetryableError(fmt.Errorf("oops"))
+				}
+				return nil
+			}, WithReadinessGateOnRetry(g))
+		}()
+
+		<-firstAttempt
+		time.Sleep(10 * time.Millisecond)
+		if got := atomic.LoadInt64(&calls); got != 1 {
+			t.Fatalf("expected retry to wait on the gate, got %d calls", got)
+		}
+
+		close(release)
+		if err := <-errc; err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+		if got, want := atomic.LoadInt64(&calls), int64(2); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("gate_always", func(t *testing.T) {
+		t.Parallel()
+
+		release := make(chan struct{})
+		g := NewReadinessGate("db", func(_ context.Context) error {
+			<-release
+			return nil
+		}, constantPolicy(t, 0))
+
+		var calls int64
+		errc := make(chan error, 1)
+		go func() {
+			errc <- DoWithOptions(context.Background(), constantPolicy(t, 3)(), func(_ context.Context) error {
+				if atomic.AddInt64(&calls, 1) == 1 {
+					return RetryableError(fmt.Errorf("oops"))
+				}
+				return nil
+			}, WithReadinessGateAlways(g))
+		}()
+
+		time.Sleep(10 * time.Millisecond)
+		if got := atomic.LoadInt64(&calls); got != 0 {
+			t.Fatalf("expected the first attempt to wait on the gate, got %d calls", got)
+		}
+
+		close(release)
+		if err := <-errc; err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+		if got, want := atomic.LoadInt64(&calls), int64(2); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+
+		for _, tc := range []struct {
+			mode gateMode
+			want [3]bool
+		}{
+			{mode: gateFirst, want: [3]bool{true, false, false}},
+			{mode: gateRetries, want: [3]bool{false, true, true}},
+			{mode: gateAlways, want: [3]bool{true, true, true}},
+		} {
+			var got [3]bool
+			for i := range got {
+				got[i] = tc.mode.applies(uint64(i + 1))
+			}
+			if got != tc.want {
+				t.Errorf("mode %d: expected %v to be %v", tc.mode, got, tc.want)
+			}
+		}
+	})
+
+	t.Run("resets_backoff_after_waiting", func(t *testing.T) {
+		t.Parallel()
+
+		release := make(chan struct{})
+		g := NewReadinessGate("db", func(_ context.Context) error {
+			<-release
+			return nil
+		}, constantPolicy(t, 0))
+
+		var resets int64
+		b := backoff.WithReset(func() backoff.Backoff {
+			atomic.AddInt64(&resets, 1)
+			return backoff.BackoffFunc(func() (time.Duration, bool) {
+				return 1 * time.Nanosecond, false
+			})
+		}, backoff.BackoffFunc(func() (time.Duration, bool) {
+			return 1 * time.Nanosecond, false
+		}))
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			close(release)
+		}()
+
+		if err := DoWithOptions(context.Background(), b, func(_ context.Context) error {
+			return nil
+		}, WithReadinessGate(g)); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if got, want := atomic.LoadInt64(&resets), int64(1); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+}
@@ -0,0 +1,68 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+// ErrPanicked is wrapped, together with ErrNonRetryable or, if panics are
+// retried, ErrBackoffSignaledToStop, in the error of a loop whose function
+// panicked, when the panic was recovered by WithRecover or
+// WithRecoverRetryable. The error also includes the panic value, which it
+// wraps if it is an error, and the stack of the panic.
+var ErrPanicked = fmt.Errorf("function panicked")
+
+// WithRecover makes DoWithOptions recover panics from f and treat them as
+// non-retryable errors wrapping ErrPanicked, so the loop stops with an error
+// wrapping ErrNonRetryable and ErrPanicked instead of the panic unwinding the
+// goroutine.
+func WithRecover() Option {
+	return func(o *options) {
+		o.recoverPanics = true
+		o.retryPanics = false
+	}
+}
+
+// WithRecoverRetryable is like WithRecover, but retries the attempts that
+// panicked, as if f had returned the error wrapping ErrPanicked marked with
+// RetryableError.
+func WithRecoverRetryable() Option {
+	return func(o *options) {
+		o.recoverPanics = true
+		o.retryPanics = true
+	}
+}
+
+// DoWithRecover is like Do, but recovers panics from f and stops the loop with
+// an error wrapping ErrNonRetryable and ErrPanicked. It is a shorthand for
+// DoWithOptions with WithRecover.
+func DoWithRecover(ctx context.Context, b backoff.Backoff, f RetryFunc) error {
+	return DoWithOptions(ctx, b, f, WithRecover())
+}
+
+// recovering returns f with its panics turned into errors wrapping
+// ErrPanicked, marked as retryable if retry is set.
+func recovering(f RetryFunc, retry bool) RetryFunc {
+	return func(ctx context.Context) (err error) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+
+			if rerr, ok := r.(error); ok {
+				err = fmt.Errorf("%w: %w\n\n%s", ErrPanicked, rerr, debug.Stack())
+			} else {
+				err = fmt.Errorf("%w: %v\n\n%s", ErrPanicked, r, debug.Stack())
+			}
+			if retry {
+				err = RetryableError(err)
+			}
+		}()
+
+		return f(ctx)
+	}
+}
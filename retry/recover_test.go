@@ -0,0 +1,111 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+func TestDoWithRecover(t *testing.T) {
+	t.Parallel()
+
+	newBackoff := func(t *testing.T) backoff.Backoff {
+		t.Helper()
+
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		return backoff.WithMaxRetries(2, b)
+	}
+
+	t.Run("panic_on_first_attempt", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int
+		err := DoWithRecover(context.Background(), newBackoff(t), func(_ context.Context) error {
+			calls++
+			var m map[string]int
+			m["boom"]++
+			return nil
+		})
+		if !errors.Is(err, ErrPanicked) || !errors.Is(err, ErrNonRetryable) {
+			t.Errorf("expected %q to be %q and %q", err, ErrPanicked, ErrNonRetryable)
+		}
+		if got := Classify(err); got != ErrNonRetryable {
+			t.Errorf("expected %v to be %v", got, ErrNonRetryable)
+		}
+		if calls != 1 {
+			t.Errorf("expected %d to be %d", calls, 1)
+		}
+
+		// The message has the panic value and the stack where it happened.
+		for _, want := range []string{"assignment to entry in nil map", "recover_test.go"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("expected %q to contain %q", err, want)
+			}
+		}
+	})
+
+	t.Run("panic_with_error", func(t *testing.T) {
+		t.Parallel()
+
+		errFoo := fmt.Errorf("foo")
+		err := DoWithRecover(context.Background(), newBackoff(t), func(_ context.Context) error {
+			panic(errFoo)
+		})
+		if !errors.Is(err, ErrPanicked) || !errors.Is(err, errFoo) {
+			t.Errorf("expected %q to be %q and %q", err, ErrPanicked, errFoo)
+		}
+	})
+
+	t.Run("retryable", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int
+		err := DoWithOptions(context.Background(), newBackoff(t), func(_ context.Context) error {
+			calls++
+			if calls == 1 {
+				panic("boom")
+			}
+			return nil
+		}, WithRecoverRetryable())
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if calls != 2 {
+			t.Errorf("expected %d to be %d", calls, 2)
+		}
+	})
+
+	t.Run("retryable_exhausted", func(t *testing.T) {
+		t.Parallel()
+
+		err := DoWithOptions(context.Background(), newBackoff(t), func(_ context.Context) error {
+			panic("boom")
+		}, WithRecoverRetryable())
+		if !errors.Is(err, ErrPanicked) || !errors.Is(err, ErrBackoffSignaledToStop) {
+			t.Errorf("expected %q to be %q and %q", err, ErrPanicked, ErrBackoffSignaledToStop)
+		}
+	})
+
+	t.Run("not_recovered_by_default", func(t *testing.T) {
+		t.Parallel()
+
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("expected %v to be %v", r, "boom")
+			}
+		}()
+
+		_ = Do(context.Background(), newBackoff(t), func(_ context.Context) error {
+			panic("boom")
+		})
+		t.Error("expected the panic to propagate")
+	})
+}
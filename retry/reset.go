@@ -0,0 +1,24 @@
+package retry
+
+import (
+	"context"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+// WithResetOnSuccess makes DoWithOptions reset the backoff after f succeeds,
+// so a backoff reused across loops, such as by a long-lived worker, starts
+// the next loop from its initial value instead of where earlier failures left
+// it. A backoff without state to reset, such as a backoff.BackoffFunc, is not
+// affected.
+func WithResetOnSuccess() Option {
+	return func(o *options) {
+		o.resetOnSuccess = true
+	}
+}
+
+// DoAndReset is like Do, but resets b after f succeeds. It is a shorthand for
+// DoWithOptions with WithResetOnSuccess.
+func DoAndReset(ctx context.Context, b backoff.Backoff, f RetryFunc) error {
+	return DoWithOptions(ctx, b, f, WithResetOnSuccess())
+}
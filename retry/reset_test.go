@@ -0,0 +1,80 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+func TestDoAndReset(t *testing.T) {
+	t.Parallel()
+
+	// run fails twice, then succeeds, and returns the sleeps it was told of.
+	run := func(t *testing.T, b backoff.Backoff, opts ...Option) []time.Duration {
+		t.Helper()
+
+		var sleeps []time.Duration
+		var calls int
+		opts = append(opts, WithClock(newFakeClock()), WithOnRetry(func(_ uint64, _ error, next time.Duration) {
+			sleeps = append(sleeps, next)
+		}))
+		err := DoWithOptions(context.Background(), b, func(_ context.Context) error {
+			calls++
+			if calls < 3 {
+				return RetryableError(fmt.Errorf("oops"))
+			}
+			return nil
+		}, opts...)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return sleeps
+	}
+
+	t.Run("resets", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewFibonacci(1 * time.Second)
+		if err != nil {
+			t.Fatalf("failed to create fibonacci backoff: %v", err)
+		}
+
+		for i := 0; i < 2; i++ {
+			sleeps := run(t, b, WithResetOnSuccess())
+			if got, want := fmt.Sprint(sleeps), "[1s 2s]"; got != want {
+				t.Errorf("loop %d: expected %v to be %v", i, got, want)
+			}
+		}
+	})
+
+	t.Run("not_reset_by_default", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewFibonacci(1 * time.Second)
+		if err != nil {
+			t.Fatalf("failed to create fibonacci backoff: %v", err)
+		}
+
+		run(t, b)
+		if got, want := fmt.Sprint(run(t, b)), "[3s 5s]"; got != want {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	})
+
+	t.Run("backoff_func", func(t *testing.T) {
+		t.Parallel()
+
+		b := backoff.BackoffFunc(func() (time.Duration, bool) {
+			return 1 * time.Second, false
+		})
+		err := DoAndReset(context.Background(), b, func(_ context.Context) error {
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
@@ -19,16 +19,42 @@ import (
 	"time"
 
 	"github.com/swayne275/go-retry/backoff"
+	"github.com/swayne275/go-retry/internal/clock"
+	"github.com/swayne275/go-retry/internal/cost"
+	"github.com/swayne275/go-retry/internal/label"
+	"github.com/swayne275/go-retry/internal/random"
 )
 
-var ErrNonRetryable = fmt.Errorf("function returned non retryable error")
-var errBackoffSignaledToStop = fmt.Errorf("backoff signaled to stop")
+// ErrNonRetryable is returned, wrapping the error from f, when f returns an
+// error that is not marked as retryable. Its label is "non_retryable".
+var ErrNonRetryable = label.New("function returned non retryable error", "non_retryable")
+
+// ErrBackoffSignaledToStop is returned, wrapping the last retryable error, when
+// the backoff signals to stop before f succeeds. Its label is
+// "retries_exhausted".
+var ErrBackoffSignaledToStop = label.New("backoff signaled to stop", "retries_exhausted")
+
+// ErrRetriesExhausted is wrapped, together with ErrBackoffSignaledToStop, when
+// the backoff stopped because a limit set with backoff.WithMaxRetries ran out,
+// rather than because the backoff itself stopped, such as when
+// backoff.WithMaxDuration ran out of time. It tells callers that the operation
+// might still succeed if tried again later.
+var ErrRetriesExhausted = fmt.Errorf("max retries exhausted")
+
+// ErrRetryable is matched by errors.Is for any error marked with
+// RetryableError or RetryableAfterError, so marked errors can be handled in a
+// switch alongside other sentinels. It is never returned by the loops in this
+// package: they remove the mark from the errors they return.
+var ErrRetryable = fmt.Errorf("retryable")
 
 // RetryFunc is a function passed to retry.
 type RetryFunc func(ctx context.Context) error
 
 type retryableError struct {
 	err error
+	// after, if hinted, replaces the backoff's next sleep.
+	after  time.Duration
+	hinted bool
 }
 
 // RetryableError marks an error as retryable.
@@ -36,7 +62,39 @@ func RetryableError(err error) error {
 	if err == nil {
 		return nil
 	}
-	return &retryableError{err}
+	return &retryableError{err: err}
+}
+
+// RetryableAfterError marks an error as retryable after a delay the server
+// asked for, such as an HTTP Retry-After header or a gRPC RetryInfo. Do sleeps
+// for after before the next attempt instead of the backoff's next value. A
+// negative after is treated as zero.
+//
+// The backoff's Next is not called for the retry, so the hint doesn't advance
+// its schedule, isn't limited by decorators such as WithCappedDuration or
+// WithMaxCumulativeSleep, and isn't overridden by a backoff that would signal
+// to stop. It still counts toward the backoff's retry limit, such as that of
+// WithMaxRetries, as reported by backoff.Budgeted: once the hinted retries and
+// the backoff's own values use it up, the loop stops with an error wrapping
+// ErrRetriesExhausted. Bound the whole loop with ctx if a hint could be
+// unreasonably long.
+func RetryableAfterError(err error, after time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	if after < 0 {
+		after = 0
+	}
+	return &retryableError{err: err, after: after, hinted: true}
+}
+
+// sleep returns how long to wait before retrying e, given the backoff's next
+// value.
+func (e *retryableError) sleep(next time.Duration) time.Duration {
+	if e.hinted {
+		return e.after
+	}
+	return next
 }
 
 // Unwrap implements error wrapping.
@@ -44,6 +102,11 @@ func (e *retryableError) Unwrap() error {
 	return e.err
 }
 
+// Is reports whether target is ErrRetryable.
+func (e *retryableError) Is(target error) bool {
+	return target == ErrRetryable
+}
+
 // Error returns the error string.
 func (e *retryableError) Error() string {
 	if e.err == nil {
@@ -52,11 +115,261 @@ func (e *retryableError) Error() string {
 	return "retryable: " + e.err.Error()
 }
 
+type nonRetryableError struct {
+	err error
+}
+
+// NonRetryableError marks an error as not retryable. It is only needed with
+// WithRetryByDefault, where errors are retried unless marked, but it is
+// honored by every loop: an error marked with NonRetryableError is never
+// retried, even if it is also marked with RetryableError.
+func NonRetryableError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &nonRetryableError{err}
+}
+
+// Unwrap implements error wrapping.
+func (e *nonRetryableError) Unwrap() error {
+	return e.err
+}
+
+// Error returns the error string.
+func (e *nonRetryableError) Error() string {
+	if e.err == nil {
+		return "non-retryable: <nil>"
+	}
+	return "non-retryable: " + e.err.Error()
+}
+
+// IsRetryable reports whether the loops in this package would retry err
+// without consulting WithShouldRetry or WithRetryByDefault: that is, whether err,
+// or any error it wraps, is marked with RetryableError or RetryableAfterError,
+// and none is marked with NonRetryableError. It is false for nil.
+func IsRetryable(err error) bool {
+	_, ok := asRetryable(err, nil)
+	return ok
+}
+
+// asRetryable returns err as a retryableError if a loop should retry it.
+// Errors marked with NonRetryableError never are, and errors marked with
+// RetryableError always are. shouldRetry decides the rest; if it is nil, they
+// aren't retried.
+func asRetryable(err error, shouldRetry func(err error) bool) (*retryableError, bool) {
+	rerr, non, ok := findMarks(err)
+	if !ok {
+		rerr, non = asMarks(err)
+	}
+	if non {
+		return nil, false
+	}
+	if rerr != nil {
+		return rerr, true
+	}
+	if shouldRetry == nil || !shouldRetry(err) {
+		return nil, false
+	}
+	return &retryableError{err: err}, true
+}
+
+// asMarks is findMarks for trees it can't walk, using errors.As. It is kept
+// apart so its targets only escape to the heap when it is needed.
+func asMarks(err error) (*retryableError, bool) {
+	var nerr *nonRetryableError
+	var rerr *retryableError
+	non := errors.As(err, &nerr)
+	errors.As(err, &rerr)
+	return rerr, non
+}
+
+// findMarks walks the tree of err in the order errors.As does, returning the
+// first error marked with RetryableError and whether any is marked with
+// NonRetryableError. Unlike errors.As it doesn't allocate, which matters since
+// it runs on every failed attempt. It reports !ok if an error in the tree has
+// an As method, which only errors.As can honor.
+func findMarks(err error) (rerr *retryableError, non, ok bool) {
+	for err != nil {
+		switch e := err.(type) {
+		case *nonRetryableError:
+			return rerr, true, true
+		case *retryableError:
+			if rerr == nil {
+				rerr = e
+			}
+		case interface{ As(any) bool }:
+			return nil, false, false
+		}
+
+		switch u := err.(type) {
+		case interface{ Unwrap() error }:
+			err = u.Unwrap()
+		case interface{ Unwrap() []error }:
+			for _, err := range u.Unwrap() {
+				r, n, ok := findMarks(err)
+				if !ok || n {
+					return rerr, n, ok
+				}
+				if rerr == nil {
+					rerr = r
+				}
+			}
+			return rerr, false, true
+		default:
+			return rerr, false, true
+		}
+	}
+	return rerr, false, true
+}
+
+// WithShouldRetry makes DoWithOptions ask shouldRetry whether to retry an
+// error from f that is not marked with RetryableError or NonRetryableError,
+// such as an error classified by a driver's own helper. The markers still take
+// precedence over shouldRetry. A nil shouldRetry restores the default of only
+// retrying marked errors. It replaces WithRetryByDefault, and vice versa.
+func WithShouldRetry(shouldRetry func(err error) bool) Option {
+	return func(o *options) {
+		o.shouldRetry = shouldRetry
+	}
+}
+
+// DoWithRetryCheck is like Do, but asks shouldRetry whether to retry errors
+// from f that are not marked retryable or non-retryable. See WithShouldRetry.
+func DoWithRetryCheck(ctx context.Context, b backoff.Backoff, f RetryFunc, shouldRetry func(err error) bool) error {
+	return DoWithOptions(ctx, b, f, WithShouldRetry(shouldRetry))
+}
+
+// WithRetryByDefault makes DoWithOptions retry every error from f except
+// those marked with NonRetryableError and context cancellation and deadline
+// errors, which end the loop with an error wrapping ErrNonRetryable, as
+// unmarked errors do by default. Errors marked with RetryableError or
+// RetryableAfterError behave as usual. It replaces WithShouldRetry, and vice
+// versa.
+func WithRetryByDefault() Option {
+	return WithShouldRetry(retryByDefault)
+}
+
+// retryByDefault retries every error but context cancellation and deadlines.
+func retryByDefault(err error) bool {
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// DoAllRetryable is like Do, but retries every error from f unless it is
+// marked with NonRetryableError. See WithRetryByDefault.
+func DoAllRetryable(ctx context.Context, b backoff.Backoff, f RetryFunc) error {
+	return DoWithOptions(ctx, b, f, WithRetryByDefault())
+}
+
+// WithBareErrors makes DoWithOptions return errors from f as f returned them,
+// for callers that compare them with == against their own sentinels, as they
+// could with sethvargo/go-retry. A non-retryable error is returned exactly as
+// is, without wrapping ErrNonRetryable, and when the backoff signals to stop
+// the last retryable error is returned with its RetryableError marker removed,
+// without wrapping ErrBackoffSignaledToStop. Errors from the loop itself, such
+// as ctx.Err() or a Budget running out, are not affected.
+//
+// Since the returned error no longer tells why the loop ended, prefer the
+// default and errors.Is in new code.
+func WithBareErrors() Option {
+	return func(o *options) {
+		o.bareErrors = true
+	}
+}
+
+// DoRaw is like Do, but returns errors from f unwrapped. See WithBareErrors.
+func DoRaw(ctx context.Context, b backoff.Backoff, f RetryFunc) error {
+	return DoWithOptions(ctx, b, f, WithBareErrors())
+}
+
 // Do wraps a function with a backoff to retry. It will retry until f returns either
 // nil or a non-retryable error.
 // The provided context is the same context passed to the RetryFunc.
+// When f succeeds, the success is recorded in b with backoff.RecordSuccess,
+// which closes a WithCircuitBreaker circuit.
+// Each attempt is reported to b with backoff.AttemptStarted and
+// backoff.AttemptFinished, so WithMaxSleepWindow can leave it out of its
+// budget.
+// When ctx is done, Do returns ctx.Err(), including when f returned it.
 func Do(ctx context.Context, b backoff.Backoff, f RetryFunc) error {
-	for {
+	return DoWithOptions(ctx, b, f)
+}
+
+// DoValue is like Do, but for a function that produces a value. It returns the
+// value from the attempt that succeeded or, if none did, the zero value of T
+// and the error Do would return.
+func DoValue[T any](ctx context.Context, b backoff.Backoff, f func(ctx context.Context) (T, error)) (T, error) {
+	var v T
+	err := Do(ctx, b, func(ctx context.Context) error {
+		var err error
+		v, err = f(ctx)
+		return err
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return v, nil
+}
+
+// DoWithOptions is like Do, but its behavior can be customized with options.
+// With no options it behaves exactly like Do.
+func DoWithOptions(ctx context.Context, b backoff.Backoff, f RetryFunc, opts ...Option) error {
+	o := newOptions(opts)
+	m := cost.NewMeter(o.costAcc, o.costKey, o.delayHistogram, o.summary.Enabled() || o.logger != nil || o.metrics != nil, o.clock)
+	err := do(ctx, b, f, &o, m)
+	m.Done()
+	logGaveUp(ctx, &o, m, err)
+	observeOutcome(&o, m, err)
+	logSummary(ctx, &o, m, b, err)
+	return err
+}
+
+// do is the loop of DoWithOptions, measured by m.
+func do(ctx context.Context, b backoff.Backoff, f RetryFunc, o *options, m *cost.Meter) error {
+	if err := backoff.Validate(b); err != nil {
+		return err
+	}
+	if o.seeded {
+		if err := backoff.UseRandomSource(b, random.NewLockedRandom(o.seed)); err != nil {
+			return fmt.Errorf("failed to seed backoff: %w", err)
+		}
+	}
+	if o.recoverPanics {
+		f = recovering(f, o.retryPanics)
+	}
+
+	// reserved holds bytes charged for an attempt that has not run yet, and
+	// the window they were charged to, so they can be refunded if the loop
+	// exits before making it.
+	var reserved int64
+	var reservedWindow time.Time
+	defer func() {
+		if reserved > 0 {
+			o.byteBudget.Refund(reserved, reservedWindow)
+		}
+	}()
+
+	// held is the priority gate whose slot the current attempt holds, so the
+	// slot is released even if f panics.
+	var held *PriorityGate
+	defer func() {
+		if held != nil {
+			held.release()
+		}
+	}()
+
+	sleeper := clock.NewSleeper(o.clock)
+	begun := o.clock.Now()
+
+	// lastErr is the last retryable error from f.
+	var lastErr error
+	// attemptErrs holds the error of every attempt, for a test expectation.
+	var attemptErrs []error
+	// hinted counts the retries paced by a RetryableAfterError hint since b
+	// was last reset, which b's retry limit doesn't know about.
+	var hinted uint64
+
+	for attempt := uint64(1); ; attempt++ {
 		// Return immediately if ctx is canceled
 		select {
 		case <-ctx.Done():
@@ -64,20 +377,130 @@ func Do(ctx context.Context, b backoff.Backoff, f RetryFunc) error {
 		default:
 		}
 
-		err := f(ctx)
+		if o.gate != nil && o.gateMode.applies(attempt) {
+			waited, err := o.gate.wait(ctx)
+			if err != nil {
+				return err
+			}
+			if waited {
+				b.Reset()
+				hinted = 0
+			}
+		}
+
+		if o.fence != nil && (!o.fenceOnRetry || attempt > 1) {
+			if err := checkFence(ctx, o.fence, lastErr); err != nil {
+				return err
+			}
+		}
+
+		if g := o.priorityGate; g != nil {
+			if err := g.acquire(ctx, attempt > 1); err != nil {
+				return err
+			}
+			held = g
+		}
+
+		attemptCtx, cancel := withAttempt(ctx, attemptInfo{n: attempt, start: begun, clock: o.clock, lastErr: lastErr}), context.CancelFunc(nil)
+		if d, ok := o.timeout(); ok {
+			attemptCtx, cancel = context.WithTimeout(attemptCtx, d)
+		}
+
+		var start time.Time
+		timed := (attempt == 1 && o.fastFirstRetry > 0) || o.adaptiveTimeout != nil
+		if timed {
+			start = o.clock.Now()
+		}
+
+		reserved = 0
+		m.Start()
+		backoff.AttemptStarted(b)
+		err, fatal := runAttempt(attemptCtx, o.attemptSetup, f)
+		backoff.AttemptFinished(b)
+		m.Attempted()
+		if held != nil {
+			held.release()
+			held = nil
+		}
+		if cancel != nil {
+			if err != nil && !o.attemptTimeoutNotRetried && ctx.Err() == nil && attemptCtx.Err() != nil && errors.Is(err, context.DeadlineExceeded) {
+				// The attempt's own timeout expired, not the caller's.
+				err = RetryableError(err)
+			}
+			cancel()
+		}
+		observeAttempt(o, attempt, err, fatal)
+		if fatal != nil {
+			if err != nil {
+				return fmt.Errorf("%w: %w", fatal, err)
+			}
+			return fatal
+		}
 		if err == nil {
+			if o.adaptiveTimeout != nil {
+				o.adaptiveTimeout.tracker.Observe(o.clock.Now().Sub(start))
+			}
+			if o.budget != nil {
+				o.budget.Succeeded()
+			}
+			backoff.RecordSuccess(b)
+			if o.resetOnSuccess {
+				b.Reset()
+			}
 			return nil
 		}
 
 		// Not retryable
-		var rerr *retryableError
-		if !errors.As(err, &rerr) {
-			return fmt.Errorf("%w: %w", ErrNonRetryable, err)
+		rerr, ok := asRetryable(err, o.shouldRetry)
+		if !ok {
+			if o.bareErrors {
+				return err
+			}
+			return nonRetryable(ctx, err)
+		}
+
+		lastErr = rerr.Unwrap()
+		if o.expectation != nil {
+			attemptErrs = append(attemptErrs, lastErr)
 		}
 
-		next, stop := b.Next()
-		if stop {
-			return fmt.Errorf("%w: %w", errBackoffSignaledToStop, rerr.Unwrap())
+		// A hinted retry doesn't consult b, so it neither advances b's
+		// schedule nor is stopped by it, but it counts toward b's retry limit.
+		if left, ok := retriesLeft(b); ok && left <= hinted && (hinted > 0 || rerr.hinted) {
+			if o.bareErrors {
+				return lastErr
+			}
+			return fmt.Errorf("%w: %w: %w", ErrBackoffSignaledToStop, ErrRetriesExhausted, lastErr)
+		}
+		var next time.Duration
+		if rerr.hinted {
+			next = rerr.after
+			hinted++
+		} else {
+			var stop bool
+			next, stop = b.Next()
+			if stop {
+				if o.bareErrors {
+					return lastErr
+				}
+				return stopped(b, lastErr)
+			}
+		}
+		if next < 0 {
+			// A buggy backoff must not reach the hooks or the timer.
+			next = 0
+		}
+
+		if o.byteBudget != nil && o.byteCost != nil {
+			cost := o.byteCost(attempt + 1)
+			window, ok := o.byteBudget.ChargeWindow(cost)
+			if !ok {
+				return fmt.Errorf("%w: %w", ErrByteBudgetExceeded, rerr.Unwrap())
+			}
+			reserved, reservedWindow = cost, window
+		}
+		if o.budget != nil && !o.budget.Withdraw() {
+			return budgetExhausted(lastErr)
 		}
 
 		// ctx.Done() has priority, so we test it alone first
@@ -87,23 +510,99 @@ func Do(ctx context.Context, b backoff.Backoff, f RetryFunc) error {
 		default:
 		}
 
-		t := time.NewTimer(next)
-		select {
-		case <-ctx.Done():
-			t.Stop()
-			return ctx.Err()
-		case <-t.C:
+		st := o.control.ctl().Retries.Load()
+		if st.Disabled {
+			return retriesDisabled(st, lastErr)
+		}
+
+		if o.expectation != nil {
+			if err := o.expectation.check(attempt, attemptErrs); err != nil {
+				return err
+			}
+		}
+
+		// A server's hint outranks the fast first retry.
+		fast := attempt == 1 && o.fastFirstRetry > 0 && !rerr.hinted && o.clock.Now().Sub(start) < o.fastFirstRetry
+		if o.onRetry != nil {
+			sleep := next
+			if fast {
+				sleep = 0
+			}
+			if err := callHook(ctx, func() { o.onRetry(attempt, lastErr, sleep) }); err != nil {
+				return hookFailed(err, o.recoverPanics)
+			}
+		}
+		if fast {
+			logRetry(ctx, o, m, attempt, lastErr, 0)
 			continue
 		}
+		logRetry(ctx, o, m, attempt, lastErr, next)
+		if o.metrics != nil {
+			o.metrics.ObserveSleep(o.name, next)
+		}
+
+		m.Start()
+		err = sleeper.Sleep(ctx, next, st.Changed)
+		m.Slept()
+		if err == clock.ErrInterrupted {
+			return retriesDisabled(o.control.ctl().Retries.Load(), lastErr)
+		}
+		if err != nil {
+			return err
+		}
 	}
 }
 
+// nonRetryable returns the error of a loop ended by err, which is not
+// retryable. If err is just f reporting that ctx is done, as functions
+// typically do when canceled mid-attempt, ctx.Err() is returned as is, as on
+// every other path where cancellation ends the loop; otherwise it wraps
+// ErrNonRetryable.
+func nonRetryable(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil && errors.Is(err, ctxErr) {
+		return ctxErr
+	}
+	return fmt.Errorf("%w: %w", ErrNonRetryable, err)
+}
+
+// retriesLeft returns how many more values the retry limit of b allows, and
+// whether it has one. See backoff.Budgeted.
+func retriesLeft(b backoff.Backoff) (uint64, bool) {
+	if bb, ok := b.(backoff.Budgeted); ok {
+		return bb.Remaining()
+	}
+	return 0, false
+}
+
+// stopped returns the error of a loop whose backoff b signaled to stop after
+// err, wrapping ErrRetriesExhausted if a retry limit caused the stop.
+func stopped(b backoff.Backoff, err error) error {
+	if backoff.Exhausted(b) {
+		return fmt.Errorf("%w: %w: %w", ErrBackoffSignaledToStop, ErrRetriesExhausted, err)
+	}
+	return fmt.Errorf("%w: %w", ErrBackoffSignaledToStop, err)
+}
+
+// build creates a backoff with factory, returning an error wrapping
+// backoff.ErrNilBackoff if there is no factory or it creates no backoff.
+func build(factory func() backoff.Backoff) (backoff.Backoff, error) {
+	if factory == nil {
+		return nil, fmt.Errorf("%w: no backoff factory", backoff.ErrNilBackoff)
+	}
+	b := factory()
+	if err := backoff.Validate(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
 // ConstantRetry is a wrapper around retry that uses a constant backoff. It will
 // retry the function f until it returns a non-retryable error, or the context is canceled.
+// DoConstant does the same with less overhead.
 func ConstantRetry(ctx context.Context, t time.Duration, f RetryFunc) error {
-	b, err := backoff.NewConstant(t)
+	b, err := helperBackoff(backoff.StrategyConstant, t)
 	if err != nil {
-		return fmt.Errorf("failed to create constant backoff: %w", err)
+		return err
 	}
 
 	return Do(ctx, b, f)
@@ -112,9 +611,9 @@ func ConstantRetry(ctx context.Context, t time.Duration, f RetryFunc) error {
 // ExponentialRetry is a wrapper around retry that uses an exponential backoff. It will
 // retry the function f until it returns a non-retryable error, or the context is canceled.
 func ExponentialRetry(ctx context.Context, base time.Duration, f RetryFunc) error {
-	b, err := backoff.NewExponential(base)
+	b, err := helperBackoff(backoff.StrategyExponential, base)
 	if err != nil {
-		return fmt.Errorf("failed to create exponential backoff: %w", err)
+		return err
 	}
 
 	return Do(ctx, b, f)
@@ -123,10 +622,82 @@ func ExponentialRetry(ctx context.Context, base time.Duration, f RetryFunc) erro
 // FibonacciRetry is a wrapper around retry that uses a FibonacciRetry backoff. It will
 // retry the function f until it returns a non-retryable error, or the context is canceled.
 func FibonacciRetry(ctx context.Context, base time.Duration, f RetryFunc) error {
-	b, err := backoff.NewFibonacci(base)
+	b, err := helperBackoff(backoff.StrategyFibonacci, base)
+	if err != nil {
+		return err
+	}
+	return Do(ctx, b, f)
+}
+
+// ConstantRetryN is like ConstantRetry, but gives up after n retries, returning
+// an error wrapping ErrBackoffSignaledToStop and the last retryable error. With
+// n == 0, f is attempted once and never retried.
+func ConstantRetryN(ctx context.Context, t time.Duration, n uint64, f RetryFunc) error {
+	b, err := helperBackoff(backoff.StrategyConstant, t)
+	if err != nil {
+		return err
+	}
+
+	return Do(ctx, backoff.WithMaxRetries(n, b), f)
+}
+
+// ExponentialRetryN is like ExponentialRetry, but gives up after n retries,
+// returning an error wrapping ErrBackoffSignaledToStop and the last retryable
+// error. With n == 0, f is attempted once and never retried.
+func ExponentialRetryN(ctx context.Context, base time.Duration, n uint64, f RetryFunc) error {
+	b, err := helperBackoff(backoff.StrategyExponential, base)
+	if err != nil {
+		return err
+	}
+
+	return Do(ctx, backoff.WithMaxRetries(n, b), f)
+}
+
+// FibonacciRetryN is like FibonacciRetry, but gives up after n retries,
+// returning an error wrapping ErrBackoffSignaledToStop and the last retryable
+// error. With n == 0, f is attempted once and never retried.
+func FibonacciRetryN(ctx context.Context, base time.Duration, n uint64, f RetryFunc) error {
+	b, err := helperBackoff(backoff.StrategyFibonacci, base)
+	if err != nil {
+		return err
+	}
+
+	return Do(ctx, backoff.WithMaxRetries(n, b), f)
+}
+
+// ConstantRetryCapped is like ConstantRetryN, but also checks t against cap,
+// like ExponentialRetryCapped, so all three strategies can be configured the
+// same way. Since a constant backoff never grows, cap has no other effect.
+func ConstantRetryCapped(ctx context.Context, t, cap time.Duration, maxRetries uint64, f RetryFunc) error {
+	b, err := cappedHelperBackoff(backoff.StrategyConstant, t, cap, maxRetries)
+	if err != nil {
+		return err
+	}
+
+	return Do(ctx, b, f)
+}
+
+// ExponentialRetryCapped is like ExponentialRetryN, but never sleeps longer
+// than cap between attempts: it composes NewExponential, WithCappedDuration and
+// WithMaxRetries. A base or cap that is not positive, or a cap less than base,
+// is returned as an error without calling f.
+func ExponentialRetryCapped(ctx context.Context, base, cap time.Duration, maxRetries uint64, f RetryFunc) error {
+	b, err := cappedHelperBackoff(backoff.StrategyExponential, base, cap, maxRetries)
 	if err != nil {
-		return fmt.Errorf("failed to create fibonacci backoff: %w", err)
+		return err
+	}
 
+	return Do(ctx, b, f)
+}
+
+// FibonacciRetryCapped is like FibonacciRetryN, but never sleeps longer than
+// cap between attempts, validating its arguments as ExponentialRetryCapped
+// does.
+func FibonacciRetryCapped(ctx context.Context, base, cap time.Duration, maxRetries uint64, f RetryFunc) error {
+	b, err := cappedHelperBackoff(backoff.StrategyFibonacci, base, cap, maxRetries)
+	if err != nil {
+		return err
 	}
+
 	return Do(ctx, b, f)
 }
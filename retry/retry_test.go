@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -21,6 +22,626 @@ func TestRetryableError(t *testing.T) {
 	}
 }
 
+func TestIsRetryable(t *testing.T) {
+	t.Parallel()
+
+	errFoo := fmt.Errorf("foo")
+
+	cases := []struct {
+		name string
+		err  error
+		exp  bool
+	}{
+		{
+			name: "nil",
+			err:  nil,
+			exp:  false,
+		},
+		{
+			name: "unmarked",
+			err:  errFoo,
+			exp:  false,
+		},
+		{
+			name: "retryable",
+			err:  RetryableError(errFoo),
+			exp:  true,
+		},
+		{
+			name: "retryable_after",
+			err:  RetryableAfterError(errFoo, time.Second),
+			exp:  true,
+		},
+		{
+			name: "wrapped",
+			err:  fmt.Errorf("fetching: %w", RetryableError(errFoo)),
+			exp:  true,
+		},
+		{
+			name: "double_wrapped",
+			err:  fmt.Errorf("handler: %w", fmt.Errorf("fetching: %w", RetryableError(errFoo))),
+			exp:  true,
+		},
+		{
+			name: "joined",
+			err:  errors.Join(errFoo, RetryableError(errFoo)),
+			exp:  true,
+		},
+		{
+			name: "non_retryable",
+			err:  NonRetryableError(RetryableError(errFoo)),
+			exp:  false,
+		},
+		{
+			name: "loop_result",
+			err:  fmt.Errorf("%w: %w", ErrBackoffSignaledToStop, errFoo),
+			exp:  false,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := IsRetryable(tc.err); got != tc.exp {
+				t.Errorf("expected %v to be %v", got, tc.exp)
+			}
+		})
+	}
+}
+
+func TestErrRetryable(t *testing.T) {
+	t.Parallel()
+
+	errFoo := fmt.Errorf("foo")
+
+	cases := []struct {
+		name string
+		err  error
+		exp  bool
+	}{
+		{
+			name: "unmarked",
+			err:  errFoo,
+			exp:  false,
+		},
+		{
+			name: "retryable",
+			err:  RetryableError(errFoo),
+			exp:  true,
+		},
+		{
+			name: "wrapped",
+			err:  fmt.Errorf("fetching: %w", RetryableError(errFoo)),
+			exp:  true,
+		},
+		{
+			name: "double_wrapped",
+			err:  fmt.Errorf("handler: %w", fmt.Errorf("fetching: %w", RetryableAfterError(errFoo, 0))),
+			exp:  true,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := errors.Is(tc.err, ErrRetryable); got != tc.exp {
+				t.Errorf("expected %v to be %v", got, tc.exp)
+			}
+			if tc.exp && !errors.Is(tc.err, errFoo) {
+				t.Errorf("expected %v to be %v", tc.err, errFoo)
+			}
+		})
+	}
+
+	t.Run("switch", func(t *testing.T) {
+		t.Parallel()
+
+		var got string
+		switch err := fmt.Errorf("fetching: %w", RetryableError(errFoo)); {
+		case errors.Is(err, ErrNonRetryable):
+			got = "non_retryable"
+		case errors.Is(err, ErrRetryable):
+			got = "retryable"
+		default:
+			got = "other"
+		}
+		if got != "retryable" {
+			t.Errorf("expected %q to be %q", got, "retryable")
+		}
+	})
+
+	t.Run("not_returned_by_loops", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		err = Do(context.Background(), backoff.WithMaxRetries(1, b), func(_ context.Context) error {
+			return RetryableError(errFoo)
+		})
+		if errors.Is(err, ErrRetryable) {
+			t.Errorf("expected %v not to be %v", err, ErrRetryable)
+		}
+	})
+}
+
+func TestNonRetryableError(t *testing.T) {
+	t.Parallel()
+
+	newBackoff := func(t *testing.T) backoff.Backoff {
+		t.Helper()
+
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		return b
+	}
+
+	errFoo := fmt.Errorf("foo")
+	err := NonRetryableError(errFoo)
+	if got, want := err.Error(), "non-retryable: foo"; got != want {
+		t.Errorf("expected %q to be %q", got, want)
+	}
+	if !errors.Is(err, errFoo) {
+		t.Errorf("expected %v to be %v", err, errFoo)
+	}
+	if err := NonRetryableError(nil); err != nil {
+		t.Errorf("expected %v to be nil", err)
+	}
+
+	// The marker wins over RetryableError, in either order.
+	for _, err := range []error{RetryableError(NonRetryableError(errFoo)), NonRetryableError(RetryableError(errFoo))} {
+		var calls int
+		err := Do(context.Background(), backoff.WithMaxRetries(3, newBackoff(t)), func(_ context.Context) error {
+			calls++
+			return err
+		})
+		if !errors.Is(err, ErrNonRetryable) || !errors.Is(err, errFoo) {
+			t.Errorf("expected %v to be %v and %v", err, ErrNonRetryable, errFoo)
+		}
+		if calls != 1 {
+			t.Errorf("expected %d to be %d", calls, 1)
+		}
+	}
+}
+
+func TestDoAllRetryable(t *testing.T) {
+	t.Parallel()
+
+	newBackoff := func(t *testing.T) backoff.Backoff {
+		t.Helper()
+
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		return b
+	}
+
+	errFoo := fmt.Errorf("foo")
+
+	cases := []struct {
+		name  string
+		err   error
+		calls int
+		is    []error
+	}{
+		{name: "unmarked", err: errFoo, calls: 4, is: []error{ErrBackoffSignaledToStop, errFoo}},
+		{name: "retryable", err: RetryableError(errFoo), calls: 4, is: []error{ErrBackoffSignaledToStop, errFoo}},
+		{name: "non_retryable", err: NonRetryableError(errFoo), calls: 1, is: []error{ErrNonRetryable, errFoo}},
+		{name: "wrapped_non_retryable", err: fmt.Errorf("bar: %w", NonRetryableError(errFoo)), calls: 1, is: []error{ErrNonRetryable, errFoo}},
+		{name: "canceled", err: context.Canceled, calls: 1, is: []error{ErrNonRetryable, context.Canceled}},
+		{name: "deadline_exceeded", err: fmt.Errorf("dial: %w", context.DeadlineExceeded), calls: 1, is: []error{ErrNonRetryable, context.DeadlineExceeded}},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls int
+			err := DoAllRetryable(context.Background(), backoff.WithMaxRetries(3, newBackoff(t)), func(_ context.Context) error {
+				calls++
+				return tc.err
+			})
+			for _, want := range tc.is {
+				if !errors.Is(err, want) {
+					t.Errorf("expected %v to be %v", err, want)
+				}
+			}
+			if calls != tc.calls {
+				t.Errorf("expected %d to be %d", calls, tc.calls)
+			}
+		})
+	}
+
+	t.Run("succeeds", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int
+		if err := DoAllRetryable(context.Background(), newBackoff(t), func(_ context.Context) error {
+			calls++
+			if calls < 3 {
+				return errFoo
+			}
+			return nil
+		}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if calls != 3 {
+			t.Errorf("expected %d to be %d", calls, 3)
+		}
+	})
+
+	t.Run("stale_while_revalidate", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int
+		v, stale, err := DoStaleWhileRevalidate(context.Background(), func() backoff.Backoff { return newBackoff(t) }, newMapCache(), "k", func(_ context.Context) (string, error) {
+			calls++
+			if calls < 2 {
+				return "", errFoo
+			}
+			return "fresh", nil
+		}, WithRetryByDefault())
+		if err != nil || stale || v != "fresh" {
+			t.Errorf("expected %q, false, nil, got %q, %t, %v", "fresh", v, stale, err)
+		}
+	})
+}
+
+func TestDoWithRetryCheck(t *testing.T) {
+	t.Parallel()
+
+	newBackoff := func(t *testing.T) backoff.Backoff {
+		t.Helper()
+
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		return backoff.WithMaxRetries(3, b)
+	}
+
+	errFoo := fmt.Errorf("foo")
+	isEOF := func(err error) bool {
+		return errors.Is(err, io.EOF)
+	}
+
+	cases := []struct {
+		name        string
+		shouldRetry func(err error) bool
+		err         error
+		calls       int
+		is          []error
+	}{
+		{name: "eof", shouldRetry: isEOF, err: io.EOF, calls: 4, is: []error{ErrBackoffSignaledToStop, io.EOF}},
+		{name: "wrapped_eof", shouldRetry: isEOF, err: fmt.Errorf("read: %w", io.EOF), calls: 4, is: []error{ErrBackoffSignaledToStop, io.EOF}},
+		{name: "other", shouldRetry: isEOF, err: errFoo, calls: 1, is: []error{ErrNonRetryable, errFoo}},
+		{name: "retryable_overrides", shouldRetry: isEOF, err: RetryableError(errFoo), calls: 4, is: []error{ErrBackoffSignaledToStop, errFoo}},
+		{name: "non_retryable_overrides", shouldRetry: isEOF, err: NonRetryableError(io.EOF), calls: 1, is: []error{ErrNonRetryable, io.EOF}},
+		{name: "nil_eof", err: io.EOF, calls: 1, is: []error{ErrNonRetryable, io.EOF}},
+		{name: "nil_retryable", err: RetryableError(errFoo), calls: 4, is: []error{ErrBackoffSignaledToStop, errFoo}},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls int
+			err := DoWithRetryCheck(context.Background(), newBackoff(t), func(_ context.Context) error {
+				calls++
+				return tc.err
+			}, tc.shouldRetry)
+			for _, want := range tc.is {
+				if !errors.Is(err, want) {
+					t.Errorf("expected %v to be %v", err, want)
+				}
+			}
+			if calls != tc.calls {
+				t.Errorf("expected %d to be %d", calls, tc.calls)
+			}
+		})
+	}
+
+	t.Run("last_option_wins", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int
+		err := DoWithOptions(context.Background(), newBackoff(t), func(_ context.Context) error {
+			calls++
+			return errFoo
+		}, WithRetryByDefault(), WithShouldRetry(isEOF))
+		if !errors.Is(err, ErrNonRetryable) {
+			t.Errorf("expected %v to be %v", err, ErrNonRetryable)
+		}
+		if calls != 1 {
+			t.Errorf("expected %d to be %d", calls, 1)
+		}
+	})
+}
+
+func TestDoRaw(t *testing.T) {
+	t.Parallel()
+
+	errSentinel := fmt.Errorf("sentinel")
+
+	cases := []struct {
+		name string
+		err  error
+		// exp is the error the default mode wraps, and the one DoRaw returns.
+		exp   error
+		is    error
+		calls int
+	}{
+		{name: "non_retryable", err: errSentinel, exp: errSentinel, is: ErrNonRetryable, calls: 1},
+		{name: "exhausted", err: RetryableError(errSentinel), exp: errSentinel, is: ErrBackoffSignaledToStop, calls: 4},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			b, err := backoff.NewConstant(1 * time.Nanosecond)
+			if err != nil {
+				t.Fatalf("failed to create constant backoff: %v", err)
+			}
+
+			var calls int
+			f := func(_ context.Context) error {
+				calls++
+				return tc.err
+			}
+
+			err = DoRaw(context.Background(), backoff.WithMaxRetries(3, b), f)
+			if err != tc.exp {
+				t.Errorf("expected %q to be %q", err, tc.exp)
+			}
+			if calls != tc.calls {
+				t.Errorf("expected %d to be %d", calls, tc.calls)
+			}
+
+			b.Reset()
+			err = Do(context.Background(), backoff.WithMaxRetries(3, b), f)
+			if !errors.Is(err, tc.is) || !errors.Is(err, tc.exp) {
+				t.Errorf("expected %q to be %q and %q", err, tc.is, tc.exp)
+			}
+			if err == tc.exp {
+				t.Errorf("expected %q to be wrapped", err)
+			}
+		})
+	}
+
+	t.Run("context_canceled", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		err = DoRaw(ctx, b, func(_ context.Context) error {
+			cancel()
+			return RetryableError(errSentinel)
+		})
+		if err != context.Canceled {
+			t.Errorf("expected %q to be %q", err, context.Canceled)
+		}
+	})
+}
+
+func TestRetryableAfterError(t *testing.T) {
+	t.Parallel()
+
+	newBackoff := func(t *testing.T) backoff.Backoff {
+		t.Helper()
+
+		b, err := backoff.NewConstant(1 * time.Second)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		return b
+	}
+
+	t.Run("nil", func(t *testing.T) {
+		t.Parallel()
+
+		if err := RetryableAfterError(nil, time.Second); err != nil {
+			t.Errorf("expected %v to be nil", err)
+		}
+	})
+
+	t.Run("hint_replaces_sleep_uncapped", func(t *testing.T) {
+		t.Parallel()
+
+		errFoo := fmt.Errorf("foo")
+		c := newFakeClock()
+		b := backoff.WithCappedDuration(2*time.Second, newBackoff(t))
+		err := DoWithOptions(context.Background(), b, func(ctx context.Context) error {
+			switch AttemptFromContext(ctx) {
+			case 1:
+				return RetryableAfterError(errFoo, 10*time.Second)
+			case 2:
+				return RetryableAfterError(errFoo, -time.Second)
+			case 3:
+				return RetryableError(errFoo)
+			default:
+				return nil
+			}
+		}, WithClock(c))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if got, want := c.Sleeps(), []time.Duration{10 * time.Second, 0, 1 * time.Second}; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	})
+
+	t.Run("counts_toward_max_retries", func(t *testing.T) {
+		t.Parallel()
+
+		errFoo := fmt.Errorf("foo")
+		c := newFakeClock()
+		var calls int
+		err := DoWithOptions(context.Background(), backoff.WithMaxRetries(2, newBackoff(t)), func(_ context.Context) error {
+			calls++
+			return RetryableAfterError(errFoo, 5*time.Second)
+		}, WithClock(c))
+		if !errors.Is(err, ErrBackoffSignaledToStop) || !errors.Is(err, ErrRetriesExhausted) || !errors.Is(err, errFoo) {
+			t.Errorf("expected %v to be %v, %v and %v", err, ErrBackoffSignaledToStop, ErrRetriesExhausted, errFoo)
+		}
+		if calls != 3 {
+			t.Errorf("expected %d to be %d", calls, 3)
+		}
+		if got, want := c.Sleeps(), []time.Duration{5 * time.Second, 5 * time.Second}; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	})
+
+	t.Run("hinted_then_plain_share_max_retries", func(t *testing.T) {
+		t.Parallel()
+
+		errFoo := fmt.Errorf("foo")
+		c := newFakeClock()
+		var calls int
+		err := DoWithOptions(context.Background(), backoff.WithMaxRetries(2, newBackoff(t)), func(_ context.Context) error {
+			calls++
+			if calls == 1 {
+				return RetryableAfterError(errFoo, 5*time.Second)
+			}
+			return RetryableError(errFoo)
+		}, WithClock(c))
+		if !errors.Is(err, ErrRetriesExhausted) || !errors.Is(err, errFoo) {
+			t.Errorf("expected %v to be %v and %v", err, ErrRetriesExhausted, errFoo)
+		}
+		if calls != 3 {
+			t.Errorf("expected %d to be %d", calls, 3)
+		}
+		if got, want := c.Sleeps(), []time.Duration{5 * time.Second, 1 * time.Second}; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	})
+
+	t.Run("does_not_advance_schedule", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewExponential(1 * time.Second)
+		if err != nil {
+			t.Fatalf("failed to create exponential backoff: %v", err)
+		}
+		c := newFakeClock()
+		err = DoWithOptions(context.Background(), b, func(ctx context.Context) error {
+			switch AttemptFromContext(ctx) {
+			case 1:
+				return RetryableAfterError(fmt.Errorf("foo"), 30*time.Second)
+			case 2, 3:
+				return RetryableError(fmt.Errorf("foo"))
+			default:
+				return nil
+			}
+		}, WithClock(c))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if got, want := c.Sleeps(), []time.Duration{30 * time.Second, 1 * time.Second, 2 * time.Second}; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	})
+
+	t.Run("not_charged_to_cumulative_sleep", func(t *testing.T) {
+		t.Parallel()
+
+		c := newFakeClock()
+		b := backoff.WithMaxCumulativeSleep(1*time.Second, newBackoff(t))
+		err := DoWithOptions(context.Background(), b, func(ctx context.Context) error {
+			switch AttemptFromContext(ctx) {
+			case 1:
+				return RetryableAfterError(fmt.Errorf("foo"), 10*time.Second)
+			case 2:
+				return RetryableError(fmt.Errorf("foo"))
+			default:
+				return nil
+			}
+		}, WithClock(c))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if got, want := c.Sleeps(), []time.Duration{10 * time.Second, 1 * time.Second}; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	})
+
+	t.Run("not_stopped_by_backoff", func(t *testing.T) {
+		t.Parallel()
+
+		c := newFakeClock()
+		stop := backoff.BackoffFunc(func() (time.Duration, bool) { return 0, true })
+		err := DoWithOptions(context.Background(), stop, func(ctx context.Context) error {
+			if AttemptFromContext(ctx) == 1 {
+				return RetryableAfterError(fmt.Errorf("foo"), 3*time.Second)
+			}
+			return nil
+		}, WithClock(c))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if got, want := c.Sleeps(), []time.Duration{3 * time.Second}; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	})
+
+	t.Run("outranks_fast_first_retry", func(t *testing.T) {
+		t.Parallel()
+
+		c := newFakeClock()
+		err := DoWithOptions(context.Background(), newBackoff(t), func(ctx context.Context) error {
+			if AttemptFromContext(ctx) == 1 {
+				return RetryableAfterError(fmt.Errorf("foo"), 3*time.Second)
+			}
+			return nil
+		}, WithClock(c), WithFastFirstRetryThreshold(time.Hour))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if got, want := c.Sleeps(), []time.Duration{3 * time.Second}; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	})
+
+	t.Run("canceled_while_sleeping", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		err := Do(ctx, newBackoff(t), func(_ context.Context) error {
+			return RetryableAfterError(fmt.Errorf("foo"), time.Hour)
+		})
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected %v to be %v", err, context.DeadlineExceeded)
+		}
+		if elapsed := time.Since(start); elapsed > time.Minute {
+			t.Errorf("expected to stop promptly, took %v", elapsed)
+		}
+	})
+}
+
 func TestDo(t *testing.T) {
 	t.Parallel()
 
@@ -146,8 +767,8 @@ func TestDo(t *testing.T) {
 		err := Do(context.Background(), maxRetryBackoff, func(_ context.Context) error {
 			return RetryableError(errUnderlyingRetryable)
 		})
-		if !errors.Is(err, errBackoffSignaledToStop) {
-			t.Errorf("expected %q to be %q", err, errBackoffSignaledToStop)
+		if !errors.Is(err, ErrBackoffSignaledToStop) {
+			t.Errorf("expected %q to be %q", err, ErrBackoffSignaledToStop)
 		}
 		if !errors.Is(err, errUnderlyingRetryable) {
 			t.Errorf("expected %q to be %q", err, errUnderlyingRetryable)
@@ -155,6 +776,354 @@ func TestDo(t *testing.T) {
 	})
 }
 
+func TestDo_slowAttempts(t *testing.T) {
+	t.Parallel()
+
+	// Each attempt takes 25s and each sleep 4s, within a 30s budget.
+	cases := []struct {
+		name     string
+		decorate func(c Clock, b backoff.Backoff) backoff.Backoff
+		attempts int
+	}{
+		{
+			name: "max_duration",
+			decorate: func(c Clock, b backoff.Backoff) backoff.Backoff {
+				return backoff.WithMaxDurationClock(30*time.Second, c, b)
+			},
+			attempts: 3,
+		},
+		{
+			name: "max_sleep_window",
+			decorate: func(c Clock, b backoff.Backoff) backoff.Backoff {
+				return backoff.WithMaxSleepWindowClock(30*time.Second, c, b)
+			},
+			attempts: 9,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			b, err := backoff.NewConstant(4 * time.Second)
+			if err != nil {
+				t.Fatalf("failed to create constant backoff: %v", err)
+			}
+
+			c := newFakeClock()
+			var attempts int
+			err = DoWithOptions(context.Background(), tc.decorate(c, b), func(_ context.Context) error {
+				attempts++
+				c.Advance(25 * time.Second)
+				return RetryableError(fmt.Errorf("slow"))
+			}, WithClock(c))
+			if !errors.Is(err, ErrBackoffSignaledToStop) {
+				t.Fatalf("expected %v to be %v", err, ErrBackoffSignaledToStop)
+			}
+			if attempts != tc.attempts {
+				t.Errorf("expected %d to be %d", attempts, tc.attempts)
+			}
+		})
+	}
+}
+
+func TestDo_negativeBackoff(t *testing.T) {
+	t.Parallel()
+
+	// A buggy backoff that asks for negative sleeps.
+	negative := backoff.BackoffFunc(func() (time.Duration, bool) {
+		return -1 * time.Second, false
+	})
+
+	t.Run("clamped", func(t *testing.T) {
+		t.Parallel()
+
+		c := newFakeClock()
+		var hooked []time.Duration
+		err := DoWithOptions(context.Background(), backoff.WithMaxRetries(3, negative), func(_ context.Context) error {
+			return RetryableError(fmt.Errorf("flaky"))
+		}, WithClock(c), WithOnRetry(func(_ uint64, _ error, next time.Duration) {
+			hooked = append(hooked, next)
+		}))
+		if !errors.Is(err, ErrBackoffSignaledToStop) {
+			t.Errorf("expected %q to be %q", err, ErrBackoffSignaledToStop)
+		}
+
+		exp := []time.Duration{0, 0, 0}
+		if got := c.Sleeps(); !reflect.DeepEqual(got, exp) {
+			t.Errorf("expected sleeps %v to be %v", got, exp)
+		}
+		if !reflect.DeepEqual(hooked, exp) {
+			t.Errorf("expected hooked %v to be %v", hooked, exp)
+		}
+	})
+
+	t.Run("validated", func(t *testing.T) {
+		t.Parallel()
+
+		// The backoff asks for ever longer sleeps, first negative, then
+		// absurd; WithValidationMax paces the first and stops at the last.
+		vals := []time.Duration{-1 * time.Second, 1 * time.Second, 1000 * time.Hour}
+		var i int
+		b := backoff.WithValidationMax(time.Hour, backoff.StopOnInvalid, backoff.BackoffFunc(func() (time.Duration, bool) {
+			val := vals[i]
+			i++
+			return val, false
+		}))
+
+		c := newFakeClock()
+		var attempts int
+		err := DoWithOptions(context.Background(), b, func(_ context.Context) error {
+			attempts++
+			return RetryableError(fmt.Errorf("flaky"))
+		}, WithClock(c))
+		if !errors.Is(err, ErrBackoffSignaledToStop) {
+			t.Errorf("expected %q to be %q", err, ErrBackoffSignaledToStop)
+		}
+		if attempts != 3 {
+			t.Errorf("expected %d to be 3", attempts)
+		}
+		exp := []time.Duration{0, 1 * time.Second}
+		if got := c.Sleeps(); !reflect.DeepEqual(got, exp) {
+			t.Errorf("expected sleeps %v to be %v", got, exp)
+		}
+	})
+}
+
+func TestDo_retryLimits(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name  string
+		limit func(b backoff.Backoff) backoff.Backoff
+		calls int
+	}{
+		{
+			name:  "max_retries_0",
+			limit: func(b backoff.Backoff) backoff.Backoff { return backoff.WithMaxRetries(0, b) },
+			calls: 1,
+		},
+		{
+			name:  "max_retries_3",
+			limit: func(b backoff.Backoff) backoff.Backoff { return backoff.WithMaxRetries(3, b) },
+			calls: 4,
+		},
+		{
+			name:  "max_attempts_0",
+			limit: func(b backoff.Backoff) backoff.Backoff { return backoff.WithMaxAttempts(0, b) },
+			calls: 1,
+		},
+		{
+			name:  "max_attempts_1",
+			limit: func(b backoff.Backoff) backoff.Backoff { return backoff.WithMaxAttempts(1, b) },
+			calls: 1,
+		},
+		{
+			name:  "max_attempts_3",
+			limit: func(b backoff.Backoff) backoff.Backoff { return backoff.WithMaxAttempts(3, b) },
+			calls: 3,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			b, err := backoff.NewConstant(1 * time.Nanosecond)
+			if err != nil {
+				t.Fatalf("failed to create constant backoff: %v", err)
+			}
+
+			errFoo := fmt.Errorf("foo")
+			var calls int
+			err = Do(context.Background(), tc.limit(b), func(_ context.Context) error {
+				calls++
+				return RetryableError(errFoo)
+			})
+			if calls != tc.calls {
+				t.Errorf("expected %d to be %d", calls, tc.calls)
+			}
+			for _, want := range []error{ErrBackoffSignaledToStop, ErrRetriesExhausted, errFoo} {
+				if !errors.Is(err, want) {
+					t.Errorf("expected %v to be %v", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDo_retriesExhausted(t *testing.T) {
+	t.Parallel()
+
+	errFoo := fmt.Errorf("foo")
+	f := func(_ context.Context) error {
+		return RetryableError(errFoo)
+	}
+
+	t.Run("limit", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		err = Do(context.Background(), backoff.WithMaxRetries(2, b), f)
+		for _, want := range []error{ErrBackoffSignaledToStop, ErrRetriesExhausted, errFoo} {
+			if !errors.Is(err, want) {
+				t.Errorf("expected %v to be %v", err, want)
+			}
+		}
+		if got := Classify(err); got != ErrBackoffSignaledToStop {
+			t.Errorf("expected %v to be %v", got, ErrBackoffSignaledToStop)
+		}
+	})
+
+	t.Run("chain_stage_limit", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		err = Do(context.Background(), backoff.Chain(
+			backoff.Stage{Backoff: b, Attempts: 1},
+			backoff.Stage{Backoff: backoff.WithMaxRetries(2, b)},
+		), f)
+		for _, want := range []error{ErrBackoffSignaledToStop, ErrRetriesExhausted, errFoo} {
+			if !errors.Is(err, want) {
+				t.Errorf("expected %v to be %v", err, want)
+			}
+		}
+	})
+
+	t.Run("inner_stop", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int
+		inner := backoff.BackoffFunc(func() (time.Duration, bool) {
+			calls++
+			return 1 * time.Nanosecond, calls > 2
+		})
+
+		err := Do(context.Background(), backoff.WithMaxRetries(5, inner), f)
+		if !errors.Is(err, ErrBackoffSignaledToStop) || !errors.Is(err, errFoo) {
+			t.Errorf("expected %v to be %v and %v", err, ErrBackoffSignaledToStop, errFoo)
+		}
+		if errors.Is(err, ErrRetriesExhausted) {
+			t.Errorf("expected %v not to be %v", err, ErrRetriesExhausted)
+		}
+	})
+}
+
+func TestDo_recordsSuccess(t *testing.T) {
+	t.Parallel()
+
+	b, err := backoff.NewConstant(1 * time.Nanosecond)
+	if err != nil {
+		t.Fatalf("failed to create constant backoff: %v", err)
+	}
+	breaker := backoff.WithCircuitBreaker(3, time.Hour, b)
+
+	// Each loop fails twice before succeeding. Without the successes being
+	// recorded, the failures would add up and open the circuit.
+	for i := 0; i < 3; i++ {
+		var calls int
+		if err := Do(context.Background(), breaker, func(_ context.Context) error {
+			calls++
+			if calls <= 2 {
+				return RetryableError(fmt.Errorf("oops"))
+			}
+			return nil
+		}); err != nil {
+			t.Fatalf("loop %d: unexpected error: %v", i, err)
+		}
+	}
+}
+
+func TestDoValue(t *testing.T) {
+	t.Parallel()
+
+	t.Run("success_on_nth_attempt", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		var i int
+		got, err := DoValue(context.Background(), b, func(_ context.Context) (string, error) {
+			i++
+			if i < 3 {
+				return "partial", RetryableError(fmt.Errorf("oops"))
+			}
+			return "done", nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if want := "done"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+		if want := 3; i != want {
+			t.Errorf("expected %v to be %v", i, want)
+		}
+	})
+
+	t.Run("exit_on_non_retryable", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		var i int
+		got, err := DoValue(context.Background(), b, func(_ context.Context) (int, error) {
+			i++
+			return 42, io.EOF // not retryable
+		})
+		if !errors.Is(err, ErrNonRetryable) || !errors.Is(err, io.EOF) {
+			t.Errorf("expected %q to wrap %q and %q", err, ErrNonRetryable, io.EOF)
+		}
+		if got != 0 {
+			t.Errorf("expected %v to be the zero value", got)
+		}
+		if want := 1; i != want {
+			t.Errorf("expected %v to be %v", i, want)
+		}
+	})
+
+	t.Run("cancel_mid_sleep", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewConstant(1 * time.Hour)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		got, err := DoValue(ctx, b, func(_ context.Context) (int, error) {
+			time.AfterFunc(10*time.Millisecond, cancel)
+			return 42, RetryableError(fmt.Errorf("oops"))
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected %q to be %q", err, context.Canceled)
+		}
+		if got != 0 {
+			t.Errorf("expected %v to be the zero value", got)
+		}
+	})
+}
+
 func TestCancel(t *testing.T) {
 	for i := 0; i < 100000; i++ {
 		ctx, cancel := context.WithCancel(context.Background())
@@ -192,6 +1161,125 @@ func TestCancel(t *testing.T) {
 	}
 }
 
+func TestCancel_duringAttempt(t *testing.T) {
+	t.Parallel()
+
+	newBackoff := func(t *testing.T) backoff.Backoff {
+		t.Helper()
+
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		return b
+	}
+
+	// blockUntilDone returns the error of ctx, optionally wrapped, once ctx is
+	// done, canceling it with cancel if it is set.
+	blockUntilDone := func(cancel context.CancelFunc, wrap bool) RetryFunc {
+		return func(ctx context.Context) error {
+			if cancel != nil {
+				cancel()
+			}
+			<-ctx.Done()
+			if wrap {
+				return fmt.Errorf("query: %w", ctx.Err())
+			}
+			return ctx.Err()
+		}
+	}
+
+	t.Run("canceled", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		if err := Do(ctx, newBackoff(t), blockUntilDone(cancel, false)); err != context.Canceled {
+			t.Errorf("expected %v to be %v", err, context.Canceled)
+		}
+	})
+
+	t.Run("canceled_wrapped", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		if err := Do(ctx, newBackoff(t), blockUntilDone(cancel, true)); err != context.Canceled {
+			t.Errorf("expected %v to be %v", err, context.Canceled)
+		}
+	})
+
+	t.Run("deadline_exceeded", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		if err := Do(ctx, newBackoff(t), blockUntilDone(nil, false)); err != context.DeadlineExceeded {
+			t.Errorf("expected %v to be %v", err, context.DeadlineExceeded)
+		}
+	})
+
+	t.Run("do_constant", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		if err := DoConstant(ctx, 1*time.Nanosecond, blockUntilDone(cancel, false)); err != context.Canceled {
+			t.Errorf("expected %v to be %v", err, context.Canceled)
+		}
+	})
+
+	// A context error that isn't ctx's own is still an ordinary error.
+	t.Run("other_context", func(t *testing.T) {
+		t.Parallel()
+
+		err := Do(context.Background(), newBackoff(t), func(_ context.Context) error {
+			return context.Canceled
+		})
+		if !errors.Is(err, ErrNonRetryable) || !errors.Is(err, context.Canceled) {
+			t.Errorf("expected %v to be %v and %v", err, ErrNonRetryable, context.Canceled)
+		}
+	})
+}
+
+func TestCancel_duringSleep(t *testing.T) {
+	t.Parallel()
+
+	// Several short sleeps rearm the loop's timer before a long one, which
+	// canceling ctx must interrupt.
+	var n int
+	b := backoff.BackoffFunc(func() (time.Duration, bool) {
+		n++
+		if n <= 5 {
+			return 1 * time.Nanosecond, false
+		}
+		return time.Hour, false
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	var calls int
+	start := time.Now()
+	err := Do(ctx, b, func(_ context.Context) error {
+		calls++
+		return RetryableError(errors.New("nope"))
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v to be %v", err, context.DeadlineExceeded)
+	}
+	if calls != 6 {
+		t.Errorf("expected %d to be %d", calls, 6)
+	}
+	if elapsed := time.Since(start); elapsed > time.Minute {
+		t.Errorf("expected to stop promptly, took %v", elapsed)
+	}
+}
+
 func TestConstantRetry(t *testing.T) {
 	t.Parallel()
 
@@ -407,3 +1495,217 @@ func TestFibonacciRetry(t *testing.T) {
 		}
 	})
 }
+
+func TestRetryN(t *testing.T) {
+	t.Parallel()
+
+	helpers := []struct {
+		name string
+		fn   func(ctx context.Context, base time.Duration, n uint64, f RetryFunc) error
+	}{
+		{name: "constant", fn: ConstantRetryN},
+		{name: "exponential", fn: ExponentialRetryN},
+		{name: "fibonacci", fn: FibonacciRetryN},
+	}
+
+	cases := []struct {
+		name      string
+		base      time.Duration
+		n         uint64
+		failures  int
+		expCalls  int
+		expErr    error
+		expConfig bool
+	}{
+		{
+			name:     "success_before_exhaustion",
+			base:     1 * time.Nanosecond,
+			n:        5,
+			failures: 2,
+			expCalls: 3,
+		},
+		{
+			name:     "exhaustion",
+			base:     1 * time.Nanosecond,
+			n:        3,
+			failures: 100,
+			expCalls: 4,
+			expErr:   ErrBackoffSignaledToStop,
+		},
+		{
+			name:     "zero_retries",
+			base:     1 * time.Nanosecond,
+			n:        0,
+			failures: 100,
+			expCalls: 1,
+			expErr:   ErrBackoffSignaledToStop,
+		},
+		{
+			name:      "bad_base",
+			base:      0,
+			n:         3,
+			expCalls:  0,
+			expConfig: true,
+		},
+	}
+
+	for _, h := range helpers {
+		for _, tc := range cases {
+			h, tc := h, tc
+
+			t.Run(h.name+"/"+tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				errFlaky := fmt.Errorf("flaky")
+				calls := 0
+				err := h.fn(context.Background(), tc.base, tc.n, func(_ context.Context) error {
+					calls++
+					if calls <= tc.failures {
+						return RetryableError(errFlaky)
+					}
+					return nil
+				})
+
+				if calls != tc.expCalls {
+					t.Errorf("expected %d to be %d", calls, tc.expCalls)
+				}
+
+				switch {
+				case tc.expConfig:
+					if err == nil || !strings.Contains(err.Error(), "failed to create") {
+						t.Errorf("expected a construction error, got %v", err)
+					}
+				case tc.expErr != nil:
+					if !errors.Is(err, tc.expErr) {
+						t.Errorf("expected %q to be %q", err, tc.expErr)
+					}
+					if !errors.Is(err, errFlaky) {
+						t.Errorf("expected %q to be %q", err, errFlaky)
+					}
+				case err != nil:
+					t.Errorf("expected no error, got %v", err)
+				}
+			})
+		}
+	}
+}
+
+func TestRetryCapped(t *testing.T) {
+	t.Parallel()
+
+	helpers := []struct {
+		name string
+		fn   func(ctx context.Context, base, cap time.Duration, maxRetries uint64, f RetryFunc) error
+	}{
+		{name: "constant", fn: ConstantRetryCapped},
+		{name: "exponential", fn: ExponentialRetryCapped},
+		{name: "fibonacci", fn: FibonacciRetryCapped},
+	}
+
+	cases := []struct {
+		name      string
+		base      time.Duration
+		cap       time.Duration
+		n         uint64
+		failures  int
+		expCalls  int
+		expErr    error
+		expConfig bool
+	}{
+		{
+			name:     "success_before_exhaustion",
+			base:     1 * time.Nanosecond,
+			cap:      1 * time.Nanosecond,
+			n:        5,
+			failures: 2,
+			expCalls: 3,
+		},
+		{
+			name:     "exhaustion",
+			base:     1 * time.Nanosecond,
+			cap:      1 * time.Nanosecond,
+			n:        3,
+			failures: 100,
+			expCalls: 4,
+			expErr:   ErrRetriesExhausted,
+		},
+		{
+			name:      "bad_base",
+			base:      0,
+			cap:       1 * time.Second,
+			n:         3,
+			expConfig: true,
+		},
+		{
+			name:      "bad_cap",
+			base:      1 * time.Nanosecond,
+			cap:       0,
+			n:         3,
+			expConfig: true,
+		},
+		{
+			name:      "cap_below_base",
+			base:      2 * time.Second,
+			cap:       1 * time.Second,
+			n:         3,
+			expConfig: true,
+		},
+	}
+
+	for _, h := range helpers {
+		for _, tc := range cases {
+			h, tc := h, tc
+
+			t.Run(h.name+"/"+tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				calls := 0
+				err := h.fn(context.Background(), tc.base, tc.cap, tc.n, func(_ context.Context) error {
+					calls++
+					if calls <= tc.failures {
+						return RetryableError(fmt.Errorf("flaky"))
+					}
+					return nil
+				})
+
+				if calls != tc.expCalls {
+					t.Errorf("expected %d to be %d", calls, tc.expCalls)
+				}
+
+				switch {
+				case tc.expConfig:
+					if err == nil || errors.Is(err, ErrBackoffSignaledToStop) {
+						t.Errorf("expected a construction error, got %v", err)
+					}
+				case tc.expErr != nil:
+					if !errors.Is(err, tc.expErr) {
+						t.Errorf("expected %q to be %q", err, tc.expErr)
+					}
+				case err != nil:
+					t.Errorf("expected no error, got %v", err)
+				}
+			})
+		}
+	}
+
+	// Uncapped, 8 retries from 1ms sleep 255ms in total with the exponential
+	// backoff and 54ms with the Fibonacci one; capped at 1ms, they sleep 8ms.
+	for _, h := range helpers {
+		h := h
+
+		t.Run(h.name+"/cap_observed", func(t *testing.T) {
+			t.Parallel()
+
+			start := time.Now()
+			err := h.fn(context.Background(), 1*time.Millisecond, 1*time.Millisecond, 8, func(_ context.Context) error {
+				return RetryableError(fmt.Errorf("flaky"))
+			})
+			if !errors.Is(err, ErrRetriesExhausted) {
+				t.Errorf("expected %q to be %q", err, ErrRetriesExhausted)
+			}
+			if elapsed := time.Since(start); elapsed > 40*time.Millisecond {
+				t.Errorf("expected %v to be at most 40ms", elapsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/swayne275/go-retry/backoff"
+	"github.com/swayne275/go-retry/internal/label"
+)
+
+// sentinels lists every terminal classification of the errors returned by
+// the loops in this package. Add new terminal sentinels here.
+var sentinels = []error{
+	ErrNonRetryable,
+	ErrBackoffSignaledToStop,
+	ErrDependencyNeverReady,
+	ErrByteBudgetExceeded,
+	ErrBudgetExhausted,
+	ErrMarkFailed,
+	ErrAttemptSetupFailed,
+	ErrCleanupPanicked,
+	ErrFenced,
+	ErrRetriesDisabled,
+	ErrLadderExhausted,
+	ErrRetryExpectationExceeded,
+	backoff.ErrRandomSourceNotSupported,
+	backoff.ErrNilBackoff,
+	context.Canceled,
+	context.DeadlineExceeded,
+}
+
+// AllSentinels returns every sentinel that classifies an error returned by the
+// loops in this package, including the context errors they return as is. Use
+// it with Classify or MustHandleAll to handle errors exhaustively: the list
+// grows when a new kind of terminal error is added.
+//
+// ErrRetriesExhausted, ErrEstablishmentPhase and ErrInSessionPhase are not
+// listed: they only qualify ErrBackoffSignaledToStop. Nor is ErrPanicked, which
+// only qualifies ErrNonRetryable or ErrBackoffSignaledToStop.
+func AllSentinels() []error {
+	return append([]error(nil), sentinels...)
+}
+
+// Classify returns the sentinel from AllSentinels that classifies err, or nil
+// if there is none. An error wrapping several sentinels is classified by the
+// outermost one, so a loop that gives up because a ReadinessGate failed is
+// classified as ErrDependencyNeverReady even though the gate's own error wraps
+// ErrBackoffSignaledToStop.
+func Classify(err error) error {
+	return label.Classify(err, sentinels)
+}
+
+// MustHandleAll returns a function that calls the handler for the sentinel
+// that classifies its argument. Errors that no sentinel classifies are passed
+// to the handler for nil, if there is one; nil errors are ignored.
+//
+// MustHandleAll panics if handlers has no handler for one of AllSentinels, so
+// code handling errors exhaustively fails fast, for example in its tests,
+// after an upgrade adds a new sentinel.
+func MustHandleAll(handlers map[error]func(error)) func(error) {
+	for _, s := range sentinels {
+		if handlers[s] == nil {
+			panic(fmt.Sprintf("retry: no handler for %q", s))
+		}
+	}
+
+	return func(err error) {
+		if err == nil {
+			return
+		}
+		if h := handlers[Classify(err)]; h != nil {
+			h(err)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestAllSentinels(t *testing.T) {
+	t.Parallel()
+
+	t.Run("returns_a_copy", func(t *testing.T) {
+		t.Parallel()
+
+		all := AllSentinels()
+		all[0] = nil
+		if AllSentinels()[0] == nil {
+			t.Error("expected AllSentinels to return a copy")
+		}
+	})
+
+	t.Run("covers_every_terminal_path", func(t *testing.T) {
+		t.Parallel()
+
+		covered := make(map[error]bool)
+		for _, tc := range terminalPaths() {
+			err := tc.run(t)
+			got := Classify(err)
+			if got != tc.class {
+				t.Errorf("%s: expected %q to be classified as %q, got %v", tc.name, err, tc.class, got)
+			}
+			covered[got] = true
+		}
+
+		// Every listed sentinel must be reachable, so the list can't keep
+		// sentinels the loops no longer return.
+		for _, s := range AllSentinels() {
+			if !covered[s] {
+				t.Errorf("no terminal path is classified as %q", s)
+			}
+		}
+	})
+}
+
+func TestClassify(t *testing.T) {
+	t.Parallel()
+
+	errFoo := fmt.Errorf("foo")
+	gateErr := fmt.Errorf("%w: db: %w", ErrDependencyNeverReady, fmt.Errorf("%w: %w", ErrBackoffSignaledToStop, errFoo))
+
+	cases := []struct {
+		name string
+		err  error
+		exp  error
+	}{
+		{name: "sentinel", err: ErrNonRetryable, exp: ErrNonRetryable},
+		{name: "wrapped", err: fmt.Errorf("%w: %w", ErrBackoffSignaledToStop, errFoo), exp: ErrBackoffSignaledToStop},
+		{name: "outermost_wins", err: gateErr, exp: ErrDependencyNeverReady},
+		{name: "context", err: fmt.Errorf("oops: %w", context.DeadlineExceeded), exp: context.DeadlineExceeded},
+		{name: "unclassified", err: errFoo, exp: nil},
+		{name: "nil", err: nil, exp: nil},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := Classify(tc.err); got != tc.exp {
+				t.Errorf("expected %v to be %v", got, tc.exp)
+			}
+		})
+	}
+}
+
+func TestMustHandleAll(t *testing.T) {
+	t.Parallel()
+
+	t.Run("panics_on_missing_handler", func(t *testing.T) {
+		t.Parallel()
+
+		handlers := make(map[error]func(error))
+		for _, s := range AllSentinels()[1:] {
+			handlers[s] = func(error) {}
+		}
+
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("expected a panic")
+			}
+		}()
+		MustHandleAll(handlers)
+	})
+
+	t.Run("dispatches_by_classification", func(t *testing.T) {
+		t.Parallel()
+
+		var got []error
+		handlers := make(map[error]func(error))
+		for _, s := range AllSentinels() {
+			s := s
+			handlers[s] = func(error) { got = append(got, s) }
+		}
+		handlers[nil] = func(error) { got = append(got, nil) }
+
+		handle := MustHandleAll(handlers)
+		handle(fmt.Errorf("%w: oops", ErrNonRetryable))
+		handle(context.Canceled)
+		handle(errors.New("unclassified"))
+		handle(nil)
+
+		want := []error{ErrNonRetryable, context.Canceled, nil}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	})
+}
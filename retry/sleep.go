@@ -0,0 +1,27 @@
+package retry
+
+import (
+	"context"
+	"time"
+
+	"github.com/swayne275/go-retry/internal/clock"
+)
+
+// Sleep pauses for d, as the loops in this package do between attempts, for
+// callers writing their own loops. It returns nil once d has passed, or
+// ctx.Err() if ctx is done first, in which case it returns promptly. A
+// non-positive d returns immediately. The timer is always stopped, so nothing
+// outlives the call.
+func Sleep(ctx context.Context, d time.Duration) error {
+	return sleep(ctx, clock.Real, d)
+}
+
+func sleep(ctx context.Context, c Clock, d time.Duration) error {
+	// ctx.Done() has priority, so we test it alone first
+	if err := ctx.Err(); err != nil || d <= 0 {
+		return err
+	}
+
+	s := clock.NewSleeper(c)
+	return s.Sleep(ctx, d, nil)
+}
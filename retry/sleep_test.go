@@ -0,0 +1,149 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stopClock is a Clock whose timers never fire on their own, recording
+// whether they were stopped.
+type stopClock struct {
+	mu     sync.Mutex
+	timers []*stopTimer
+}
+
+func (c *stopClock) Now() time.Time {
+	return time.Unix(0, 0)
+}
+
+func (c *stopClock) NewTimer(d time.Duration) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &stopTimer{c: make(chan time.Time)}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+func (c *stopClock) running() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var n int
+	for _, t := range c.timers {
+		t.mu.Lock()
+		if !t.stopped {
+			n++
+		}
+		t.mu.Unlock()
+	}
+	return n
+}
+
+type stopTimer struct {
+	c       chan time.Time
+	mu      sync.Mutex
+	stopped bool
+}
+
+func (t *stopTimer) C() <-chan time.Time { return t.c }
+
+func (t *stopTimer) Stop() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	was := !t.stopped
+	t.stopped = true
+	return was
+}
+
+func TestSleep(t *testing.T) {
+	t.Parallel()
+
+	t.Run("zero_duration", func(t *testing.T) {
+		t.Parallel()
+
+		c := &stopClock{}
+		if err := sleep(context.Background(), c, 0); err != nil {
+			t.Fatalf("expected %v to be nil", err)
+		}
+		if got := len(c.timers); got != 0 {
+			t.Errorf("expected %d timers to be 0", got)
+		}
+	})
+
+	t.Run("completes", func(t *testing.T) {
+		t.Parallel()
+
+		c := newFakeClock()
+		if err := sleep(context.Background(), c, time.Minute); err != nil {
+			t.Fatalf("expected %v to be nil", err)
+		}
+		if got, want := c.Sleeps(), []time.Duration{time.Minute}; len(got) != 1 || got[0] != want[0] {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	})
+
+	t.Run("already_canceled", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		c := newFakeClock()
+		if err := sleep(ctx, c, time.Minute); !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected %v to be %v", err, context.Canceled)
+		}
+		if got := len(c.Sleeps()); got != 0 {
+			t.Errorf("expected %d sleeps to be 0", got)
+		}
+	})
+
+	t.Run("canceled_mid_sleep", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		c := &stopClock{}
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- sleep(ctx, c, time.Hour)
+		}()
+
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+
+		select {
+		case err := <-errCh:
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("expected %v to be %v", err, context.Canceled)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("expected Sleep to return promptly after cancellation")
+		}
+		if got := c.running(); got != 0 {
+			t.Errorf("expected %d running timers to be 0", got)
+		}
+	})
+
+	t.Run("real_clock", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		if err := Sleep(ctx, time.Hour); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected %v to be %v", err, context.DeadlineExceeded)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("expected %v to be less than %v", elapsed, time.Second)
+		}
+
+		if err := Sleep(context.Background(), time.Millisecond); err != nil {
+			t.Errorf("expected %v to be nil", err)
+		}
+	})
+}
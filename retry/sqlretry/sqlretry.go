@@ -0,0 +1,141 @@
+// Package sqlretry retries database/sql calls that fail with transient
+// errors, such as broken connections and serialization failures, with the
+// retry package.
+package sqlretry
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+
+	"github.com/swayne275/go-retry/backoff"
+	"github.com/swayne275/go-retry/retry"
+)
+
+// DB is the part of *sql.DB, *sql.Conn and *sql.Tx that the helpers use.
+// Retrying within a transaction only makes sense if the failed statement
+// left it usable, which most databases don't guarantee.
+type DB interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Classifier reports whether err, from a database/sql call, is transient and
+// the call should be retried.
+type Classifier func(err error) bool
+
+// Or returns a Classifier that reports whether c or any of others does, to
+// extend a Classifier such as DefaultClassifier with driver-specific errors.
+func (c Classifier) Or(others ...Classifier) Classifier {
+	return func(err error) bool {
+		if c != nil && c(err) {
+			return true
+		}
+		for _, o := range others {
+			if o != nil && o(err) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// SQLStater is implemented by driver errors that carry a SQLSTATE code, such
+// as those of pgx. Drivers whose errors expose the code differently can be
+// supported with a Classifier.
+type SQLStater interface {
+	SQLState() string
+}
+
+// transientStates are the SQLSTATE codes of failures that succeed when
+// retried: serialization failure and deadlock.
+var transientStates = map[string]bool{
+	"40001": true,
+	"40P01": true,
+}
+
+// DefaultClassifier reports whether err is a broken connection
+// (driver.ErrBadConn) that database/sql didn't already retry, a deadline that
+// expired in the pool or driver, or a serialization failure or deadlock
+// (SQLSTATE 40001 or 40P01) from a driver whose errors implement SQLStater.
+//
+// A deadline of the caller's own context is not retried, as the loop stops
+// as soon as its context is done.
+func DefaultClassifier(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var s SQLStater
+	return errors.As(err, &s) && transientStates[s.SQLState()]
+}
+
+// Retrier retries database/sql calls whose errors its Classifier reports as
+// transient. The zero Retrier uses DefaultClassifier.
+type Retrier struct {
+	// Classifier decides which errors are retried. If nil,
+	// DefaultClassifier is used.
+	Classifier Classifier
+}
+
+// ExecContext calls db.ExecContext, retrying it with b while it fails with a
+// transient error. It returns the result of the call that succeeded or, if
+// none did, the error retry.Do returns: a non-transient error is wrapped in
+// retry.ErrNonRetryable, and a transient one in
+// retry.ErrBackoffSignaledToStop once b stops.
+func (r Retrier) ExecContext(ctx context.Context, db DB, b backoff.Backoff, query string, args ...any) (sql.Result, error) {
+	var res sql.Result
+	err := retry.Do(ctx, b, func(ctx context.Context) error {
+		var err error
+		res, err = db.ExecContext(ctx, query, args...)
+		return r.classify(err)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// QueryRowContext calls db.QueryRowContext, retrying it with b while the
+// query fails with a transient error, as reported by the Err method of the
+// row. It returns the row of the call that succeeded, whose Scan may still
+// report sql.ErrNoRows, or, if none did, the error retry.Do returns, as
+// ExecContext does.
+func (r Retrier) QueryRowContext(ctx context.Context, db DB, b backoff.Backoff, query string, args ...any) (*sql.Row, error) {
+	var row *sql.Row
+	err := retry.Do(ctx, b, func(ctx context.Context) error {
+		row = db.QueryRowContext(ctx, query, args...)
+		return r.classify(row.Err())
+	})
+	if err != nil {
+		return nil, err
+	}
+	return row, nil
+}
+
+// classify marks err retryable if it is transient.
+func (r Retrier) classify(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	c := r.Classifier
+	if c == nil {
+		c = DefaultClassifier
+	}
+	if c(err) {
+		return retry.RetryableError(err)
+	}
+	return err
+}
+
+// ExecContext is like Retrier.ExecContext, with DefaultClassifier.
+func ExecContext(ctx context.Context, db DB, b backoff.Backoff, query string, args ...any) (sql.Result, error) {
+	return Retrier{}.ExecContext(ctx, db, b, query, args...)
+}
+
+// QueryRowContext is like Retrier.QueryRowContext, with DefaultClassifier.
+func QueryRowContext(ctx context.Context, db DB, b backoff.Backoff, query string, args ...any) (*sql.Row, error) {
+	return Retrier{}.QueryRowContext(ctx, db, b, query, args...)
+}
@@ -0,0 +1,305 @@
+package sqlretry
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+	"github.com/swayne275/go-retry/retry"
+)
+
+// fakeConnector opens connections whose statements fail with err the first
+// failures times they run, and succeed after that.
+type fakeConnector struct {
+	mu       sync.Mutex
+	calls    int
+	failures int
+	err      error
+}
+
+func (c *fakeConnector) Connect(context.Context) (driver.Conn, error) { return &fakeConn{c: c}, nil }
+func (c *fakeConnector) Driver() driver.Driver                        { return nil }
+
+// run records a statement and returns the error it fails with, if any.
+func (c *fakeConnector) run() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.calls++
+	if c.calls <= c.failures {
+		return c.err
+	}
+	return nil
+}
+
+func (c *fakeConnector) Calls() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.calls
+}
+
+type fakeConn struct {
+	c *fakeConnector
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c *fakeConn) Close() error                        { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (c *fakeConn) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	if err := c.c.run(); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c *fakeConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	if err := c.c.run(); err != nil {
+		return nil, err
+	}
+	return &fakeRows{}, nil
+}
+
+// fakeRows has one row with one column, 42.
+type fakeRows struct {
+	done bool
+}
+
+func (r *fakeRows) Columns() []string { return []string{"n"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = int64(42)
+	return nil
+}
+
+// sqlStateError is a driver error carrying a SQLSTATE code.
+type sqlStateError string
+
+func (e sqlStateError) Error() string    { return "sqlstate " + string(e) }
+func (e sqlStateError) SQLState() string { return string(e) }
+
+// badConnCalls is how many times database/sql itself tries a statement that
+// fails with driver.ErrBadConn before giving up.
+const badConnCalls = 3
+
+func TestExecContext(t *testing.T) {
+	t.Parallel()
+
+	newBackoff := func(t *testing.T, retries uint64) backoff.Backoff {
+		t.Helper()
+
+		b, err := backoff.NewConstant(1 * time.Millisecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		return backoff.WithMaxRetries(retries, b)
+	}
+
+	errSyntax := fmt.Errorf("syntax error")
+
+	cases := []struct {
+		name     string
+		failures int
+		err      error
+		retries  uint64
+		r        Retrier
+		calls    int
+		// wantErr lists the errors the returned error must wrap.
+		wantErr []error
+	}{
+		{
+			name:     "bad_conn",
+			failures: badConnCalls + 2,
+			err:      driver.ErrBadConn,
+			retries:  3,
+			calls:    badConnCalls + 3,
+		},
+		{
+			name:     "bad_conn_exhausted",
+			failures: 100,
+			err:      driver.ErrBadConn,
+			retries:  1,
+			calls:    2 * badConnCalls,
+			wantErr:  []error{retry.ErrBackoffSignaledToStop, driver.ErrBadConn},
+		},
+		{
+			name:     "deadline",
+			failures: 1,
+			err:      context.DeadlineExceeded,
+			retries:  3,
+			calls:    2,
+		},
+		{
+			name:     "serialization_failure",
+			failures: 2,
+			err:      sqlStateError("40001"),
+			retries:  3,
+			calls:    3,
+		},
+		{
+			name:     "deadlock",
+			failures: 1,
+			err:      sqlStateError("40P01"),
+			retries:  3,
+			calls:    2,
+		},
+		{
+			name:     "other_sqlstate",
+			failures: 1,
+			err:      sqlStateError("23505"),
+			retries:  3,
+			calls:    1,
+			wantErr:  []error{retry.ErrNonRetryable, sqlStateError("23505")},
+		},
+		{
+			name:     "non_transient",
+			failures: 1,
+			err:      errSyntax,
+			retries:  3,
+			calls:    1,
+			wantErr:  []error{retry.ErrNonRetryable, errSyntax},
+		},
+		{
+			name:     "custom_classifier",
+			failures: 1,
+			err:      errSyntax,
+			retries:  3,
+			r: Retrier{Classifier: Classifier(DefaultClassifier).Or(func(err error) bool {
+				return errors.Is(err, errSyntax)
+			})},
+			calls: 2,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := &fakeConnector{failures: tc.failures, err: tc.err}
+			db := sql.OpenDB(c)
+			defer db.Close()
+
+			res, err := tc.r.ExecContext(context.Background(), db, newBackoff(t, tc.retries), "UPDATE t SET n = n + 1")
+			if len(tc.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if n, err := res.RowsAffected(); err != nil || n != 1 {
+					t.Errorf("expected %d, %v to be 1, nil", n, err)
+				}
+			}
+			for _, want := range tc.wantErr {
+				if !errors.Is(err, want) {
+					t.Errorf("expected %q to wrap %q", err, want)
+				}
+			}
+			if got := c.Calls(); got != tc.calls {
+				t.Errorf("expected %d to be %d", got, tc.calls)
+			}
+		})
+	}
+}
+
+func TestQueryRowContext(t *testing.T) {
+	t.Parallel()
+
+	newBackoff := func(t *testing.T) backoff.Backoff {
+		t.Helper()
+
+		b, err := backoff.NewConstant(1 * time.Millisecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		return backoff.WithMaxRetries(3, b)
+	}
+
+	t.Run("retried", func(t *testing.T) {
+		t.Parallel()
+
+		c := &fakeConnector{failures: badConnCalls + 1, err: driver.ErrBadConn}
+		db := sql.OpenDB(c)
+		defer db.Close()
+
+		row, err := QueryRowContext(context.Background(), db, newBackoff(t), "SELECT n FROM t")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var n int
+		if err := row.Scan(&n); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if n != 42 {
+			t.Errorf("expected %d to be %d", n, 42)
+		}
+		if got, want := c.Calls(), badConnCalls+2; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("non_transient", func(t *testing.T) {
+		t.Parallel()
+
+		errSyntax := fmt.Errorf("syntax error")
+		c := &fakeConnector{failures: 1, err: errSyntax}
+		db := sql.OpenDB(c)
+		defer db.Close()
+
+		_, err := QueryRowContext(context.Background(), db, newBackoff(t), "SELECT n FROM t")
+		if !errors.Is(err, retry.ErrNonRetryable) || !errors.Is(err, errSyntax) {
+			t.Errorf("expected %q to wrap %q and %q", err, retry.ErrNonRetryable, errSyntax)
+		}
+		if got := c.Calls(); got != 1 {
+			t.Errorf("expected %d to be %d", got, 1)
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		t.Parallel()
+
+		c := &fakeConnector{}
+		db := sql.OpenDB(c)
+		defer db.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := QueryRowContext(ctx, db, newBackoff(t), "SELECT n FROM t")
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected %q to be %q", err, context.Canceled)
+		}
+		if got := c.Calls(); got != 0 {
+			t.Errorf("expected %d to be %d", got, 0)
+		}
+	})
+
+	t.Run("nil_backoff", func(t *testing.T) {
+		t.Parallel()
+
+		c := &fakeConnector{}
+		db := sql.OpenDB(c)
+		defer db.Close()
+
+		_, err := QueryRowContext(context.Background(), db, nil, "SELECT n FROM t")
+		if !errors.Is(err, backoff.ErrNilBackoff) {
+			t.Errorf("expected %q to be %q", err, backoff.ErrNilBackoff)
+		}
+		if got := c.Calls(); got != 0 {
+			t.Errorf("expected %d to be %d", got, 0)
+		}
+	})
+}
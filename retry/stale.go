@@ -0,0 +1,157 @@
+package retry
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+// defaultRevalidateTimeout bounds background refreshes started by
+// DoStaleWhileRevalidate unless WithRevalidateTimeout says otherwise.
+const defaultRevalidateTimeout = 1 * time.Minute
+
+// StaleCache holds the last good value for each key, for
+// DoStaleWhileRevalidate. It must be safe for concurrent use.
+type StaleCache[T any] interface {
+	Get(key string) (T, bool)
+	Set(key string, value T)
+}
+
+// revalidations holds the background refreshes in flight, per cache and key.
+var revalidations flightGroup
+
+// revalidateKey identifies a background refresh. cache is the identity of
+// the cache, from cacheIdentity, so it is always comparable.
+type revalidateKey struct {
+	cache any
+	key   string
+}
+
+// pointerIdentity identifies a cache held by reference, by its type and
+// address.
+type pointerIdentity struct {
+	typ  reflect.Type
+	addr uintptr
+}
+
+// cacheIdentity returns a comparable value that identifies cache: its address
+// if it is a pointer, map or channel, and cache itself if it is a comparable
+// value. It reports false if cache is neither, such as a struct holding a
+// slice.
+func cacheIdentity(cache any) (any, bool) {
+	v := reflect.ValueOf(cache)
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Chan, reflect.UnsafePointer:
+		return pointerIdentity{typ: v.Type(), addr: v.Pointer()}, true
+	}
+	if v.Comparable() {
+		return cache, true
+	}
+	return nil, false
+}
+
+// DoStaleWhileRevalidate calls fetch once and, if it succeeds, stores and
+// returns its result. If it fails with a retryable error and cache holds a
+// value for key, that stale value is returned at once, with stale set, while a
+// background loop retries fetch with a backoff from b and stores its result in
+// cache. If cache holds no value, DoStaleWhileRevalidate keeps retrying with a
+// backoff from b, as Do would, and stores the result if it gets one.
+//
+// The background loop is detached from ctx, so it outlives the request that
+// started it, and is bounded by WithRevalidateTimeout instead. At most one
+// background loop runs per cache and key; requests arriving while one is in
+// flight just return the stale value. Caches are told apart by address if they
+// are pointers, maps or channels, and by value otherwise; background loops for
+// a cache that is none of these and not comparable either are not
+// deduplicated.
+//
+// opts apply to the blocking and background loops alike. If b is nil or
+// builds an invalid backoff, DoStaleWhileRevalidate returns an error wrapping
+// backoff.ErrNilBackoff without calling fetch.
+func DoStaleWhileRevalidate[T any](ctx context.Context, b func() backoff.Backoff, cache StaleCache[T], key string, fetch func(ctx context.Context) (T, error), opts ...Option) (T, bool, error) {
+	var zero T
+
+	// Return immediately if ctx is canceled
+	select {
+	case <-ctx.Done():
+		return zero, false, ctx.Err()
+	default:
+	}
+
+	bo, err := build(b)
+	if err != nil {
+		return zero, false, err
+	}
+
+	v, err := fetch(ctx)
+	if err == nil {
+		cache.Set(key, v)
+		return v, false, nil
+	}
+
+	// Not retryable
+	if _, ok := asRetryable(err, newOptions(opts).shouldRetry); !ok {
+		return zero, false, nonRetryable(ctx, err)
+	}
+
+	if stale, ok := cache.Get(key); ok {
+		revalidate(ctx, bo, cache, key, fetch, opts)
+		return stale, true, nil
+	}
+
+	// The first attempt has already been made, so the loop replays its error
+	// instead of calling fetch again.
+	first := err
+	err = DoWithOptions(ctx, bo, func(ctx context.Context) error {
+		if first != nil {
+			err := first
+			first = nil
+			return err
+		}
+
+		var err error
+		v, err = fetch(ctx)
+		return err
+	}, opts...)
+	if err != nil {
+		return zero, false, err
+	}
+	cache.Set(key, v)
+	return v, false, nil
+}
+
+// revalidate starts a background loop refreshing key in cache with the backoff
+// b, unless one is already running.
+func revalidate[T any](ctx context.Context, b backoff.Backoff, cache StaleCache[T], key string, fetch func(ctx context.Context) (T, error), opts []Option) {
+	refresh := func() {
+		timeout := newOptions(opts).revalidateTimeout
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+		defer cancel()
+
+		_ = DoWithOptions(ctx, b, func(ctx context.Context) error {
+			v, err := fetch(ctx)
+			if err != nil {
+				return err
+			}
+			cache.Set(key, v)
+			return nil
+		}, opts...)
+	}
+
+	id, ok := cacheIdentity(cache)
+	if !ok {
+		go refresh()
+		return
+	}
+	revalidations.start(revalidateKey{cache: id, key: key}, refresh)
+}
+
+// WithRevalidateTimeout bounds the background refreshes started by
+// DoStaleWhileRevalidate to d, instead of one minute.
+func WithRevalidateTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.revalidateTimeout = d
+	}
+}
@@ -0,0 +1,276 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+// mapCache is a StaleCache backed by a map.
+type mapCache struct {
+	mu sync.Mutex
+	m  map[string]string
+}
+
+func newMapCache(kv ...string) *mapCache {
+	c := &mapCache{m: make(map[string]string)}
+	for i := 0; i+1 < len(kv); i += 2 {
+		c.m[kv[i]] = kv[i+1]
+	}
+	return c
+}
+
+func (c *mapCache) Get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	v, ok := c.m[key]
+	return v, ok
+}
+
+func (c *mapCache) Set(key, value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.m[key] = value
+}
+
+// sliceCache is a StaleCache that is not comparable.
+type sliceCache struct {
+	*mapCache
+	_ []string
+}
+
+// waitCached waits for key to hold want in c.
+func waitCached(t *testing.T, c *mapCache, key, want string) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if v, _ := c.Get(key); v == want {
+			return
+		}
+		time.Sleep(1 * time.Millisecond)
+	}
+	v, _ := c.Get(key)
+	t.Fatalf("expected %q to be %q", v, want)
+}
+
+func TestDoStaleWhileRevalidate(t *testing.T) {
+	t.Parallel()
+
+	policy := func() backoff.Backoff {
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		return b
+	}
+
+	t.Run("fresh_fetch", func(t *testing.T) {
+		t.Parallel()
+
+		cache := newMapCache("k", "old")
+		v, stale, err := DoStaleWhileRevalidate(context.Background(), policy, cache, "k", func(_ context.Context) (string, error) {
+			return "new", nil
+		})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if v != "new" || stale {
+			t.Errorf("expected (%q, %v) to be (%q, %v)", v, stale, "new", false)
+		}
+		if got, _ := cache.Get("k"); got != "new" {
+			t.Errorf("expected %q to be %q", got, "new")
+		}
+	})
+
+	t.Run("serves_stale_and_refreshes_in_background", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cache := newMapCache("k", "old")
+		var calls int64
+		v, stale, err := DoStaleWhileRevalidate(ctx, policy, cache, "k", func(_ context.Context) (string, error) {
+			if atomic.AddInt64(&calls, 1) < 3 {
+				return "", RetryableError(fmt.Errorf("oops"))
+			}
+			return "new", nil
+		})
+		// The refresh must outlive the request.
+		cancel()
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if v != "old" || !stale {
+			t.Errorf("expected (%q, %v) to be (%q, %v)", v, stale, "old", true)
+		}
+
+		waitCached(t, cache, "k", "new")
+		if got, want := atomic.LoadInt64(&calls), int64(3); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("blocks_without_stale_value", func(t *testing.T) {
+		t.Parallel()
+
+		cache := newMapCache()
+		var calls int
+		v, stale, err := DoStaleWhileRevalidate(context.Background(), policy, cache, "k", func(_ context.Context) (string, error) {
+			calls++
+			if calls < 3 {
+				return "", RetryableError(fmt.Errorf("oops"))
+			}
+			return "new", nil
+		})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if v != "new" || stale {
+			t.Errorf("expected (%q, %v) to be (%q, %v)", v, stale, "new", false)
+		}
+		if calls != 3 {
+			t.Errorf("expected %d to be %d", calls, 3)
+		}
+		if got, _ := cache.Get("k"); got != "new" {
+			t.Errorf("expected %q to be %q", got, "new")
+		}
+	})
+
+	t.Run("blocking_retries_exhausted", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int
+		_, _, err := DoStaleWhileRevalidate(context.Background(), func() backoff.Backoff {
+			return backoff.WithMaxRetries(2, policy())
+		}, newMapCache(), "k", func(_ context.Context) (string, error) {
+			calls++
+			return "", RetryableError(fmt.Errorf("oops"))
+		})
+		if !errors.Is(err, ErrBackoffSignaledToStop) {
+			t.Errorf("expected %q to be %q", err, ErrBackoffSignaledToStop)
+		}
+		if calls != 3 {
+			t.Errorf("expected %d to be %d", calls, 3)
+		}
+	})
+
+	t.Run("non_retryable", func(t *testing.T) {
+		t.Parallel()
+
+		cache := newMapCache("k", "old")
+		errFoo := fmt.Errorf("foo")
+		_, stale, err := DoStaleWhileRevalidate(context.Background(), policy, cache, "k", func(_ context.Context) (string, error) {
+			return "", errFoo
+		})
+		if !errors.Is(err, ErrNonRetryable) {
+			t.Errorf("expected %q to be %q", err, ErrNonRetryable)
+		}
+		if stale {
+			t.Error("expected no stale value")
+		}
+	})
+
+	t.Run("deduplicates_background_refreshes", func(t *testing.T) {
+		t.Parallel()
+
+		cache := newMapCache("k", "old")
+		release := make(chan struct{})
+		var background int64
+		fetch := func(ctx context.Context) (string, error) {
+			// Only the background loop has a deadline.
+			if _, ok := ctx.Deadline(); !ok {
+				return "", RetryableError(fmt.Errorf("oops"))
+			}
+			atomic.AddInt64(&background, 1)
+			<-release
+			return "new", nil
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				v, stale, err := DoStaleWhileRevalidate(context.Background(), policy, cache, "k", fetch)
+				if err != nil || v != "old" || !stale {
+					t.Errorf("expected stale %q, got (%q, %v, %v)", "old", v, stale, err)
+				}
+			}()
+		}
+		wg.Wait()
+		close(release)
+
+		waitCached(t, cache, "k", "new")
+		if got := atomic.LoadInt64(&background); got != 1 {
+			t.Errorf("expected %d to be %d", got, 1)
+		}
+	})
+
+	t.Run("background_bounded_by_timeout", func(t *testing.T) {
+		t.Parallel()
+
+		cache := newMapCache("k", "old")
+		deadlines := make(chan time.Duration, 1)
+		var once sync.Once
+		_, stale, _ := DoStaleWhileRevalidate(context.Background(), policy, cache, "k", func(ctx context.Context) (string, error) {
+			if deadline, ok := ctx.Deadline(); ok {
+				once.Do(func() { deadlines <- time.Until(deadline) })
+			}
+			return "", RetryableError(fmt.Errorf("oops"))
+		}, WithRevalidateTimeout(10*time.Millisecond))
+		if !stale {
+			t.Fatal("expected a stale value")
+		}
+
+		select {
+		case d := <-deadlines:
+			if d > 10*time.Millisecond {
+				t.Errorf("expected deadline %v to be at most %v", d, 10*time.Millisecond)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the background refresh")
+		}
+	})
+
+	t.Run("non_comparable_cache", func(t *testing.T) {
+		t.Parallel()
+
+		cache := sliceCache{mapCache: newMapCache("k", "old")}
+		var calls int64
+		v, stale, err := DoStaleWhileRevalidate[string](context.Background(), policy, cache, "k", func(_ context.Context) (string, error) {
+			if atomic.AddInt64(&calls, 1) < 2 {
+				return "", RetryableError(fmt.Errorf("oops"))
+			}
+			return "new", nil
+		})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if v != "old" || !stale {
+			t.Errorf("expected (%q, %v) to be (%q, %v)", v, stale, "old", true)
+		}
+		waitCached(t, cache.mapCache, "k", "new")
+	})
+
+	t.Run("canceled_during_fetch", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		_, _, err := DoStaleWhileRevalidate(ctx, policy, newMapCache("k", "old"), "k", func(ctx context.Context) (string, error) {
+			cancel()
+			return "", ctx.Err()
+		})
+		if err != context.Canceled {
+			t.Errorf("expected %v to be %v", err, context.Canceled)
+		}
+	})
+}
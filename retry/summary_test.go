@@ -0,0 +1,266 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+// captureHandler is a slog.Handler that keeps every record it handles.
+type captureHandler struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *captureHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *captureHandler) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h *captureHandler) WithGroup(string) slog.Handler            { return h }
+
+func (h *captureHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.records = append(h.records, r.Clone())
+	return nil
+}
+
+// attrs returns the attributes of every record handled so far.
+func (h *captureHandler) attrs() []map[string]any {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	out := make([]map[string]any, 0, len(h.records))
+	for _, r := range h.records {
+		m := make(map[string]any)
+		r.Attrs(func(a slog.Attr) bool {
+			m[a.Key] = a.Value.Any()
+			return true
+		})
+		out = append(out, m)
+	}
+	return out
+}
+
+func TestWithSummaryLog(t *testing.T) {
+	t.Parallel()
+
+	newBackoff := func(t *testing.T, maxRetries uint64) backoff.Backoff {
+		t.Helper()
+
+		b, err := backoff.NewConstant(10 * time.Millisecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		return backoff.WithMaxRetries(maxRetries, b)
+	}
+	retryable := func(_ context.Context) error {
+		return RetryableError(fmt.Errorf("oops"))
+	}
+
+	cases := []struct {
+		name     string
+		ctx      func() (context.Context, context.CancelFunc)
+		f        RetryFunc
+		opts     func(t *testing.T) []Option
+		outcome  string
+		attempts uint64
+		sleepMS  int64
+	}{
+		{
+			name: "success",
+			f: func() RetryFunc {
+				var calls int
+				return func(_ context.Context) error {
+					calls++
+					if calls < 3 {
+						return RetryableError(fmt.Errorf("oops"))
+					}
+					return nil
+				}
+			}(),
+			outcome:  "success",
+			attempts: 3,
+			sleepMS:  20,
+		},
+		{
+			name: "non_retryable",
+			f: func(_ context.Context) error {
+				return fmt.Errorf("oops")
+			},
+			outcome:  "non_retryable",
+			attempts: 1,
+		},
+		{
+			name:     "retries_exhausted",
+			f:        retryable,
+			outcome:  "retries_exhausted",
+			attempts: 4,
+			sleepMS:  30,
+		},
+		{
+			name: "canceled_between_attempts",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithCancel(context.Background())
+			},
+			outcome:  "context_canceled",
+			attempts: 1,
+		},
+		{
+			name: "deadline_exceeded",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 5*time.Millisecond)
+			},
+			f: func(ctx context.Context) error {
+				<-ctx.Done()
+				return RetryableError(ctx.Err())
+			},
+			outcome:  "context_deadline_exceeded",
+			attempts: 1,
+		},
+		{
+			name: "dependency_never_ready",
+			f:    retryable,
+			opts: func(t *testing.T) []Option {
+				return []Option{WithReadinessGate(NewReadinessGate("db", retryable, constantPolicy(t, 1)))}
+			},
+			outcome: "dependency_never_ready",
+		},
+		{
+			name: "byte_budget_exceeded",
+			f:    retryable,
+			opts: func(t *testing.T) []Option {
+				return []Option{WithByteBudget(NewByteBudget(0, time.Hour), func(uint64) int64 { return 1 })}
+			},
+			outcome:  "byte_budget_exceeded",
+			attempts: 1,
+		},
+		{
+			name: "attempt_setup_failed",
+			f:    retryable,
+			opts: func(t *testing.T) []Option {
+				return []Option{WithAttemptCleanup(func(context.Context) (func(), error) {
+					return nil, fmt.Errorf("no temp dir")
+				})}
+			},
+			outcome:  "attempt_setup_failed",
+			attempts: 1,
+		},
+		{
+			name: "cleanup_panicked",
+			f:    retryable,
+			opts: func(t *testing.T) []Option {
+				return []Option{WithAttemptCleanup(func(context.Context) (func(), error) {
+					return func() { panic("oops") }, nil
+				})}
+			},
+			outcome:  "cleanup_panicked",
+			attempts: 1,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.Background(), context.CancelFunc(func() {})
+			if tc.ctx != nil {
+				ctx, cancel = tc.ctx()
+			}
+			defer cancel()
+
+			f := tc.f
+			if f == nil {
+				f = func(_ context.Context) error {
+					cancel()
+					return RetryableError(fmt.Errorf("oops"))
+				}
+			}
+
+			h := &captureHandler{}
+			opts := []Option{WithClock(newFakeClock()), WithSummaryLog(slog.New(h), slog.LevelWarn, "fetch")}
+			if tc.opts != nil {
+				opts = append(opts, tc.opts(t)...)
+			}
+			err := DoWithOptions(ctx, newBackoff(t, 3), f, opts...)
+
+			errMsg := ""
+			if err != nil {
+				errMsg = err.Error()
+			}
+			want := []map[string]any{{
+				"operation":      "fetch",
+				"outcome":        tc.outcome,
+				"attempts":       tc.attempts,
+				"total_sleep_ms": tc.sleepMS,
+				"elapsed_ms":     tc.sleepMS,
+				"error":          errMsg,
+				"policy":         "constant(10ms)|max_retries(3)",
+			}}
+			if got := h.attrs(); !reflect.DeepEqual(got, want) {
+				t.Errorf("expected %v to be %v", got, want)
+			}
+			if got := h.records[0].Level; got != slog.LevelWarn {
+				t.Errorf("expected %v to be %v", got, slog.LevelWarn)
+			}
+		})
+	}
+
+	t.Run("early_cancel", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		h := &captureHandler{}
+		_ = DoWithOptions(ctx, newBackoff(t, 3), retryable, WithSummaryLog(slog.New(h), slog.LevelInfo, "fetch"))
+		if got := len(h.attrs()); got != 0 {
+			t.Errorf("expected %d to be %d", got, 0)
+		}
+
+		_ = DoWithOptions(ctx, newBackoff(t, 3), retryable, WithSummaryLog(slog.New(h), slog.LevelInfo, "fetch"), WithSummaryLogOnEarlyCancel())
+		got := h.attrs()
+		if len(got) != 1 {
+			t.Fatalf("expected %d to be %d", len(got), 1)
+		}
+		if got[0]["outcome"] != "context_canceled" || got[0]["attempts"] != uint64(0) {
+			t.Errorf("expected a zero attempt cancellation, got %v", got[0])
+		}
+	})
+
+	t.Run("policy_stringer", func(t *testing.T) {
+		t.Parallel()
+
+		h := &captureHandler{}
+		_ = DoWithOptions(context.Background(), namedBackoff{newBackoff(t, 0)}, retryable, WithSummaryLog(slog.New(h), slog.LevelInfo, "fetch"))
+		if got := h.attrs()[0]["policy"]; got != "constant 10ms" {
+			t.Errorf("expected %q to be %q", got, "constant 10ms")
+		}
+	})
+
+	t.Run("nil_logger", func(t *testing.T) {
+		t.Parallel()
+
+		c := &countingClock{fakeClock: newFakeClock()}
+		_ = DoWithOptions(context.Background(), newBackoff(t, 3), retryable, WithClock(c), WithSummaryLog(nil, slog.LevelInfo, "fetch"))
+		// The loop only reads its start, for ElapsedFromContext.
+		if got := atomic.LoadInt64(&c.nows); got != 1 {
+			t.Errorf("expected %d to be %d", got, 1)
+		}
+	})
+}
+
+// namedBackoff describes itself.
+type namedBackoff struct {
+	backoff.Backoff
+}
+
+func (namedBackoff) String() string { return "constant 10ms" }
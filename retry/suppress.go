@@ -0,0 +1,195 @@
+package retry
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/swayne275/go-retry/internal/clock"
+)
+
+const (
+	// maxSuppressedKeys is the number of distinct errors an ErrorSuppressor
+	// tracks before it forgets the least recently seen one.
+	maxSuppressedKeys = 1024
+	// maxSuppressionKeyLen truncates the default key of an error.
+	maxSuppressionKeyLen = 256
+)
+
+// ErrorSuppressor limits how often identical errors are logged: each distinct
+// error may be logged burst times per window, and is suppressed after that
+// until the next window. Errors are identical when they have the same key,
+// which by default is their message, truncated.
+//
+// An ErrorSuppressor tracks a bounded number of distinct errors, forgetting
+// the least recently seen ones first, so it can be shared by many loops
+// without growing without bound. It is safe for concurrent use. The zero
+// ErrorSuppressor suppresses nothing.
+type ErrorSuppressor struct {
+	window time.Duration
+	burst  int
+	key    func(err error) string
+	clock  Clock
+	max    int
+
+	mu     sync.Mutex
+	states map[string]*list.Element
+	// lru orders the tracked errors, most recently seen first.
+	lru *list.List
+}
+
+// suppression is the state of one distinct error.
+type suppression struct {
+	key         string
+	windowStart time.Time
+	logged      int
+	suppressed  int
+}
+
+// NewErrorSuppressor creates a suppressor that lets each distinct error be
+// logged burst times per window. A burst smaller than 1 is treated as 1.
+func NewErrorSuppressor(window time.Duration, burst int) *ErrorSuppressor {
+	return NewErrorSuppressorWithClock(window, burst, nil, nil)
+}
+
+// NewErrorSuppressorWithClock is like NewErrorSuppressor, but decides which
+// errors are identical with key and measures windows with c. A nil key selects
+// the truncated error message, and a nil c the real clock.
+func NewErrorSuppressorWithClock(window time.Duration, burst int, key func(err error) string, c Clock) *ErrorSuppressor {
+	if burst < 1 {
+		burst = 1
+	}
+	if key == nil {
+		key = defaultSuppressionKey
+	}
+	return &ErrorSuppressor{
+		window: window,
+		burst:  burst,
+		key:    key,
+		clock:  clock.Or(c),
+		max:    maxSuppressedKeys,
+		states: make(map[string]*list.Element),
+		lru:    list.New(),
+	}
+}
+
+func defaultSuppressionKey(err error) string {
+	msg := err.Error()
+	if len(msg) > maxSuppressionKeyLen {
+		msg = msg[:maxSuppressionKeyLen]
+	}
+	return msg
+}
+
+// ShouldLog reports whether err should be logged now. When it should, the
+// count is the number of identical errors suppressed since the last one was
+// logged, so the record can say how many it stands for. When it shouldn't, the
+// count is the number suppressed so far, including err. A nil err is always
+// logged. Counts of errors that have been forgotten are lost.
+func (s *ErrorSuppressor) ShouldLog(err error) (bool, int) {
+	if err == nil {
+		return true, 0
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.init()
+	key := s.key(err)
+	now := s.clock.Now()
+	st := s.lookup(key, now)
+	if now.Sub(st.windowStart) >= s.window {
+		st.windowStart = now
+		st.logged = 0
+	}
+
+	if st.logged < s.burst {
+		st.logged++
+		n := st.suppressed
+		st.suppressed = 0
+		return true, n
+	}
+	st.suppressed++
+	return false, st.suppressed
+}
+
+// init sets up the zero ErrorSuppressor. The caller must hold s.mu.
+func (s *ErrorSuppressor) init() {
+	if s.states != nil {
+		return
+	}
+	s.burst = 1
+	s.key = defaultSuppressionKey
+	s.clock = clock.Real
+	s.max = maxSuppressedKeys
+	s.states = make(map[string]*list.Element)
+	s.lru = list.New()
+}
+
+// lookup returns the state of key, tracking it if it isn't yet and marking it
+// as the most recently seen. The caller must hold s.mu.
+func (s *ErrorSuppressor) lookup(key string, now time.Time) *suppression {
+	if e, ok := s.states[key]; ok {
+		s.lru.MoveToFront(e)
+		return e.Value.(*suppression)
+	}
+
+	if s.lru.Len() >= s.max {
+		oldest := s.lru.Back()
+		s.lru.Remove(oldest)
+		delete(s.states, oldest.Value.(*suppression).key)
+	}
+	st := &suppression{key: key, windowStart: now}
+	s.states[key] = s.lru.PushFront(st)
+	return st
+}
+
+// WithSummaryLogSuppressor makes WithSummaryLog consult s before logging the
+// summary of a loop that failed, skipping the record if s suppresses its
+// error. Records then carry a "suppressed" attribute with the number of
+// identical failures suppressed since the previous one was logged. A nil s
+// suppresses nothing.
+func WithSummaryLogSuppressor(s *ErrorSuppressor) Option {
+	return func(o *options) {
+		o.summary.Suppress = nil
+		if s != nil {
+			o.summary.Suppress = s.ShouldLog
+		}
+	}
+}
+
+// WithLoggerSuppressor makes WithLogger consult s before logging a failed
+// attempt or the loop giving up, skipping the record if s suppresses its
+// error. Records then carry a "suppressed" attribute with the number of
+// identical errors suppressed since the previous one was logged. A nil s
+// suppresses nothing.
+func WithLoggerSuppressor(s *ErrorSuppressor) Option {
+	return func(o *options) {
+		o.logSuppress = nil
+		if s != nil {
+			o.logSuppress = s.ShouldLog
+		}
+	}
+}
+
+// WithOnRetrySuppressed is like WithOnRetry, but consults s before calling
+// hook, skipping the call if s suppresses the error. hook also receives the
+// number of identical errors suppressed since it was last called for one. A
+// nil s suppresses nothing. It replaces any hook set with WithOnRetry.
+func WithOnRetrySuppressed(s *ErrorSuppressor, hook func(attempt uint64, err error, next time.Duration, suppressed int)) Option {
+	return func(o *options) {
+		o.onRetry = nil
+		if hook == nil {
+			return
+		}
+		o.onRetry = func(attempt uint64, err error, next time.Duration) {
+			ok, suppressed := true, 0
+			if s != nil {
+				ok, suppressed = s.ShouldLog(err)
+			}
+			if ok {
+				hook(attempt, err, next, suppressed)
+			}
+		}
+	}
+}
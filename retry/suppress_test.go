@@ -0,0 +1,269 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+func TestErrorSuppressor(t *testing.T) {
+	t.Parallel()
+
+	errA := fmt.Errorf("connection refused")
+	errB := fmt.Errorf("timeout")
+
+	t.Run("scripted", func(t *testing.T) {
+		t.Parallel()
+
+		c := newFakeClock()
+		s := NewErrorSuppressorWithClock(time.Minute, 2, nil, c)
+
+		steps := []struct {
+			advance time.Duration
+			err     error
+			ok      bool
+			count   int
+		}{
+			{err: errA, ok: true},
+			{err: errA, ok: true},
+			{err: errA, ok: false, count: 1},
+			{err: errB, ok: true},
+			{err: errA, ok: false, count: 2},
+			{err: nil, ok: true},
+			{advance: 59 * time.Second, err: errA, ok: false, count: 3},
+			{advance: time.Second, err: errA, ok: true, count: 3},
+			{err: errA, ok: true},
+			{err: errA, ok: false, count: 1},
+			{advance: time.Hour, err: errA, ok: true, count: 1},
+		}
+		for i, step := range steps {
+			c.Advance(step.advance)
+			ok, count := s.ShouldLog(step.err)
+			if ok != step.ok || count != step.count {
+				t.Errorf("step %d: expected (%v, %d) to be (%v, %d)", i, ok, count, step.ok, step.count)
+			}
+		}
+	})
+
+	t.Run("custom_key", func(t *testing.T) {
+		t.Parallel()
+
+		// Key by the wrapped cause, so errors differing only in detail are
+		// identical.
+		s := NewErrorSuppressorWithClock(time.Minute, 1, func(err error) string {
+			return errors.Unwrap(err).Error()
+		}, newFakeClock())
+
+		if ok, _ := s.ShouldLog(fmt.Errorf("dial 10.0.0.1: %w", errA)); !ok {
+			t.Errorf("expected first error to be logged")
+		}
+		if ok, count := s.ShouldLog(fmt.Errorf("dial 10.0.0.2: %w", errA)); ok || count != 1 {
+			t.Errorf("expected (%v, %d) to be (false, 1)", ok, count)
+		}
+	})
+
+	t.Run("eviction", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewErrorSuppressorWithClock(time.Minute, 1, nil, newFakeClock())
+		s.max = 2
+
+		s.ShouldLog(errA)
+		s.ShouldLog(errA) // suppressed
+		s.ShouldLog(errB)
+		s.ShouldLog(errA) // errA is now the most recently seen
+		s.ShouldLog(fmt.Errorf("third"))
+
+		// errB was the least recently seen, so it was forgotten.
+		if ok, count := s.ShouldLog(errB); !ok || count != 0 {
+			t.Errorf("expected (%v, %d) to be (true, 0)", ok, count)
+		}
+		// Tracking errB again forgot errA, along with its count.
+		if ok, count := s.ShouldLog(errA); !ok || count != 0 {
+			t.Errorf("expected (%v, %d) to be (true, 0)", ok, count)
+		}
+		if got := s.lru.Len(); got != 2 {
+			t.Errorf("expected %d to be %d", got, 2)
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		t.Parallel()
+
+		const burst, loops, calls = 3, 50, 100
+		s := NewErrorSuppressorWithClock(time.Hour, burst, nil, newFakeClock())
+
+		var logged int64
+		var wg sync.WaitGroup
+		for i := 0; i < loops; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				for j := 0; j < calls; j++ {
+					if ok, _ := s.ShouldLog(errA); ok {
+						atomic.AddInt64(&logged, 1)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		if got := atomic.LoadInt64(&logged); got != burst {
+			t.Errorf("expected %d to be %d", got, burst)
+		}
+		if _, count := s.ShouldLog(errA); count != loops*calls-burst+1 {
+			t.Errorf("expected %d to be %d", count, loops*calls-burst+1)
+		}
+	})
+}
+
+func TestWithSummaryLogSuppressor(t *testing.T) {
+	t.Parallel()
+
+	b, err := backoff.NewConstant(1 * time.Nanosecond)
+	if err != nil {
+		t.Fatalf("failed to create constant backoff: %v", err)
+	}
+
+	c := newFakeClock()
+	s := NewErrorSuppressorWithClock(time.Minute, 1, nil, c)
+	h := &captureHandler{}
+	run := func(f RetryFunc) {
+		_ = DoWithOptions(context.Background(), backoff.WithMaxRetries(1, b), f,
+			WithClock(newFakeClock()),
+			WithSummaryLog(slog.New(h), slog.LevelInfo, "fetch"),
+			WithSummaryLogSuppressor(s))
+	}
+	failing := func(_ context.Context) error {
+		return fmt.Errorf("down")
+	}
+
+	for i := 0; i < 3; i++ {
+		run(failing)
+	}
+	run(func(_ context.Context) error { return nil })
+	c.Advance(time.Minute)
+	run(failing)
+
+	got := h.attrs()
+	if len(got) != 3 {
+		t.Fatalf("expected %d to be %d", len(got), 3)
+	}
+	for i, want := range []struct {
+		outcome    string
+		suppressed int64
+	}{
+		{"non_retryable", 0},
+		{"success", 0},
+		{"non_retryable", 2},
+	} {
+		if got[i]["outcome"] != want.outcome || got[i]["suppressed"] != want.suppressed {
+			t.Errorf("record %d: expected %v to have outcome %q and suppressed %d", i, got[i], want.outcome, want.suppressed)
+		}
+	}
+}
+
+func TestWithLoggerSuppressor(t *testing.T) {
+	t.Parallel()
+
+	b, err := backoff.NewConstant(1 * time.Nanosecond)
+	if err != nil {
+		t.Fatalf("failed to create constant backoff: %v", err)
+	}
+
+	c := newFakeClock()
+	s := NewErrorSuppressorWithClock(time.Minute, 1, nil, c)
+	h := &captureHandler{}
+	run := func() {
+		_ = DoWithOptions(context.Background(), backoff.WithMaxRetries(3, b), func(_ context.Context) error {
+			return RetryableError(fmt.Errorf("down"))
+		}, WithClock(newFakeClock()), WithLogger(slog.New(h)), WithLoggerSuppressor(s))
+	}
+
+	run()
+	c.Advance(time.Minute)
+	run()
+
+	// Each run retries 3 times and gives up once. Only the first retry of
+	// each window is logged, and the gave up records, whose error differs,
+	// are logged once per window too.
+	got := h.attrs()
+	if len(got) != 4 {
+		t.Fatalf("expected %d to be %d", len(got), 4)
+	}
+	for i, want := range []struct {
+		key        string
+		suppressed int64
+	}{
+		{"attempt", 0},
+		{"outcome", 0},
+		{"attempt", 2},
+		{"outcome", 0},
+	} {
+		if _, ok := got[i][want.key]; !ok || got[i]["suppressed"] != want.suppressed {
+			t.Errorf("record %d: expected %v to have %q and suppressed %d", i, got[i], want.key, want.suppressed)
+		}
+	}
+}
+
+func TestWithOnRetrySuppressed(t *testing.T) {
+	t.Parallel()
+
+	b, err := backoff.NewConstant(1 * time.Nanosecond)
+	if err != nil {
+		t.Fatalf("failed to create constant backoff: %v", err)
+	}
+
+	type call struct {
+		attempt    uint64
+		suppressed int
+	}
+
+	t.Run("suppresses", func(t *testing.T) {
+		t.Parallel()
+
+		c := newFakeClock()
+		s := NewErrorSuppressorWithClock(time.Minute, 1, nil, c)
+		var calls []call
+		var attempt int
+		_ = DoWithOptions(context.Background(), backoff.WithMaxRetries(5, b), func(_ context.Context) error {
+			attempt++
+			if attempt == 4 {
+				c.Advance(time.Minute)
+			}
+			return RetryableError(fmt.Errorf("down"))
+		}, WithClock(newFakeClock()), WithOnRetrySuppressed(s, func(attempt uint64, _ error, _ time.Duration, suppressed int) {
+			calls = append(calls, call{attempt, suppressed})
+		}))
+
+		want := []call{{1, 0}, {4, 2}}
+		if !reflect.DeepEqual(calls, want) {
+			t.Errorf("expected %v to be %v", calls, want)
+		}
+	})
+
+	t.Run("nil_suppressor", func(t *testing.T) {
+		t.Parallel()
+
+		var calls []call
+		_ = DoWithOptions(context.Background(), backoff.WithMaxRetries(2, b), func(_ context.Context) error {
+			return RetryableError(fmt.Errorf("down"))
+		}, WithClock(newFakeClock()), WithOnRetrySuppressed(nil, func(attempt uint64, _ error, _ time.Duration, suppressed int) {
+			calls = append(calls, call{attempt, suppressed})
+		}))
+
+		want := []call{{1, 0}, {2, 0}}
+		if !reflect.DeepEqual(calls, want) {
+			t.Errorf("expected %v to be %v", calls, want)
+		}
+	})
+}
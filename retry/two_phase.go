@@ -0,0 +1,115 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/swayne275/go-retry/backoff"
+	"github.com/swayne275/go-retry/internal/clock"
+)
+
+// ErrEstablishmentPhase and ErrInSessionPhase are wrapped, together with
+// ErrBackoffSignaledToStop, in the error DoTwoPhase returns to tell which
+// phase's backoff signaled to stop.
+var (
+	ErrEstablishmentPhase = fmt.Errorf("establishment phase")
+	ErrInSessionPhase     = fmt.Errorf("in-session phase")
+)
+
+// DoTwoPhase retries f, a function that establishes a session and then uses
+// it, with one backoff for failures to establish the session and another for
+// failures after it was established. f reports, along with each failure,
+// whether it had established a session before failing; that selects which
+// schedule paces the next retry.
+//
+// Each schedule is created from its factory. Crossing from in-session failures
+// back to establishment failures starts a fresh establishment backoff, and
+// crossing the other way starts a fresh in-session backoff, so every phase
+// starts from the beginning of its schedule.
+//
+// As with Do, f must mark errors as retryable. The loop stops when f returns
+// nil, a non-retryable error, or when the schedule of the current phase
+// signals to stop, in which case the error wraps ErrBackoffSignaledToStop,
+// ErrEstablishmentPhase or ErrInSessionPhase, and the last error from f.
+//
+// Of opts, the loop honors WithClock, WithControl and WithShouldRetry; the
+// others only apply to DoWithOptions.
+func DoTwoPhase(ctx context.Context, establish func() backoff.Backoff, inSession func() backoff.Backoff, f func(ctx context.Context) (hadSession bool, err error), opts ...Option) error {
+	o := newOptions(opts)
+	c := o.clock
+
+	establishing, err := build(establish)
+	if err != nil {
+		return err
+	}
+	var session backoff.Backoff
+	var wasInSession bool
+	sleeper := clock.NewSleeper(c)
+	begun := c.Now()
+	// lastErr is the last retryable error from f.
+	var lastErr error
+
+	for attempt := uint64(1); ; attempt++ {
+		// Return immediately if ctx is canceled
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		hadSession, err := f(withAttempt(ctx, attemptInfo{n: attempt, start: begun, clock: c, lastErr: lastErr}))
+		if err == nil {
+			return nil
+		}
+
+		// Not retryable
+		rerr, ok := asRetryable(err, o.shouldRetry)
+		if !ok {
+			return nonRetryable(ctx, err)
+		}
+		lastErr = rerr.Unwrap()
+
+		b, phase := establishing, ErrEstablishmentPhase
+		switch {
+		case hadSession && !wasInSession:
+			if session, err = build(inSession); err != nil {
+				return err
+			}
+			b, phase = session, ErrInSessionPhase
+		case hadSession:
+			b, phase = session, ErrInSessionPhase
+		case wasInSession:
+			if establishing, err = build(establish); err != nil {
+				return err
+			}
+			b = establishing
+		}
+		wasInSession = hadSession
+
+		next, stop := b.Next()
+		if stop {
+			return stopped(b, fmt.Errorf("%w: %w", phase, rerr.Unwrap()))
+		}
+		next = rerr.sleep(next)
+
+		// ctx.Done() has priority, so we test it alone first
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		st := o.control.ctl().Retries.Load()
+		if st.Disabled {
+			return retriesDisabled(st, rerr.Unwrap())
+		}
+
+		err = sleeper.Sleep(ctx, next, st.Changed)
+		if err == clock.ErrInterrupted {
+			return retriesDisabled(o.control.ctl().Retries.Load(), rerr.Unwrap())
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
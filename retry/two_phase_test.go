@@ -0,0 +1,238 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+func TestDoTwoPhase(t *testing.T) {
+	t.Parallel()
+
+	// Establishment backs off exponentially from 1s; in-session retries are
+	// quick and limited to maxSession per session.
+	newFactories := func(t *testing.T, maxSession uint64) (establish, inSession func() backoff.Backoff, calls *[2]int) {
+		t.Helper()
+
+		calls = new([2]int)
+		establish = func() backoff.Backoff {
+			calls[0]++
+			b, err := backoff.NewExponential(1 * time.Second)
+			if err != nil {
+				t.Fatalf("failed to create exponential backoff: %v", err)
+			}
+			return b
+		}
+		inSession = func() backoff.Backoff {
+			calls[1]++
+			b, err := backoff.NewConstant(10 * time.Millisecond)
+			if err != nil {
+				t.Fatalf("failed to create constant backoff: %v", err)
+			}
+			return backoff.WithMaxRetries(maxSession, b)
+		}
+		return establish, inSession, calls
+	}
+
+	// script returns f failing with the given hadSession values in order, then
+	// succeeding.
+	script := func(phases ...bool) (func(context.Context) (bool, error), *int) {
+		var n int
+		return func(_ context.Context) (bool, error) {
+			n++
+			if n > len(phases) {
+				return true, nil
+			}
+			return phases[n-1], RetryableError(fmt.Errorf("failure %d", n))
+		}, &n
+	}
+
+	t.Run("retry_after_hint", func(t *testing.T) {
+		t.Parallel()
+
+		establish, inSession, _ := newFactories(t, 5)
+		var n int
+		c := newFakeClock()
+		if err := DoTwoPhase(context.Background(), establish, inSession, func(_ context.Context) (bool, error) {
+			n++
+			switch n {
+			case 1:
+				return true, RetryableAfterError(fmt.Errorf("throttled"), 30*time.Second)
+			case 2:
+				return true, RetryableError(fmt.Errorf("failure"))
+			default:
+				return true, nil
+			}
+		}, WithClock(c)); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		want := []time.Duration{30 * time.Second, 10 * time.Millisecond}
+		if got := c.Sleeps(); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	})
+
+	t.Run("alternating_phases", func(t *testing.T) {
+		t.Parallel()
+
+		establish, inSession, calls := newFactories(t, 5)
+		f, n := script(false, false, true, true, false, false, true)
+		c := newFakeClock()
+		if err := DoTwoPhase(context.Background(), establish, inSession, f, WithClock(c)); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if got, want := *n, 8; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		want := []time.Duration{
+			1 * time.Second, 2 * time.Second, // establishing
+			10 * time.Millisecond, 10 * time.Millisecond, // in session
+			1 * time.Second, 2 * time.Second, // establishing again, from the start
+			10 * time.Millisecond, // a new session
+		}
+		if got := c.Sleeps(); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+		if got, want := *calls, [2]int{2, 2}; got != want {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	})
+
+	t.Run("in_session_exhausted", func(t *testing.T) {
+		t.Parallel()
+
+		establish, inSession, _ := newFactories(t, 2)
+		f, n := script(false, true, true, true, true)
+		c := newFakeClock()
+		err := DoTwoPhase(context.Background(), establish, inSession, f, WithClock(c))
+		if !errors.Is(err, ErrBackoffSignaledToStop) {
+			t.Errorf("expected %q to be %q", err, ErrBackoffSignaledToStop)
+		}
+		if !errors.Is(err, ErrInSessionPhase) {
+			t.Errorf("expected %q to be %q", err, ErrInSessionPhase)
+		}
+		if errors.Is(err, ErrEstablishmentPhase) {
+			t.Errorf("expected %q not to be %q", err, ErrEstablishmentPhase)
+		}
+		if !errors.Is(err, ErrRetriesExhausted) {
+			t.Errorf("expected %q to be %q", err, ErrRetriesExhausted)
+		}
+		if got, want := err.Error(), "backoff signaled to stop: max retries exhausted: in-session phase: failure 4"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+		if got, want := *n, 4; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("establishment_exhausted", func(t *testing.T) {
+		t.Parallel()
+
+		establish := func() backoff.Backoff {
+			b, err := backoff.NewConstant(1 * time.Second)
+			if err != nil {
+				t.Fatalf("failed to create constant backoff: %v", err)
+			}
+			return backoff.WithMaxRetries(2, b)
+		}
+		_, inSession, _ := newFactories(t, 5)
+		f, n := script(false, false, false, false)
+		c := newFakeClock()
+		err := DoTwoPhase(context.Background(), establish, inSession, f, WithClock(c))
+		if !errors.Is(err, ErrBackoffSignaledToStop) {
+			t.Errorf("expected %q to be %q", err, ErrBackoffSignaledToStop)
+		}
+		if !errors.Is(err, ErrEstablishmentPhase) {
+			t.Errorf("expected %q to be %q", err, ErrEstablishmentPhase)
+		}
+		if got, want := *n, 3; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("non_retryable", func(t *testing.T) {
+		t.Parallel()
+
+		establish, inSession, _ := newFactories(t, 5)
+		errFoo := fmt.Errorf("foo")
+		err := DoTwoPhase(context.Background(), establish, inSession, func(_ context.Context) (bool, error) {
+			return true, errFoo
+		})
+		if !errors.Is(err, ErrNonRetryable) {
+			t.Errorf("expected %q to be %q", err, ErrNonRetryable)
+		}
+		if !errors.Is(err, errFoo) {
+			t.Errorf("expected %q to be %q", err, errFoo)
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		t.Parallel()
+
+		establish, inSession, _ := newFactories(t, 5)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+		defer cancel()
+		err := DoTwoPhase(ctx, establish, inSession, func(_ context.Context) (bool, error) {
+			return false, RetryableError(fmt.Errorf("oops"))
+		})
+		if err != context.DeadlineExceeded {
+			t.Errorf("expected %q to be %q", err, context.DeadlineExceeded)
+		}
+	})
+
+	t.Run("canceled_during_attempt", func(t *testing.T) {
+		t.Parallel()
+
+		establish, inSession, _ := newFactories(t, 5)
+		ctx, cancel := context.WithCancel(context.Background())
+		err := DoTwoPhase(ctx, establish, inSession, func(ctx context.Context) (bool, error) {
+			cancel()
+			return false, ctx.Err()
+		})
+		if err != context.Canceled {
+			t.Errorf("expected %v to be %v", err, context.Canceled)
+		}
+	})
+
+	t.Run("control", func(t *testing.T) {
+		t.Parallel()
+
+		establish, inSession, _ := newFactories(t, 5)
+		var ctl Control
+		ctl.DisableRetries("maintenance")
+		var calls int
+		err := DoTwoPhase(context.Background(), establish, inSession, func(_ context.Context) (bool, error) {
+			calls++
+			return false, RetryableError(fmt.Errorf("oops"))
+		}, WithControl(&ctl))
+		if !errors.Is(err, ErrRetriesDisabled) {
+			t.Errorf("expected %q to be %q", err, ErrRetriesDisabled)
+		}
+		if calls != 1 {
+			t.Errorf("expected %d to be %d", calls, 1)
+		}
+	})
+
+	t.Run("should_retry", func(t *testing.T) {
+		t.Parallel()
+
+		establish, inSession, _ := newFactories(t, 5)
+		f, n := script(false, false)
+		err := DoTwoPhase(context.Background(), establish, inSession, func(ctx context.Context) (bool, error) {
+			hadSession, err := f(ctx)
+			return hadSession, errors.Unwrap(err)
+		}, WithClock(newFakeClock()), WithShouldRetry(func(error) bool { return true }))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if got, want := *n, 3; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+}
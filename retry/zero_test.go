@@ -0,0 +1,401 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+	"github.com/swayne275/go-retry/internal/apitest"
+)
+
+func TestZeroValues(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	newBackoff := func(t *testing.T) backoff.Backoff {
+		t.Helper()
+
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		return backoff.WithMaxRetries(2, b)
+	}
+	retryable := func(_ context.Context) error {
+		return RetryableError(fmt.Errorf("oops"))
+	}
+
+	apitest.CheckZeroValues(t, map[string]func(t *testing.T){
+		"Budget": func(t *testing.T) {
+			var b Budget
+			if b.Withdraw() {
+				t.Error("expected zero budget to allow no retries")
+			}
+			b.Succeeded()
+			err := DoWithBudget(ctx, &b, newBackoff(t), retryable)
+			if !errors.Is(err, ErrBudgetExhausted) {
+				t.Errorf("expected %v to be %v", err, ErrBudgetExhausted)
+			}
+		},
+		"ByteBudget": func(t *testing.T) {
+			var b ByteBudget
+			if b.Charge(1) || b.Remaining() != 0 {
+				t.Error("expected zero budget to allow nothing")
+			}
+			b.Refund(1, time.Time{})
+			err := DoWithOptions(ctx, newBackoff(t), retryable, WithByteBudget(&b, func(uint64) int64 { return 1 }))
+			if !errors.Is(err, ErrByteBudgetExceeded) {
+				t.Errorf("expected %v to be %v", err, ErrByteBudgetExceeded)
+			}
+		},
+		"Control": func(t *testing.T) {
+			var c Control
+			c.DisableRetries("incident")
+			if st := c.Status(); !st.RetriesDisabled || st.RetriesReason != "incident" {
+				t.Errorf("unexpected status %+v", st)
+			}
+			if GlobalControl().Status().RetriesDisabled {
+				t.Error("expected zero control to be independent of the global one")
+			}
+			err := DoWithOptions(ctx, newBackoff(t), retryable, WithControl(&c))
+			if !errors.Is(err, ErrRetriesDisabled) {
+				t.Errorf("expected %v to be %v", err, ErrRetriesDisabled)
+			}
+			c.EnableRetries()
+			c.DisableRepeats("incident")
+			c.EnableRepeats()
+		},
+		"ControlStatus": func(t *testing.T) {
+			var st ControlStatus
+			if st.RetriesDisabled || st.RepeatsDisabled {
+				t.Error("expected zero status to be enabled")
+			}
+		},
+		"Cost": func(t *testing.T) {
+			var c Cost
+			if c.Attempts != 0 {
+				t.Errorf("expected %d to be %d", c.Attempts, 0)
+			}
+		},
+		"CostAccumulator": func(t *testing.T) {
+			var acc CostAccumulator
+			if err := DoWithOptions(ctx, newBackoff(t), func(context.Context) error { return nil }, WithCostKey(&acc, "k")); err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+			if got := acc.Snapshot()["k"].Attempts; got != 1 {
+				t.Errorf("expected %d to be %d", got, 1)
+			}
+			if got := acc.Drain()["k"].Attempts; got != 1 {
+				t.Errorf("expected %d to be %d", got, 1)
+			}
+		},
+		"DelayHistogram": func(t *testing.T) {
+			var h DelayHistogram
+			_ = DoWithOptions(ctx, newBackoff(t), retryable, WithDelayHistogram(&h))
+			if s := h.Snapshot(); len(s.Counts) != 0 || s.Sum <= 0 {
+				t.Errorf("expected only a sum, got %+v", s)
+			}
+			if err := h.Merge(&DelayHistogram{}); err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		},
+		"DelayHistogramSnapshot": func(t *testing.T) {
+			var s DelayHistogramSnapshot
+			if len(s.Counts) != 0 {
+				t.Errorf("expected no counts, got %v", s.Counts)
+			}
+		},
+		"DisabledError": func(t *testing.T) {
+			var err DisabledError
+			if err.Error() == "" {
+				t.Error("expected an error message")
+			}
+			if errors.Is(&err, ErrRetriesDisabled) {
+				t.Error("expected zero error to wrap nothing")
+			}
+		},
+		"ErrorSuppressor": func(t *testing.T) {
+			var s ErrorSuppressor
+			for i := 0; i < 3; i++ {
+				if ok, n := s.ShouldLog(fmt.Errorf("oops")); !ok || n != 0 {
+					t.Errorf("expected zero suppressor to suppress nothing, got %t, %d", ok, n)
+				}
+			}
+		},
+		"FileMarkerStore": func(t *testing.T) {
+			var s FileMarkerStore
+			if _, err := s.IsDone(ctx, "k"); err == nil {
+				t.Error("expected err")
+			}
+			if err := s.MarkDone(ctx, "k"); err == nil {
+				t.Error("expected err")
+			}
+			err := DoOnce(ctx, &s, "k", newBackoff(t), func(context.Context) error { return nil })
+			if err == nil {
+				t.Error("expected err")
+			}
+		},
+		"Group": func(t *testing.T) {
+			var g Group
+			g.Go(func(context.Context) error { return nil })
+			if err := g.Wait(); !errors.Is(err, backoff.ErrNilBackoff) {
+				t.Errorf("expected %v to be %v", err, backoff.ErrNilBackoff)
+			}
+		},
+		"Ladder": func(t *testing.T) {
+			var l Ladder[int]
+			if _, _, err := l.Do(ctx); !errors.Is(err, ErrLadderExhausted) {
+				t.Errorf("expected %v to be %v", err, ErrLadderExhausted)
+			}
+		},
+		"LatencyTracker": func(t *testing.T) {
+			var tr LatencyTracker
+			if _, ok := tr.Quantile(0.5); ok {
+				t.Error("expected no quantile yet")
+			}
+			tr.Observe(time.Second)
+			tr.Observe(2 * time.Second)
+			if got, ok := tr.Quantile(0.5); !ok || got != 2*time.Second {
+				t.Errorf("expected %v to be %v", got, 2*time.Second)
+			}
+			if got := tr.Len(); got != 1 {
+				t.Errorf("expected %d to be %d", got, 1)
+			}
+		},
+		"MemoryMarkerStore": func(t *testing.T) {
+			var s MemoryMarkerStore
+			if err := DoOnce(ctx, &s, "k", newBackoff(t), func(context.Context) error { return nil }); err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+			if done, err := s.IsDone(ctx, "k"); err != nil || !done {
+				t.Errorf("expected k to be done, got %t, %v", done, err)
+			}
+		},
+		"PolicySet": func(t *testing.T) {
+			var s PolicySet
+			if len(s.Snapshot()) != 0 {
+				t.Error("expected an empty set")
+			}
+			if _, err := s.NewPolicy("p"); !errors.Is(err, ErrUnknownPolicy) {
+				t.Errorf("expected %v to be %v", err, ErrUnknownPolicy)
+			}
+			var changed []string
+			unsubscribe := s.Subscribe(func(c []string) { changed = c })
+			defer unsubscribe()
+			if err := s.Update(map[string]backoff.Config{"p": {Strategy: backoff.StrategyConstant, Base: time.Second}}); err != nil {
+				t.Fatalf("failed to update: %v", err)
+			}
+			if len(changed) != 1 || changed[0] != "p" {
+				t.Errorf("expected %v to be [p]", changed)
+			}
+		},
+		"PriorityGate": func(t *testing.T) {
+			var g PriorityGate
+			if err := DoWithOptions(ctx, newBackoff(t), func(context.Context) error { return nil }, WithPriorityGate(&g)); err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+			expectIdle(t, &g)
+		},
+		"ReadinessGate": func(t *testing.T) {
+			var g ReadinessGate
+			err := DoWithOptions(ctx, newBackoff(t), func(context.Context) error { return nil }, WithReadinessGate(&g))
+			if !errors.Is(err, ErrDependencyNeverReady) {
+				t.Errorf("expected %v to be %v", err, ErrDependencyNeverReady)
+			}
+			if !errors.Is(g.Err(), ErrDependencyNeverReady) {
+				t.Errorf("expected %v to be %v", g.Err(), ErrDependencyNeverReady)
+			}
+		},
+		"Rung": func(t *testing.T) {
+			l := Ladder[int]{Rungs: []Rung[int]{{}, {Backoff: func() backoff.Backoff { return newBackoff(t) }}}}
+			_, _, err := l.Do(ctx)
+			if !errors.Is(err, ErrLadderExhausted) || !errors.Is(err, backoff.ErrNilBackoff) {
+				t.Errorf("expected %v to be %v and %v", err, ErrLadderExhausted, backoff.ErrNilBackoff)
+			}
+		},
+	})
+}
+
+func TestNilBackoff(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var zero backoff.ResettableBackoff
+	f := func(context.Context) error { return RetryableError(fmt.Errorf("oops")) }
+
+	cases := []struct {
+		name string
+		run  func() error
+	}{
+		{name: "do", run: func() error { return Do(ctx, &zero, f) }},
+		{name: "do_nil", run: func() error { return Do(ctx, nil, f) }},
+		{name: "do_with_options", run: func() error { return DoWithOptions(ctx, &zero, f, WithDeterministicSeed(1)) }},
+		{name: "do_pipelined", run: func() error { return DoPipelined(ctx, &zero, 2, f) }},
+		{name: "do_two_phase", run: func() error {
+			return DoTwoPhase(ctx, nil, nil, func(context.Context) (bool, error) { return false, f(ctx) })
+		}},
+		{name: "do_paginated", run: func() error {
+			_, err := DoPaginated(ctx, nil, &zero, 0, func(context.Context, int) ([]int, int, bool, error) {
+				return nil, 0, true, nil
+			}, func([]int) error { return nil })
+			return err
+		}},
+		{name: "do_paginated_factory", run: func() error {
+			global, err := backoff.NewConstant(time.Second)
+			if err != nil {
+				return err
+			}
+			_, err = DoPaginated(ctx, func() backoff.Backoff { return nil }, global, 0, func(context.Context, int) ([]int, int, bool, error) {
+				return nil, 0, true, nil
+			}, func([]int) error { return nil })
+			return err
+		}},
+		{name: "new_group", run: func() error {
+			g := NewGroup(ctx, nil)
+			g.Go(f)
+			return g.Wait()
+		}},
+		{name: "readiness_gate", run: func() error {
+			b, err := backoff.NewConstant(time.Nanosecond)
+			if err != nil {
+				return err
+			}
+			g := NewReadinessGate("db", func(context.Context) error { return nil }, nil)
+			return DoWithOptions(ctx, backoff.WithMaxRetries(1, b), f, WithReadinessGate(g))
+		}},
+		{name: "do_stale", run: func() error {
+			_, _, err := DoStaleWhileRevalidate(ctx, nil, newMapCache("k", "stale"), "k", func(context.Context) (string, error) {
+				return "", f(ctx)
+			})
+			return err
+		}},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := tc.run(); !errors.Is(err, backoff.ErrNilBackoff) {
+				t.Errorf("expected %v to be %v", err, backoff.ErrNilBackoff)
+			}
+		})
+	}
+}
+
+// TestNilOptions checks that options given nil, where they take a pointer,
+// interface or function, leave the loop running as if they weren't passed.
+func TestNilOptions(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	cases := []struct {
+		name string
+		opt  Option
+	}{
+		{name: "adaptive_attempt_timeout", opt: WithAdaptiveAttemptTimeout(nil, 0.9, 2, time.Millisecond, time.Second)},
+		{name: "attempt_cleanup", opt: WithAttemptCleanup(nil)},
+		{name: "budget", opt: WithBudget(nil)},
+		{name: "byte_budget", opt: WithByteBudget(nil, nil)},
+		{name: "clock", opt: WithClock(nil)},
+		{name: "control", opt: WithControl(nil)},
+		{name: "cost_key", opt: WithCostKey(nil, "k")},
+		{name: "delay_histogram", opt: WithDelayHistogram(nil)},
+		{name: "fence", opt: WithFence(nil)},
+		{name: "fence_on_retry", opt: WithFenceOnRetry(nil)},
+		{name: "logger", opt: WithLogger(nil)},
+		{name: "logger_suppressor", opt: WithLoggerSuppressor(nil)},
+		{name: "metrics", opt: WithMetrics(nil)},
+		{name: "on_retry", opt: WithOnRetry(nil)},
+		{name: "on_retry_chained", opt: WithOnRetryChained(nil)},
+		{name: "on_retry_suppressed", opt: WithOnRetrySuppressed(nil, nil)},
+		{name: "priority_gate", opt: WithPriorityGate(nil)},
+		{name: "readiness_gate", opt: WithReadinessGate(nil)},
+		{name: "readiness_gate_on_retry", opt: WithReadinessGateOnRetry(nil)},
+		{name: "readiness_gate_always", opt: WithReadinessGateAlways(nil)},
+		{name: "should_retry", opt: WithShouldRetry(nil)},
+		{name: "summary_log", opt: WithSummaryLog(nil, 0, "op")},
+		{name: "summary_log_suppressor", opt: WithSummaryLogSuppressor(nil)},
+		{name: "test_expectation", opt: WithTestExpectation(nil, 1)},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			b, err := backoff.NewConstant(1 * time.Nanosecond)
+			if err != nil {
+				t.Fatalf("failed to create constant backoff: %v", err)
+			}
+
+			var calls int
+			err = DoWithOptions(ctx, backoff.WithMaxRetries(1, b), func(context.Context) error {
+				calls++
+				if calls == 1 {
+					return RetryableError(fmt.Errorf("oops"))
+				}
+				return nil
+			}, tc.opt)
+			if err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+			if calls != 2 {
+				t.Errorf("expected %d to be %d", calls, 2)
+			}
+		})
+	}
+}
+
+// sliceMarker is a MarkerStore that is not comparable.
+type sliceMarker struct {
+	*MemoryMarkerStore
+	_ []string
+}
+
+// TestNonComparable checks that the helpers that deduplicate calls per store
+// or cache accept one that is not comparable, and just don't deduplicate.
+func TestNonComparable(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	cases := []struct {
+		name string
+		run  func(b backoff.Backoff) error
+	}{
+		{name: "do_once", run: func(b backoff.Backoff) error {
+			return DoOnce(ctx, sliceMarker{MemoryMarkerStore: NewMemoryMarkerStore()}, "k", b, func(context.Context) error { return nil })
+		}},
+		{name: "do_stale", run: func(b backoff.Backoff) error {
+			cache := sliceCache{mapCache: newMapCache("k", "stale")}
+			_, _, err := DoStaleWhileRevalidate(ctx, func() backoff.Backoff { return b }, cache, "k", func(context.Context) (string, error) {
+				return "", RetryableError(fmt.Errorf("oops"))
+			})
+			return err
+		}},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			b, err := backoff.NewConstant(1 * time.Nanosecond)
+			if err != nil {
+				t.Fatalf("failed to create constant backoff: %v", err)
+			}
+
+			if err := tc.run(backoff.WithMaxRetries(1, b)); err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+}
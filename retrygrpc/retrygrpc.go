@@ -0,0 +1,150 @@
+// Package retrygrpc provides a gRPC unary client interceptor that retries
+// failed calls with the retry package. It is a separate module, so the core
+// module stays free of dependencies.
+package retrygrpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/swayne275/go-retry/backoff"
+	"github.com/swayne275/go-retry/retry"
+)
+
+// DefaultCodes are the status codes retried unless WithCodes is given.
+var DefaultCodes = []codes.Code{codes.Unavailable, codes.ResourceExhausted}
+
+// Option configures an interceptor created by UnaryClientInterceptor.
+type Option func(*options)
+
+type options struct {
+	codes map[codes.Code]bool
+	// idempotent reports whether a method is safe to call more than once.
+	idempotent    map[string]bool
+	allIdempotent bool
+}
+
+// WithCodes replaces DefaultCodes as the status codes that are retried.
+func WithCodes(c ...codes.Code) Option {
+	return func(o *options) {
+		o.codes = make(map[codes.Code]bool, len(c))
+		for _, code := range c {
+			o.codes[code] = true
+		}
+	}
+}
+
+// Codes returns a predicate reporting whether err is a gRPC status error with
+// one of the codes c, looking through wrapped errors. It plugs into
+// retry.WithShouldRetry and combines with the predicates of the classify
+// package, for gRPC calls made outside of UnaryClientInterceptor.
+func Codes(c ...codes.Code) func(err error) bool {
+	set := make(map[codes.Code]bool, len(c))
+	for _, code := range c {
+		set[code] = true
+	}
+	return func(err error) bool {
+		if err == nil {
+			return false
+		}
+		s, ok := status.FromError(err)
+		return ok && set[s.Code()]
+	}
+}
+
+// WithIdempotentMethods allows the calls to the given methods, named in full
+// as in "/package.Service/Method", to be retried.
+func WithIdempotentMethods(methods ...string) Option {
+	return func(o *options) {
+		for _, m := range methods {
+			o.idempotent[m] = true
+		}
+	}
+}
+
+// WithAllIdempotent allows every call to be retried, for clients whose
+// methods are all safe to call more than once.
+func WithAllIdempotent() Option {
+	return func(o *options) {
+		o.allIdempotent = true
+	}
+}
+
+// callOption overrides, for one call, whether it may be retried.
+type callOption struct {
+	grpc.EmptyCallOption
+	retry bool
+}
+
+// WithRetry is a grpc.CallOption that overrides, for one call, whether the
+// interceptor may retry it: true allows it even if the method isn't known to
+// be idempotent, and false sends it once.
+func WithRetry(allowed bool) grpc.CallOption {
+	return callOption{retry: allowed}
+}
+
+// UnaryClientInterceptor returns an interceptor that retries unary calls
+// failing with one of DefaultCodes, or those given with WithCodes, with a
+// fresh backoff from newBackoff for each call. Waiting stops as soon as the
+// call's context is done, and the call then fails with the status of the
+// context's error.
+//
+// Only calls known to be idempotent are retried: those to methods given with
+// WithIdempotentMethods, every call with WithAllIdempotent, or calls made
+// with WithRetry(true). Other calls are sent once. Streams are never retried,
+// as there is no stream interceptor.
+//
+// When the retries run out, or a call fails with a status that isn't
+// retried, the status error of the last attempt is returned unchanged.
+func UnaryClientInterceptor(newBackoff func() backoff.Backoff, opts ...Option) grpc.UnaryClientInterceptor {
+	o := options{idempotent: make(map[string]bool)}
+	WithCodes(DefaultCodes...)(&o)
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		if !o.retryable(method, callOpts) {
+			return invoker(ctx, method, req, reply, cc, callOpts...)
+		}
+		if newBackoff == nil {
+			return backoff.ErrNilBackoff
+		}
+
+		// last is the error of the latest attempt.
+		var last error
+		err := retry.Do(ctx, newBackoff(), func(ctx context.Context) error {
+			last = invoker(ctx, method, req, reply, cc, callOpts...)
+			if last != nil && o.codes[status.Code(last)] {
+				return retry.RetryableError(last)
+			}
+			return nil
+		})
+		switch {
+		case err == nil:
+			return last
+		case ctx.Err() != nil:
+			return status.FromContextError(ctx.Err()).Err()
+		case last != nil:
+			return last
+		default:
+			return err
+		}
+	}
+}
+
+// retryable reports whether a call to method made with callOpts may be
+// retried.
+func (o *options) retryable(method string, callOpts []grpc.CallOption) bool {
+	for i := len(callOpts) - 1; i >= 0; i-- {
+		if c, ok := callOpts[i].(callOption); ok {
+			return c.retry
+		}
+	}
+	return o.allIdempotent || o.idempotent[method]
+}
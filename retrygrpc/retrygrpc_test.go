@@ -0,0 +1,261 @@
+package retrygrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/swayne275/go-retry/backoff"
+)
+
+const checkMethod = "/grpc.health.v1.Health/Check"
+
+// failingServer is a health server that fails the first failures calls with
+// code, and reports SERVING after that.
+type failingServer struct {
+	healthpb.UnimplementedHealthServer
+
+	mu       sync.Mutex
+	calls    int
+	failures int
+	code     codes.Code
+}
+
+func (s *failingServer) Check(context.Context, *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls++
+	if s.calls <= s.failures {
+		return nil, status.Errorf(s.code, "failure %d", s.calls)
+	}
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+}
+
+func (s *failingServer) Calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.calls
+}
+
+// newClient serves s over an in-memory connection and returns a client that
+// calls it through interceptor.
+func newClient(t *testing.T, s *failingServer, interceptor grpc.UnaryClientInterceptor) healthpb.HealthClient {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	healthpb.RegisterHealthServer(srv, s)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(interceptor),
+	)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return healthpb.NewHealthClient(conn)
+}
+
+func newBackoff() backoff.Backoff {
+	b, err := backoff.NewConstant(1 * time.Millisecond)
+	if err != nil {
+		panic(err)
+	}
+	return backoff.WithMaxRetries(3, b)
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		failures int
+		code     codes.Code
+		opts     []Option
+		callOpts []grpc.CallOption
+		calls    int
+		// err is the code of the error the call fails with, or codes.OK.
+		err codes.Code
+	}{
+		{
+			name:     "retried_until_success",
+			failures: 2,
+			code:     codes.Unavailable,
+			opts:     []Option{WithIdempotentMethods(checkMethod)},
+			calls:    3,
+		},
+		{
+			name:     "resource_exhausted",
+			failures: 1,
+			code:     codes.ResourceExhausted,
+			opts:     []Option{WithAllIdempotent()},
+			calls:    2,
+		},
+		{
+			name:     "exhausted",
+			failures: 10,
+			code:     codes.Unavailable,
+			opts:     []Option{WithAllIdempotent()},
+			calls:    4,
+			err:      codes.Unavailable,
+		},
+		{
+			name:     "code_not_retried",
+			failures: 1,
+			code:     codes.InvalidArgument,
+			opts:     []Option{WithAllIdempotent()},
+			calls:    1,
+			err:      codes.InvalidArgument,
+		},
+		{
+			name:     "custom_codes",
+			failures: 1,
+			code:     codes.Aborted,
+			opts:     []Option{WithAllIdempotent(), WithCodes(codes.Aborted)},
+			calls:    2,
+		},
+		{
+			name:     "not_idempotent",
+			failures: 1,
+			code:     codes.Unavailable,
+			calls:    1,
+			err:      codes.Unavailable,
+		},
+		{
+			name:     "call_option_allows",
+			failures: 1,
+			code:     codes.Unavailable,
+			callOpts: []grpc.CallOption{WithRetry(true)},
+			calls:    2,
+		},
+		{
+			name:     "call_option_refuses",
+			failures: 1,
+			code:     codes.Unavailable,
+			opts:     []Option{WithAllIdempotent()},
+			callOpts: []grpc.CallOption{WithRetry(false)},
+			calls:    1,
+			err:      codes.Unavailable,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := &failingServer{failures: tc.failures, code: tc.code}
+			client := newClient(t, s, UnaryClientInterceptor(newBackoff, tc.opts...))
+
+			_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{}, tc.callOpts...)
+			if got := status.Code(err); got != tc.err {
+				t.Errorf("expected %v to be %v", got, tc.err)
+			}
+			if got := s.Calls(); got != tc.calls {
+				t.Errorf("expected %d to be %d", got, tc.calls)
+			}
+		})
+	}
+
+	t.Run("status_unchanged", func(t *testing.T) {
+		t.Parallel()
+
+		s := &failingServer{failures: 10, code: codes.Unavailable}
+		client := newClient(t, s, UnaryClientInterceptor(newBackoff, WithAllIdempotent()))
+
+		_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+		st, ok := status.FromError(err)
+		if !ok || st.Message() != "failure 4" {
+			t.Errorf("expected %q to be the status of the last attempt", err)
+		}
+	})
+
+	t.Run("context_canceled", func(t *testing.T) {
+		t.Parallel()
+
+		slow := func() backoff.Backoff {
+			b, err := backoff.NewConstant(1 * time.Hour)
+			if err != nil {
+				panic(err)
+			}
+			return b
+		}
+
+		s := &failingServer{failures: 10, code: codes.Unavailable}
+		client := newClient(t, s, UnaryClientInterceptor(slow, WithAllIdempotent()))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+		if got := status.Code(err); got != codes.DeadlineExceeded {
+			t.Errorf("expected %v to be %v", got, codes.DeadlineExceeded)
+		}
+		if got := s.Calls(); got != 1 {
+			t.Errorf("expected %d to be %d", got, 1)
+		}
+	})
+
+	t.Run("nil_backoff", func(t *testing.T) {
+		t.Parallel()
+
+		s := &failingServer{}
+		client := newClient(t, s, UnaryClientInterceptor(nil, WithAllIdempotent()))
+
+		_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+		if !errors.Is(err, backoff.ErrNilBackoff) {
+			t.Errorf("expected %q to be %q", err, backoff.ErrNilBackoff)
+		}
+	})
+}
+
+func TestCodes(t *testing.T) {
+	t.Parallel()
+
+	pred := Codes(codes.Unavailable, codes.Aborted)
+
+	cases := []struct {
+		name string
+		err  error
+		exp  bool
+	}{
+		{name: "nil", err: nil, exp: false},
+		{name: "not_status", err: errors.New("oops"), exp: false},
+		{name: "matching", err: status.Error(codes.Unavailable, "down"), exp: true},
+		{name: "wrapped", err: fmt.Errorf("call: %w", status.Error(codes.Aborted, "conflict")), exp: true},
+		{name: "other_code", err: status.Error(codes.InvalidArgument, "bad"), exp: false},
+		{name: "ok", err: status.Error(codes.OK, ""), exp: false},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := pred(tc.err); got != tc.exp {
+				t.Errorf("expected %t to be %t", got, tc.exp)
+			}
+		})
+	}
+}
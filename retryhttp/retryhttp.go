@@ -0,0 +1,248 @@
+// Package retryhttp provides an http.RoundTripper that retries failed requests
+// with the retry package.
+package retryhttp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+	"github.com/swayne275/go-retry/internal/clock"
+	"github.com/swayne275/go-retry/retry"
+)
+
+// Option configures a transport created by NewTransport.
+type Option func(*options)
+
+type options struct {
+	clock       retry.Clock
+	shouldRetry func(resp *http.Response, err error) bool
+
+	// maxRetries, if maxRetriesSet, limits the retries of each request.
+	maxRetries    uint64
+	maxRetriesSet bool
+}
+
+// WithShouldRetry replaces DefaultShouldRetry as the predicate that decides
+// whether a request is retried, given the response and error of the attempt.
+// A nil f selects DefaultShouldRetry.
+func WithShouldRetry(f func(resp *http.Response, err error) bool) Option {
+	return func(o *options) {
+		o.shouldRetry = f
+	}
+}
+
+// WithMaxRetries limits each request to at most max retries, on top of
+// whatever limit the backoff itself has.
+func WithMaxRetries(max uint64) Option {
+	return func(o *options) {
+		o.maxRetries = max
+		o.maxRetriesSet = true
+	}
+}
+
+// WithClock makes the transport use c for its sleeps and to interpret
+// Retry-After dates, instead of the real clock. A nil c selects the real
+// clock.
+func WithClock(c retry.Clock) Option {
+	return func(o *options) {
+		o.clock = clock.Or(c)
+	}
+}
+
+// DefaultShouldRetry retries transport errors and responses with a 5xx
+// status code.
+func DefaultShouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode >= 500 && resp.StatusCode <= 599
+}
+
+type transport struct {
+	base    http.RoundTripper
+	backoff func() backoff.Backoff
+	o       options
+}
+
+// NewTransport returns a RoundTripper that sends requests through base,
+// retrying them with a fresh backoff from b for each request. A nil base
+// selects http.DefaultTransport.
+//
+// By default, transport errors and 5xx responses are retried; see
+// WithShouldRetry. If a retried response carries a Retry-After header, in
+// seconds or as a date, the transport waits at least that long before the
+// next attempt. Waiting stops as soon as the request's context is done.
+//
+// Only requests whose body can be replayed are retried: those with no body,
+// or with GetBody set, as http.NewRequest does for in-memory bodies. Other
+// requests are sent once.
+//
+// When the retries run out, the last response is returned as is, so callers
+// see the final status code and body. If the last attempt failed with a
+// transport error, the returned error wraps it and
+// retry.ErrBackoffSignaledToStop.
+func NewTransport(base http.RoundTripper, b func() backoff.Backoff, opts ...Option) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	o := options{
+		clock:       clock.Real,
+		shouldRetry: DefaultShouldRetry,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+	if o.shouldRetry == nil {
+		o.shouldRetry = DefaultShouldRetry
+	}
+	return &transport{base: base, backoff: b, o: o}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !replayable(req) {
+		return t.base.RoundTrip(req)
+	}
+
+	if t.backoff == nil {
+		closeBody(req)
+		return nil, backoff.ErrNilBackoff
+	}
+	b := &retryAfter{Backoff: t.backoff()}
+	var bo backoff.Backoff = b
+	if t.o.maxRetriesSet {
+		bo = backoff.WithMaxRetries(t.o.maxRetries, bo)
+	}
+
+	// resp and rtErr are the outcome of the latest attempt. A response that
+	// is retried stays open until the next attempt starts, so it can be
+	// returned if the retries run out.
+	var resp *http.Response
+	var rtErr error
+	sent := false
+	err := retry.DoWithOptions(req.Context(), bo, func(ctx context.Context) error {
+		sent = true
+		attemptReq := req
+		if retry.AttemptFromContext(ctx) > 1 {
+			discard(resp)
+			resp, rtErr = nil, nil
+
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					rtErr = fmt.Errorf("retryhttp: failed to replay body: %w", err)
+					return nil
+				}
+				attemptReq = req.Clone(req.Context())
+				attemptReq.Body = body
+			}
+		}
+
+		resp, rtErr = t.base.RoundTrip(attemptReq)
+		if !t.o.shouldRetry(resp, rtErr) {
+			return nil
+		}
+		if rtErr != nil {
+			return retry.RetryableError(rtErr)
+		}
+		b.hint = retryAfterHint(resp, t.o.clock.Now())
+		return retry.RetryableError(fmt.Errorf("retryhttp: %s", resp.Status))
+	}, retry.WithClock(t.o.clock))
+
+	if !sent {
+		closeBody(req)
+	}
+	switch {
+	case err == nil:
+		return resp, rtErr
+	case req.Context().Err() != nil || resp == nil:
+		discard(resp)
+		return nil, err
+	default:
+		return resp, nil
+	}
+}
+
+// replayable reports whether req can be sent more than once.
+func replayable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// closeBody closes the body of a request that will not be sent, as a
+// RoundTripper must.
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
+
+// discard drains and closes the body of a response that is not returned, so
+// its connection can be reused.
+func discard(resp *http.Response) {
+	if resp == nil || resp.Body == nil {
+		return
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrain))
+	resp.Body.Close()
+}
+
+// maxDrain is how much of a discarded response body is read to let its
+// connection be reused. Larger bodies are abandoned.
+const maxDrain = 4 << 10
+
+// retryAfterHint returns the wait requested by resp's Retry-After header,
+// relative to now, or zero if there is none.
+func retryAfterHint(resp *http.Response, now time.Time) time.Duration {
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+		if secs <= 0 || secs > int64(maxRetryAfter/time.Second) {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		if d := at.Sub(now); d > 0 && d <= maxRetryAfter {
+			return d
+		}
+	}
+	return 0
+}
+
+// maxRetryAfter bounds the Retry-After values that are honored, keeping a
+// malformed header from stalling a request indefinitely.
+const maxRetryAfter = 24 * time.Hour
+
+// retryAfter is a backoff that waits at least hint before the next attempt,
+// once.
+type retryAfter struct {
+	backoff.Backoff
+	hint time.Duration
+}
+
+// Next returns the wrapped backoff's next value, raised to the pending hint.
+func (b *retryAfter) Next() (time.Duration, bool) {
+	next, stop := b.Backoff.Next()
+	if stop {
+		return 0, true
+	}
+	if b.hint > next {
+		next = b.hint
+	}
+	b.hint = 0
+	return next, false
+}
+
+// Unwrap returns the wrapped backoff.
+func (b *retryAfter) Unwrap() backoff.Backoff {
+	return b.Backoff
+}
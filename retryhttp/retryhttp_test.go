@@ -0,0 +1,364 @@
+package retryhttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+	"github.com/swayne275/go-retry/retry"
+	"github.com/swayne275/go-retry/retrytest"
+)
+
+// failingServer fails the first failures requests with status and serves
+// "ok" after that. It records the body of every request.
+type failingServer struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	calls  int
+	bodies []string
+}
+
+func newFailingServer(t *testing.T, failures, status int, header http.Header) *failingServer {
+	t.Helper()
+
+	s := &failingServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		s.mu.Lock()
+		s.calls++
+		s.bodies = append(s.bodies, string(body))
+		fail := s.calls <= failures
+		s.mu.Unlock()
+
+		if fail {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(status)
+			fmt.Fprint(w, "failed")
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *failingServer) Calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.calls
+}
+
+func (s *failingServer) Bodies() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.bodies...)
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func constant(t *testing.T) func() backoff.Backoff {
+	t.Helper()
+
+	return func() backoff.Backoff {
+		b, err := backoff.NewConstant(1 * time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create constant backoff: %v", err)
+		}
+		return b
+	}
+}
+
+func readBody(t *testing.T, resp *http.Response) string {
+	t.Helper()
+
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read body: %v", err)
+	}
+	return string(b)
+}
+
+func TestTransport(t *testing.T) {
+	t.Parallel()
+
+	t.Run("fails_then_succeeds", func(t *testing.T) {
+		t.Parallel()
+
+		for _, status := range []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable} {
+			status := status
+
+			t.Run(fmt.Sprint(status), func(t *testing.T) {
+				t.Parallel()
+
+				s := newFailingServer(t, 3, status, nil)
+				client := &http.Client{Transport: NewTransport(nil, constant(t))}
+
+				resp, err := client.Get(s.URL)
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				if resp.StatusCode != http.StatusOK {
+					t.Errorf("expected %d to be %d", resp.StatusCode, http.StatusOK)
+				}
+				if got := readBody(t, resp); got != "ok" {
+					t.Errorf("expected %q to be %q", got, "ok")
+				}
+				if got, want := s.Calls(), 4; got != want {
+					t.Errorf("expected %d to be %d", got, want)
+				}
+			})
+		}
+	})
+
+	t.Run("client_error_not_retried", func(t *testing.T) {
+		t.Parallel()
+
+		s := newFailingServer(t, 3, http.StatusNotFound, nil)
+		client := &http.Client{Transport: NewTransport(nil, constant(t))}
+
+		resp, err := client.Get(s.URL)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("expected %d to be %d", resp.StatusCode, http.StatusNotFound)
+		}
+		if got := readBody(t, resp); got != "failed" {
+			t.Errorf("expected %q to be %q", got, "failed")
+		}
+		if got, want := s.Calls(), 1; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("exhausted_returns_last_response", func(t *testing.T) {
+		t.Parallel()
+
+		s := newFailingServer(t, 100, http.StatusServiceUnavailable, nil)
+		client := &http.Client{Transport: NewTransport(nil, constant(t), WithMaxRetries(2))}
+
+		resp, err := client.Get(s.URL)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if resp.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("expected %d to be %d", resp.StatusCode, http.StatusServiceUnavailable)
+		}
+		if got := readBody(t, resp); got != "failed" {
+			t.Errorf("expected %q to be %q", got, "failed")
+		}
+		if got, want := s.Calls(), 3; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("post_body_replayed", func(t *testing.T) {
+		t.Parallel()
+
+		s := newFailingServer(t, 2, http.StatusInternalServerError, nil)
+		client := &http.Client{Transport: NewTransport(nil, constant(t))}
+
+		resp, err := client.Post(s.URL, "text/plain", strings.NewReader("payload"))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if got := readBody(t, resp); got != "ok" {
+			t.Errorf("expected %q to be %q", got, "ok")
+		}
+
+		bodies := s.Bodies()
+		if len(bodies) != 3 {
+			t.Fatalf("expected 3 requests, got %d", len(bodies))
+		}
+		for i, body := range bodies {
+			if body != "payload" {
+				t.Errorf("expected body %d %q to be %q", i, body, "payload")
+			}
+		}
+	})
+
+	t.Run("non_replayable_body_not_retried", func(t *testing.T) {
+		t.Parallel()
+
+		s := newFailingServer(t, 2, http.StatusInternalServerError, nil)
+		client := &http.Client{Transport: NewTransport(nil, constant(t))}
+
+		req, err := http.NewRequest(http.MethodPost, s.URL, io.NopCloser(strings.NewReader("payload")))
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if resp.StatusCode != http.StatusInternalServerError {
+			t.Errorf("expected %d to be %d", resp.StatusCode, http.StatusInternalServerError)
+		}
+		readBody(t, resp)
+		if got, want := s.Calls(), 1; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("transport_errors", func(t *testing.T) {
+		t.Parallel()
+
+		s := newFailingServer(t, 0, 0, nil)
+		var calls int64
+		base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if atomic.AddInt64(&calls, 1) <= 2 {
+				return nil, fmt.Errorf("connection reset")
+			}
+			return http.DefaultTransport.RoundTrip(req)
+		})
+		client := &http.Client{Transport: NewTransport(base, constant(t))}
+
+		resp, err := client.Get(s.URL)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if got := readBody(t, resp); got != "ok" {
+			t.Errorf("expected %q to be %q", got, "ok")
+		}
+		if got, want := atomic.LoadInt64(&calls), int64(3); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("transport_errors_exhausted", func(t *testing.T) {
+		t.Parallel()
+
+		errReset := fmt.Errorf("connection reset")
+		base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return nil, errReset
+		})
+
+		req, err := http.NewRequest(http.MethodGet, "http://example.invalid", nil)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		_, err = NewTransport(base, constant(t), WithMaxRetries(2)).RoundTrip(req)
+		if !errors.Is(err, errReset) || !errors.Is(err, retry.ErrBackoffSignaledToStop) {
+			t.Errorf("expected %v to be %v and %v", err, errReset, retry.ErrBackoffSignaledToStop)
+		}
+	})
+
+	t.Run("context_canceled", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var calls int64
+		base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			atomic.AddInt64(&calls, 1)
+			cancel()
+			return nil, req.Context().Err()
+		})
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://example.invalid", nil)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		_, err = NewTransport(base, constant(t)).RoundTrip(req)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected %v to be %v", err, context.Canceled)
+		}
+		if got, want := atomic.LoadInt64(&calls), int64(1); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("retry_after", func(t *testing.T) {
+		t.Parallel()
+
+		start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		cases := []struct {
+			name   string
+			header string
+			want   time.Duration
+		}{
+			{name: "seconds", header: "7", want: 7 * time.Second},
+			{name: "date", header: start.Add(30 * time.Second).Format(http.TimeFormat), want: 30 * time.Second},
+			{name: "past_date", header: start.Add(-time.Minute).Format(http.TimeFormat), want: 1 * time.Nanosecond},
+			{name: "invalid", header: "soon", want: 1 * time.Nanosecond},
+		}
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				s := newFailingServer(t, 1, http.StatusServiceUnavailable, http.Header{"Retry-After": {tc.header}})
+				c := retrytest.NewInstantClock(start)
+				client := &http.Client{Transport: NewTransport(nil, constant(t), WithClock(c))}
+
+				resp, err := client.Get(s.URL)
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				readBody(t, resp)
+
+				sleeps := c.Sleeps()
+				if len(sleeps) != 1 || sleeps[0] != tc.want {
+					t.Errorf("expected %v to be [%v]", sleeps, tc.want)
+				}
+			})
+		}
+	})
+
+	t.Run("should_retry", func(t *testing.T) {
+		t.Parallel()
+
+		s := newFailingServer(t, 2, http.StatusTooManyRequests, nil)
+		client := &http.Client{Transport: NewTransport(nil, constant(t), WithShouldRetry(func(resp *http.Response, err error) bool {
+			return err != nil || resp.StatusCode == http.StatusTooManyRequests
+		}))}
+
+		resp, err := client.Get(s.URL)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if got := readBody(t, resp); got != "ok" {
+			t.Errorf("expected %q to be %q", got, "ok")
+		}
+		if got, want := s.Calls(), 3; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("nil_backoff", func(t *testing.T) {
+		t.Parallel()
+
+		req, err := http.NewRequest(http.MethodGet, "http://example.invalid", nil)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		for _, b := range []func() backoff.Backoff{nil, func() backoff.Backoff { return nil }} {
+			if _, err := NewTransport(nil, b).RoundTrip(req); !errors.Is(err, backoff.ErrNilBackoff) {
+				t.Errorf("expected %v to be %v", err, backoff.ErrNilBackoff)
+			}
+		}
+	})
+}
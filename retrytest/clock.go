@@ -0,0 +1,195 @@
+// Package retrytest provides fakes and behavioral checks for testing code
+// built on the retry and repeat packages.
+package retrytest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/swayne275/go-retry/retry"
+)
+
+var _ retry.Clock = (*InstantClock)(nil)
+
+// InstantClock is a fake clock whose timers fire immediately. Creating a timer
+// for d advances the clock's time by d, so a retry loop driven by it runs
+// without real sleeps while still observing the time it would have slept.
+//
+// InstantClock is safe for concurrent use. The zero InstantClock starts at the
+// zero time.
+type InstantClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	sleeps []time.Duration
+}
+
+// NewInstantClock creates an InstantClock whose time starts at start.
+func NewInstantClock(start time.Time) *InstantClock {
+	return &InstantClock{now: start}
+}
+
+// Now returns the clock's current fake time.
+func (c *InstantClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// NewTimer advances the clock by d and returns a timer that has already fired.
+func (c *InstantClock) NewTimer(d time.Duration) retry.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if d > 0 {
+		c.now = c.now.Add(d)
+	}
+	c.sleeps = append(c.sleeps, d)
+
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return firedTimer(ch)
+}
+
+// Advance moves the clock's time forward by d without recording a sleep, to
+// simulate time passing while the code under test is busy.
+func (c *InstantClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+// Sleeps returns the durations of every timer created so far, in order.
+func (c *InstantClock) Sleeps() []time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]time.Duration(nil), c.sleeps...)
+}
+
+type firedTimer chan time.Time
+
+func (t firedTimer) C() <-chan time.Time {
+	return t
+}
+
+func (t firedTimer) Stop() bool {
+	return false
+}
+
+var _ retry.Clock = (*ManualClock)(nil)
+
+// ManualClock is a fake clock whose time only moves when Advance is called.
+// Its timers fire once the clock has been advanced to or past their deadline,
+// so a test can run the code under test in a goroutine and step it through its
+// sleeps deterministically, using WaitForTimers to know when it is sleeping.
+//
+// ManualClock is safe for concurrent use. The zero ManualClock starts at the
+// zero time.
+type ManualClock struct {
+	mu      sync.Mutex
+	cond    sync.Cond
+	now     time.Time
+	pending []*manualTimer
+}
+
+// NewManualClock creates a ManualClock whose time starts at start.
+func NewManualClock(start time.Time) *ManualClock {
+	return &ManualClock{now: start}
+}
+
+// Now returns the clock's current fake time.
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// NewTimer returns a timer that fires once the clock has been advanced by d.
+// A timer for zero or a negative d has already fired.
+func (c *ManualClock) NewTimer(d time.Duration) retry.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &manualTimer{c: c, at: c.now.Add(d), ch: make(chan time.Time, 1)}
+	if d <= 0 {
+		t.ch <- c.now
+		return t
+	}
+	c.pending = append(c.pending, t)
+	c.signal()
+	return t
+}
+
+// Advance moves the clock's time forward by d and fires every timer whose
+// deadline has been reached.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	pending := c.pending[:0]
+	for _, t := range c.pending {
+		if t.at.After(c.now) {
+			pending = append(pending, t)
+			continue
+		}
+		t.ch <- c.now
+	}
+	clear(c.pending[len(pending):])
+	c.pending = pending
+	c.signal()
+}
+
+// Timers returns the number of timers that have neither fired nor been
+// stopped.
+func (c *ManualClock) Timers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.pending)
+}
+
+// WaitForTimers blocks until at least n timers are pending, that is, until the
+// code under test is sleeping on them.
+func (c *ManualClock) WaitForTimers(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cond.L = &c.mu
+	for len(c.pending) < n {
+		c.cond.Wait()
+	}
+}
+
+// signal wakes WaitForTimers callers. c.mu must be held.
+func (c *ManualClock) signal() {
+	c.cond.L = &c.mu
+	c.cond.Broadcast()
+}
+
+type manualTimer struct {
+	c  *ManualClock
+	at time.Time
+	ch chan time.Time
+}
+
+func (t *manualTimer) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *manualTimer) Stop() bool {
+	t.c.mu.Lock()
+	defer t.c.mu.Unlock()
+
+	for i, p := range t.c.pending {
+		if p == t {
+			t.c.pending = append(t.c.pending[:i], t.c.pending[i+1:]...)
+			t.c.signal()
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,244 @@
+package retrytest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+	"github.com/swayne275/go-retry/retry"
+)
+
+// TB is the subset of testing.TB used by the contract checks.
+type TB interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// DoFunc adapts a retry loop under test, such as retry.DoWithOptions or a
+// wrapper built on it, to the contract checks. It must call f until f returns
+// nil or b signals to stop, treating every non-nil error from f as retryable,
+// and must perform all of its sleeps through c.
+type DoFunc func(ctx context.Context, b backoff.Backoff, c retry.Clock, f func(ctx context.Context) error) error
+
+// errAttempt is returned by the attempt function the checks pass to a DoFunc.
+var errAttempt = errors.New("retrytest: scripted failure")
+
+// scriptedDelays is the schedule the checks drive loops with. The values are
+// small so that an implementation which ignores the clock and really sleeps
+// still finishes quickly.
+var scriptedDelays = []time.Duration{
+	1 * time.Millisecond,
+	3 * time.Millisecond,
+	2 * time.Millisecond,
+}
+
+type eventKind int
+
+const (
+	eventAttempt eventKind = iota
+	eventNext
+	eventStop
+	eventSleep
+)
+
+func (k eventKind) String() string {
+	switch k {
+	case eventAttempt:
+		return "attempt"
+	case eventNext:
+		return "next"
+	case eventStop:
+		return "stop"
+	case eventSleep:
+		return "sleep"
+	default:
+		return "unknown"
+	}
+}
+
+type event struct {
+	kind eventKind
+	d    time.Duration
+	at   time.Time
+}
+
+func (e event) String() string {
+	if e.kind == eventAttempt {
+		return e.kind.String()
+	}
+	return fmt.Sprintf("%v(%v)", e.kind, e.d)
+}
+
+// trace runs do against a scripted backoff that yields scriptedDelays and then
+// stops, with an attempt function that always fails. It returns everything
+// that happened, in order.
+func trace(do DoFunc) ([]event, error) {
+	start := time.Unix(0, 0)
+	c := &recordingClock{InstantClock: NewInstantClock(start)}
+
+	var i int
+	b := backoff.BackoffFunc(func() (time.Duration, bool) {
+		if i >= len(scriptedDelays) {
+			c.record(eventStop, 0)
+			return 0, true
+		}
+		d := scriptedDelays[i]
+		i++
+		c.record(eventNext, d)
+		return d, false
+	})
+
+	err := do(context.Background(), b, c, func(_ context.Context) error {
+		c.record(eventAttempt, 0)
+		return errAttempt
+	})
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]event(nil), c.events...), err
+}
+
+type recordingClock struct {
+	*InstantClock
+
+	mu     sync.Mutex
+	events []event
+}
+
+func (c *recordingClock) record(kind eventKind, d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.events = append(c.events, event{kind: kind, d: d, at: c.Now()})
+}
+
+func (c *recordingClock) NewTimer(d time.Duration) retry.Timer {
+	t := c.InstantClock.NewTimer(d)
+	c.record(eventSleep, d)
+	return t
+}
+
+func attempts(events []event) []event {
+	var out []event
+	for _, e := range events {
+		if e.kind == eventAttempt {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Contract runs every check in this package against do.
+func Contract(t TB, do DoFunc) {
+	t.Helper()
+
+	AssertFirstAttemptImmediate(t, do)
+	AssertOneAttemptPerDelay(t, do)
+	AssertSleepAtLeast(t, do)
+	AssertNoAttemptAfterStop(t, do)
+}
+
+// AssertFirstAttemptImmediate checks that do makes its first attempt before
+// consulting the backoff or sleeping.
+func AssertFirstAttemptImmediate(t TB, do DoFunc) {
+	t.Helper()
+
+	events, _ := trace(do)
+	if len(events) == 0 || events[0].kind != eventAttempt {
+		t.Errorf("first attempt is not immediate: events were %v", events)
+		return
+	}
+	if !events[0].at.Equal(time.Unix(0, 0)) {
+		t.Errorf("first attempt is not immediate: it started %v after the loop", events[0].at.Sub(time.Unix(0, 0)))
+	}
+}
+
+// AssertOneAttemptPerDelay checks that do makes exactly one attempt per
+// value taken from the backoff, plus the initial attempt.
+func AssertOneAttemptPerDelay(t TB, do DoFunc) {
+	t.Helper()
+
+	events, _ := trace(do)
+
+	var attemptsSinceNext int
+	var sawFirst bool
+	for _, e := range events {
+		switch e.kind {
+		case eventAttempt:
+			attemptsSinceNext++
+			if attemptsSinceNext > 1 {
+				t.Errorf("more than one attempt per backoff value: events were %v", events)
+				return
+			}
+			sawFirst = true
+		case eventNext:
+			if sawFirst && attemptsSinceNext == 0 {
+				t.Errorf("backoff consulted without an attempt in between: events were %v", events)
+				return
+			}
+			attemptsSinceNext = 0
+		}
+	}
+
+	if got, want := len(attempts(events)), len(scriptedDelays)+1; got != want {
+		t.Errorf("expected %d attempts for %d backoff values, got %d: events were %v", want, len(scriptedDelays), got, events)
+	}
+}
+
+// AssertSleepAtLeast checks that do never starts an attempt sooner after the
+// previous one than the backoff value between them.
+func AssertSleepAtLeast(t TB, do DoFunc) {
+	t.Helper()
+
+	events, _ := trace(do)
+
+	var prev *event
+	var delay time.Duration
+	for i := range events {
+		e := events[i]
+		switch e.kind {
+		case eventNext:
+			delay = e.d
+		case eventAttempt:
+			if prev != nil {
+				if gap := e.at.Sub(prev.at); gap < delay {
+					t.Errorf("attempt started %v after the previous one, want at least %v: events were %v", gap, delay, events)
+					return
+				}
+			}
+			prev = &events[i]
+			delay = 0
+		}
+	}
+}
+
+// AssertNoAttemptAfterStop checks that once the backoff signals to stop, do
+// returns an error without making further attempts or consulting the backoff
+// again.
+func AssertNoAttemptAfterStop(t TB, do DoFunc) {
+	t.Helper()
+
+	events, err := trace(do)
+
+	stopped := false
+	for _, e := range events {
+		if stopped {
+			t.Errorf("%v after the backoff signaled to stop: events were %v", e.kind, events)
+			return
+		}
+		if e.kind == eventStop {
+			stopped = true
+		}
+	}
+
+	if !stopped {
+		t.Errorf("loop returned without exhausting the backoff: events were %v", events)
+		return
+	}
+	if err == nil {
+		t.Errorf("expected an error after the backoff signaled to stop")
+	}
+}
@@ -0,0 +1,313 @@
+package retrytest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+	"github.com/swayne275/go-retry/repeat"
+	"github.com/swayne275/go-retry/retry"
+)
+
+var errDone = errors.New("done")
+
+func retryDo(ctx context.Context, b backoff.Backoff, c retry.Clock, f func(ctx context.Context) error) error {
+	return retry.DoWithOptions(ctx, b, func(ctx context.Context) error {
+		return retry.RetryableError(f(ctx))
+	}, retry.WithClock(c))
+}
+
+func repeatDoUntilError(ctx context.Context, b backoff.Backoff, c retry.Clock, f func(ctx context.Context) error) error {
+	return repeat.DoUntilErrorWithOptions(ctx, b, func(ctx context.Context) error {
+		if err := f(ctx); err == nil {
+			return errDone
+		}
+		return nil
+	}, repeat.WithClock(c))
+}
+
+func repeatDo(ctx context.Context, b backoff.Backoff, c retry.Clock, f func(ctx context.Context) error) error {
+	return repeat.DoWithOptions(ctx, b, func(ctx context.Context) bool {
+		return f(ctx) != nil
+	}, repeat.WithClock(c))
+}
+
+// toyLoop is a simple retry loop with hooks to break individual guarantees.
+type toyLoop struct {
+	sleepFirst   bool
+	doubleTap    bool
+	sleepDivisor time.Duration
+	ignoreStop   bool
+}
+
+func (l toyLoop) do(ctx context.Context, b backoff.Backoff, c retry.Clock, f func(ctx context.Context) error) error {
+	sleep := func(d time.Duration) {
+		if l.sleepDivisor > 0 {
+			d /= l.sleepDivisor
+		}
+		<-c.NewTimer(d).C()
+	}
+
+	if l.sleepFirst {
+		sleep(1 * time.Millisecond)
+	}
+
+	stopped := false
+	for {
+		err := f(ctx)
+		if err == nil {
+			return nil
+		}
+		if stopped {
+			return err
+		}
+		if l.doubleTap {
+			if err = f(ctx); err == nil {
+				return nil
+			}
+		}
+
+		next, stop := b.Next()
+		if stop {
+			if !l.ignoreStop {
+				return err
+			}
+			stopped = true
+		}
+		sleep(next)
+	}
+}
+
+type fakeTB struct {
+	errors []string
+}
+
+func (t *fakeTB) Helper() {}
+
+func (t *fakeTB) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestContract(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		do   DoFunc
+	}{
+		{name: "retry.DoWithOptions", do: retryDo},
+		{name: "repeat.DoWithOptions", do: repeatDo},
+		{name: "repeat.DoUntilErrorWithOptions", do: repeatDoUntilError},
+		{name: "toy", do: toyLoop{}.do},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			Contract(t, tc.do)
+		})
+	}
+}
+
+func TestContractFlagsBrokenLoops(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		check  func(TB, DoFunc)
+		loop   toyLoop
+		expect string
+	}{
+		{
+			name:   "sleeps_before_first_attempt",
+			check:  AssertFirstAttemptImmediate,
+			loop:   toyLoop{sleepFirst: true},
+			expect: "first attempt is not immediate",
+		},
+		{
+			name:   "two_attempts_per_delay",
+			check:  AssertOneAttemptPerDelay,
+			loop:   toyLoop{doubleTap: true},
+			expect: "more than one attempt per backoff value",
+		},
+		{
+			name:   "sleeps_too_little",
+			check:  AssertSleepAtLeast,
+			loop:   toyLoop{sleepDivisor: 2},
+			expect: "want at least",
+		},
+		{
+			name:   "attempts_after_stop",
+			check:  AssertNoAttemptAfterStop,
+			loop:   toyLoop{ignoreStop: true},
+			expect: "after the backoff signaled to stop",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			// The healthy loop passes the check.
+			var healthy fakeTB
+			tc.check(&healthy, toyLoop{}.do)
+			if len(healthy.errors) != 0 {
+				t.Fatalf("expected healthy loop to pass, got %v", healthy.errors)
+			}
+
+			var broken fakeTB
+			tc.check(&broken, tc.loop.do)
+			if len(broken.errors) != 1 {
+				t.Fatalf("expected exactly one failure, got %v", broken.errors)
+			}
+			if got := broken.errors[0]; !strings.Contains(got, tc.expect) {
+				t.Errorf("expected %q to contain %q", got, tc.expect)
+			}
+		})
+	}
+}
+
+func TestInstantClock(t *testing.T) {
+	t.Parallel()
+
+	start := time.Unix(100, 0)
+	c := NewInstantClock(start)
+
+	timer := c.NewTimer(2 * time.Second)
+	select {
+	case at := <-timer.C():
+		if want := start.Add(2 * time.Second); !at.Equal(want) {
+			t.Errorf("expected %v to be %v", at, want)
+		}
+	default:
+		t.Fatal("expected timer to have fired")
+	}
+	if timer.Stop() {
+		t.Error("expected Stop on a fired timer to return false")
+	}
+
+	c.NewTimer(3 * time.Second)
+	if got, want := c.Now(), start.Add(5*time.Second); !got.Equal(want) {
+		t.Errorf("expected %v to be %v", got, want)
+	}
+	if got, want := c.Sleeps(), []time.Duration{2 * time.Second, 3 * time.Second}; len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("expected %v to be %v", got, want)
+	}
+
+	// Advancing moves time without counting as a sleep.
+	c.Advance(time.Minute)
+	if got, want := c.Now(), start.Add(time.Minute+5*time.Second); !got.Equal(want) {
+		t.Errorf("expected %v to be %v", got, want)
+	}
+	if got := len(c.Sleeps()); got != 2 {
+		t.Errorf("expected %d to be %d", got, 2)
+	}
+}
+
+func TestManualClock(t *testing.T) {
+	t.Parallel()
+
+	t.Run("fires_on_advance", func(t *testing.T) {
+		t.Parallel()
+
+		start := time.Unix(100, 0)
+		c := NewManualClock(start)
+
+		timer := c.NewTimer(2 * time.Second)
+		c.Advance(1 * time.Second)
+		select {
+		case <-timer.C():
+			t.Fatal("expected timer not to have fired yet")
+		default:
+		}
+
+		c.Advance(1 * time.Second)
+		select {
+		case at := <-timer.C():
+			if want := start.Add(2 * time.Second); !at.Equal(want) {
+				t.Errorf("expected %v to be %v", at, want)
+			}
+		default:
+			t.Fatal("expected timer to have fired")
+		}
+		if timer.Stop() {
+			t.Error("expected Stop on a fired timer to return false")
+		}
+		if got := c.Timers(); got != 0 {
+			t.Errorf("expected %d to be %d", got, 0)
+		}
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		t.Parallel()
+
+		var c ManualClock
+		timer := c.NewTimer(time.Second)
+		if !timer.Stop() {
+			t.Error("expected Stop on a pending timer to return true")
+		}
+		c.Advance(time.Minute)
+		select {
+		case <-timer.C():
+			t.Fatal("expected stopped timer not to fire")
+		default:
+		}
+	})
+
+	t.Run("non_positive", func(t *testing.T) {
+		t.Parallel()
+
+		var c ManualClock
+		select {
+		case <-c.NewTimer(0).C():
+		default:
+			t.Fatal("expected timer to have fired")
+		}
+	})
+
+	t.Run("drives_retry", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := backoff.NewExponential(1 * time.Hour)
+		if err != nil {
+			t.Fatalf("failed to create exponential backoff: %v", err)
+		}
+		c := NewManualClock(time.Unix(0, 0))
+
+		var attempts int
+		errc := make(chan error, 1)
+		go func() {
+			errc <- retry.DoWithOptions(context.Background(), backoff.WithMaxRetries(2, b), func(_ context.Context) error {
+				attempts++
+				return retry.RetryableError(fmt.Errorf("attempt %d", attempts))
+			}, retry.WithClock(c))
+		}()
+
+		// The loop sleeps 1h, then 2h, then gives up.
+		for _, d := range []time.Duration{1 * time.Hour, 2 * time.Hour} {
+			c.WaitForTimers(1)
+			c.Advance(d - time.Nanosecond)
+			if got := c.Timers(); got != 1 {
+				t.Fatalf("expected %d to be %d", got, 1)
+			}
+			c.Advance(time.Nanosecond)
+		}
+
+		err = <-errc
+		if !errors.Is(err, retry.ErrRetriesExhausted) {
+			t.Errorf("expected %v to be %v", err, retry.ErrRetriesExhausted)
+		}
+		if got, want := attempts, 3; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+}
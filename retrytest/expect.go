@@ -0,0 +1,9 @@
+package retrytest
+
+import "github.com/swayne275/go-retry/retry"
+
+// ExpectNoRetries returns an option that fails t if the loop it is passed to
+// retries at all. It is retry.WithTestExpectation with a bound of zero.
+func ExpectNoRetries(t TB) retry.Option {
+	return retry.WithTestExpectation(t, 0)
+}
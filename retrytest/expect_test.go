@@ -0,0 +1,42 @@
+package retrytest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+	"github.com/swayne275/go-retry/retry"
+)
+
+func TestExpectNoRetries(t *testing.T) {
+	t.Parallel()
+
+	b, err := backoff.NewConstant(1 * time.Nanosecond)
+	if err != nil {
+		t.Fatalf("failed to create constant backoff: %v", err)
+	}
+
+	var silent fakeTB
+	if err := retry.DoWithOptions(context.Background(), b, func(_ context.Context) error {
+		return nil
+	}, ExpectNoRetries(&silent)); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if len(silent.errors) != 0 {
+		t.Errorf("expected no failures, got %v", silent.errors)
+	}
+
+	var failed fakeTB
+	err = retry.DoWithOptions(context.Background(), b, func(_ context.Context) error {
+		return retry.RetryableError(fmt.Errorf("oops"))
+	}, ExpectNoRetries(&failed))
+	if !errors.Is(err, retry.ErrRetryExpectationExceeded) {
+		t.Errorf("expected %v to be %v", err, retry.ErrRetryExpectationExceeded)
+	}
+	if len(failed.errors) != 1 {
+		t.Errorf("expected exactly one failure, got %v", failed.errors)
+	}
+}
@@ -0,0 +1,45 @@
+package retrytest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/swayne275/go-retry/backoff"
+	"github.com/swayne275/go-retry/internal/apitest"
+	"github.com/swayne275/go-retry/retry"
+)
+
+func TestZeroValues(t *testing.T) {
+	t.Parallel()
+
+	apitest.CheckZeroValues(t, map[string]func(t *testing.T){
+		"InstantClock": func(t *testing.T) {
+			b, err := backoff.NewConstant(1 * time.Second)
+			if err != nil {
+				t.Fatalf("failed to create constant backoff: %v", err)
+			}
+
+			var c InstantClock
+			_ = retry.DoWithOptions(context.Background(), backoff.WithMaxRetries(2, b), func(_ context.Context) error {
+				return retry.RetryableError(fmt.Errorf("oops"))
+			}, retry.WithClock(&c))
+			if got, want := c.Now(), (time.Time{}).Add(2*time.Second); !got.Equal(want) {
+				t.Errorf("expected %v to be %v", got, want)
+			}
+			if got := len(c.Sleeps()); got != 2 {
+				t.Errorf("expected %d to be %d", got, 2)
+			}
+		},
+		"ManualClock": func(t *testing.T) {
+			var c ManualClock
+			timer := c.NewTimer(1 * time.Second)
+			go c.Advance(1 * time.Second)
+			if got, want := <-timer.C(), (time.Time{}).Add(1*time.Second); !got.Equal(want) {
+				t.Errorf("expected %v to be %v", got, want)
+			}
+			c.WaitForTimers(0)
+		},
+	})
+}